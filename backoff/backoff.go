@@ -1,13 +1,57 @@
 package backoff
 
 import (
+	"context"
+	"github.com/pkg/errors"
 	"math/rand"
 	"time"
 )
 
 // Backoff returns the backoff duration for a specific retry attempt.
+// A Backoff may return Stop to indicate that no further attempts should be made.
 type Backoff func(uint64) time.Duration
 
+// Stop is returned by a Backoff if no further attempts should be made.
+const Stop time.Duration = -1
+
+// ErrStop is returned by Sleep if the Backoff returned Stop.
+var ErrStop = errors.New("backoff stopped")
+
+// Sleep sleeps for the backoff duration of the given attempt.
+// It returns early with the context error if ctx is canceled and with ErrStop if b returns Stop.
+func Sleep(ctx context.Context, b Backoff, attempt uint64) error {
+	d := b(attempt)
+	if d < 0 {
+		return ErrStop
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithMaxElapsed returns a backoff implementation that returns the durations of b until the time elapsed
+// since calling WithMaxElapsed plus the next duration exceeds max, and Stop afterwards.
+// Therefore, a new one should be created for each sequence of attempts.
+func WithMaxElapsed(b Backoff, max time.Duration) Backoff {
+	start := time.Now()
+
+	return func(attempt uint64) time.Duration {
+		d := b(attempt)
+		if d < 0 || time.Since(start)+d > max {
+			return Stop
+		}
+
+		return d
+	}
+}
+
 // NewExponentialWithJitter returns a backoff implementation that
 // exponentially increases the backoff duration for each retry from min,
 // never exceeding max. Some randomization is added to the backoff duration.
@@ -0,0 +1,50 @@
+package backoff
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSleep(t *testing.T) {
+	constant := func(d time.Duration) Backoff {
+		return func(uint64) time.Duration { return d }
+	}
+
+	t.Run("elapsed", func(t *testing.T) {
+		start := time.Now()
+		require.NoError(t, Sleep(context.Background(), constant(10*time.Millisecond), 1))
+		require.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.ErrorIs(t, Sleep(ctx, constant(time.Hour), 1), context.Canceled)
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		require.ErrorIs(t, Sleep(context.Background(), constant(Stop), 1), ErrStop)
+	})
+}
+
+func TestWithMaxElapsed(t *testing.T) {
+	linear := func(attempt uint64) time.Duration { return time.Duration(attempt) * 10 * time.Millisecond }
+	b := WithMaxElapsed(linear, 45*time.Millisecond)
+
+	var slept []time.Duration
+	for attempt := uint64(1); ; attempt++ {
+		d := b(attempt)
+		if d == Stop {
+			break
+		}
+
+		slept = append(slept, d)
+		time.Sleep(d)
+	}
+
+	// Attempt 3 would exceed the maximum after sleeping 10ms and 20ms.
+	require.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, slept)
+}
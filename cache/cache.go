@@ -0,0 +1,161 @@
+// Package cache memoizes entity lookups by ID, e.g. in order to avoid redundant SELECTs
+// during runtime update processing, using a pluggable Backend.
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/pkg/errors"
+	"strings"
+	"sync/atomic"
+)
+
+// Backend stores entities by key.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get returns the entity stored at key and whether there is one.
+	Get(ctx context.Context, key string) (database.Entity, bool, error)
+
+	// Set stores the entity at key.
+	Set(ctx context.Context, key string, entity database.Entity) error
+
+	// Delete removes the entity stored at key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// LoaderFunc loads the entity with the given ID on a cache miss.
+// It returns nil without an error if there is no such entity.
+type LoaderFunc func(ctx context.Context, id database.ID) (database.Entity, error)
+
+// Cache memoizes the entities returned by a LoaderFunc in a Backend.
+// Note that cached entities are shared between callers and must not be modified.
+// Entities loaded while Invalidate is called are not stored, as they may predate the change that caused it,
+// or removed again right after being stored. This only applies to the Cache itself,
+// not to other processes sharing its Backend.
+type Cache struct {
+	backend Backend
+	load    LoaderFunc
+
+	// generation is incremented by each Invalidate call,
+	// so that Get doesn't store entities which have been loaded before, as they may be stale.
+	generation atomic.Uint64
+}
+
+// New returns a new Cache that loads missing entities using load and stores them in backend.
+func New(backend Backend, load LoaderFunc) *Cache {
+	return &Cache{backend: backend, load: load}
+}
+
+// Get returns the entity with the given ID from the backend or, on a cache miss, loads and stores it.
+// Returns nil without an error if there is no such entity, which is not cached.
+func (c *Cache) Get(ctx context.Context, id database.ID) (database.Entity, error) {
+	key := id.String()
+
+	entity, ok, err := c.backend.Get(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't get entity %s from cache", key)
+	}
+	if ok {
+		return entity, nil
+	}
+
+	generation := c.generation.Load()
+
+	entity, err = c.load(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't load entity %s", key)
+	}
+	if entity == nil {
+		return nil, nil
+	}
+
+	if c.generation.Load() != generation {
+		// Invalidated while loading.
+		return entity, nil
+	}
+
+	// The backend may be remote, so don't block other callers while storing the entity.
+	if err := c.backend.Set(ctx, key, entity); err != nil {
+		return nil, errors.Wrapf(err, "can't store entity %s in cache", key)
+	}
+
+	if c.generation.Load() != generation {
+		// Invalidated while storing, possibly before the entity was stored, so remove it again.
+		if err := c.backend.Delete(ctx, key); err != nil {
+			return nil, errors.Wrapf(err, "can't remove stale entity %s from cache", key)
+		}
+	}
+
+	return entity, nil
+}
+
+// Invalidate removes the entities with the given IDs from the backend and
+// prevents concurrent Get calls from storing the entities they are loading.
+// IDs that don't implement database.ID are converted to keys using fmt.Sprint.
+func (c *Cache) Invalidate(ctx context.Context, ids ...any) error {
+	c.generation.Add(1)
+
+	for _, id := range ids {
+		key := keyOf(id)
+		if err := c.backend.Delete(ctx, key); err != nil {
+			return errors.Wrapf(err, "can't invalidate entity %s", key)
+		}
+	}
+
+	return nil
+}
+
+// OnUpserted returns a database.OnSuccess callback for database.DB.UpsertStreamed and similar functions,
+// which invalidates the upserted entities.
+func (c *Cache) OnUpserted() database.OnSuccess[database.Entity] {
+	return func(ctx context.Context, entities []database.Entity) error {
+		for _, e := range entities {
+			if err := c.Invalidate(ctx, e.ID()); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// OnDeleted returns a database.OnSuccess callback for database.DB.DeleteStreamed,
+// which invalidates the deleted IDs.
+func (c *Cache) OnDeleted() database.OnSuccess[any] {
+	return func(ctx context.Context, ids []any) error {
+		return c.Invalidate(ctx, ids...)
+	}
+}
+
+// NewDatabaseLoader returns a LoaderFunc that SELECTs entities created by factoryFunc by their ID from db.
+func NewDatabaseLoader(db *database.DB, factoryFunc database.EntityFactoryFunc) LoaderFunc {
+	return func(ctx context.Context, id database.ID) (database.Entity, error) {
+		entity := factoryFunc()
+		query := db.Rebind(fmt.Sprintf(
+			`SELECT "%s" FROM "%s" WHERE "id" = ?`,
+			strings.Join(db.BuildColumns(entity), `", "`),
+			database.TableName(entity),
+		))
+
+		if err := db.GetContext(ctx, entity, query, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil
+			}
+
+			return nil, database.CantPerformQuery(err, query)
+		}
+
+		return entity, nil
+	}
+}
+
+// keyOf returns the cache key of id.
+func keyOf(id any) string {
+	if id, ok := id.(database.ID); ok {
+		return id.String()
+	}
+
+	return fmt.Sprint(id)
+}
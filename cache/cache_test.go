@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type testID string
+
+func (id testID) String() string {
+	return string(id)
+}
+
+type testEntity struct {
+	Id   testID
+	Name string
+}
+
+func (e *testEntity) Fingerprint() database.Fingerprinter {
+	return e
+}
+
+func (e *testEntity) ID() database.ID {
+	return e.Id
+}
+
+func (e *testEntity) SetID(id database.ID) {
+	e.Id = id.(testID)
+}
+
+func TestCache_Get(t *testing.T) {
+	loads := 0
+	c := New(NewLRU(10), func(_ context.Context, id database.ID) (database.Entity, error) {
+		loads++
+
+		switch id {
+		case testID("missing"):
+			return nil, nil
+		case testID("failing"):
+			return nil, errors.New("failed")
+		default:
+			return &testEntity{Id: id.(testID), Name: "loaded"}, nil
+		}
+	})
+
+	ctx := context.Background()
+
+	for range 2 {
+		e, err := c.Get(ctx, testID("a"))
+		require.NoError(t, err)
+		require.Equal(t, &testEntity{Id: "a", Name: "loaded"}, e)
+	}
+	require.Equal(t, 1, loads, "entity must be loaded only once")
+
+	e, err := c.Get(ctx, testID("missing"))
+	require.NoError(t, err)
+	require.Nil(t, e)
+
+	_, err = c.Get(ctx, testID("failing"))
+	require.Error(t, err)
+
+	require.NoError(t, c.OnUpserted()(ctx, []database.Entity{&testEntity{Id: "a"}}))
+	_, err = c.Get(ctx, testID("a"))
+	require.NoError(t, err)
+	require.Equal(t, 4, loads, "upserted entity must be invalidated")
+
+	require.NoError(t, c.OnDeleted()(ctx, []any{testID("a")}))
+	_, err = c.Get(ctx, testID("a"))
+	require.NoError(t, err)
+	require.Equal(t, 5, loads, "deleted entity must be invalidated")
+}
+
+func TestCache_Invalidate_WhileLoading(t *testing.T) {
+	loading := make(chan struct{})
+	loaded := make(chan struct{})
+	lru := NewLRU(10)
+
+	c := New(lru, func(_ context.Context, id database.ID) (database.Entity, error) {
+		close(loading)
+		<-loaded
+
+		return &testEntity{Id: id.(testID), Name: "stale"}, nil
+	})
+
+	ctx := context.Background()
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := c.Get(ctx, testID("a"))
+		done <- err
+	}()
+
+	<-loading
+	require.NoError(t, c.Invalidate(ctx, testID("a")))
+	close(loaded)
+	require.NoError(t, <-done)
+
+	_, ok, err := lru.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok, "entity loaded before invalidation must not be stored")
+}
+
+// blockingBackend is a Backend whose Set calls block until unblocked, e.g. like a slow remote backend.
+type blockingBackend struct {
+	*LRU
+	setting chan string
+	unblock chan struct{}
+}
+
+func (b blockingBackend) Set(ctx context.Context, key string, entity database.Entity) error {
+	b.setting <- key
+	<-b.unblock
+
+	return b.LRU.Set(ctx, key, entity)
+}
+
+func TestCache_Get_WhileStoring(t *testing.T) {
+	backend := blockingBackend{LRU: NewLRU(10), setting: make(chan string, 2), unblock: make(chan struct{})}
+	c := New(backend, func(_ context.Context, id database.ID) (database.Entity, error) {
+		return &testEntity{Id: id.(testID)}, nil
+	})
+
+	ctx := context.Background()
+	done := make(chan error, 2)
+
+	go func() {
+		_, err := c.Get(ctx, testID("a"))
+		done <- err
+	}()
+	require.Equal(t, "a", <-backend.setting)
+
+	go func() {
+		_, err := c.Get(ctx, testID("b"))
+		done <- err
+	}()
+
+	select {
+	case key := <-backend.setting:
+		require.Equal(t, "b", key)
+	case <-time.After(time.Second):
+		require.Fail(t, "storing an entity must not block other Get calls")
+	}
+
+	require.NoError(t, c.Invalidate(ctx, testID("a")), "storing an entity must not block Invalidate")
+
+	close(backend.unblock)
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+
+	_, ok, err := backend.Get(ctx, "a")
+	require.NoError(t, err)
+	require.False(t, ok, "entity invalidated while being stored must be removed again")
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	l := NewLRU(2)
+
+	require.NoError(t, l.Set(ctx, "a", &testEntity{Id: "a"}))
+	require.NoError(t, l.Set(ctx, "b", &testEntity{Id: "b"}))
+
+	// Use a, so that b is the least recently used one.
+	_, ok, err := l.Get(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, l.Set(ctx, "c", &testEntity{Id: "c"}))
+	require.Equal(t, 2, l.Len())
+
+	_, ok, _ = l.Get(ctx, "b")
+	require.False(t, ok, "least recently used entity must be evicted")
+
+	_, ok, _ = l.Get(ctx, "a")
+	require.True(t, ok)
+
+	require.NoError(t, l.Delete(ctx, "a"))
+	_, ok, _ = l.Get(ctx, "a")
+	require.False(t, ok)
+	require.Equal(t, 1, l.Len())
+
+	require.Panics(t, func() { NewLRU(0) })
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"sync"
+)
+
+// LRU is an in-memory Backend that holds a limited number of entities
+// and evicts the least recently used one when full.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // order contains *lruEntry, most recently used first.
+}
+
+// lruEntry is an element of LRU.order.
+type lruEntry struct {
+	key    string
+	entity database.Entity
+}
+
+// NewLRU returns a new LRU that holds at most capacity entities.
+// Panics if capacity is less than 1.
+func NewLRU(capacity int) *LRU {
+	if capacity < 1 {
+		panic("LRU capacity must be at least 1")
+	}
+
+	return &LRU{capacity: capacity, entries: make(map[string]*list.Element), order: list.New()}
+}
+
+// Get implements the Backend interface.
+func (l *LRU) Get(_ context.Context, key string) (database.Entity, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	l.order.MoveToFront(element)
+
+	return element.Value.(*lruEntry).entity, true, nil
+}
+
+// Set implements the Backend interface.
+func (l *LRU) Set(_ context.Context, key string, entity database.Entity) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		element.Value.(*lruEntry).entity = entity
+		l.order.MoveToFront(element)
+
+		return nil
+	}
+
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, entity: entity})
+
+	if l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	return nil
+}
+
+// Delete implements the Backend interface.
+func (l *LRU) Delete(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if element, ok := l.entries[key]; ok {
+		l.order.Remove(element)
+		delete(l.entries, key)
+	}
+
+	return nil
+}
+
+// Len returns the number of entities held.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+// Assert interface compliance.
+var (
+	_ Backend = (*LRU)(nil)
+)
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// RedisBackend is a Backend that stores entities JSON-encoded in Redis,
+// so that the cache can be shared between processes.
+type RedisBackend struct {
+	client      *redis.Client
+	prefix      string
+	ttl         time.Duration
+	factoryFunc database.EntityFactoryFunc
+}
+
+// NewRedisBackend returns a new RedisBackend that stores the entities created by factoryFunc
+// at prefix followed by their key, which expire after ttl unless it is 0.
+func NewRedisBackend(
+	client *redis.Client, prefix string, ttl time.Duration, factoryFunc database.EntityFactoryFunc,
+) *RedisBackend {
+	return &RedisBackend{client: client, prefix: prefix, ttl: ttl, factoryFunc: factoryFunc}
+}
+
+// Get implements the Backend interface.
+func (r *RedisBackend) Get(ctx context.Context, key string) (database.Entity, bool, error) {
+	cmd := r.client.Get(ctx, r.key(key))
+	data, err := cmd.Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+
+		return nil, false, redis.WrapCmdErr(cmd)
+	}
+
+	entity := r.factoryFunc()
+	if err := types.UnmarshalJSON(data, entity); err != nil {
+		return nil, false, err
+	}
+
+	return entity, true, nil
+}
+
+// Set implements the Backend interface.
+func (r *RedisBackend) Set(ctx context.Context, key string, entity database.Entity) error {
+	data, err := types.MarshalJSON(entity)
+	if err != nil {
+		return err
+	}
+
+	if cmd := r.client.Set(ctx, r.key(key), data, r.ttl); cmd.Err() != nil {
+		return redis.WrapCmdErr(cmd)
+	}
+
+	return nil
+}
+
+// Delete implements the Backend interface.
+func (r *RedisBackend) Delete(ctx context.Context, key string) error {
+	if cmd := r.client.Del(ctx, r.key(key)); cmd.Err() != nil {
+		return redis.WrapCmdErr(cmd)
+	}
+
+	return nil
+}
+
+// key returns the Redis key for the cache key, including the prefix and the Client's key prefix, if any.
+func (r *RedisBackend) key(key string) string {
+	return r.client.Key(r.prefix + key)
+}
+
+// Assert interface compliance.
+var (
+	_ Backend = (*RedisBackend)(nil)
+)
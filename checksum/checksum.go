@@ -0,0 +1,121 @@
+// Package checksum computes checksums of arbitrary values, e.g. entities, using a selectable hash algorithm.
+package checksum
+
+import (
+	"crypto/sha1" // #nosec G505 -- SHA-1 is only used for compatibility with existing checksums.
+	"crypto/sha256"
+	"github.com/icinga/icinga-go-library/objectpacker"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/pkg/errors"
+	"hash"
+	"io"
+	"reflect"
+)
+
+// Algorithm is a hash algorithm to compute checksums with.
+type Algorithm string
+
+const (
+	// SHA1 computes SHA-1 checksums, which are compatible with those of utils.Checksum.
+	SHA1 Algorithm = "sha1"
+
+	// SHA256 computes SHA-256 checksums and should be used for new checksums.
+	SHA256 Algorithm = "sha256"
+)
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Returns an error if the algorithm is not supported.
+func (a *Algorithm) UnmarshalText(text []byte) error {
+	algorithm := Algorithm(text)
+	if _, err := algorithm.newHash(); err != nil {
+		return err
+	}
+
+	*a = algorithm
+
+	return nil
+}
+
+// newHash returns a new hash.Hash for the algorithm.
+func (a Algorithm) newHash() (func() hash.Hash, error) {
+	switch a {
+	case SHA1:
+		return sha1.New, nil
+	case SHA256:
+		return sha256.New, nil
+	default:
+		return nil, errors.Errorf("unsupported checksum algorithm %q", a)
+	}
+}
+
+// Checksummer computes checksums of arbitrary values.
+type Checksummer interface {
+	// Checksum returns the checksum of v.
+	// Strings and byte slices are hashed as is. Any other value is packed using objectpacker.PackAny first,
+	// with structs being converted to their JSON representation, so that all of their exported fields are hashed.
+	Checksum(v any) (types.Checksum, error)
+}
+
+// New returns a Checksummer for the given algorithm.
+// Returns an error if the algorithm is not supported.
+func New(algorithm Algorithm) (Checksummer, error) {
+	newHash, err := algorithm.newHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return checksummer{newHash: newHash}, nil
+}
+
+// checksummer implements Checksummer using a hash.Hash.
+type checksummer struct {
+	newHash func() hash.Hash
+}
+
+// Checksum implements the Checksummer interface.
+func (c checksummer) Checksum(v any) (types.Checksum, error) {
+	h := c.newHash()
+
+	switch v := v.(type) {
+	case string:
+		_, _ = io.WriteString(h, v)
+	case []byte:
+		_, _ = h.Write(v)
+	default:
+		if err := pack(v, h); err != nil {
+			return nil, errors.Wrapf(err, "can't compute checksum of %T", v)
+		}
+	}
+
+	return h.Sum(nil), nil
+}
+
+// pack packs v into out using objectpacker.PackAny,
+// but converts structs to their JSON representation first as they can't be packed.
+func pack(v any, out io.Writer) error {
+	if isStruct(v) {
+		b, err := types.MarshalJSON(v)
+		if err != nil {
+			return err
+		}
+
+		var generic any
+		if err := types.UnmarshalJSON(b, &generic); err != nil {
+			return err
+		}
+
+		v = generic
+	}
+
+	return objectpacker.PackAny(v, out)
+}
+
+// isStruct returns whether v is a struct or a pointer to one.
+func isStruct(v any) bool {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	return rv.Kind() == reflect.Struct
+}
@@ -0,0 +1,73 @@
+package checksum
+
+import (
+	"encoding/hex"
+	"github.com/icinga/icinga-go-library/objectpacker"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/icinga/icinga-go-library/utils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New("md5")
+	require.Error(t, err)
+}
+
+func TestAlgorithm_UnmarshalText(t *testing.T) {
+	var a Algorithm
+	require.NoError(t, a.UnmarshalText([]byte("sha256")))
+	require.Equal(t, SHA256, a)
+
+	require.Error(t, a.UnmarshalText([]byte("md5")))
+	require.Equal(t, SHA256, a)
+}
+
+func TestChecksummer_Checksum(t *testing.T) {
+	sha1, err := New(SHA1)
+	require.NoError(t, err)
+
+	sha256, err := New(SHA256)
+	require.NoError(t, err)
+
+	t.Run("compatibility", func(t *testing.T) {
+		for _, v := range []any{"example", []byte("example")} {
+			actual, err := sha1.Checksum(v)
+			require.NoError(t, err)
+			require.Equal(t, types.Checksum(utils.Checksum(v)), actual)
+		}
+
+		packed := []any{"example", true, nil, 42.0}
+		actual, err := sha1.Checksum(packed)
+		require.NoError(t, err)
+		require.Equal(t, types.Checksum(utils.Checksum(objectpacker.MustPackSlice(packed...))), actual)
+	})
+
+	t.Run("sha256", func(t *testing.T) {
+		actual, err := sha256.Checksum("example")
+		require.NoError(t, err)
+		require.Equal(t, "50d858e0985ecc7f60418aaf0cc5ab587f42c2570a884095a9e8ccacd0f6545c", hex.EncodeToString(actual))
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		type entity struct {
+			Name  string         `json:"name"`
+			Vars  map[string]int `json:"vars"`
+			Count int            `json:"count"`
+		}
+
+		e := entity{Name: "example", Vars: map[string]int{"a": 1}, Count: 2}
+
+		actual, err := sha256.Checksum(&e)
+		require.NoError(t, err)
+
+		expected, err := sha256.Checksum(map[string]any{"name": "example", "vars": map[string]any{"a": 1.0}, "count": 2.0})
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := sha256.Checksum(make(chan int))
+		require.Error(t, err)
+	})
+}
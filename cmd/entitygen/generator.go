@@ -0,0 +1,427 @@
+package main
+
+import (
+	"bytes"
+	"github.com/icinga/icinga-go-library/strcase"
+	"github.com/pkg/errors"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// directive prefixes the doc comment lines which annotate a type to be processed.
+const directive = "//entitygen:"
+
+// databaseImport is the import path of the database package referenced by the generated code.
+const databaseImport = "github.com/icinga/icinga-go-library/database"
+
+// entity describes the code to generate for an annotated struct type.
+type entity struct {
+	// Name is the name of the struct type.
+	Name string
+
+	// Table is the table name returned by TableName.
+	Table string
+
+	// IDField and IDType are the name and type of the id column field, if any.
+	IDField string
+	IDType  string
+
+	// Upsert lists the fields returned by Upsert.
+	Upsert []field
+
+	// Skip contains the methods and functions which are already declared and must not be generated.
+	Skip map[string]bool
+}
+
+// Factory returns the name of the factory function.
+func (e entity) Factory() string {
+	return "New" + e.Name
+}
+
+// usesDatabase returns whether the generated code of e references the database package.
+func (e entity) usesDatabase() bool {
+	return e.IDField != "" && (!e.Skip["ID"] || !e.Skip["SetID"]) || !e.Skip["Fingerprint"] || !e.Skip[e.Factory()]
+}
+
+// field is a struct field to be rendered in the generated code.
+type field struct {
+	Name string
+	Type string
+	Tag  string
+
+	typ types.Type
+}
+
+// run generates the code for the package in dir and writes it to the output file in dir.
+func run(dir, output string) error {
+	src, err := generate(dir, output)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, output), src, 0644); err != nil {
+		return errors.Wrapf(err, "can't write %q", output)
+	}
+
+	return nil
+}
+
+// generate returns the formatted code for the package in dir, ignoring test files and the output file.
+func generate(dir, output string) ([]byte, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't list Go files")
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") || filepath.Base(name) == output {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't parse %q", name)
+		}
+
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, errors.Errorf("no Go files in %q", dir)
+	}
+
+	pkg, idType, err := check(fset, files)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string]string)
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+
+		imports[p.Name()] = strconv.Quote(p.Path())
+
+		return p.Name()
+	}
+
+	var entities []entity
+
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+
+				directives := parseDirectives(doc)
+				if directives == nil {
+					continue
+				}
+
+				named, ok := pkg.Scope().Lookup(ts.Name.Name).Type().(*types.Named)
+				if !ok {
+					return nil, errors.Errorf("%s: %s is not a defined type", fset.Position(ts.Pos()), ts.Name.Name)
+				}
+
+				st, ok := named.Underlying().(*types.Struct)
+				if !ok {
+					return nil, errors.Errorf("%s: %s is not a struct", fset.Position(ts.Pos()), ts.Name.Name)
+				}
+
+				e, err := newEntity(named, st, directives, idType, qualifier)
+				if err != nil {
+					return nil, errors.Wrapf(err, "%s", fset.Position(ts.Pos()))
+				}
+
+				if e.usesDatabase() {
+					imports["database"] = strconv.Quote(databaseImport)
+				}
+
+				entities = append(entities, e)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	err = codeTemplate.Execute(&buf, struct {
+		Package  string
+		Imports  []string
+		Entities []entity
+	}{pkg.Name(), sortedImports(imports), entities})
+	if err != nil {
+		return nil, errors.Wrap(err, "can't render code")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "can't format generated code")
+	}
+
+	return src, nil
+}
+
+// parseDirectives returns the arguments of the entitygen directives in doc by directive name,
+// e.g. "table" for //entitygen:table, or nil if doc doesn't contain any.
+func parseDirectives(doc *ast.CommentGroup) map[string][]string {
+	if doc == nil {
+		return nil
+	}
+
+	var directives map[string][]string
+	for _, c := range doc.List {
+		rest, ok := strings.CutPrefix(c.Text, directive)
+		if !ok {
+			continue
+		}
+
+		args := strings.Fields(rest)
+		if len(args) == 0 {
+			continue
+		}
+
+		if directives == nil {
+			directives = make(map[string][]string)
+		}
+
+		directives[args[0]] = args[1:]
+	}
+
+	return directives
+}
+
+// check type-checks the package consisting of files and returns it along with the database.ID interface.
+func check(fset *token.FileSet, files []*ast.File) (*types.Package, *types.Interface, error) {
+	imp := importer.ForCompiler(fset, "source", nil)
+
+	db, err := imp.Import(databaseImport)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "can't import %q", databaseImport)
+	}
+
+	conf := types.Config{
+		Importer: imp,
+		// The package may reference the code to be generated, e.g. the factory functions,
+		// so errors are ignored as long as the types of the annotated structs can be determined.
+		Error: func(error) {},
+	}
+
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+
+	return pkg, db.Scope().Lookup("ID").Type().Underlying().(*types.Interface), nil
+}
+
+// newEntity returns the entity of the given struct type.
+// Type names of the fields used in the generated code are rendered using qualifier.
+func newEntity(
+	named *types.Named, st *types.Struct, directives map[string][]string, idType *types.Interface,
+	qualifier types.Qualifier,
+) (entity, error) {
+	name := named.Obj().Name()
+	pkg := named.Obj().Pkg()
+	e := entity{Name: name, Table: strcase.Snake(name), Skip: make(map[string]bool)}
+
+	if table, ok := directives["table"]; ok {
+		if len(table) != 1 {
+			return entity{}, errors.New("entitygen:table requires exactly one table name")
+		}
+
+		e.Table = table[0]
+	}
+
+	// Methods the type already has, including those promoted from embedded types, must not be overridden.
+	methods := types.NewMethodSet(types.NewPointer(named))
+	for _, method := range []string{"TableName", "ID", "SetID", "Fingerprint", "Upsert"} {
+		e.Skip[method] = methods.Lookup(pkg, method) != nil
+	}
+	e.Skip[e.Factory()] = pkg.Scope().Lookup(e.Factory()) != nil
+
+	fields := make(map[string]field)
+	for _, f := range structFields(pkg, st) {
+		if f.Type() == types.Typ[types.Invalid] {
+			return entity{}, errors.Errorf("can't determine type of field %q", f.Name())
+		}
+
+		fields[f.Name()] = field{Name: f.Name(), Tag: f.tag, typ: f.Type()}
+
+		column, _, _ := strings.Cut(reflect.StructTag(f.tag).Get("db"), ",")
+		if (column == "id" || column == "" && f.Name() == "Id") && types.Implements(f.Type(), idType) {
+			e.IDField = f.Name()
+			if !e.Skip["SetID"] {
+				e.IDType = types.TypeString(f.Type(), qualifier)
+			}
+		}
+	}
+
+	for _, name := range directives["upsert"] {
+		f, ok := fields[name]
+		if !ok {
+			return entity{}, errors.Errorf("entitygen:upsert: unknown field %q", name)
+		}
+
+		if !e.Skip["Upsert"] {
+			f.Type = types.TypeString(f.typ, qualifier)
+		}
+
+		e.Upsert = append(e.Upsert, f)
+	}
+
+	return e, nil
+}
+
+// taggedField is a struct field along with its tag.
+type taggedField struct {
+	*types.Var
+	tag string
+}
+
+// structFields returns the fields of st accessible from pkg, including those of embedded structs,
+// which are flattened the same way sqlx maps them to columns: Fields of embedded structs are promoted,
+// unless the embedded field is tagged with a column name or "-". Embedded structs themselves are not returned.
+// As in Go, a field shadows the fields of the same name at deeper levels.
+func structFields(pkg *types.Package, st *types.Struct) []taggedField {
+	var fields []taggedField
+	seen := make(map[string]bool)
+	visited := make(map[*types.Struct]bool)
+
+	for level := []*types.Struct{st}; len(level) > 0; {
+		var next []*types.Struct
+		found := make(map[string]bool)
+
+		for _, s := range level {
+			if visited[s] {
+				continue
+			}
+			visited[s] = true
+
+			for i := range s.NumFields() {
+				f, tag := s.Field(i), s.Tag(i)
+
+				if f.Embedded() {
+					if _, ok := reflect.StructTag(tag).Lookup("db"); !ok {
+						t := f.Type()
+						if ptr, ok := t.(*types.Pointer); ok {
+							t = ptr.Elem()
+						}
+
+						if embedded, ok := t.Underlying().(*types.Struct); ok {
+							next = append(next, embedded)
+
+							continue
+						}
+					}
+				}
+
+				if seen[f.Name()] || !f.Exported() && f.Pkg() != pkg {
+					continue
+				}
+
+				found[f.Name()] = true
+				fields = append(fields, taggedField{Var: f, tag: tag})
+			}
+		}
+
+		for name := range found {
+			seen[name] = true
+		}
+
+		level = next
+	}
+
+	return fields
+}
+
+// sortedImports returns the import specs of imports sorted by path.
+func sortedImports(imports map[string]string) []string {
+	specs := make([]string, 0, len(imports))
+	for _, spec := range imports {
+		specs = append(specs, spec)
+	}
+
+	path := func(spec string) string {
+		return spec[strings.IndexByte(spec, '"'):]
+	}
+	sort.Slice(specs, func(i, j int) bool { return path(specs[i]) < path(specs[j]) })
+
+	return specs
+}
+
+// codeTemplate renders the generated code.
+var codeTemplate = template.Must(template.New("").Parse(`// Code generated by entitygen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	{{ . }}
+{{- end }}
+)
+{{ range .Entities }}
+{{- if not (index .Skip "TableName") }}
+// TableName implements the database.TableNamer interface.
+func (*{{ .Name }}) TableName() string {
+	return {{ printf "%q" .Table }}
+}
+{{ end }}
+{{- if and .IDField (not (index .Skip "ID")) }}
+// ID implements part of the database.IDer interface.
+func (e *{{ .Name }}) ID() database.ID {
+	return e.{{ .IDField }}
+}
+{{ end }}
+{{- if and .IDField (not (index .Skip "SetID")) }}
+// SetID implements part of the database.IDer interface.
+func (e *{{ .Name }}) SetID(id database.ID) {
+	e.{{ .IDField }} = id.({{ .IDType }})
+}
+{{ end }}
+{{- if not (index .Skip "Fingerprint") }}
+// Fingerprint implements the database.Fingerprinter interface.
+func (e *{{ .Name }}) Fingerprint() database.Fingerprinter {
+	return e
+}
+{{ end }}
+{{- if and .Upsert (not (index .Skip "Upsert")) }}
+// Upsert implements the database.Upserter interface.
+func (e *{{ .Name }}) Upsert() any {
+	return struct {
+	{{- range .Upsert }}
+		{{ .Name }} {{ .Type }}{{ if .Tag }} {{ printf "%#q" .Tag }}{{ end }}
+	{{- end }}
+	}{
+	{{- range .Upsert }}
+		{{ .Name }}: e.{{ .Name }},
+	{{- end }}
+	}
+}
+{{ end }}
+{{- if not (index .Skip .Factory) }}
+// {{ .Factory }} returns a new {{ .Name }}. It can be used as database.EntityFactoryFunc.
+func {{ .Factory }}() database.Entity {
+	return &{{ .Name }}{}
+}
+{{ end }}
+{{- end }}`))
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	expected, err := os.ReadFile(filepath.Join("testdata", "entities_gen.go.golden"))
+	require.NoError(t, err)
+
+	actual, err := generate("testdata", "entities_gen.go")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
@@ -0,0 +1,52 @@
+// Entitygen generates the boilerplate methods and functions of database entities,
+// i.e. of Go structs whose fields map to table columns via db tags, as used by the database package.
+//
+// It is intended to be run via go generate, processing the package in the current directory:
+//
+//	//go:generate go run github.com/icinga/icinga-go-library/cmd/entitygen
+//
+// Only struct types annotated with entitygen directives in their doc comment are processed,
+// at least with //entitygen:entity:
+//
+//	// Host is a monitored host.
+//	//
+//	//entitygen:entity
+//	//entitygen:table monitored_host
+//	//entitygen:upsert Name Address
+//	type Host struct {
+//		Id      types.Binary
+//		Name    string
+//		Address string
+//	}
+//
+// For each of them, the following is generated:
+//   - TableName, returning the table set by entitygen:table or the snake_case type name.
+//   - ID and SetID, if the struct has an id column, i.e. a field named Id or tagged `db:"id"`,
+//     whose type implements database.ID.
+//   - Fingerprint, returning the entity itself.
+//   - Upsert, returning the fields listed by entitygen:upsert, if any.
+//   - A factory function New<Type> returning a new database.Entity, usable as database.EntityFactoryFunc.
+//
+// The fields of embedded structs are taken into account the same way sqlx maps them to columns,
+// i.e. unless the embedded field is tagged with a column name.
+// Methods the type already has, including those promoted from embedded types, and functions
+// already declared in the package are not generated, so that any of them can be written by hand instead.
+// The package is type-checked for this, so its imports must be resolvable.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the package to process")
+	output := flag.String("output", "entities_gen.go", "name of the file to generate in the package directory")
+	flag.Parse()
+
+	if err := run(*dir, *output); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "entitygen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,64 @@
+package testdata
+
+import (
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/types"
+)
+
+// Host is annotated with all directives.
+//
+//entitygen:entity
+//entitygen:table monitored_host
+//entitygen:upsert Name Address
+type Host struct {
+	Id      types.Binary
+	Name    string
+	Address string            `db:"address4"`
+	Vars    map[string]string `db:"-"`
+}
+
+// HostState has a custom id column and a hand-written Fingerprint.
+//
+//entitygen:entity
+type HostState struct {
+	HostId types.Binary `db:"id"`
+	State  uint8
+}
+
+// Fingerprint is not generated, as it is declared here.
+func (s *HostState) Fingerprint() database.Fingerprinter {
+	return s
+}
+
+// EntityWithChecksum is embedded into entities, providing their id and Fingerprint.
+type EntityWithChecksum struct {
+	Id                 types.Binary
+	PropertiesChecksum types.Binary
+}
+
+// Fingerprint is promoted to the entities embedding EntityWithChecksum and therefore not generated for them.
+func (e EntityWithChecksum) Fingerprint() database.Fingerprinter {
+	return e
+}
+
+// Service embeds EntityWithChecksum, whose fields can be upserted.
+//
+//entitygen:entity
+//entitygen:upsert PropertiesChecksum Name
+type Service struct {
+	EntityWithChecksum
+	Name string
+}
+
+// Comment has an id column which doesn't implement database.ID, so ID and SetID are not generated.
+//
+//entitygen:entity
+type Comment struct {
+	Id   int64
+	Text string
+}
+
+// Environment is not annotated and therefore ignored.
+type Environment struct {
+	Id types.Binary
+}
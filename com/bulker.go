@@ -24,21 +24,39 @@ func neverSplit[T any](T) bool {
 	return false
 }
 
+// DefaultBulkTimeout is the maximum time Bulk and NewBulker wait for a chunk to fill up before streaming it anyway.
+const DefaultBulkTimeout = 256 * time.Millisecond
+
 // Bulker reads all values from a channel and streams them in chunks into a Bulk channel.
 type Bulker[T any] struct {
-	ch  chan []T
-	ctx context.Context
-	mu  sync.Mutex
+	ch      chan []T
+	ctx     context.Context
+	mu      sync.Mutex
+	timeout time.Duration
 }
 
-// NewBulker returns a new Bulker and starts streaming.
+// NewBulker returns a new Bulker with DefaultBulkTimeout and starts streaming.
 func NewBulker[T any](
 	ctx context.Context, ch <-chan T, count int, splitPolicyFactory BulkChunkSplitPolicyFactory[T],
 ) *Bulker[T] {
+	return NewBulkerWithTimeout(ctx, ch, count, DefaultBulkTimeout, splitPolicyFactory)
+}
+
+// NewBulkerWithTimeout returns a new Bulker and starts streaming.
+// Chunks are streamed once they contain count items, but at the latest after timeout,
+// so that items of slow streams are delayed by at most timeout. Panics if timeout is not positive.
+func NewBulkerWithTimeout[T any](
+	ctx context.Context, ch <-chan T, count int, timeout time.Duration, splitPolicyFactory BulkChunkSplitPolicyFactory[T],
+) *Bulker[T] {
+	if timeout <= 0 {
+		panic("timeout must be positive")
+	}
+
 	b := &Bulker[T]{
-		ch:  make(chan []T),
-		ctx: ctx,
-		mu:  sync.Mutex{},
+		ch:      make(chan []T),
+		ctx:     ctx,
+		mu:      sync.Mutex{},
+		timeout: timeout,
 	}
 
 	go b.run(ch, count, splitPolicyFactory)
@@ -78,7 +96,7 @@ func (b *Bulker[T]) run(ch <-chan T, count int, splitPolicyFactory BulkChunkSpli
 	g.Go(func() error {
 		for done := false; !done; {
 			buf := make([]T, 0, count)
-			timeout := time.After(256 * time.Millisecond)
+			timeout := time.After(b.timeout)
 
 			for drain := true; drain && len(buf) < count; {
 				select {
@@ -96,7 +114,7 @@ func (b *Bulker[T]) run(ch <-chan T, count int, splitPolicyFactory BulkChunkSpli
 							buf = make([]T, 0, count)
 						}
 
-						timeout = time.After(256 * time.Millisecond)
+						timeout = time.After(b.timeout)
 					}
 
 					buf = append(buf, v)
@@ -123,14 +141,22 @@ func (b *Bulker[T]) run(ch <-chan T, count int, splitPolicyFactory BulkChunkSpli
 }
 
 // Bulk reads all values from a channel and streams them in chunks into a returned channel.
+// Partial chunks are streamed after DefaultBulkTimeout.
 func Bulk[T any](
 	ctx context.Context, ch <-chan T, count int, splitPolicyFactory BulkChunkSplitPolicyFactory[T],
+) <-chan []T {
+	return BulkWithTimeout(ctx, ch, count, DefaultBulkTimeout, splitPolicyFactory)
+}
+
+// BulkWithTimeout works like Bulk, but streams partial chunks after the specified timeout.
+func BulkWithTimeout[T any](
+	ctx context.Context, ch <-chan T, count int, timeout time.Duration, splitPolicyFactory BulkChunkSplitPolicyFactory[T],
 ) <-chan []T {
 	if count <= 1 {
 		return oneBulk(ctx, ch)
 	}
 
-	return NewBulker(ctx, ch, count, splitPolicyFactory).Bulk()
+	return NewBulkerWithTimeout(ctx, ch, count, timeout, splitPolicyFactory).Bulk()
 }
 
 // oneBulk operates just as NewBulker(ctx, ch, 1, splitPolicy).Bulk(),
@@ -0,0 +1,50 @@
+package com
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBulkWithTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan int)
+	defer close(ch)
+
+	bulks := BulkWithTimeout(ctx, ch, 100, 20*time.Millisecond, NeverSplit[int])
+
+	for i := range 3 {
+		ch <- i
+	}
+
+	select {
+	case bulk := <-bulks:
+		require.Equal(t, []int{0, 1, 2}, bulk)
+	case <-time.After(time.Second):
+		require.Fail(t, "partial chunk must be streamed after the timeout")
+	}
+}
+
+func TestBulk(t *testing.T) {
+	ch := make(chan int)
+	bulks := Bulk(context.Background(), ch, 2, NeverSplit[int])
+
+	go func() {
+		defer close(ch)
+
+		for i := range 5 {
+			ch <- i
+		}
+	}()
+
+	var items []int
+	for bulk := range bulks {
+		require.LessOrEqual(t, len(bulk), 2)
+		items = append(items, bulk...)
+	}
+
+	require.Equal(t, []int{0, 1, 2, 3, 4}, items)
+}
@@ -0,0 +1,137 @@
+package com
+
+import (
+	"context"
+	"sync"
+)
+
+// Bus implements a typed in-process publish/subscribe event bus,
+// which broadcasts values of type T to all of its current subscribers.
+// The zero value is ready to use.
+type Bus[T any] struct {
+	mu          sync.Mutex // Protects subscribers and closed.
+	subscribers map[*subscription[T]]struct{}
+	closed      bool
+}
+
+// Subscribe returns a channel with the given buffer size that receives all values published after this call.
+// The channel is closed once ctx is done or the Bus is closed, whichever happens first.
+// Subscribers must either keep receiving from the channel or cancel ctx,
+// as Publish blocks while the buffer of a subscriber is full.
+func (b *Bus[T]) Subscribe(ctx context.Context, buffer int) <-chan T {
+	s := &subscription[T]{
+		ch:   make(chan T, buffer),
+		done: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		s.close()
+
+		return s.ch
+	}
+
+	if b.subscribers == nil {
+		b.subscribers = make(map[*subscription[T]]struct{})
+	}
+
+	b.subscribers[s] = struct{}{}
+	s.stop = context.AfterFunc(ctx, func() {
+		b.unsubscribe(s)
+	})
+
+	return s.ch
+}
+
+// Publish sends v to all current subscribers and blocks until all of them have either received it,
+// have been unsubscribed in the meantime or ctx is done, in which case ctx.Err() is returned.
+// Values published by the same goroutine are received by each subscriber in the order they were published.
+// Publish does not block the Bus itself, so subscribers may subscribe or unsubscribe while it is in progress.
+func (b *Bus[T]) Publish(ctx context.Context, v T) error {
+	b.mu.Lock()
+	subscribers := make([]*subscription[T], 0, len(b.subscribers))
+	for s := range b.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	b.mu.Unlock()
+
+	for _, s := range subscribers {
+		if err := s.send(ctx, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close unsubscribes all subscribers by closing their channels.
+// Subsequent calls to Subscribe return an already closed channel.
+// Implements the io.Closer interface, hence the error return value, which is always nil.
+func (b *Bus[T]) Close() error {
+	b.mu.Lock()
+	subscribers := b.subscribers
+	b.subscribers = nil
+	b.closed = true
+	b.mu.Unlock()
+
+	for s := range subscribers {
+		s.stop()
+		s.close()
+	}
+
+	return nil
+}
+
+// unsubscribe removes s from the subscribers and closes its channel.
+func (b *Bus[T]) unsubscribe(s *subscription[T]) {
+	b.mu.Lock()
+	delete(b.subscribers, s)
+	b.mu.Unlock()
+
+	s.close()
+}
+
+// subscription is a single subscriber of a Bus.
+type subscription[T any] struct {
+	ch   chan T
+	done chan struct{} // Closed before ch, so that pending sends are aborted.
+	mu   sync.RWMutex  // Prevents ch from being closed while sending.
+	once sync.Once
+	stop func() bool // Stops the context.AfterFunc of Subscribe.
+}
+
+// send sends v to the subscriber unless it has been unsubscribed or ctx is done.
+func (s *subscription[T]) send(ctx context.Context, v T) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// ch is closed only after done while holding the write lock,
+	// so it can't be closed below if done isn't closed yet.
+	select {
+	case <-s.done:
+		return nil
+	default:
+	}
+
+	select {
+	case s.ch <- v:
+		return nil
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close aborts pending sends and closes the channel of the subscriber.
+func (s *subscription[T]) close() {
+	s.once.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		close(s.ch)
+		s.mu.Unlock()
+	})
+}
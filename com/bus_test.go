@@ -0,0 +1,96 @@
+package com
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("Publish", func(t *testing.T) {
+		var b Bus[int]
+		ctx := context.Background()
+
+		a := b.Subscribe(ctx, 2)
+		c := b.Subscribe(ctx, 2)
+
+		require.NoError(t, b.Publish(ctx, 1))
+		require.NoError(t, b.Publish(ctx, 2))
+		require.NoError(t, b.Close())
+
+		require.Equal(t, []int{1, 2}, receiveAll(a))
+		require.Equal(t, []int{1, 2}, receiveAll(c))
+	})
+
+	t.Run("NoSubscribers", func(t *testing.T) {
+		var b Bus[int]
+		require.NoError(t, b.Publish(context.Background(), 1))
+	})
+
+	t.Run("Unsubscribe", func(t *testing.T) {
+		var b Bus[int]
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := b.Subscribe(ctx, 0)
+
+		published := make(chan error, 1)
+		go func() { published <- b.Publish(context.Background(), 1) }()
+
+		// Publish must not block forever on a subscriber which has been unsubscribed while sending.
+		cancel()
+		requireReceive(t, published)
+
+		require.Empty(t, receiveAll(ch))
+		require.NoError(t, b.Publish(context.Background(), 2))
+	})
+
+	t.Run("PublishCanceled", func(t *testing.T) {
+		var b Bus[int]
+		ch := b.Subscribe(context.Background(), 1)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, b.Publish(ctx, 1))
+
+		published := make(chan error, 1)
+		go func() { published <- b.Publish(ctx, 2) }()
+
+		cancel()
+		require.ErrorIs(t, requireReceive(t, published), context.Canceled)
+
+		require.NoError(t, b.Close())
+		require.Equal(t, []int{1}, receiveAll(ch))
+	})
+
+	t.Run("SubscribeClosed", func(t *testing.T) {
+		var b Bus[int]
+		require.NoError(t, b.Close())
+
+		_, ok := <-b.Subscribe(context.Background(), 1)
+		require.False(t, ok)
+	})
+}
+
+// receiveAll returns all values received from ch until it is closed.
+func receiveAll[T any](ch <-chan T) []T {
+	var values []T
+	for v := range ch {
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// requireReceive receives from ch or fails the test if nothing is received within a second.
+func requireReceive[T any](t *testing.T, ch <-chan T) T {
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		require.FailNow(t, "nothing received")
+	}
+
+	var zero T
+
+	return zero
+}
@@ -2,8 +2,10 @@ package com
 
 import (
 	"context"
+	stderrors "errors"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
+	"sync"
 )
 
 // Waiter implements the Wait method,
@@ -38,6 +40,76 @@ func WaitAsync(w Waiter) <-chan error {
 	return errs
 }
 
+// WaitAll waits for all of the passed error channels, such as those returned by WaitAsync, to be closed
+// and sends all non-nil errors received from them joined via errors.Join to the returned channel, if any.
+// All channels are drained, so that their senders never block. Nil channels are ignored.
+// The returned channel is always closed when all channels are done.
+func WaitAll(errs ...<-chan error) <-chan error {
+	joined := make(chan error, 1)
+
+	go func() {
+		defer close(joined)
+
+		var mu sync.Mutex
+		var all []error
+
+		drainErrors(errs, func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			all = append(all, err)
+		})
+
+		if err := stderrors.Join(all...); err != nil {
+			joined <- err
+		}
+	}()
+
+	return joined
+}
+
+// FirstError sends the first non-nil error received from any of the passed error channels, such as those returned
+// by WaitAsync, to the returned channel as soon as it is received. The remaining errors are discarded,
+// but all channels are still drained, so that their senders never block. Nil channels are ignored.
+// The returned channel is always closed when all channels are done.
+func FirstError(errs ...<-chan error) <-chan error {
+	first := make(chan error, 1)
+
+	go func() {
+		defer close(first)
+
+		var once sync.Once
+		drainErrors(errs, func(err error) {
+			once.Do(func() { first <- err })
+		})
+	}()
+
+	return first
+}
+
+// drainErrors receives from all non-nil channels concurrently until they are closed and calls f with each non-nil error.
+func drainErrors(errs []<-chan error, f func(error)) {
+	var wg sync.WaitGroup
+	for _, ch := range errs {
+		if ch == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for err := range ch {
+				if err != nil {
+					f(err)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
 // ErrgroupReceive adds a goroutine to the specified group that
 // returns the first non-nil error (if any) from the specified channel.
 // If the channel is closed, it will return nil.
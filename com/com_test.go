@@ -0,0 +1,81 @@
+package com
+
+import (
+	"errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWaitAll(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+
+	t.Run("None", func(t *testing.T) {
+		err, ok := <-WaitAll()
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		err, ok := <-WaitAll(errChan(), errChan(nil), nil)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		errs := WaitAll(errChan(errA), errChan(), errChan(nil, errB))
+
+		err := <-errs
+		require.ErrorIs(t, err, errA)
+		require.ErrorIs(t, err, errB)
+
+		_, ok := <-errs
+		require.False(t, ok)
+	})
+}
+
+func TestFirstError(t *testing.T) {
+	errA := errors.New("a")
+
+	t.Run("Success", func(t *testing.T) {
+		err, ok := <-FirstError(errChan(), errChan(nil), nil)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("First error before others are done", func(t *testing.T) {
+		pending := make(chan error)
+		errs := FirstError(errChan(errA), pending)
+
+		select {
+		case err := <-errs:
+			require.ErrorIs(t, err, errA)
+		case <-time.After(time.Second):
+			require.Fail(t, "first error not received")
+		}
+
+		// The pending channel is still drained, so its sender doesn't block.
+		select {
+		case pending <- errors.New("b"):
+		case <-time.After(time.Second):
+			require.Fail(t, "pending channel not drained")
+		}
+
+		close(pending)
+
+		_, ok := <-errs
+		require.False(t, ok)
+	})
+}
+
+// errChan returns a closed channel that yields errs.
+func errChan(errs ...error) <-chan error {
+	ch := make(chan error, len(errs))
+	for _, err := range errs {
+		ch <- err
+	}
+	close(ch)
+
+	return ch
+}
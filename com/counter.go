@@ -3,17 +3,44 @@ package com
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Counter implements an atomic counter.
 type Counter struct {
 	value uint64
+	since int64      // Unix nanoseconds of the first Add or the last Reset, whichever is later.
 	mu    sync.Mutex // Protects total.
 	total uint64
 }
 
+// CounterSnapshot is a point-in-time view of a Counter.
+type CounterSnapshot struct {
+	// Total is the total counter value.
+	Total uint64
+
+	// Delta is the counter value since the last Reset.
+	Delta uint64
+
+	// Elapsed is the time since the last Reset or, if the Counter was never reset, since the first Add.
+	Elapsed time.Duration
+}
+
+// Rate returns the number of items per second counted in Elapsed, or 0 if no time has elapsed.
+func (s CounterSnapshot) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(s.Delta) / s.Elapsed.Seconds()
+}
+
 // Add adds the given delta to the counter.
 func (c *Counter) Add(delta uint64) {
+	if atomic.LoadInt64(&c.since) == 0 {
+		atomic.CompareAndSwapInt64(&c.since, 0, time.Now().UnixNano())
+	}
+
 	atomic.AddUint64(&c.value, delta)
 }
 
@@ -25,13 +52,36 @@ func (c *Counter) Inc() {
 // Reset resets the counter to 0 and returns its previous value.
 // Does not reset the total value returned from Total.
 func (c *Counter) Reset() uint64 {
+	return c.ResetSnapshot().Delta
+}
+
+// ResetSnapshot works like Reset, but returns a snapshot of the counter right before it was reset,
+// e.g. in order to log the rate since the previous reset.
+func (c *Counter) ResetSnapshot() CounterSnapshot {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	now := time.Now()
+	since := atomic.SwapInt64(&c.since, now.UnixNano())
 	v := atomic.SwapUint64(&c.value, 0)
 	c.total += v
 
-	return v
+	return CounterSnapshot{Total: c.total, Delta: v, Elapsed: elapsedSince(since, now)}
+}
+
+// Snapshot returns a snapshot of the counter without resetting it.
+func (c *Counter) Snapshot() CounterSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v := c.Val()
+
+	return CounterSnapshot{Total: c.total + v, Delta: v, Elapsed: elapsedSince(atomic.LoadInt64(&c.since), time.Now())}
+}
+
+// Rate returns the number of items per second counted since the last Reset.
+func (c *Counter) Rate() float64 {
+	return c.Snapshot().Rate()
 }
 
 // Total returns the total counter value.
@@ -46,3 +96,12 @@ func (c *Counter) Total() uint64 {
 func (c *Counter) Val() uint64 {
 	return atomic.LoadUint64(&c.value)
 }
+
+// elapsedSince returns the time elapsed from the given Unix nanoseconds until now, or 0 if since is not set.
+func elapsedSince(since int64, now time.Time) time.Duration {
+	if since == 0 {
+		return 0
+	}
+
+	return now.Sub(time.Unix(0, since))
+}
@@ -3,6 +3,7 @@ package com
 import (
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestCounter_Add(t *testing.T) {
@@ -30,3 +31,34 @@ func TestCounter_Reset(t *testing.T) {
 	require.Equal(t, uint64(23), c.Val(), "unexpected new value")
 	require.Equal(t, uint64(65), c.Total(), "unexpected new total")
 }
+
+func TestCounter_Snapshot(t *testing.T) {
+	var c Counter
+	require.Equal(t, CounterSnapshot{}, c.Snapshot(), "unexpected snapshot of unused counter")
+	require.Zero(t, c.Rate())
+
+	c.Add(42)
+	time.Sleep(10 * time.Millisecond)
+
+	s := c.Snapshot()
+	require.Equal(t, uint64(42), s.Total, "unexpected total")
+	require.Equal(t, uint64(42), s.Delta, "unexpected delta")
+	require.GreaterOrEqual(t, s.Elapsed, 10*time.Millisecond, "unexpected elapsed time")
+	require.InDelta(t, 42/s.Elapsed.Seconds(), s.Rate(), 0.001, "unexpected rate")
+
+	s = c.ResetSnapshot()
+	require.Equal(t, uint64(42), s.Delta, "unexpected delta before reset")
+	require.Equal(t, uint64(0), c.Val(), "unexpected value after reset")
+
+	c.Add(23)
+
+	s = c.Snapshot()
+	require.Equal(t, uint64(65), s.Total, "unexpected new total")
+	require.Equal(t, uint64(23), s.Delta, "unexpected new delta")
+	require.Less(t, s.Elapsed, time.Second, "elapsed time must start at the last reset")
+}
+
+func TestCounterSnapshot_Rate(t *testing.T) {
+	require.Equal(t, 5.0, CounterSnapshot{Delta: 10, Elapsed: 2 * time.Second}.Rate())
+	require.Zero(t, CounterSnapshot{Delta: 10}.Rate())
+}
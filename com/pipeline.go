@@ -0,0 +1,266 @@
+package com
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// MapOption configures Map.
+type MapOption interface {
+	apply(*mapOptions)
+}
+
+// Ordered makes Map emit results in the order of the input items.
+// Without this option, results are emitted as soon as they are available.
+// Note that a slow item then delays all subsequent results, but at most workers items are processed at a time.
+func Ordered() MapOption {
+	return mapOptionFunc(func(o *mapOptions) {
+		o.ordered = true
+	})
+}
+
+// Map calls fn with each item from in using the specified number of concurrent workers and
+// streams the results to the returned channel. If fn returns an error, Map stops processing,
+// cancels the context passed to fn and sends the error to the returned error channel.
+// Both returned channels are closed once in is closed and all items have been processed, or on error.
+func Map[TIn, TOut any](
+	ctx context.Context, in <-chan TIn, workers int, fn func(context.Context, TIn) (TOut, error), options ...MapOption,
+) (<-chan TOut, <-chan error) {
+	if workers < 1 {
+		panic("workers must be at least 1")
+	}
+
+	var o mapOptions
+	for _, option := range options {
+		option.apply(&o)
+	}
+
+	out := make(chan TOut)
+	g, ctx := errgroup.WithContext(ctx)
+
+	if o.ordered {
+		mapOrdered(ctx, g, in, workers, fn, out)
+	} else {
+		mapUnordered(ctx, g, in, workers, fn, out)
+	}
+
+	return out, WaitAsync(WaiterFunc(func() error {
+		defer close(out)
+
+		return g.Wait()
+	}))
+}
+
+// FanIn forwards all items from the specified channels to the returned channel in no particular order.
+// Both returned channels are closed once all input channels are closed.
+// The error channel only receives the context error if ctx is canceled before.
+func FanIn[T any](ctx context.Context, ins ...<-chan T) (<-chan T, <-chan error) {
+	out := make(chan T)
+	g, ctx := errgroup.WithContext(ctx)
+
+	for _, in := range ins {
+		g.Go(func() error {
+			for {
+				v, err := receive(ctx, in)
+				if err != nil {
+					return err
+				}
+				if v == nil {
+					return nil
+				}
+
+				if err := send(ctx, out, *v); err != nil {
+					return err
+				}
+			}
+		})
+	}
+
+	return out, WaitAsync(WaiterFunc(func() error {
+		defer close(out)
+
+		return g.Wait()
+	}))
+}
+
+// FanOut distributes the items from in to n returned channels. Each item is sent to
+// the channel at the index returned by partition, so items of the same partition keep their order.
+// Note that a consumer that doesn't keep up blocks all other channels.
+// All returned channels are closed once in is closed, partition returns an index out of range or ctx is canceled.
+func FanOut[T any](ctx context.Context, in <-chan T, n int, partition func(T) int) ([]<-chan T, <-chan error) {
+	if n < 1 {
+		panic("n must be at least 1")
+	}
+
+	outs := make([]chan T, n)
+	result := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T)
+		result[i] = outs[i]
+	}
+
+	return result, WaitAsync(WaiterFunc(func() error {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		for {
+			v, err := receive(ctx, in)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				return nil
+			}
+
+			i := partition(*v)
+			if i < 0 || i >= n {
+				return errors.Errorf("partition %d out of range [0, %d)", i, n)
+			}
+
+			if err := send(ctx, outs[i], *v); err != nil {
+				return err
+			}
+		}
+	}))
+}
+
+// mapOptions stores the options of Map.
+type mapOptions struct {
+	ordered bool
+}
+
+// mapOptionFunc is a function that implements MapOption.
+type mapOptionFunc func(*mapOptions)
+
+func (f mapOptionFunc) apply(o *mapOptions) {
+	f(o)
+}
+
+// mapUnordered implements Map without the Ordered option.
+func mapUnordered[TIn, TOut any](
+	ctx context.Context, g *errgroup.Group, in <-chan TIn, workers int,
+	fn func(context.Context, TIn) (TOut, error), out chan<- TOut,
+) {
+	for range workers {
+		g.Go(func() error {
+			for {
+				v, err := receive(ctx, in)
+				if err != nil {
+					return err
+				}
+				if v == nil {
+					return nil
+				}
+
+				r, err := fn(ctx, *v)
+				if err != nil {
+					return err
+				}
+
+				if err := send(ctx, out, r); err != nil {
+					return err
+				}
+			}
+		})
+	}
+}
+
+// mapOrdered implements Map with the Ordered option.
+func mapOrdered[TIn, TOut any](
+	ctx context.Context, g *errgroup.Group, in <-chan TIn, workers int,
+	fn func(context.Context, TIn) (TOut, error), out chan<- TOut,
+) {
+	type job struct {
+		item   TIn
+		result chan<- TOut
+	}
+
+	jobs := make(chan job)
+	// pending holds the result channels of all items in flight in input order.
+	// Its capacity limits the number of items processed but not yet emitted.
+	pending := make(chan chan TOut, workers)
+
+	g.Go(func() error {
+		defer close(jobs)
+		defer close(pending)
+
+		for {
+			v, err := receive(ctx, in)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				return nil
+			}
+
+			// Buffered, so that workers never wait for the emitter.
+			result := make(chan TOut, 1)
+
+			if err := send(ctx, pending, result); err != nil {
+				return err
+			}
+
+			if err := send(ctx, jobs, job{item: *v, result: result}); err != nil {
+				return err
+			}
+		}
+	})
+
+	for range workers {
+		g.Go(func() error {
+			for j := range jobs {
+				r, err := fn(ctx, j.item)
+				if err != nil {
+					return err
+				}
+
+				j.result <- r
+			}
+
+			return nil
+		})
+	}
+
+	g.Go(func() error {
+		for result := range pending {
+			r, err := receive(ctx, result)
+			if err != nil {
+				return err
+			}
+
+			if err := send(ctx, out, *r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// receive returns a pointer to the next item from ch, nil if ch is closed or the context error if ctx is canceled.
+func receive[T any](ctx context.Context, ch <-chan T) (*T, error) {
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			return nil, nil
+		}
+
+		return &v, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// send sends v to ch or returns the context error if ctx is canceled before.
+func send[T any](ctx context.Context, ch chan<- T, v T) error {
+	select {
+	case ch <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,137 @@
+package com
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	square := func(_ context.Context, i int) (int, error) {
+		// Let earlier items take longer, so that unordered results would likely be reordered.
+		time.Sleep(time.Duration(10-i%10) * time.Millisecond)
+
+		return i * i, nil
+	}
+
+	var expected []int
+	for i := range 50 {
+		expected = append(expected, i*i)
+	}
+
+	t.Run("unordered", func(t *testing.T) {
+		out, errs := Map(context.Background(), generate(50), 4, square)
+
+		actual := collect(out)
+		require.NoError(t, <-errs)
+
+		slices.Sort(actual)
+		require.Equal(t, expected, actual)
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		out, errs := Map(context.Background(), generate(50), 4, square, Ordered())
+
+		require.Equal(t, expected, collect(out))
+		require.NoError(t, <-errs)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+
+		for _, options := range [][]MapOption{nil, {Ordered()}} {
+			out, errs := Map(context.Background(), generate(50), 4, func(_ context.Context, i int) (int, error) {
+				if i == 10 {
+					return 0, errFailed
+				}
+
+				return i, nil
+			}, options...)
+
+			require.Less(t, len(collect(out)), 50)
+			require.ErrorIs(t, <-errs, errFailed)
+		}
+	})
+}
+
+func TestFanIn(t *testing.T) {
+	out, errs := FanIn(context.Background(), generate(10), generate(20), generate(0))
+
+	actual := collect(out)
+	require.NoError(t, <-errs)
+	require.Len(t, actual, 30)
+}
+
+func TestFanOut(t *testing.T) {
+	outs, errs := FanOut(context.Background(), generate(30), 3, func(i int) int { return i % 3 })
+
+	results := make([][]int, len(outs))
+	done := make([]<-chan struct{}, len(outs))
+	for i, out := range outs {
+		done[i] = drain(out, &results[i])
+	}
+
+	for _, d := range done {
+		<-d
+	}
+	require.NoError(t, <-errs)
+
+	for i, result := range results {
+		require.Len(t, result, 10)
+		require.True(t, slices.IsSorted(result), "partition %d must preserve order", i)
+
+		for _, v := range result {
+			require.Equal(t, i, v%3)
+		}
+	}
+
+	t.Run("out-of-range", func(t *testing.T) {
+		outs, errs := FanOut(context.Background(), generate(1), 2, func(int) int { return 2 })
+
+		require.Error(t, <-errs)
+		for _, out := range outs {
+			require.Empty(t, collect(out))
+		}
+	})
+}
+
+// generate returns a channel that yields the numbers from 0 to n-1.
+func generate(n int) <-chan int {
+	ch := make(chan int)
+
+	go func() {
+		defer close(ch)
+
+		for i := range n {
+			ch <- i
+		}
+	}()
+
+	return ch
+}
+
+// collect returns all items from ch until it is closed.
+func collect[T any](ch <-chan T) []T {
+	var items []T
+	for v := range ch {
+		items = append(items, v)
+	}
+
+	return items
+}
+
+// drain asynchronously collects all items from ch into items and closes the returned channel when done.
+func drain[T any](ch <-chan T, items *[]T) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		*items = collect(ch)
+	}()
+
+	return done
+}
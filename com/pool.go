@@ -0,0 +1,155 @@
+package com
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/retry"
+	"golang.org/x/sync/errgroup"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolOption configures a Pool.
+type PoolOption interface {
+	apply(*poolOptions)
+}
+
+// PoolRetry retries each failed task individually with retry.WithBackoff using the given parameters.
+// If retryable is nil, retry.Retryable is used. If b is nil, an exponential backoff with jitter
+// between 1ms and 1s is used, as for database and Redis operations.
+func PoolRetry(retryable retry.IsRetryable, b backoff.Backoff, settings retry.Settings) PoolOption {
+	if retryable == nil {
+		retryable = retry.Retryable
+	}
+
+	if b == nil {
+		b = backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second)
+	}
+
+	return poolOptionFunc(func(o *poolOptions) {
+		o.retryable = retryable
+		o.backoff = b
+		o.settings = settings
+	})
+}
+
+// PoolContinueOnError lets a Pool continue processing the remaining tasks if a task fails.
+// The errors of all failed tasks are then returned together once all tasks have been processed.
+// By default, the first failed task stops the Pool.
+func PoolContinueOnError() PoolOption {
+	return poolOptionFunc(func(o *poolOptions) {
+		o.continueOnError = true
+	})
+}
+
+// PoolResult summarizes the tasks processed by Pool.Run.
+type PoolResult struct {
+	// Succeeded is the number of tasks that have been processed successfully.
+	Succeeded uint64
+
+	// Failed is the number of tasks that have failed, after retrying them if configured via PoolRetry.
+	Failed uint64
+}
+
+// Pool processes tasks of type T using a bounded number of concurrent workers,
+// each of which calls the handler of the Pool with one task at a time.
+type Pool[T any] struct {
+	workers int
+	handler func(context.Context, T) error
+	options poolOptions
+}
+
+// NewPool returns a new Pool that processes tasks with handler using the specified number of concurrent workers.
+// Panics if workers is less than 1.
+func NewPool[T any](workers int, handler func(context.Context, T) error, options ...PoolOption) *Pool[T] {
+	if workers < 1 {
+		panic("workers must be at least 1")
+	}
+
+	p := &Pool[T]{workers: workers, handler: handler}
+	for _, option := range options {
+		option.apply(&p.options)
+	}
+
+	return p
+}
+
+// Run processes all tasks from the channel until it is closed and returns how many of them succeeded and failed.
+// Unless PoolContinueOnError is set, Run stops processing on the first failed task, cancels the context passed to
+// the handler and returns the task's error. Otherwise, the errors of all failed tasks are returned joined.
+// Run also stops if ctx is canceled and then returns the context error.
+func (p *Pool[T]) Run(ctx context.Context, tasks <-chan T) (PoolResult, error) {
+	var succeeded, failed atomic.Uint64
+	var mu sync.Mutex
+	var errs []error
+
+	g, ctx := errgroup.WithContext(ctx)
+	for range p.workers {
+		g.Go(func() error {
+			for {
+				task, err := receive(ctx, tasks)
+				if err != nil {
+					return err
+				}
+				if task == nil {
+					return nil
+				}
+
+				if err := p.process(ctx, *task); err != nil {
+					failed.Add(1)
+
+					if !p.options.continueOnError || ctx.Err() != nil {
+						return err
+					}
+
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+
+					continue
+				}
+
+				succeeded.Add(1)
+			}
+		})
+	}
+
+	err := g.Wait()
+	if err == nil {
+		err = stderrors.Join(errs...)
+	}
+
+	return PoolResult{Succeeded: succeeded.Load(), Failed: failed.Load()}, err
+}
+
+// process calls the handler with task, retrying it if configured via PoolRetry.
+func (p *Pool[T]) process(ctx context.Context, task T) error {
+	if p.options.backoff == nil {
+		return p.handler(ctx, task)
+	}
+
+	return retry.WithBackoff(
+		ctx,
+		func(ctx context.Context) error { return p.handler(ctx, task) },
+		p.options.retryable,
+		p.options.backoff,
+		p.options.settings,
+	)
+}
+
+// poolOptions stores the options of Pool.
+type poolOptions struct {
+	retryable       retry.IsRetryable
+	backoff         backoff.Backoff
+	settings        retry.Settings
+	continueOnError bool
+}
+
+// poolOptionFunc is a function that implements PoolOption.
+type poolOptionFunc func(*poolOptions)
+
+func (f poolOptionFunc) apply(o *poolOptions) {
+	f(o)
+}
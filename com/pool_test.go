@@ -0,0 +1,120 @@
+package com
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestPool_Run(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	t.Run("bounded", func(t *testing.T) {
+		var running, maxRunning atomic.Int64
+
+		r, err := NewPool(4, func(context.Context, int) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			return nil
+		}).Run(context.Background(), generate(50))
+
+		require.NoError(t, err)
+		require.Equal(t, PoolResult{Succeeded: 50}, r)
+		require.LessOrEqual(t, maxRunning.Load(), int64(4))
+	})
+
+	t.Run("stop-on-error", func(t *testing.T) {
+		r, err := NewPool(4, func(_ context.Context, i int) error {
+			if i == 10 {
+				return errFailed
+			}
+
+			return nil
+		}).Run(context.Background(), generate(50))
+
+		require.ErrorIs(t, err, errFailed)
+		require.Equal(t, uint64(1), r.Failed)
+		require.Less(t, r.Succeeded, uint64(49))
+	})
+
+	t.Run("continue-on-error", func(t *testing.T) {
+		r, err := NewPool(4, func(_ context.Context, i int) error {
+			if i%10 == 0 {
+				return errors.Wrapf(errFailed, "task %d", i)
+			}
+
+			return nil
+		}, PoolContinueOnError()).Run(context.Background(), generate(50))
+
+		require.ErrorIs(t, err, errFailed)
+		for _, i := range []string{"0", "10", "20", "30", "40"} {
+			require.ErrorContains(t, err, "task "+i+": failed")
+		}
+		require.Equal(t, PoolResult{Succeeded: 45, Failed: 5}, r)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		var mu sync.Mutex
+		attempts := make(map[int]int)
+
+		r, err := NewPool(4, func(_ context.Context, i int) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if attempts[i]++; i%2 == 0 && attempts[i] < 3 {
+				return errFailed
+			}
+
+			return nil
+		}, PoolRetry(
+			func(err error) bool { return errors.Is(err, errFailed) },
+			backoff.NewExponentialWithJitter(time.Millisecond, 2*time.Millisecond),
+			retry.Settings{},
+		)).Run(context.Background(), generate(10))
+
+		require.NoError(t, err)
+		require.Equal(t, PoolResult{Succeeded: 10}, r)
+		require.Equal(t, map[int]int{0: 3, 1: 1, 2: 3, 3: 1, 4: 3, 5: 1, 6: 3, 7: 1, 8: 3, 9: 1}, attempts)
+	})
+
+	t.Run("retry-defaults", func(t *testing.T) {
+		var attempts atomic.Int64
+
+		r, err := NewPool(1, func(context.Context, int) error {
+			if attempts.Add(1) < 3 {
+				return syscall.ECONNRESET
+			}
+
+			return nil
+		}, PoolRetry(nil, nil, retry.Settings{})).Run(context.Background(), generate(1))
+
+		require.NoError(t, err)
+		require.Equal(t, PoolResult{Succeeded: 1}, r)
+		require.Equal(t, int64(3), attempts.Load(), "retryable errors must be retried by default")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewPool(4, func(context.Context, int) error { return nil }).Run(ctx, make(chan int))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
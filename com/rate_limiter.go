@@ -0,0 +1,106 @@
+package com
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"sync"
+	"time"
+)
+
+// RateLimiterOption configures a RateLimiter.
+type RateLimiterOption interface {
+	apply(*RateLimiter)
+}
+
+// RateLimiterLogger logs a notice at info level when operations are throttled,
+// at most once per logging interval, see logging.Logger.Interval.
+func RateLimiterLogger(logger *logging.Logger) RateLimiterOption {
+	return rateLimiterOptionFunc(func(l *RateLimiter) {
+		l.logger = logger
+	})
+}
+
+// RateLimiter limits the rate of operations using a token bucket, e.g. to cap the load on a backend shared by
+// the database, Redis and HTTP clients. A single RateLimiter can be shared by any number of goroutines.
+// A nil RateLimiter doesn't limit anything.
+type RateLimiter struct {
+	limiter *rate.Limiter
+	logger  *logging.Logger
+
+	mu         sync.Mutex
+	throttled  uint64
+	lastNotice time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter that allows perSecond operations per second on average
+// and bursts of up to burst operations at once.
+// Panics if perSecond is not positive or burst is less than 1.
+func NewRateLimiter(perSecond float64, burst int, options ...RateLimiterOption) *RateLimiter {
+	if perSecond <= 0 {
+		panic("perSecond must be positive")
+	}
+
+	if burst < 1 {
+		panic("burst must be at least 1")
+	}
+
+	l := &RateLimiter{limiter: rate.NewLimiter(rate.Limit(perSecond), burst)}
+	for _, option := range options {
+		option.apply(l)
+	}
+
+	return l
+}
+
+// Wait blocks until the next operation is allowed.
+// Returns an error if ctx is canceled or its deadline would expire before that.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil || l.limiter.Allow() {
+		return nil
+	}
+
+	l.throttle()
+
+	if err := l.limiter.Wait(ctx); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		return errors.Wrap(err, "can't wait for rate limiter")
+	}
+
+	return nil
+}
+
+// throttle counts a throttled operation and logs the throttling notice if due.
+func (l *RateLimiter) throttle() {
+	if l.logger == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.throttled++
+
+	if now := time.Now(); now.Sub(l.lastNotice) >= l.logger.Interval() {
+		l.logger.Infow("Throttling operations due to rate limit",
+			zap.Float64("limit", float64(l.limiter.Limit())),
+			zap.Int("burst", l.limiter.Burst()),
+			zap.Uint64("throttled", l.throttled))
+
+		l.throttled = 0
+		l.lastNotice = now
+	}
+}
+
+// rateLimiterOptionFunc is a function that implements RateLimiterOption.
+type rateLimiterOptionFunc func(*RateLimiter)
+
+// apply implements the RateLimiterOption interface.
+func (f rateLimiterOptionFunc) apply(l *RateLimiter) {
+	f(l)
+}
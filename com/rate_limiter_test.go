@@ -0,0 +1,70 @@
+package com
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		var l *RateLimiter
+		require.NoError(t, l.Wait(context.Background()))
+	})
+
+	t.Run("burst", func(t *testing.T) {
+		l := NewRateLimiter(10, 3)
+
+		start := time.Now()
+		for range 3 {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+		require.Less(t, time.Since(start), 50*time.Millisecond)
+
+		require.NoError(t, l.Wait(context.Background()))
+		require.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		l := NewRateLimiter(0.001, 1)
+		require.NoError(t, l.Wait(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.ErrorIs(t, l.Wait(ctx), context.Canceled)
+	})
+
+	t.Run("deadline too short", func(t *testing.T) {
+		l := NewRateLimiter(0.001, 1)
+		require.NoError(t, l.Wait(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		require.ErrorContains(t, l.Wait(ctx), "can't wait for rate limiter")
+	})
+
+	t.Run("notice", func(t *testing.T) {
+		core, logs := observer.New(zapcore.InfoLevel)
+		l := NewRateLimiter(1000, 1, RateLimiterLogger(logging.NewLogger(zap.New(core).Sugar(), time.Hour)))
+
+		for range 5 {
+			require.NoError(t, l.Wait(context.Background()))
+		}
+
+		entries := logs.FilterMessage("Throttling operations due to rate limit").All()
+		require.Len(t, entries, 1)
+		require.Equal(t, uint64(1), entries[0].ContextMap()["throttled"])
+	})
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	require.Panics(t, func() { NewRateLimiter(0, 1) })
+	require.Panics(t, func() { NewRateLimiter(1, 0) })
+}
@@ -51,7 +51,10 @@ import (
 	"github.com/goccy/go-yaml"
 	"github.com/jessevdk/go-flags"
 	"github.com/pkg/errors"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"reflect"
 )
 
@@ -64,8 +67,8 @@ var ErrInvalidArgument = stderrors.New("invalid argument")
 // in the value pointed to by v. If v is nil or not a struct pointer,
 // FromYAMLFile returns an [ErrInvalidArgument] error.
 // It is possible to define default values via the struct tag `default`.
-// The function also validates the configuration using the Validate method
-// of the provided [Validator] interface.
+// The function also validates the configuration using the rules declared via the struct tag `validate`,
+// see [ValidateTags], and the Validate method of the provided [Validator] interface.
 //
 // Example usage:
 //
@@ -91,26 +94,44 @@ var ErrInvalidArgument = stderrors.New("invalid argument")
 //		// ...
 //	}
 func FromYAMLFile(name string, v Validator) error {
+	return FromYAMLFileWithIncludes(name, "", v)
+}
+
+// FromYAMLFileWithIncludes works like [FromYAMLFile], but after parsing the given YAML file,
+// it also parses all files with the extension .yml or .yaml in includeDir, e.g. /etc/icingadb/conf.d,
+// into the same value in lexicographical order of their names, so that later files override earlier ones:
+// Mappings of structs are merged, while all other values, including maps and sequences, are replaced.
+// Files without any content, e.g. only comments, are ignored in includeDir.
+// If includeDir is empty or does not exist, only the given YAML file is parsed.
+// The configuration is validated once after all files have been parsed, see also [ValidateTags].
+func FromYAMLFileWithIncludes(name, includeDir string, v Validator) error {
 	if err := validateNonNilStructPointer(v); err != nil {
 		return errors.WithStack(err)
 	}
 
-	// #nosec G304 -- Accept user-controlled input for config file.
-	f, err := os.Open(name)
-	if err != nil {
-		return errors.Wrap(err, "can't open YAML file "+name)
-	}
-	defer func(f *os.File) {
-		_ = f.Close()
-	}(f)
-
 	if err := defaults.Set(v); err != nil {
 		return errors.Wrap(err, "can't set config defaults")
 	}
 
-	d := yaml.NewDecoder(f, yaml.DisallowUnknownField())
-	if err := d.Decode(v); err != nil {
-		return errors.Wrap(err, "can't parse YAML file "+name)
+	if err := parseYAMLFile(name, v, false); err != nil {
+		return err
+	}
+
+	if includeDir != "" {
+		includes, err := yamlFiles(includeDir)
+		if err != nil {
+			return err
+		}
+
+		for _, include := range includes {
+			if err := parseYAMLFile(include, v, true); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := ValidateTags(v); err != nil {
+		return errors.Wrap(err, "invalid configuration")
 	}
 
 	if err := v.Validate(); err != nil {
@@ -125,6 +146,7 @@ type EnvOptions = env.Options
 
 // FromEnv parses environment variables and stores the result in the value pointed to by v.
 // If v is nil or not a struct pointer, FromEnv returns an [ErrInvalidArgument] error.
+// Like [FromYAMLFile], FromEnv validates the configuration using [ValidateTags] and the Validate method of v.
 func FromEnv(v Validator, options EnvOptions) error {
 	if err := validateNonNilStructPointer(v); err != nil {
 		return errors.WithStack(err)
@@ -138,6 +160,10 @@ func FromEnv(v Validator, options EnvOptions) error {
 		return errors.Wrap(err, "can't parse environment variables")
 	}
 
+	if err := ValidateTags(v); err != nil {
+		return errors.Wrap(err, "invalid configuration")
+	}
+
 	if err := v.Validate(); err != nil {
 		return errors.Wrap(err, "invalid configuration")
 	}
@@ -189,6 +215,55 @@ func ParseFlags(v any) error {
 	return nil
 }
 
+// parseYAMLFile parses the given YAML file into v. If allowEmpty is true, files without any content are ignored.
+func parseYAMLFile(name string, v any, allowEmpty bool) error {
+	// #nosec G304 -- Accept user-controlled input for config file.
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "can't open YAML file "+name)
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	d := yaml.NewDecoder(f, yaml.DisallowUnknownField())
+	if err := d.Decode(v); err != nil {
+		if allowEmpty && errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		return errors.Wrap(err, "can't parse YAML file "+name)
+	}
+
+	return nil
+}
+
+// yamlFiles returns the paths of all files with the extension .yml or .yaml in dir
+// in lexicographical order of their names. Returns no files if dir does not exist.
+func yamlFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "can't read include directory "+dir)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yml", ".yaml":
+			if !entry.IsDir() {
+				// os.ReadDir returns the entries sorted by name.
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+
+	return files, nil
+}
+
 // validateNonNilStructPointer checks if the provided value is a non-nil pointer to a struct.
 // It returns an error if the value is not a pointer, is nil, or does not point to a struct.
 func validateNonNilStructPointer(v any) error {
@@ -9,6 +9,7 @@ import (
 	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -324,6 +325,52 @@ func TestFromYAMLFile(t *testing.T) {
 	})
 }
 
+func TestFromYAMLFileWithIncludes(t *testing.T) {
+	writeFile := func(t *testing.T, name, content string) string {
+		require.NoError(t, os.WriteFile(name, []byte(content), 0600))
+		return name
+	}
+
+	dir := t.TempDir()
+	main := writeFile(t, filepath.Join(dir, "config.yml"), "key: main\nembedded:\n  embedded-key: main\n")
+	confd := filepath.Join(dir, "conf.d")
+	require.NoError(t, os.Mkdir(confd, 0700))
+
+	t.Run("Missing include directory", func(t *testing.T) {
+		var actual embeddedConfig
+		require.NoError(t, FromYAMLFileWithIncludes(main, filepath.Join(dir, "missing"), &actual))
+		require.Equal(t, "main", actual.Key)
+		require.Equal(t, "main", actual.Embedded.Key)
+	})
+
+	t.Run("Fragments", func(t *testing.T) {
+		writeFile(t, filepath.Join(confd, "20-embedded.yaml"), "embedded:\n  embedded-key: 20\n")
+		writeFile(t, filepath.Join(confd, "10-key.yml"), "key: 10\n")
+		writeFile(t, filepath.Join(confd, "30-key.yml"), "key: 30\n")
+		writeFile(t, filepath.Join(confd, "40-empty.yml"), "# Nothing to see here.\n")
+		writeFile(t, filepath.Join(confd, "50-ignored.yml.dist"), "key: ignored\n")
+
+		var actual embeddedConfig
+		require.NoError(t, FromYAMLFileWithIncludes(main, confd, &actual))
+		require.Equal(t, "30", actual.Key)
+		require.Equal(t, "20", actual.Embedded.Key)
+	})
+
+	t.Run("Invalid fragment", func(t *testing.T) {
+		writeFile(t, filepath.Join(confd, "60-unknown.yml"), "unknown: key\n")
+
+		var actual embeddedConfig
+		err := FromYAMLFileWithIncludes(main, confd, &actual)
+		require.ErrorContains(t, err, "60-unknown.yml")
+	})
+
+	t.Run("Validated once", func(t *testing.T) {
+		var actual validateInvalid
+		err := FromYAMLFileWithIncludes(writeFile(t, filepath.Join(dir, "empty.yml"), "{}"), t.TempDir(), &actual)
+		require.ErrorIs(t, err, errInvalidConfiguration)
+	})
+}
+
 func TestParseFlags(t *testing.T) {
 	t.Run("Simple flags", func(t *testing.T) {
 		originalArgs := os.Args
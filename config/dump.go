@@ -0,0 +1,159 @@
+package config
+
+import (
+	"encoding"
+	"github.com/goccy/go-yaml"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+)
+
+// Redacted replaces the values of secret fields in the output of Dump.
+const Redacted = "***"
+
+// Dump serializes the given configuration, usually after it has been populated by [FromYAMLFile] or [FromEnv],
+// to YAML, e.g. for troubleshooting or support bundles. It honors the same `yaml` struct tags as parsing does.
+// Fields tagged with `secret:"true"`, e.g. passwords, are replaced with [Redacted] if they are set,
+// so that the output can be shared safely. Zero secrets are kept, so that it's obvious that they are not set.
+func Dump(v any) ([]byte, error) {
+	out, err := yaml.Marshal(redact(reflect.ValueOf(v)))
+	if err != nil {
+		return nil, errors.Wrap(err, "can't dump configuration")
+	}
+
+	return out, nil
+}
+
+// redact returns a copy of v that can be serialized to YAML, with secret struct fields replaced by Redacted.
+// Structs are converted to yaml.MapSlice, so that the order of their fields is retained.
+func redact(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		if isMarshaler(v) {
+			return v.Interface()
+		}
+
+		return redact(v.Elem())
+	case reflect.Struct:
+		if isMarshaler(v) {
+			return v.Interface()
+		}
+
+		return redactStruct(v, nil)
+	case reflect.Map:
+		if v.IsNil() || isMarshaler(v) {
+			return v.Interface()
+		}
+
+		m := make(map[any]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m[iter.Key().Interface()] = redact(iter.Value())
+		}
+
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 || isMarshaler(v) {
+			return v.Interface()
+		}
+
+		s := make([]any, 0, v.Len())
+		for i := range v.Len() {
+			s = append(s, redact(v.Index(i)))
+		}
+
+		return s
+	default:
+		return v.Interface()
+	}
+}
+
+// redactStruct appends the fields of the struct v to out as redact does and returns the result.
+func redactStruct(v reflect.Value, out yaml.MapSlice) yaml.MapSlice {
+	if out == nil {
+		out = yaml.MapSlice{}
+	}
+
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("yaml")
+		if tag == "" {
+			tag = field.Tag.Get("json")
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fv := v.Field(i)
+
+		if hasOption(options, "inline") {
+			for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+				fv = fv.Elem()
+			}
+
+			if fv.Kind() == reflect.Struct {
+				out = redactStruct(fv, out)
+			}
+
+			continue
+		}
+
+		if hasOption(options, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		var value any
+		if field.Tag.Get("secret") == "true" && !fv.IsZero() {
+			value = Redacted
+		} else {
+			value = redact(fv)
+		}
+
+		out = append(out, yaml.MapItem{Key: name, Value: value})
+	}
+
+	return out
+}
+
+// hasOption returns whether the comma-separated struct tag options contain the given option.
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isMarshaler returns whether v serializes itself to YAML, so that it must not be inspected further.
+func isMarshaler(v reflect.Value) bool {
+	if !v.CanInterface() {
+		return false
+	}
+
+	switch v.Interface().(type) {
+	case yaml.BytesMarshaler, yaml.InterfaceMarshaler, encoding.TextMarshaler:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDump(t *testing.T) {
+	type database struct {
+		Host     string `yaml:"host"`
+		Password string `yaml:"password" secret:"true"`
+		TLS      `yaml:",inline"`
+	}
+
+	type dumpConfig struct {
+		Database database          `yaml:"database"`
+		Token    []byte            `yaml:"token" secret:"true"`
+		Empty    string            `yaml:"empty" secret:"true"`
+		Omitted  string            `yaml:"omitted,omitempty"`
+		Interval time.Duration     `yaml:"interval"`
+		Options  map[string]string `yaml:"options"`
+		Hosts    []*database       `yaml:"hosts"`
+		Nil      *database         `yaml:"nil"`
+		Ignored  string            `yaml:"-"`
+		Default  int
+		private  string
+	}
+
+	c := dumpConfig{
+		Database: database{Host: "localhost", Password: "secret", TLS: TLS{Enable: true, Key: "key.pem"}},
+		Token:    []byte("token"),
+		Interval: time.Minute,
+		Options:  map[string]string{"a": "b"},
+		Hosts:    []*database{{Host: "replica", Password: "secret"}},
+		Ignored:  "ignored",
+		Default:  42,
+		private:  "private",
+	}
+
+	out, err := Dump(&c)
+	require.NoError(t, err)
+	require.Equal(t, `database:
+  host: localhost
+  password: "***"
+  tls: true
+  cert: ""
+  key: key.pem
+  ca: ""
+  insecure: false
+token: "***"
+empty: ""
+interval: 1m0s
+options:
+  a: b
+hosts:
+- host: replica
+  password: "***"
+  tls: false
+  cert: ""
+  key: ""
+  ca: ""
+  insecure: false
+nil: null
+default: 42
+`, string(out))
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"go.uber.org/zap"
+	"slices"
+)
+
+// Feature is a feature, e.g. an experimental one, that can be toggled via FeatureFlags.
+// Libraries and daemons declare their features as package-level variables, for example:
+//
+//	var AdaptiveBatching = config.Feature{Name: "adaptive_batching", Default: false}
+type Feature struct {
+	// Name is the name of the feature in the configuration.
+	Name string
+
+	// Default specifies whether the feature is enabled if it's not configured.
+	Default bool
+}
+
+// FeatureFlags enables or disables features by name. Use it as a field of configuration structs, e.g.
+//
+//	Features config.FeatureFlags `yaml:"features" env:"FEATURES"`
+//
+// which can then be set via YAML as a mapping of feature names to booleans
+// or via the environment as comma-separated name:bool pairs, e.g. FEATURES=adaptive_batching:true.
+type FeatureFlags map[string]bool
+
+// Enabled returns whether the feature is enabled, i.e. its configured value or, if not configured, its default.
+func (f FeatureFlags) Enabled(feature Feature) bool {
+	if enabled, ok := f[feature.Name]; ok {
+		return enabled
+	}
+
+	return feature.Default
+}
+
+// Active returns the names of the given features that are enabled.
+func (f FeatureFlags) Active(features ...Feature) []string {
+	var active []string
+	for _, feature := range features {
+		if f.Enabled(feature) {
+			active = append(active, feature.Name)
+		}
+	}
+
+	slices.Sort(active)
+
+	return active
+}
+
+// Unknown returns the configured names that are not among the given features, e.g. due to typos.
+func (f FeatureFlags) Unknown(features ...Feature) []string {
+	var unknown []string
+	for name := range f {
+		if !slices.ContainsFunc(features, func(feature Feature) bool { return feature.Name == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+
+	slices.Sort(unknown)
+
+	return unknown
+}
+
+// Log logs the active ones of the given features at info level and warns about unknown feature names,
+// which is intended to be called once at startup. Pass the SugaredLogger of a logging.Logger,
+// as this package can't depend on the logging package.
+func (f FeatureFlags) Log(logger *zap.SugaredLogger, features ...Feature) {
+	if active := f.Active(features...); len(active) > 0 {
+		logger.Infow("Enabled features", zap.Strings("features", active))
+	}
+
+	if unknown := f.Unknown(features...); len(unknown) > 0 {
+		logger.Warnw("Ignoring unknown features", zap.Strings("features", unknown))
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"os"
+	"testing"
+)
+
+// featureFlagsConfig is a test configuration struct with feature flags.
+type featureFlagsConfig struct {
+	Features FeatureFlags `yaml:"features" env:"FEATURES"`
+	validateValid
+}
+
+var (
+	testFeatureA = Feature{Name: "a"}
+	testFeatureB = Feature{Name: "b", Default: true}
+	testFeatureC = Feature{Name: "c", Default: true}
+)
+
+func TestFeatureFlags(t *testing.T) {
+	f := FeatureFlags{"a": true, "c": false, "typo": true}
+
+	require.True(t, f.Enabled(testFeatureA))
+	require.True(t, f.Enabled(testFeatureB))
+	require.False(t, f.Enabled(testFeatureC))
+	require.False(t, FeatureFlags(nil).Enabled(testFeatureA))
+	require.True(t, FeatureFlags(nil).Enabled(testFeatureB))
+
+	require.Equal(t, []string{"a", "b"}, f.Active(testFeatureC, testFeatureB, testFeatureA))
+	require.Equal(t, []string{"typo"}, f.Unknown(testFeatureA, testFeatureB, testFeatureC))
+}
+
+func TestFeatureFlags_Parse(t *testing.T) {
+	expected := FeatureFlags{"a": true, "c": false}
+
+	t.Run("FromEnv", func(t *testing.T) {
+		var c featureFlagsConfig
+		require.NoError(t, FromEnv(&c, EnvOptions{Environment: map[string]string{"FEATURES": "a:true,c:false"}}))
+		require.Equal(t, expected, c.Features)
+	})
+
+	t.Run("FromYAMLFile", func(t *testing.T) {
+		var c featureFlagsConfig
+		testutils.WithYAMLFile(t, "features:\n  a: true\n  c: false\n", func(file *os.File) {
+			require.NoError(t, FromYAMLFile(file.Name(), &c))
+		})
+		require.Equal(t, expected, c.Features)
+	})
+}
+
+func TestFeatureFlags_Log(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	FeatureFlags{"a": true, "typo": true}.Log(zap.New(core).Sugar(), testFeatureA, testFeatureB)
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	require.Equal(t, "Enabled features", entries[0].Message)
+	require.Equal(t, []any{"a", "b"}, entries[0].ContextMap()["features"])
+	require.Equal(t, "Ignoring unknown features", entries[1].Message)
+	require.Equal(t, []any{"typo"}, entries[1].ContextMap()["features"])
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"github.com/pkg/errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateTags checks the fields of the struct pointed to by v, including nested structs,
+// against the rules declared in their validate struct tags and returns an error for the first violated rule.
+// [FromYAMLFile] and [FromEnv] call ValidateTags after setting defaults and parsing the configuration,
+// before calling the Validate method, which then only needs to check what can't be expressed via tags.
+// Multiple rules are separated by commas, e.g. `validate:"min=1,max=15"`. The following rules are supported:
+//
+//   - required: The value must not be the zero value.
+//   - min=N, max=N: Numbers, including durations such as min=1s, must be at least or at most N.
+//     Strings, slices and maps must have at least or at most N characters or elements.
+//   - oneof=a b c: The value must be one of the space-separated values.
+//   - hostport: The string must be in the form host:port, unless empty.
+//
+// Fields are referred to by their YAML names in errors.
+func ValidateTags(v any) error {
+	if err := validateNonNilStructPointer(v); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return validateStruct(reflect.ValueOf(v).Elem(), "")
+}
+
+// validateStruct validates the fields of the struct value rv, whose field names are prefixed with path.
+func validateStruct(rv reflect.Value, path string) error {
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := fieldPath(path, field)
+		value := rv.Field(i)
+
+		if tag, ok := field.Tag.Lookup("validate"); ok {
+			for _, rule := range strings.Split(tag, ",") {
+				if err := validateRule(value, name, rule); err != nil {
+					return err
+				}
+			}
+		}
+
+		if value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		if value.Kind() == reflect.Struct {
+			if err := validateStruct(value, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldPath returns the YAML name of field prefixed with path, or path itself if the field is inlined.
+func fieldPath(path string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	switch name {
+	case "":
+		if field.Anonymous || strings.Contains(field.Tag.Get("yaml"), "inline") {
+			return path
+		}
+
+		name = field.Name
+	case "-":
+		name = field.Name
+	}
+
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// validateRule checks value, which is referred to as name in errors, against a single rule of a validate tag.
+func validateRule(value reflect.Value, name, rule string) error {
+	rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+	if rule == "required" {
+		if value.IsZero() {
+			return errors.Errorf("%s is required", name)
+		}
+
+		return nil
+	}
+
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	switch rule {
+	case "min", "max":
+		return validateBound(value, name, rule, param)
+	case "oneof":
+		options := strings.Fields(param)
+		for _, option := range options {
+			if fmt.Sprint(value.Interface()) == option {
+				return nil
+			}
+		}
+
+		return errors.Errorf("%s must be one of %s, got %q", name, strings.Join(options, ", "), fmt.Sprint(value.Interface()))
+	case "hostport":
+		if value.Kind() != reflect.String {
+			return errors.Errorf("can't validate %s: hostport is not supported for %s", name, value.Type())
+		}
+
+		if s := value.String(); s != "" {
+			_, port, err := net.SplitHostPort(s)
+			if err == nil {
+				_, err = strconv.ParseUint(port, 10, 16)
+			}
+			if err != nil {
+				return errors.Errorf("%s must be in the form host:port, got %q", name, s)
+			}
+		}
+
+		return nil
+	default:
+		return errors.Errorf("can't validate %s: unknown rule %q", name, rule)
+	}
+}
+
+// validateBound checks value against the min or max rule with the given param.
+func validateBound(value reflect.Value, name, rule, param string) error {
+	var order int
+	var err error
+	lengthOf := ""
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var bound int64
+		if value.Type() == reflect.TypeOf(time.Duration(0)) {
+			var d time.Duration
+			d, err = time.ParseDuration(param)
+			bound = int64(d)
+		} else {
+			bound, err = strconv.ParseInt(param, 10, 64)
+		}
+		order = cmp.Compare(value.Int(), bound)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var bound uint64
+		bound, err = strconv.ParseUint(param, 10, 64)
+		order = cmp.Compare(value.Uint(), bound)
+	case reflect.Float32, reflect.Float64:
+		var bound float64
+		bound, err = strconv.ParseFloat(param, 64)
+		order = cmp.Compare(value.Float(), bound)
+	case reflect.String:
+		var bound int
+		bound, err = strconv.Atoi(param)
+		order = cmp.Compare(len([]rune(value.String())), bound)
+		lengthOf = "characters"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		var bound int
+		bound, err = strconv.Atoi(param)
+		order = cmp.Compare(value.Len(), bound)
+		lengthOf = "elements"
+	default:
+		return errors.Errorf("can't validate %s: %s is not supported for %s", name, rule, value.Type())
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "can't validate %s: invalid %s %q", name, rule, param)
+	}
+
+	switch {
+	case rule == "min" && order < 0:
+		if lengthOf != "" {
+			return errors.Errorf("%s must have at least %s %s", name, param, lengthOf)
+		}
+
+		if param == "0" {
+			return errors.Errorf("%s cannot be negative", name)
+		}
+
+		return errors.Errorf("%s must be at least %s", name, param)
+	case rule == "max" && order > 0:
+		if lengthOf != "" {
+			return errors.Errorf("%s must have at most %s %s", name, param, lengthOf)
+		}
+
+		return errors.Errorf("%s must be at most %s", name, param)
+	}
+
+	return nil
+}
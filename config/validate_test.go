@@ -0,0 +1,84 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// validateTagsPart is a nested part of validateTagsConfig.
+type validateTagsPart struct {
+	Count int `yaml:"count" validate:"min=1,max=15"`
+}
+
+// validateTagsConfig is a test configuration struct with validate tags.
+type validateTagsConfig struct {
+	Name     string            `yaml:"name" validate:"required"`
+	Interval time.Duration     `yaml:"interval" validate:"min=1s"`
+	Mode     string            `yaml:"mode" validate:"oneof=block drop"`
+	Addr     string            `yaml:"addr" validate:"hostport"`
+	Hosts    []string          `yaml:"hosts" validate:"max=2"`
+	Limit    *uint             `yaml:"limit" validate:"min=0,max=10"`
+	Part     validateTagsPart  `yaml:"part"`
+	Inlined  validateTagsPart  `yaml:",inline"`
+	Optional *validateTagsPart `yaml:"optional"`
+}
+
+func TestValidateTags(t *testing.T) {
+	valid := func() *validateTagsConfig {
+		return &validateTagsConfig{
+			Name:     "icinga",
+			Interval: time.Second,
+			Mode:     "drop",
+			Addr:     "localhost:5665",
+			Hosts:    []string{"a", "b"},
+			Part:     validateTagsPart{Count: 1},
+			Inlined:  validateTagsPart{Count: 15},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *validateTagsConfig)
+		error  string
+	}{
+		{"valid", func(*validateTagsConfig) {}, ""},
+		{"empty hostport", func(c *validateTagsConfig) { c.Addr = "" }, ""},
+		{"required", func(c *validateTagsConfig) { c.Name = "" }, "name is required"},
+		{"min duration", func(c *validateTagsConfig) { c.Interval = time.Millisecond }, "interval must be at least 1s"},
+		{"oneof", func(c *validateTagsConfig) { c.Mode = "x" }, `mode must be one of block, drop, got "x"`},
+		{"hostport", func(c *validateTagsConfig) { c.Addr = "localhost" }, "addr must be in the form host:port"},
+		{"hostport port", func(c *validateTagsConfig) { c.Addr = "localhost:http" }, "addr must be in the form host:port"},
+		{"max length", func(c *validateTagsConfig) { c.Hosts = append(c.Hosts, "c") }, "hosts must have at most 2 elements"},
+		{"pointer", func(c *validateTagsConfig) { c.Limit = new(uint); *c.Limit = 11 }, "limit must be at most 10"},
+		{"nested", func(c *validateTagsConfig) { c.Part.Count = 0 }, "part.count must be at least 1"},
+		{"inlined", func(c *validateTagsConfig) { c.Inlined.Count = 16 }, "count must be at most 15"},
+		{"nested pointer", func(c *validateTagsConfig) { c.Optional = &validateTagsPart{} }, "optional.count must be at least 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid()
+			tt.modify(c)
+
+			err := ValidateTags(c)
+			if tt.error == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.error)
+			}
+		})
+	}
+
+	t.Run("unknown rule", func(t *testing.T) {
+		require.ErrorContains(t, ValidateTags(&struct {
+			Key string `yaml:"key" validate:"email"`
+		}{}), `can't validate key: unknown rule "email"`)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		require.EqualError(t, ValidateTags(&struct {
+			Key int `yaml:"key" validate:"min=0"`
+		}{Key: -1}), "key cannot be negative")
+	})
+}
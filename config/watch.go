@@ -0,0 +1,191 @@
+package config
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// DefaultWatchInterval is the default interval at which Watch checks the YAML file for modifications.
+const DefaultWatchInterval = 5 * time.Second
+
+// WatchOption configures Watch.
+type WatchOption interface {
+	apply(*watchOptions)
+}
+
+// watchOptions holds the settings of Watch.
+type watchOptions struct {
+	interval   time.Duration
+	includeDir string
+	onError    func(error)
+}
+
+// watchOptionFunc implements WatchOption.
+type watchOptionFunc func(*watchOptions)
+
+// apply implements WatchOption.
+func (f watchOptionFunc) apply(o *watchOptions) {
+	f(o)
+}
+
+// WithWatchInterval overrides DefaultWatchInterval. A non-positive interval disables checking for modifications,
+// so that the configuration is only reloaded on SIGHUP.
+func WithWatchInterval(interval time.Duration) WatchOption {
+	return watchOptionFunc(func(o *watchOptions) {
+		o.interval = interval
+	})
+}
+
+// WithWatchIncludeDir lets Watch parse the configuration with the YAML files in includeDir,
+// as [FromYAMLFileWithIncludes] does. Adding, removing or modifying such files then also triggers a reload.
+func WithWatchIncludeDir(includeDir string) WatchOption {
+	return watchOptionFunc(func(o *watchOptions) {
+		o.includeDir = includeDir
+	})
+}
+
+// WithOnError sets a callback for errors that occur while reloading the configuration, e.g. to log them.
+// The previous configuration remains in effect in this case.
+func WithOnError(f func(error)) WatchOption {
+	return watchOptionFunc(func(o *watchOptions) {
+		o.onError = f
+	})
+}
+
+// Watch parses the given YAML file into a new value returned by newFn, as [FromYAMLFile] does,
+// or [FromYAMLFileWithIncludes] if configured via [WithWatchIncludeDir], and re-parses it whenever the process
+// receives SIGHUP or any of the files has been modified. Modifications are detected by polling the modification
+// times and sizes of the files every [DefaultWatchInterval], which can be changed via [WithWatchInterval],
+// so they may take up to that long to be picked up. Each time the re-parsed configuration is valid and differs
+// from the previous one, it is passed to onChange, allowing daemons to apply parts of it, e.g. log levels,
+// without a restart.
+// Watch blocks until ctx is canceled, in which case it returns nil.
+// It returns an error only if the initial configuration cannot be parsed.
+func Watch[T Validator](
+	ctx context.Context, name string, newFn func() T, onChange func(T), options ...WatchOption,
+) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	return watch(ctx, name, newFn, onChange, hup, options...)
+}
+
+// watch implements Watch, reloading the configuration on receiving from reload.
+func watch[T Validator](
+	ctx context.Context, name string, newFn func() T, onChange func(T), reload <-chan os.Signal, options ...WatchOption,
+) error {
+	o := watchOptions{interval: DefaultWatchInterval}
+	for _, option := range options {
+		option.apply(&o)
+	}
+
+	stats, err := filesStat(name, o.includeDir)
+	if err != nil {
+		return err
+	}
+
+	current := newFn()
+	if err := FromYAMLFileWithIncludes(name, o.includeDir, current); err != nil {
+		return err
+	}
+
+	var tick <-chan time.Time
+	if o.interval > 0 {
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-reload:
+		case <-tick:
+			s, err := filesStat(name, o.includeDir)
+			if err != nil {
+				if o.onError != nil {
+					o.onError(err)
+				}
+
+				continue
+			}
+
+			if slices.EqualFunc(s, stats, stat.equal) {
+				continue
+			}
+		case <-ctx.Done():
+			return nil
+		}
+
+		if s, err := filesStat(name, o.includeDir); err == nil {
+			stats = s
+		}
+
+		next := newFn()
+		if err := FromYAMLFileWithIncludes(name, o.includeDir, next); err != nil {
+			if o.onError != nil {
+				o.onError(errors.Wrap(err, "can't reload configuration"))
+			}
+
+			continue
+		}
+
+		if !reflect.DeepEqual(current, next) {
+			current = next
+			onChange(next)
+		}
+	}
+}
+
+// stat contains the properties of a file used to detect modifications.
+type stat struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+// equal returns whether s and other describe the same state of the same file.
+func (s stat) equal(other stat) bool {
+	return s.name == other.name && s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// filesStat returns the stats of the given file and the YAML files in includeDir, if not empty.
+func filesStat(name, includeDir string) ([]stat, error) {
+	files := []string{name}
+	if includeDir != "" {
+		includes, err := yamlFiles(includeDir)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, includes...)
+	}
+
+	stats := make([]stat, 0, len(files))
+	for _, file := range files {
+		s, err := fileStat(file)
+		if err != nil {
+			return nil, err
+		}
+
+		stats = append(stats, s)
+	}
+
+	return stats, nil
+}
+
+// fileStat returns the stat of the given file.
+func fileStat(name string) (stat, error) {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return stat{}, errors.Wrap(err, "can't stat YAML file "+name)
+	}
+
+	return stat{name: name, modTime: fi.ModTime(), size: fi.Size()}, nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.yml")
+	write := func(t *testing.T, content string, modTime time.Time) {
+		// Replace the file atomically, so that Watch does not see it without the final modification time.
+		tmp := name + ".tmp"
+		require.NoError(t, os.WriteFile(tmp, []byte(content), 0600))
+		require.NoError(t, os.Chtimes(tmp, modTime, modTime))
+		require.NoError(t, os.Rename(tmp, name))
+	}
+
+	start := time.Now().Add(-time.Hour)
+	write(t, "key: initial\n", start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reload := make(chan os.Signal)
+	changes := make(chan *simpleConfig)
+	errs := make(chan error)
+	done := make(chan error)
+
+	go func() {
+		done <- watch(ctx, name, func() *simpleConfig { return &simpleConfig{} }, func(c *simpleConfig) {
+			changes <- c
+		}, reload, WithWatchInterval(10*time.Millisecond), WithOnError(func(err error) { errs <- err }))
+	}()
+
+	// Wait for the initial configuration to be parsed, as the watcher only accepts reloads afterwards.
+	reload <- os.Interrupt
+
+	t.Run("modified", func(t *testing.T) {
+		write(t, "key: modified\n", start.Add(time.Second))
+		require.Equal(t, "modified", (<-changes).Key)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		write(t, "key: modified\n", start.Add(2*time.Second))
+		reload <- os.Interrupt
+
+		select {
+		case c := <-changes:
+			require.Fail(t, "unchanged configuration must not be passed to onChange", "%#v", c)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		write(t, "unknown: key1\n", start.Add(3*time.Second))
+		require.ErrorContains(t, <-errs, "can't reload configuration")
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		// Keep the modification time and size, so that only the reload signal can trigger reloading.
+		write(t, "key: reloaded\n", start.Add(3*time.Second))
+		reload <- os.Interrupt
+		require.Equal(t, "reloaded", (<-changes).Key)
+	})
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatch_IncludeDir(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "config.yml")
+	includeDir := filepath.Join(dir, "conf.d")
+
+	require.NoError(t, os.WriteFile(name, []byte("key: initial\n"), 0600))
+	require.NoError(t, os.Mkdir(includeDir, 0700))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reload := make(chan os.Signal)
+	changes := make(chan *simpleConfig)
+	done := make(chan error)
+
+	go func() {
+		done <- watch(ctx, name, func() *simpleConfig { return &simpleConfig{} }, func(c *simpleConfig) {
+			changes <- c
+		}, reload, WithWatchInterval(10*time.Millisecond), WithWatchIncludeDir(includeDir))
+	}()
+
+	// Wait for the initial configuration to be parsed, as the watcher only accepts reloads afterwards.
+	reload <- os.Interrupt
+
+	require.NoError(t, os.WriteFile(filepath.Join(includeDir, "override.yml"), []byte("key: included\n"), 0600))
+	require.Equal(t, "included", (<-changes).Key, "added include files must be parsed")
+
+	require.NoError(t, os.Remove(filepath.Join(includeDir, "override.yml")))
+	require.Equal(t, "initial", (<-changes).Key, "removed include files must not be parsed anymore")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatch_Initial(t *testing.T) {
+	err := Watch(context.Background(), filepath.Join(t.TempDir(), "missing.yml"),
+		func() *simpleConfig { return &simpleConfig{} }, func(*simpleConfig) {})
+	require.Error(t, err)
+}
@@ -0,0 +1,164 @@
+// Package configsync provides ConfigSync, which synchronizes entities from a source, e.g. Redis, to a sink,
+// e.g. the database, by computing and applying their delta, so that projects don't have to implement
+// the full config sync of Icinga DB on their own.
+package configsync
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/database/delta"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/icinga/icinga-go-library/structify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"time"
+)
+
+// Source yields the desired entities of a Subject.
+type Source interface {
+	// Yield streams the desired entities into the returned channel, which is closed once all have been sent.
+	Yield(ctx context.Context) (<-chan database.Entity, <-chan error)
+}
+
+// Sink stores the entities of a Subject.
+type Sink interface {
+	// Yield streams the stored entities into the returned channel, which is closed once all have been sent.
+	Yield(ctx context.Context) (<-chan database.Entity, <-chan error)
+
+	// Apply creates, updates and deletes the entities of d.
+	Apply(ctx context.Context, d *delta.Delta) error
+}
+
+// Subject is a kind of entities synchronized from Source to Sink.
+type Subject struct {
+	// Name identifies the subject in log messages, e.g. the name of its table.
+	Name string
+
+	Source Source
+	Sink   Sink
+}
+
+// ConfigSync synchronizes the entities of subjects from their sources to their sinks.
+type ConfigSync struct {
+	logger *logging.Logger
+}
+
+// NewConfigSync returns a new ConfigSync that logs its progress using logger.
+func NewConfigSync(logger *logging.Logger) *ConfigSync {
+	return &ConfigSync{logger: logger}
+}
+
+// Sync synchronizes all subjects concurrently. For each subject, the entities of its source and sink are
+// compared using delta.Compute and the resulting delta is applied to its sink.
+// If a subject fails, the synchronization of all subjects is canceled and the error is returned.
+func (s *ConfigSync) Sync(ctx context.Context, subjects ...Subject) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	for _, subject := range subjects {
+		g.Go(func() error {
+			return errors.Wrapf(s.sync(ctx, subject), "can't sync %s", subject.Name)
+		})
+	}
+
+	return g.Wait()
+}
+
+// sync synchronizes a single subject.
+func (s *ConfigSync) sync(ctx context.Context, subject Subject) error {
+	start := time.Now()
+
+	// gctx is canceled once g.Wait returns, so it must not be used for applying the delta.
+	g, gctx := errgroup.WithContext(ctx)
+
+	actual, errs := subject.Sink.Yield(gctx)
+	com.ErrgroupReceive(g, errs)
+
+	desired, errs := subject.Source.Yield(gctx)
+	com.ErrgroupReceive(g, errs)
+
+	var d *delta.Delta
+	g.Go(func() (err error) {
+		d, err = delta.Compute(gctx, actual, desired)
+
+		return err
+	})
+
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	if d.Empty() {
+		s.logger.Debugw("Nothing to sync", zap.String("subject", subject.Name))
+
+		return nil
+	}
+
+	s.logger.Infow("Syncing",
+		zap.String("subject", subject.Name),
+		zap.Int("create", len(d.Create)),
+		zap.Int("update", len(d.Update)),
+		zap.Int("delete", len(d.Delete)))
+
+	if err := subject.Sink.Apply(ctx, d); err != nil {
+		return err
+	}
+
+	s.logger.Infow("Finished sync", zap.String("subject", subject.Name), zap.Duration("took", time.Since(start)))
+
+	return nil
+}
+
+// RedisSource returns a Source that yields the entities decoded from the values of the hash stored at key
+// using redis.YieldEntities. structifier must return database.Entity values.
+func RedisSource(client *redis.Client, key string, structifier structify.MapStructifier) Source {
+	return sourceFunc(func(ctx context.Context) (<-chan database.Entity, <-chan error) {
+		return redis.YieldEntities[database.Entity](ctx, client, key, structifier)
+	})
+}
+
+// DatabaseSink returns a Sink that yields the entities created by factoryFunc from the rows of their table,
+// selected by the query of database.DB.BuildSelectStmt, and applies deltas using delta.Delta.Apply.
+// The rows are selected from the primary database, as a lagging replica would result in wrong deltas.
+func DatabaseSink(db *database.DB, factoryFunc database.EntityFactoryFunc) Sink {
+	return databaseSink{db: db, factoryFunc: factoryFunc}
+}
+
+// databaseSink implements DatabaseSink.
+type databaseSink struct {
+	db          *database.DB
+	factoryFunc database.EntityFactoryFunc
+}
+
+// Yield implements the Sink interface.
+func (s databaseSink) Yield(ctx context.Context) (<-chan database.Entity, <-chan error) {
+	e := s.factoryFunc()
+
+	var scope any = struct{}{}
+	if scoper, ok := e.(database.Scoper); ok {
+		scope = scoper.Scope()
+	}
+
+	return s.db.YieldAll(ctx, s.factoryFunc, s.db.BuildSelectStmt(e, e), scope, database.YieldFromPrimary())
+}
+
+// Apply implements the Sink interface.
+func (s databaseSink) Apply(ctx context.Context, d *delta.Delta) error {
+	return d.Apply(ctx, s.db)
+}
+
+// sourceFunc is a function that implements Source.
+type sourceFunc func(ctx context.Context) (<-chan database.Entity, <-chan error)
+
+// Yield implements the Source interface.
+func (f sourceFunc) Yield(ctx context.Context) (<-chan database.Entity, <-chan error) {
+	return f(ctx)
+}
+
+// Assert interface compliance.
+var (
+	_ Sink   = databaseSink{}
+	_ Source = sourceFunc(nil)
+)
@@ -0,0 +1,148 @@
+package configsync
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/database/delta"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+type testID string
+
+func (id testID) String() string {
+	return string(id)
+}
+
+type testEntity struct {
+	Id  testID
+	Sum []byte
+}
+
+func (e *testEntity) Fingerprint() database.Fingerprinter {
+	return e
+}
+
+func (e *testEntity) ID() database.ID {
+	return e.Id
+}
+
+func (e *testEntity) SetID(id database.ID) {
+	e.Id = id.(testID)
+}
+
+func (e *testEntity) Checksum() []byte {
+	return e.Sum
+}
+
+// testStore is a Source and Sink that holds its entities in memory.
+type testStore struct {
+	entities []database.Entity
+	err      error
+
+	applied *delta.Delta
+}
+
+func (s *testStore) Yield(ctx context.Context) (<-chan database.Entity, <-chan error) {
+	entities := make(chan database.Entity)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(entities)
+
+		if s.err != nil {
+			errs <- s.err
+			return
+		}
+
+		for _, e := range s.entities {
+			select {
+			case entities <- e:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return entities, errs
+}
+
+func (s *testStore) Apply(ctx context.Context, d *delta.Delta) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.applied = d
+
+	return nil
+}
+
+func TestConfigSync_Sync(t *testing.T) {
+	entity := func(id, checksum string) database.Entity {
+		return &testEntity{Id: testID(id), Sum: []byte(checksum)}
+	}
+
+	sorted := func(ebi delta.EntitiesById) []string {
+		ids := make([]string, 0, len(ebi))
+		for id := range ebi {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+
+		return ids
+	}
+
+	s := NewConfigSync(logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Second))
+
+	t.Run("delta", func(t *testing.T) {
+		hosts := &testStore{entities: []database.Entity{entity("a", "1"), entity("b", "1"), entity("c", "1")}}
+		services := &testStore{entities: []database.Entity{entity("x", "1")}}
+
+		err := s.Sync(context.Background(),
+			Subject{
+				Name:   "host",
+				Source: &testStore{entities: []database.Entity{entity("b", "2"), entity("c", "1"), entity("d", "1")}},
+				Sink:   hosts,
+			},
+			Subject{Name: "service", Source: &testStore{entities: []database.Entity{entity("x", "1")}}, Sink: services},
+		)
+		require.NoError(t, err)
+
+		require.NotNil(t, hosts.applied)
+		require.Equal(t, []string{"d"}, sorted(hosts.applied.Create))
+		require.Equal(t, []string{"b"}, sorted(hosts.applied.Update))
+		require.Equal(t, []string{"a"}, sorted(hosts.applied.Delete))
+
+		require.Nil(t, services.applied, "empty delta must not be applied")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		sink := &testStore{entities: []database.Entity{entity("a", "1")}}
+
+		err := s.Sync(context.Background(), Subject{Name: "host", Source: &testStore{err: errFailed}, Sink: sink})
+		require.ErrorIs(t, err, errFailed)
+		require.ErrorContains(t, err, "can't sync host")
+		require.Nil(t, sink.applied)
+	})
+
+	t.Run("apply-context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		source := &testStore{entities: []database.Entity{entity("a", "1")}}
+		sink := &testStore{}
+
+		err := s.Sync(ctx, Subject{Name: "host", Source: source, Sink: sink})
+		require.NoError(t, err, "delta must be applied with a context that is still alive")
+		require.NotNil(t, sink.applied)
+		require.Equal(t, []string{"a"}, sorted(sink.applied.Create))
+	})
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+)
+
+// BuildCountStmt returns a SELECT COUNT(*) query for the given table struct.
+// Like BuildSelectStmt, it only counts the rows matching the scope of table if it implements Scoper
+// and adds the hints of table if it implements QueryHinter.
+func (db *DB) BuildCountStmt(table interface{}) string {
+	q := fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, TableName(table))
+
+	if scoper, ok := table.(Scoper); ok {
+		where, _ := db.BuildWhere(scoper.Scope())
+		q += ` WHERE ` + where
+	}
+
+	if hinter, ok := table.(QueryHinter); ok {
+		q = db.AddHints(q, hinter.QueryHints()...)
+	}
+
+	return q
+}
+
+// BuildExistsStmt returns a SELECT EXISTS query for the given table struct that checks whether any row
+// matches the WHERE clause built from the specified scope struct using BuildWhere, or any row at all if scope is nil.
+func (db *DB) BuildExistsStmt(table interface{}, scope interface{}) string {
+	q := fmt.Sprintf(`SELECT 1 FROM "%s"`, TableName(table))
+
+	if scope != nil {
+		where, _ := db.BuildWhere(scope)
+		q += ` WHERE ` + where
+	}
+
+	return `SELECT EXISTS (` + q + `)`
+}
+
+// Count returns the number of rows in the table of the given struct using the query of BuildCountStmt,
+// i.e. only the rows matching its scope if it implements Scoper.
+// The query is executed on DB.Reader, unless set otherwise via WithQuerier, and retried on retryable errors.
+func (db *DB) Count(ctx context.Context, table interface{}) (count uint64, err error) {
+	var scope interface{}
+	if scoper, ok := table.(Scoper); ok {
+		scope = scoper.Scope()
+	}
+
+	err = db.queryScalar(ctx, "Count", db.BuildCountStmt(table), scope, &count)
+
+	return count, err
+}
+
+// ExistsByScope reports whether any row in the table of the given struct matches the specified scope struct
+// using the query of BuildExistsStmt.
+// The query is executed on DB.Reader, unless set otherwise via WithQuerier, and retried on retryable errors.
+func (db *DB) ExistsByScope(ctx context.Context, table interface{}, scope interface{}) (exists bool, err error) {
+	err = db.queryScalar(ctx, "ExistsByScope", db.BuildExistsStmt(table, scope), scope, &exists)
+
+	return exists, err
+}
+
+// queryScalar executes the query with named placeholders bound to the fields of arg, if not nil,
+// and scans the single column of its single row into dest. name is the name of the calling operation for tracing.
+func (db *DB) queryScalar(ctx context.Context, name, query string, arg interface{}, dest interface{}) (err error) {
+	_, custom := db.querier(ctx)
+	op, table := parseQuery(query)
+
+	ctx, span := db.startSpan(ctx, name, query, 0)
+	defer func() { endSpan(span, err) }()
+
+	return retry.WithBackoff(
+		ctx,
+		func(ctx context.Context) error {
+			// Choose the reader for each attempt, so that retries are not performed on a failed replica.
+			q, reader := db.readQuerier(ctx)
+
+			stmt, args := query, []interface{}(nil)
+			if arg != nil {
+				var err error
+				if stmt, args, err = q.BindNamed(query, arg); err != nil {
+					return retry.MarkPermanent(errors.Wrapf(err, "can't bind named parameters for %q", query))
+				}
+			}
+
+			if err := q.QueryRowxContext(ctx, stmt, args...).Scan(dest); err != nil {
+				err = cantPerformQueryWith(custom, err, query)
+				reader.checkReplica(err)
+
+				return err
+			}
+
+			return nil
+		},
+		retry.Retryable,
+		db.retryBackoff(ctx),
+		db.retrySettings(ctx, table, op),
+	)
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testScopedHost is a testHost which only selects the hosts of an environment.
+type testScopedHost struct {
+	testHost
+}
+
+func (testScopedHost) TableName() string {
+	return "test_host"
+}
+
+func (testScopedHost) Scope() any {
+	return struct{ EnvironmentId string }{"env"}
+}
+
+func TestDB_BuildCountStmt(t *testing.T) {
+	db := newTestDb(t, MySQL)
+
+	require.Equal(t, `SELECT COUNT(*) FROM "test_host"`, db.BuildCountStmt(testHost{}))
+	require.Equal(t,
+		`SELECT COUNT(*) FROM "test_host" WHERE "environment_id" = :environment_id`, db.BuildCountStmt(testScopedHost{}))
+	require.Equal(t,
+		`SELECT /*+ MAX_EXECUTION_TIME(1000) NO_INDEX_MERGE(test_host) */ COUNT(*) FROM "test_host"`,
+		db.BuildCountStmt(testHintedHost{}))
+}
+
+func TestDB_BuildExistsStmt(t *testing.T) {
+	db := newTestDb(t, MySQL)
+
+	require.Equal(t, `SELECT EXISTS (SELECT 1 FROM "test_host")`, db.BuildExistsStmt(testHost{}, nil))
+	require.Equal(t,
+		`SELECT EXISTS (SELECT 1 FROM "test_host" WHERE "id" = :id)`, db.BuildExistsStmt(testHost{}, testHost{}))
+}
+
+func TestDB_Count(t *testing.T) {
+	c := &scalarTestConnector{value: int64(42)}
+	db := newScalarTestDb(t, c)
+
+	count, err := db.Count(context.Background(), testScopedHost{})
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), count)
+	require.Equal(t, []string{`SELECT COUNT(*) FROM "test_host" WHERE "environment_id" = ?`}, c.queries)
+	require.Equal(t, [][]driver.Value{{"env"}}, c.args)
+}
+
+func TestDB_ExistsByScope(t *testing.T) {
+	for _, value := range []driver.Value{int64(0), int64(1), false, true} {
+		c := &scalarTestConnector{value: value}
+		db := newScalarTestDb(t, c)
+
+		exists, err := db.ExistsByScope(context.Background(), testHost{}, testHost{Id: "host"})
+		require.NoError(t, err)
+		require.Equal(t, value == int64(1) || value == true, exists, "%#v", value)
+		require.Equal(t, []string{`SELECT EXISTS (SELECT 1 FROM "test_host" WHERE "id" = ?)`}, c.queries)
+		require.Equal(t, [][]driver.Value{{"host"}}, c.args)
+	}
+}
+
+// newScalarTestDb returns a MySQL DB whose queries are answered by c.
+func newScalarTestDb(t *testing.T, c *scalarTestConnector) *DB {
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(c), MySQL), &Options{}, "test", logging.NewLogger(zap.NewNop().Sugar(), time.Hour))
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// scalarTestConnector is a driver.Connector whose connections yield a single row with value for every query.
+type scalarTestConnector struct {
+	value driver.Value
+
+	mu      sync.Mutex
+	queries []string
+	args    [][]driver.Value
+}
+
+func (c *scalarTestConnector) Connect(context.Context) (driver.Conn, error) {
+	return scalarTestConn{c}, nil
+}
+
+func (c *scalarTestConnector) Driver() driver.Driver {
+	return nil
+}
+
+type scalarTestConn struct {
+	c *scalarTestConnector
+}
+
+func (scalarTestConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (scalarTestConn) Close() error {
+	return nil
+}
+
+func (scalarTestConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c scalarTestConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+
+	c.c.queries = append(c.c.queries, query)
+	c.c.args = append(c.c.args, values)
+
+	return &scalarTestRows{values: []driver.Value{c.c.value}}, nil
+}
+
+type scalarTestRows struct {
+	values []driver.Value
+}
+
+func (*scalarTestRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (*scalarTestRows) Close() error {
+	return nil
+}
+
+func (r *scalarTestRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"github.com/icinga/icinga-go-library/com"
+	"sync"
+	"time"
+)
+
+// adaptiveBatchSize is a feedback controller that adjusts the number of rows per chunk
+// so that executing a chunk takes about the target latency.
+type adaptiveBatchSize struct {
+	mu     sync.Mutex
+	size   int
+	lower  int
+	target time.Duration
+}
+
+// newAdaptiveBatchSize returns a new adaptiveBatchSize, starting with size rows per chunk,
+// which never shrinks below lower.
+func newAdaptiveBatchSize(size, lower int, target time.Duration) *adaptiveBatchSize {
+	return &adaptiveBatchSize{size: max(size, lower), lower: lower, target: target}
+}
+
+// Size returns the current number of rows per chunk, limited to upper.
+func (a *adaptiveBatchSize) Size(upper int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return min(a.size, upper)
+}
+
+// Observe takes into account that executing a chunk of the given number of rows took the given time
+// and adjusts the size of the next chunks, limited to upper, towards the number of rows which can
+// presumably be executed in the target latency. The size changes at most by a factor of 2 per call,
+// so that outliers don't cause it to jump.
+func (a *adaptiveBatchSize) Observe(rows int, took time.Duration, upper int) {
+	if rows < 1 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	estimate := rows
+	if took > 0 {
+		estimate = int(float64(rows) * float64(a.target) / float64(took))
+	}
+
+	if size := min(a.size, upper); estimate > size && rows < size {
+		// A partial chunk that was executed fast doesn't mean that a larger one would be, too.
+		estimate = a.size
+	}
+
+	next := min(max(estimate, a.size/2), a.size*2)
+	a.size = max(min(next, upper), a.lower)
+}
+
+// SplitPolicyFactory returns a com.BulkChunkSplitPolicyFactory that splits chunks once they reach the current size,
+// limited to upper, in addition to the chunks split by the given factory.
+func (a *adaptiveBatchSize) SplitPolicyFactory(
+	upper int, splitPolicyFactory com.BulkChunkSplitPolicyFactory[Entity],
+) com.BulkChunkSplitPolicyFactory[Entity] {
+	return func() com.BulkChunkSplitPolicy[Entity] {
+		splitPolicy := splitPolicyFactory()
+		limit := a.Size(upper)
+		n := 0
+
+		return func(entity Entity) bool {
+			if n >= limit {
+				// Start over with a fresh state of the wrapped policy, which only includes the given entity.
+				splitPolicy = splitPolicyFactory()
+				_ = splitPolicy(entity)
+				limit = a.Size(upper)
+				n = 1
+
+				return true
+			}
+
+			if splitPolicy(entity) {
+				limit = a.Size(upper)
+				n = 1
+
+				return true
+			}
+
+			n++
+
+			return false
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveBatchSize_Observe(t *testing.T) {
+	subtests := []struct {
+		name     string
+		size     int
+		rows     int
+		took     time.Duration
+		expected int
+	}{
+		{"on-target", 100, 100, time.Second, 100},
+		{"slow", 100, 100, 4 * time.Second, 50},
+		{"slightly-slow", 100, 100, 1250 * time.Millisecond, 80},
+		{"fast", 100, 100, 100 * time.Millisecond, 200},
+		{"slightly-fast", 100, 100, 800 * time.Millisecond, 125},
+		{"fast-partial", 100, 10, time.Millisecond, 100},
+		{"slow-partial", 100, 10, 200 * time.Millisecond, 50},
+		{"lower-bound", 10, 10, time.Minute, 8},
+		{"upper-bound", 800, 800, time.Millisecond, 1000},
+		{"no-rows", 100, 0, time.Minute, 100},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			a := newAdaptiveBatchSize(st.size, 8, time.Second)
+			a.Observe(st.rows, st.took, 1000)
+			require.Equal(t, st.expected, a.Size(1000))
+		})
+	}
+}
+
+// testEntity is a minimal Entity identified by testID.
+type testEntity struct {
+	Id testID
+}
+
+func (e *testEntity) Fingerprint() Fingerprinter {
+	return e
+}
+
+func (e *testEntity) ID() ID {
+	return e.Id
+}
+
+func (e *testEntity) SetID(id ID) {
+	e.Id = id.(testID)
+}
+
+func TestAdaptiveBatchSize_SplitPolicyFactory(t *testing.T) {
+	chunks := func(factory com.BulkChunkSplitPolicyFactory[Entity], ids ...testID) []int {
+		var chunks []int
+		splitPolicy := factory()
+		n := 0
+
+		for _, id := range ids {
+			if splitPolicy(&testEntity{Id: id}) {
+				chunks = append(chunks, n)
+				n = 0
+			}
+
+			n++
+		}
+
+		return append(chunks, n)
+	}
+
+	a := newAdaptiveBatchSize(3, 1, time.Second)
+
+	t.Run("size", func(t *testing.T) {
+		require.Equal(t, []int{3, 3, 1}, chunks(a.SplitPolicyFactory(1000, com.NeverSplit[Entity]), "1", "2", "3", "4", "5", "6", "7"))
+	})
+
+	t.Run("upper", func(t *testing.T) {
+		require.Equal(t, []int{2, 2, 1}, chunks(a.SplitPolicyFactory(2, com.NeverSplit[Entity]), "1", "2", "3", "4", "5"))
+	})
+
+	t.Run("wrapped", func(t *testing.T) {
+		require.Equal(t, []int{1, 3, 2}, chunks(a.SplitPolicyFactory(1000, SplitOnDupId[Entity]), "1", "1", "2", "3", "4", "1"))
+	})
+}
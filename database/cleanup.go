@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/periodic"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CleanupStmt defines information needed to compose cleanup statements,
+// which delete rows whose time column is older than a given time in bounded batches.
+type CleanupStmt struct {
+	// Table is the name of the table to clean up.
+	Table string
+
+	// Column is the name of the column with the millisecond UNIX timestamp compared with the retention period.
+	Column string
+
+	// Where is an optional additional condition, e.g. "environment_id = :environment_id",
+	// whose named parameters are taken from Args.
+	Where string
+
+	// Args contains the values of the named parameters used in Where.
+	Args map[string]any
+}
+
+// Build assembles the cleanup statement for the specified database driver with the given limit.
+// On PostgreSQL, which does not support DELETE ... LIMIT, rows are addressed by their tableoid and ctid,
+// which also works for partitioned tables, as ctid is unique only within a partition.
+func (stmt *CleanupStmt) Build(driverName string, limit uint64) string {
+	where := fmt.Sprintf(`"%s" < :time`, stmt.Column)
+	if stmt.Where != "" {
+		where += " AND (" + stmt.Where + ")"
+	}
+
+	switch driverName {
+	case MySQL:
+		return fmt.Sprintf(`DELETE FROM "%s" WHERE %s ORDER BY "%s" LIMIT %d`, stmt.Table, where, stmt.Column, limit)
+	case PostgreSQL:
+		return fmt.Sprintf(`WITH rows AS (SELECT tableoid, ctid FROM "%[1]s" WHERE %[2]s ORDER BY "%[3]s" LIMIT %[4]d)`+
+			` DELETE FROM "%[1]s" WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM rows)`,
+			stmt.Table, where, stmt.Column, limit)
+	default:
+		panic(fmt.Sprintf("invalid database type %s", driverName))
+	}
+}
+
+// CleanupOlderThan deletes all rows of the table specified in stmt whose time column is older than olderThan
+// in batches of at most count rows, until no such rows are left. Each batch is retried on retryable errors,
+// unless executed using a Querier set via WithQuerier.
+// The number of rows deleted by each batch is passed to onSuccess as the length of its affectedRows.
+// In dry-run mode, only a single batch is executed, as the rows affected are only estimated.
+// Returns the total number of rows deleted.
+// Returns an error if count is zero or olderThan is not after the UNIX epoch.
+func (db *DB) CleanupOlderThan(
+	ctx context.Context, stmt CleanupStmt, count uint64, olderThan time.Time, onSuccess ...OnSuccess[struct{}],
+) (uint64, error) {
+	if count == 0 {
+		return 0, errors.Errorf("can't clean up table %q in batches of zero rows", stmt.Table)
+	}
+
+	if olderThan.UnixMilli() <= 0 {
+		return 0, errors.Errorf("can't clean up table %q older than %s", stmt.Table, olderThan)
+	}
+
+	var counter com.Counter
+
+	q := stmt.Build(db.DriverName(), count)
+
+	defer db.Log(ctx, q, &counter).Stop()
+
+	args := make(map[string]any, len(stmt.Args)+1)
+	for k, v := range stmt.Args {
+		args[k] = v
+	}
+	args["time"] = types.UnixMilli(olderThan)
+
+	querier, custom := db.querier(ctx)
+
+	for {
+		var rowsDeleted int64
+
+		err := retry.WithBackoff(
+			ctx,
+			func(ctx context.Context) error {
+				rs, err := querier.NamedExecContext(ctx, q, args)
+				if err != nil {
+					return cantPerformQueryWith(custom, err, q)
+				}
+
+				rowsDeleted, err = rs.RowsAffected()
+
+				return errors.WithStack(err)
+			},
+			retry.Retryable,
+			db.retryBackoff(ctx),
+			db.retrySettings(ctx, stmt.Table, OpDelete),
+		)
+		if err != nil {
+			return counter.Total(), err
+		}
+
+		counter.Add(uint64(rowsDeleted))
+
+		for _, onSuccess := range onSuccess {
+			if err := onSuccess(ctx, make([]struct{}, rowsDeleted)); err != nil {
+				return counter.Total(), err
+			}
+		}
+
+		if rowsDeleted < int64(count) || db.Options.DryRun {
+			break
+		}
+	}
+
+	return counter.Total(), nil
+}
+
+// CleanupTable configures the retention of a table for Cleanup.
+type CleanupTable struct {
+	CleanupStmt
+
+	// Retention is the period after which rows are deleted.
+	Retention time.Duration
+}
+
+// Cleanup periodically deletes the rows of the configured tables that are older than their retention period.
+type Cleanup struct {
+	db       *DB
+	logger   *logging.Logger
+	interval time.Duration
+	count    uint64
+	tables   []CleanupTable
+	deleted  map[string]*com.Counter
+}
+
+// NewCleanup returns a new Cleanup, which cleans up the specified tables every interval
+// in batches of at most count rows once started with Run.
+func NewCleanup(db *DB, logger *logging.Logger, interval time.Duration, count uint64, tables ...CleanupTable) *Cleanup {
+	deleted := make(map[string]*com.Counter, len(tables))
+	for _, table := range tables {
+		deleted[table.Table] = &com.Counter{}
+	}
+
+	return &Cleanup{
+		db:       db,
+		logger:   logger,
+		interval: interval,
+		count:    count,
+		tables:   tables,
+		deleted:  deleted,
+	}
+}
+
+// Run cleans up all tables immediately and after each interval, until ctx is canceled or a cleanup fails.
+// The tables are cleaned up concurrently, but each table at most once at a time.
+func (c *Cleanup) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	for _, table := range c.tables {
+		stopper := periodic.Start(ctx, c.interval, func(tick periodic.Tick) {
+			olderThan := tick.Time.Add(-table.Retention)
+
+			rows, err := c.db.CleanupOlderThan(
+				ctx, table.CleanupStmt, c.count, olderThan, OnSuccessIncrement[struct{}](c.deleted[table.Table]),
+			)
+			if err != nil {
+				cancel(errors.Wrapf(err, "can't clean up table %q", table.Table))
+
+				return
+			}
+
+			if rows > 0 {
+				c.logger.Infow("Cleaned up table",
+					zap.String("table", table.Table),
+					zap.Uint64("rows", rows),
+					zap.Time("older_than", olderThan))
+			}
+		}, periodic.Immediate())
+
+		defer stopper.Stop()
+	}
+
+	<-ctx.Done()
+
+	return context.Cause(ctx)
+}
+
+// Deleted returns the total number of rows deleted from the specified table since the Cleanup was created.
+func (c *Cleanup) Deleted(table string) uint64 {
+	if counter, ok := c.deleted[table]; ok {
+		return counter.Total()
+	}
+
+	return 0
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"testing"
+	"time"
+)
+
+func TestCleanupStmt_Build(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		stmt := CleanupStmt{Table: "history", Column: "event_time"}
+
+		testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+			MySQL: `DELETE FROM "history" WHERE "event_time" < :time ORDER BY "event_time" LIMIT 5000`,
+			PostgreSQL: `WITH rows AS (SELECT tableoid, ctid FROM "history" WHERE "event_time" < :time` +
+				` ORDER BY "event_time" LIMIT 5000)` +
+				` DELETE FROM "history" WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM rows)`,
+		}, func(t *testing.T, driver string) string {
+			return stmt.Build(driver, 5000)
+		})
+	})
+
+	t.Run("where", func(t *testing.T) {
+		stmt := CleanupStmt{Table: "history", Column: "event_time", Where: "environment_id = :environment_id"}
+
+		testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+			MySQL: `DELETE FROM "history" WHERE "event_time" < :time AND (environment_id = :environment_id)` +
+				` ORDER BY "event_time" LIMIT 10`,
+			PostgreSQL: `WITH rows AS (SELECT tableoid, ctid FROM "history"` +
+				` WHERE "event_time" < :time AND (environment_id = :environment_id) ORDER BY "event_time" LIMIT 10)` +
+				` DELETE FROM "history" WHERE (tableoid, ctid) IN (SELECT tableoid, ctid FROM rows)`,
+		}, func(t *testing.T, driver string) string {
+			return stmt.Build(driver, 10)
+		})
+	})
+}
+
+func TestDB_CleanupOlderThan(t *testing.T) {
+	stmt := CleanupStmt{Table: "history", Column: "event_time"}
+
+	t.Run("zero-count", func(t *testing.T) {
+		db := newTestDb(t, MySQL)
+
+		_, err := db.CleanupOlderThan(context.Background(), stmt, 0, time.Now())
+		require.ErrorContains(t, err, "batches of zero rows")
+	})
+
+	t.Run("invalid-older-than", func(t *testing.T) {
+		db := newTestDb(t, MySQL)
+
+		for _, olderThan := range []time.Time{{}, time.UnixMilli(0), time.UnixMilli(-1)} {
+			_, err := db.CleanupOlderThan(context.Background(), stmt, 10, olderThan)
+			require.ErrorContains(t, err, "can't clean up table", "%s", olderThan)
+		}
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		d := &stmtTestDriver{prepared: map[string]int{}}
+		logger := logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour)
+		options := &Options{DryRun: true}
+
+		db := newDb(sqlx.NewDb(sql.OpenDB(withDryRun(d, logger, *options)), MySQL), options, "test", logger)
+		t.Cleanup(func() { _ = db.Close() })
+
+		// In dry-run mode, each batch reports one deleted row, which must not cause an endless loop with a count of 1.
+		deleted, err := db.CleanupOlderThan(context.Background(), stmt, 1, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), deleted)
+		require.Equal(t, 0, d.executed)
+	})
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+)
+
+// CompositeKey is the value of a primary key consisting of multiple columns, see CompositeKeyer,
+// in the order of the key columns. It implements ID, so that entities with composite keys can return it from ID.
+type CompositeKey []any
+
+// String implements the ID interface.
+// Returns the quoted values in parentheses, e.g. ("host1", "1").
+func (k CompositeKey) String() string {
+	values := make([]string, 0, len(k))
+	for _, v := range k {
+		values = append(values, strconv.Quote(fmt.Sprint(v)))
+	}
+
+	return "(" + strings.Join(values, ", ") + ")"
+}
+
+// bindIn expands the single slice placeholder of the given query in the form of `IN (?)` with args like sqlx.In.
+// If args are CompositeKeys, the placeholder is expanded to row values, i.e. `IN ((?, ?), (?, ?))`.
+func bindIn(query string, args []any) (string, []any, error) {
+	if len(args) == 0 {
+		return sqlx.In(query, args)
+	}
+
+	if _, ok := args[0].(CompositeKey); !ok {
+		return sqlx.In(query, args)
+	}
+
+	i := strings.Index(query, "(?)")
+	if i < 0 {
+		return "", nil, errors.New("query has no placeholder in the form of (?)")
+	}
+
+	rows := make([]string, 0, len(args))
+	values := make([]any, 0, len(args)*len(args[0].(CompositeKey)))
+	for _, arg := range args {
+		key, ok := arg.(CompositeKey)
+		if !ok {
+			return "", nil, errors.Errorf("can't mix %T with composite keys", arg)
+		}
+		if len(key) == 0 {
+			return "", nil, errors.New("composite key must not be empty")
+		}
+
+		rows = append(rows, "("+strings.Repeat("?, ", len(key)-1)+"?)")
+		values = append(values, key...)
+	}
+
+	return query[:i] + "(" + strings.Join(rows, ", ") + ")" + query[i+len("(?)"):], values, nil
+}
+
+// Assert interface compliance.
+var (
+	_ ID = CompositeKey(nil)
+)
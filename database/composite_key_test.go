@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCompositeKey_String(t *testing.T) {
+	require.Equal(t, `("host1", "1")`, CompositeKey{"host1", 1}.String())
+	require.Equal(t, `("a, b", "c")`, CompositeKey{"a, b", "c"}.String())
+}
+
+func TestBindIn(t *testing.T) {
+	subtests := []struct {
+		name  string
+		args  []any
+		query string
+		error string
+	}{
+		{"ids", []any{1, 2}, `DELETE FROM "t" WHERE id IN (?, ?)`, ""},
+		{"composite", []any{CompositeKey{1, "a"}, CompositeKey{2, "b"}},
+			`DELETE FROM "t" WHERE id IN ((?, ?), (?, ?))`, ""},
+		{"mixed", []any{CompositeKey{1, "a"}, 2}, "", "can't mix int with composite keys"},
+		{"empty", []any{CompositeKey{}}, "", "composite key must not be empty"},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			query, args, err := bindIn(`DELETE FROM "t" WHERE id IN (?)`, st.args)
+			if st.error != "" {
+				require.EqualError(t, err, st.error)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, st.query, query)
+
+			var expected []any
+			for _, arg := range st.args {
+				if key, ok := arg.(CompositeKey); ok {
+					expected = append(expected, key...)
+				} else {
+					expected = append(expected, arg)
+				}
+			}
+			require.Equal(t, expected, args)
+		})
+	}
+}
+
+func TestDB_DeleteStreamed_CompositeKey(t *testing.T) {
+	db, d := newStmtTestDb(t, 0)
+	db.Options.MaxConnectionsPerTable = 1
+	db.Options.MaxPlaceholdersPerStatement = 4
+
+	ids := []any{CompositeKey{1, "a"}, CompositeKey{2, "b"}, CompositeKey{3, "c"}}
+	require.NoError(t, db.Delete(context.Background(), &testCustomvarFlatEntity{}, ids))
+	require.Equal(t, map[string]int{
+		`DELETE FROM "test_customvar_flat_entity" WHERE ("customvar_id", "flatname_checksum") IN ((?, ?), (?, ?))`: 1,
+		`DELETE FROM "test_customvar_flat_entity" WHERE ("customvar_id", "flatname_checksum") IN ((?, ?))`:         1,
+	}, d.prepared)
+}
+
+// testCustomvarFlatEntity is an Entity with a composite primary key.
+type testCustomvarFlatEntity struct {
+	testCustomvarFlat
+	Key CompositeKey `db:"-"`
+}
+
+func (e *testCustomvarFlatEntity) Fingerprint() Fingerprinter {
+	return e
+}
+
+func (e *testCustomvarFlatEntity) ID() ID {
+	return e.Key
+}
+
+func (e *testCustomvarFlatEntity) SetID(id ID) {
+	e.Key = id.(CompositeKey)
+}
@@ -0,0 +1,285 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Condition is a SQL condition composed of column comparisons, which is rendered with named placeholders,
+// so that its values are never concatenated into the query. Use W to compare a column and And, Or and Not
+// to combine conditions, e.g.:
+//
+//	where := database.And(database.W("environment_id").Eq(envId), database.W("state").In(1, 2))
+//	stmt, args := db.BuildSelectWhereStmt(&Host{}, &Host{}, where)
+//	rows, err := db.NamedQueryContext(ctx, stmt, args)
+type Condition interface {
+	// render returns the condition with its values replaced by named placeholders allocated from args.
+	render(args *conditionArgs) string
+}
+
+// BuildCondition renders the condition with named placeholders in the form of :where_N and
+// returns it along with the values of the placeholders.
+func BuildCondition(c Condition) (string, map[string]any) {
+	args := &conditionArgs{prefix: "where_", values: map[string]any{}}
+
+	return c.render(args), args.values
+}
+
+// Column is a column of a table for which a Condition is built, see W.
+type Column string
+
+// W returns the column with the given name to build a Condition for.
+// The name may be qualified with the table, e.g. "host.id", and is quoted when rendered.
+func W(column string) Column {
+	return Column(column)
+}
+
+// Eq returns a Condition that is true if the column is equal to v.
+// If v is NULL, i.e. nil or a driver.Valuer with a nil value, it is equivalent to IsNull.
+func (c Column) Eq(v any) Condition {
+	if isNull(v) {
+		return c.IsNull()
+	}
+
+	return comparison{column: c, op: "=", value: v}
+}
+
+// Ne returns a Condition that is true if the column is not equal to v.
+// If v is NULL, i.e. nil or a driver.Valuer with a nil value, it is equivalent to IsNotNull.
+func (c Column) Ne(v any) Condition {
+	if isNull(v) {
+		return c.IsNotNull()
+	}
+
+	return comparison{column: c, op: "<>", value: v}
+}
+
+// Lt returns a Condition that is true if the column is less than v.
+func (c Column) Lt(v any) Condition {
+	return comparison{column: c, op: "<", value: v}
+}
+
+// Le returns a Condition that is true if the column is less than or equal to v.
+func (c Column) Le(v any) Condition {
+	return comparison{column: c, op: "<=", value: v}
+}
+
+// Gt returns a Condition that is true if the column is greater than v.
+func (c Column) Gt(v any) Condition {
+	return comparison{column: c, op: ">", value: v}
+}
+
+// Ge returns a Condition that is true if the column is greater than or equal to v.
+func (c Column) Ge(v any) Condition {
+	return comparison{column: c, op: ">=", value: v}
+}
+
+// In returns a Condition that is true if the column is equal to any of the given values.
+// Without values, the Condition is always false.
+func (c Column) In(values ...any) Condition {
+	return in{column: c, values: values}
+}
+
+// IsNull returns a Condition that is true if the column is NULL.
+func (c Column) IsNull() Condition {
+	return raw(c.quote() + " IS NULL")
+}
+
+// IsNotNull returns a Condition that is true if the column is not NULL.
+func (c Column) IsNotNull() Condition {
+	return raw(c.quote() + " IS NOT NULL")
+}
+
+// quote returns the quoted column name, e.g. "host"."id". Double quotes within the name are escaped by doubling them.
+func (c Column) quote() string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(string(c), `"`, `""`), ".", `"."`) + `"`
+}
+
+// isNull returns whether v is NULL in SQL, i.e. nil, a nil pointer or a driver.Valuer with a nil value.
+func isNull(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return true
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+
+		return err == nil && value == nil
+	}
+
+	return false
+}
+
+// And returns a Condition that is true if all the given conditions are true, or always true without any.
+func And(conds ...Condition) Condition {
+	return junction{op: "AND", conds: conds, empty: "1 = 1"}
+}
+
+// Or returns a Condition that is true if any of the given conditions is true, or always false without any.
+func Or(conds ...Condition) Condition {
+	return junction{op: "OR", conds: conds, empty: "1 = 0"}
+}
+
+// Not returns a Condition that is true if c is false.
+func Not(c Condition) Condition {
+	return not{c}
+}
+
+// conditionArgs allocates named placeholders for the values of a Condition.
+type conditionArgs struct {
+	prefix string
+	values map[string]any
+}
+
+// add returns a new named placeholder for v.
+func (a *conditionArgs) add(v any) string {
+	name := fmt.Sprintf("%s%d", a.prefix, len(a.values)+1)
+	a.values[name] = v
+
+	return ":" + name
+}
+
+// comparison compares a column with a value using op.
+type comparison struct {
+	column Column
+	op     string
+	value  any
+}
+
+// render implements the Condition interface.
+func (c comparison) render(args *conditionArgs) string {
+	return fmt.Sprintf("%s %s %s", c.column.quote(), c.op, args.add(c.value))
+}
+
+// in checks whether a column is equal to any of the values.
+type in struct {
+	column Column
+	values []any
+}
+
+// render implements the Condition interface.
+func (c in) render(args *conditionArgs) string {
+	if len(c.values) == 0 {
+		return "1 = 0"
+	}
+
+	placeholders := make([]string, 0, len(c.values))
+	for _, v := range c.values {
+		placeholders = append(placeholders, args.add(v))
+	}
+
+	return fmt.Sprintf("%s IN (%s)", c.column.quote(), strings.Join(placeholders, ", "))
+}
+
+// junction combines conditions using op.
+type junction struct {
+	op    string
+	conds []Condition
+	empty string
+}
+
+// render implements the Condition interface.
+func (j junction) render(args *conditionArgs) string {
+	switch len(j.conds) {
+	case 0:
+		return j.empty
+	case 1:
+		return j.conds[0].render(args)
+	}
+
+	rendered := make([]string, 0, len(j.conds))
+	for _, c := range j.conds {
+		rendered = append(rendered, "("+c.render(args)+")")
+	}
+
+	return strings.Join(rendered, " "+j.op+" ")
+}
+
+// not negates a condition.
+type not struct {
+	cond Condition
+}
+
+// render implements the Condition interface.
+func (n not) render(args *conditionArgs) string {
+	return "NOT (" + n.cond.render(args) + ")"
+}
+
+// raw is a condition without values.
+type raw string
+
+// render implements the Condition interface.
+func (r raw) render(*conditionArgs) string {
+	return string(r)
+}
+
+// BuildSelectWhereStmt returns a SELECT statement like BuildSelectStmt that only selects the rows matching where,
+// along with the values of its named placeholders. Unlike BuildSelectStmt, it ignores Scoper.
+func (db *DB) BuildSelectWhereStmt(table interface{}, columns interface{}, where Condition) (string, map[string]any) {
+	cond, args := BuildCondition(where)
+
+	q := fmt.Sprintf(
+		`SELECT "%s" FROM "%s" WHERE %s`,
+		strings.Join(db.columnMap.Columns(columns), `", "`),
+		TableName(table),
+		cond,
+	)
+
+	if hinter, ok := table.(QueryHinter); ok {
+		q = db.AddHints(q, hinter.QueryHints()...)
+	}
+
+	return q, args
+}
+
+// BuildUpdateWhereStmt returns an UPDATE statement for the table of the given struct that sets the columns
+// to the values in set for all rows matching where, along with the values of its named placeholders.
+// Panics if set is empty.
+func (db *DB) BuildUpdateWhereStmt(table interface{}, set map[string]any, where Condition) (string, map[string]any) {
+	if len(set) == 0 {
+		panic("no columns to update")
+	}
+
+	cond, args := BuildCondition(where)
+
+	columns := make([]string, 0, len(set))
+	for column := range set {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	assignments := make([]string, 0, len(columns))
+	for i, column := range columns {
+		name := fmt.Sprintf("set_%d", i+1)
+		args[name] = set[column]
+		assignments = append(assignments, fmt.Sprintf("%s = :%s", Column(column).quote(), name))
+	}
+
+	return fmt.Sprintf(
+		`UPDATE "%s" SET %s WHERE %s`, TableName(table), strings.Join(assignments, ", "), cond,
+	), args
+}
+
+// BuildDeleteWhereStmt returns a DELETE statement for the table of the given struct
+// that deletes all rows matching where, along with the values of its named placeholders.
+func (db *DB) BuildDeleteWhereStmt(from interface{}, where Condition) (string, map[string]any) {
+	cond, args := BuildCondition(where)
+
+	return fmt.Sprintf(`DELETE FROM "%s" WHERE %s`, TableName(from), cond), args
+}
+
+// Assert interface compliance.
+var (
+	_ Condition = comparison{}
+	_ Condition = in{}
+	_ Condition = junction{}
+	_ Condition = not{}
+	_ Condition = raw("")
+)
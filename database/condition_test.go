@@ -0,0 +1,107 @@
+package database
+
+import (
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBuildCondition(t *testing.T) {
+	tests := []struct {
+		name  string
+		cond  Condition
+		where string
+		args  map[string]any
+	}{
+		{"Eq", W("id").Eq(1), `"id" = :where_1`, map[string]any{"where_1": 1}},
+		{"Ne", W("id").Ne(1), `"id" <> :where_1`, map[string]any{"where_1": 1}},
+		{"Lt", W("id").Lt(1), `"id" < :where_1`, map[string]any{"where_1": 1}},
+		{"Le", W("id").Le(1), `"id" <= :where_1`, map[string]any{"where_1": 1}},
+		{"Gt", W("id").Gt(1), `"id" > :where_1`, map[string]any{"where_1": 1}},
+		{"Ge", W("id").Ge(1), `"id" >= :where_1`, map[string]any{"where_1": 1}},
+		{"In", W("id").In(1, 2), `"id" IN (:where_1, :where_2)`, map[string]any{"where_1": 1, "where_2": 2}},
+		{"empty In", W("id").In(), `1 = 0`, map[string]any{}},
+		{"IsNull", W("name").IsNull(), `"name" IS NULL`, map[string]any{}},
+		{"IsNotNull", W("name").IsNotNull(), `"name" IS NOT NULL`, map[string]any{}},
+		{"qualified", W("host.id").Eq(1), `"host"."id" = :where_1`, map[string]any{"where_1": 1}},
+		{"quoted", W(`host"name`).Eq(1), `"host""name" = :where_1`, map[string]any{"where_1": 1}},
+		{"Eq nil", W("name").Eq(nil), `"name" IS NULL`, map[string]any{}},
+		{"Ne nil", W("name").Ne(nil), `"name" IS NOT NULL`, map[string]any{}},
+		{"Eq nil pointer", W("name").Eq((*string)(nil)), `"name" IS NULL`, map[string]any{}},
+		{"Eq NULL", W("name").Eq(types.String{}), `"name" IS NULL`, map[string]any{}},
+		{"Eq valid", W("name").Eq(types.MakeString("a")), `"name" = :where_1`,
+			map[string]any{"where_1": types.MakeString("a")}},
+		{"injection", W("name").Eq("'; DROP TABLE host; --"), `"name" = :where_1`,
+			map[string]any{"where_1": "'; DROP TABLE host; --"}},
+		{"empty And", And(), `1 = 1`, map[string]any{}},
+		{"empty Or", Or(), `1 = 0`, map[string]any{}},
+		{"single And", And(W("id").Eq(1)), `"id" = :where_1`, map[string]any{"where_1": 1}},
+		{
+			"nested",
+			And(W("environment_id").Eq("env"), Or(W("state").In(1, 2), Not(W("name").IsNull()))),
+			`("environment_id" = :where_1) AND (("state" IN (:where_2, :where_3)) OR (NOT ("name" IS NULL)))`,
+			map[string]any{"where_1": "env", "where_2": 1, "where_3": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := BuildCondition(tt.cond)
+			require.Equal(t, tt.where, where)
+			require.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestDB_BuildSelectWhereStmt(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL:      `SELECT "id" FROM "test_host" WHERE ("id" = ?) AND ("id" IN (?, ?))`,
+		PostgreSQL: `SELECT "id" FROM "test_host" WHERE ("id" = $1) AND ("id" IN ($2, $3))`,
+	}, func(t *testing.T, driver string) string {
+		db := newTestDb(t, driver)
+		stmt, args := db.BuildSelectWhereStmt(testHost{}, testHost{}, And(W("id").Eq("a"), W("id").In("b", "c")))
+
+		stmt, values, err := sqlx.Named(stmt, args)
+		require.NoError(t, err)
+		require.Equal(t, []any{"a", "b", "c"}, values)
+
+		return db.Rebind(stmt)
+	})
+}
+
+func TestDB_BuildUpdateWhereStmt(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL:      `UPDATE "test_host" SET "a" = ?, "b" = ? WHERE "id" = ?`,
+		PostgreSQL: `UPDATE "test_host" SET "a" = $1, "b" = $2 WHERE "id" = $3`,
+	}, func(t *testing.T, driver string) string {
+		db := newTestDb(t, driver)
+		stmt, args := db.BuildUpdateWhereStmt(testHost{}, map[string]any{"b": 2, "a": 1}, W("id").Eq("x"))
+
+		stmt, values, err := sqlx.Named(stmt, args)
+		require.NoError(t, err)
+		require.Equal(t, []any{1, 2, "x"}, values)
+
+		return db.Rebind(stmt)
+	})
+
+	require.Panics(t, func() {
+		newTestDb(t, MySQL).BuildUpdateWhereStmt(testHost{}, nil, W("id").Eq("x"))
+	})
+}
+
+func TestDB_BuildDeleteWhereStmt(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL:      `DELETE FROM "test_host" WHERE "id" < ?`,
+		PostgreSQL: `DELETE FROM "test_host" WHERE "id" < $1`,
+	}, func(t *testing.T, driver string) string {
+		db := newTestDb(t, driver)
+		stmt, args := db.BuildDeleteWhereStmt(testHost{}, W("id").Lt("x"))
+
+		stmt, _, err := sqlx.Named(stmt, args)
+		require.NoError(t, err)
+
+		return db.Rebind(stmt)
+	})
+}
@@ -2,7 +2,10 @@ package database
 
 import (
 	"github.com/icinga/icinga-go-library/config"
+	"github.com/icinga/icinga-go-library/utils"
 	"github.com/pkg/errors"
+	"net"
+	"strconv"
 )
 
 // Config defines database client configuration.
@@ -12,9 +15,19 @@ type Config struct {
 	Port       int        `yaml:"port" env:"PORT"`
 	Database   string     `yaml:"database" env:"DATABASE"`
 	User       string     `yaml:"user" env:"USER"`
-	Password   string     `yaml:"password" env:"PASSWORD,unset"`
+	Password   string     `yaml:"password" env:"PASSWORD,unset" secret:"true"`
 	TlsOptions config.TLS `yaml:",inline"`
 	Options    Options    `yaml:"options" envPrefix:"OPTIONS_"`
+
+	// Hosts lists further hosts as host or host:port, or Unix domain socket paths, to fail over to
+	// if connecting to Host fails, e.g. the nodes of a Galera cluster or a PostgreSQL HA setup.
+	// New connections are made to the host that last succeeded, and on failure, the next host is tried with backoff.
+	// If no port is given, Port is used. Host may be empty if Hosts is specified.
+	Hosts []string `yaml:"hosts" env:"HOSTS"`
+
+	// Replicas lists read replicas of the database as host or host:port, or Unix domain socket paths.
+	// If no port is given, Port is used. Replicas are connected to with the same credentials and TLS settings.
+	Replicas []string `yaml:"replicas" env:"REPLICAS"`
 }
 
 // Validate checks constraints in the supplied database configuration and returns an error if they are violated.
@@ -25,10 +38,16 @@ func (c *Config) Validate() error {
 		return unknownDbType(c.Type)
 	}
 
-	if c.Host == "" {
+	if c.Host == "" && len(c.Hosts) == 0 {
 		return errors.New("database host missing")
 	}
 
+	for _, host := range c.Hosts {
+		if host == "" {
+			return errors.New("database host missing")
+		}
+	}
+
 	if c.User == "" {
 		return errors.New("database user missing")
 	}
@@ -37,9 +56,49 @@ func (c *Config) Validate() error {
 		return errors.New("database name missing")
 	}
 
+	for _, replica := range c.Replicas {
+		if replica == "" {
+			return errors.New("database replica host missing")
+		}
+	}
+
+	if len(c.Replicas) > 0 && c.Options.MaxReplicaConnections == 0 {
+		return errors.New("max_replica_connections cannot be 0. Configure a value greater than zero, or use -1 for no connection limit")
+	}
+
 	return c.Options.Validate()
 }
 
+// hosts returns the hosts to connect to in order of preference, i.e. Host followed by Hosts.
+func (c *Config) hosts() []string {
+	if c.Host == "" && len(c.Hosts) > 0 {
+		return c.Hosts
+	}
+
+	return append([]string{c.Host}, c.Hosts...)
+}
+
+// splitAddr splits the given host or host:port address into host and port,
+// using the given default port if it doesn't specify one.
+// Unix domain socket paths and IPv6 addresses without brackets are returned as host.
+func splitAddr(addr string, defaultPort int) (string, int) {
+	if utils.IsUnixAddr(addr) {
+		return addr, defaultPort
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, defaultPort
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return addr, defaultPort
+	}
+
+	return host, p
+}
+
 func unknownDbType(t string) error {
 	return errors.Errorf(`unknown database type %q, must be one of: "mysql", "pgsql"`, t)
 }
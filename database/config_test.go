@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"os"
 	"testing"
+	"time"
 )
 
 // minimalYaml is a constant string representing a minimal valid YAML configuration for
@@ -161,6 +162,60 @@ ca: ca.pem`,
 				},
 			},
 		},
+		{
+			Name: "Hosts",
+			Data: testutils.ConfigTestData{
+				Yaml: `
+type: pgsql
+hosts: [node1, "node2:5433"]
+user: icinga
+database: icingadb`,
+				Env: map[string]string{
+					"TYPE":     "pgsql",
+					"HOSTS":    "node1,node2:5433",
+					"USER":     "icinga",
+					"DATABASE": "icingadb",
+				},
+			},
+			Expected: Config{
+				Type:     "pgsql",
+				Hosts:    []string{"node1", "node2:5433"},
+				User:     "icinga",
+				Database: "icingadb",
+				Options:  defaultOptions,
+			},
+		},
+		{
+			Name: "Replicas",
+			Data: testutils.ConfigTestData{
+				Yaml: minimalYaml + `
+replicas: [replica1, "replica2:5433"]`,
+				Env: withMinimalEnv(map[string]string{"REPLICAS": "replica1,replica2:5433"}),
+			},
+			Expected: Config{
+				Type:     "pgsql",
+				Host:     "localhost",
+				User:     "icinga",
+				Database: "icingadb",
+				Password: "secret",
+				Options:  defaultOptions,
+				Replicas: []string{"replica1", "replica2:5433"},
+			},
+		},
+		{
+			Name: "max_replica_connections cannot be 0 with replicas",
+			Data: testutils.ConfigTestData{
+				Yaml: minimalYaml + `
+replicas: [replica1]
+options:
+  max_replica_connections: 0`,
+				Env: withMinimalEnv(map[string]string{
+					"REPLICAS":                        "replica1",
+					"OPTIONS_MAX_REPLICA_CONNECTIONS": "0",
+				}),
+			},
+			Error: testutils.ErrorContains("max_replica_connections cannot be 0"),
+		},
 		{
 			Name: "max_connections cannot be 0",
 			Data: testutils.ConfigTestData{
@@ -181,6 +236,16 @@ options:
 			},
 			Error: testutils.ErrorContains("max_connections_per_table must be at least 1"),
 		},
+		{
+			Name: "max_deletes_per_table cannot be negative",
+			Data: testutils.ConfigTestData{
+				Yaml: minimalYaml + `
+options:
+  max_deletes_per_table: -1`,
+				Env: withMinimalEnv(map[string]string{"OPTIONS_MAX_DELETES_PER_TABLE": "-1"}),
+			},
+			Error: testutils.ErrorContains("max_deletes_per_table cannot be negative"),
+		},
 		{
 			Name: "max_placeholders_per_statement must be at least 1",
 			Data: testutils.ConfigTestData{
@@ -201,6 +266,16 @@ options:
 			},
 			Error: testutils.ErrorContains("max_rows_per_transaction must be at least 1"),
 		},
+		{
+			Name: "min_batch_size must be at least 1",
+			Data: testutils.ConfigTestData{
+				Yaml: minimalYaml + `
+options:
+  min_batch_size: 0`,
+				Env: withMinimalEnv(map[string]string{"OPTIONS_MIN_BATCH_SIZE": "0"}),
+			},
+			Error: testutils.ErrorContains("min_batch_size must be at least 1"),
+		},
 		{
 			Name: "wsrep_sync_wait can only be set to a number between 0 and 15",
 			Data: testutils.ConfigTestData{
@@ -231,9 +306,14 @@ options:
 				Password: "secret",
 				Options: Options{
 					MaxConnections:              8,
+					MaxReplicaConnections:       defaultOptions.MaxReplicaConnections,
+					ReplicaFailbackInterval:     defaultOptions.ReplicaFailbackInterval,
+					ReplicaConnectTimeout:       defaultOptions.ReplicaConnectTimeout,
 					MaxConnectionsPerTable:      4,
+					SemaphoreWaitWarning:        defaultOptions.SemaphoreWaitWarning,
 					MaxPlaceholdersPerStatement: defaultOptions.MaxPlaceholdersPerStatement,
 					MaxRowsPerTransaction:       defaultOptions.MaxRowsPerTransaction,
+					MinBatchSize:                defaultOptions.MinBatchSize,
 					WsrepSyncWait:               defaultOptions.WsrepSyncWait,
 				},
 			},
@@ -244,16 +324,50 @@ options:
 				Yaml: minimalYaml + `
 options:
   max_connections: 8
+  max_replica_connections: 6
+  replica_failback_interval: 1m
+  replica_connect_timeout: 2s
   max_connections_per_table: 4
+  max_upserts_per_table: 2
+  max_updates_per_table: 3
+  max_deletes_per_table: 1
+  semaphore_wait_warning: 30s
   max_placeholders_per_statement: 4096
   max_rows_per_transaction: 2048
-  wsrep_sync_wait: 15`,
+  chunks_per_transaction: 4
+  batch_target_latency: 500ms
+  min_batch_size: 64
+  statement_cache_size: 32
+  serialize_writes: [host_state, service_state]
+  wsrep_sync_wait: 15
+  session_variables:
+    statement_timeout: 30s
+    lock_timeout: "10"
+  log_queries: true
+  log_queries_redact: [password, pin]
+  dry_run: true`,
 				Env: withMinimalEnv(map[string]string{
 					"OPTIONS_MAX_CONNECTIONS":                "8",
+					"OPTIONS_MAX_REPLICA_CONNECTIONS":        "6",
+					"OPTIONS_REPLICA_FAILBACK_INTERVAL":      "1m",
+					"OPTIONS_REPLICA_CONNECT_TIMEOUT":        "2s",
 					"OPTIONS_MAX_CONNECTIONS_PER_TABLE":      "4",
+					"OPTIONS_MAX_UPSERTS_PER_TABLE":          "2",
+					"OPTIONS_MAX_UPDATES_PER_TABLE":          "3",
+					"OPTIONS_MAX_DELETES_PER_TABLE":          "1",
+					"OPTIONS_SEMAPHORE_WAIT_WARNING":         "30s",
 					"OPTIONS_MAX_PLACEHOLDERS_PER_STATEMENT": "4096",
 					"OPTIONS_MAX_ROWS_PER_TRANSACTION":       "2048",
+					"OPTIONS_CHUNKS_PER_TRANSACTION":         "4",
+					"OPTIONS_BATCH_TARGET_LATENCY":           "500ms",
+					"OPTIONS_MIN_BATCH_SIZE":                 "64",
+					"OPTIONS_STATEMENT_CACHE_SIZE":           "32",
+					"OPTIONS_SERIALIZE_WRITES":               "host_state,service_state",
 					"OPTIONS_WSREP_SYNC_WAIT":                "15",
+					"OPTIONS_SESSION_VARIABLES":              "statement_timeout:30s,lock_timeout:10",
+					"OPTIONS_LOG_QUERIES":                    "true",
+					"OPTIONS_LOG_QUERIES_REDACT":             "password,pin",
+					"OPTIONS_DRY_RUN":                        "true",
 				}),
 			},
 			Expected: Config{
@@ -264,10 +378,26 @@ options:
 				Password: "secret",
 				Options: Options{
 					MaxConnections:              8,
+					MaxReplicaConnections:       6,
+					ReplicaFailbackInterval:     time.Minute,
+					ReplicaConnectTimeout:       2 * time.Second,
 					MaxConnectionsPerTable:      4,
+					MaxUpsertsPerTable:          2,
+					MaxUpdatesPerTable:          3,
+					MaxDeletesPerTable:          1,
+					SemaphoreWaitWarning:        30 * time.Second,
 					MaxPlaceholdersPerStatement: 4096,
 					MaxRowsPerTransaction:       2048,
+					ChunksPerTransaction:        4,
+					BatchTargetLatency:          500 * time.Millisecond,
+					MinBatchSize:                64,
+					StatementCacheSize:          32,
+					SerializeWrites:             []string{"host_state", "service_state"},
 					WsrepSyncWait:               15,
+					SessionVariables:            map[string]string{"statement_timeout": "30s", "lock_timeout": "10"},
+					LogQueries:                  true,
+					LogQueriesRedact:            []string{"password", "pin"},
+					DryRun:                      true,
 				},
 			},
 		},
@@ -300,3 +430,33 @@ options:
 		}
 	})
 }
+
+func TestSplitAddr(t *testing.T) {
+	subtests := []struct {
+		name string
+		addr string
+		host string
+		port int
+	}{
+		{"host", "db", "db", 3306},
+		{"host-port", "db:3307", "db", 3307},
+		{"ipv4", "192.0.2.1", "192.0.2.1", 3306},
+		{"ipv6", "2001:db8::1", "2001:db8::1", 3306},
+		{"ipv6-port", "[2001:db8::1]:3307", "2001:db8::1", 3307},
+		{"unix", "/run/mysqld/mysqld.sock", "/run/mysqld/mysqld.sock", 3306},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			host, port := splitAddr(st.addr, 3306)
+			require.Equal(t, st.host, host)
+			require.Equal(t, st.port, port)
+		})
+	}
+}
+
+func TestConfig_hosts(t *testing.T) {
+	require.Equal(t, []string{"a"}, (&Config{Host: "a"}).hosts())
+	require.Equal(t, []string{"a", "b", "c"}, (&Config{Host: "a", Hosts: []string{"b", "c"}}).hosts())
+	require.Equal(t, []string{"b", "c"}, (&Config{Hosts: []string{"b", "c"}}).hosts())
+}
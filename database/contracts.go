@@ -12,6 +12,13 @@ type Fingerprinter interface {
 	Fingerprint() Fingerprinter
 }
 
+// Checksummer is implemented by entities, or rather their Fingerprint, that provide a checksum of their properties,
+// e.g. computed by checksum.Checksummer, which is compared to detect whether an entity has changed.
+type Checksummer interface {
+	// Checksum returns the checksum of the entity's properties.
+	Checksum() []byte
+}
+
 // ID is a unique identifier of an entity.
 type ID interface {
 	// String returns the string representation form of the ID.
@@ -35,6 +42,21 @@ type Upserter interface {
 	Upsert() any // Upsert partitions the object.
 }
 
+// UpsertWherer is implemented by entities whose upserts should only update a conflicting row
+// if a condition holds, e.g. to skip no-op writes of unchanged rows.
+type UpsertWherer interface {
+	// UpsertWhere returns the condition, in which the columns of the conflicting row are referenced as
+	// "table"."column" and the values to be inserted as EXCLUDED."column",
+	// e.g. `EXCLUDED."checksum" <> "host"."checksum"`.
+	//
+	// For PostgreSQL, the condition is used as the WHERE clause of ON CONFLICT DO UPDATE,
+	// where unqualified "column" references would be ambiguous and are therefore qualified with the table.
+	// For MySQL, each updated column is set conditionally using IF(), with EXCLUDED."column" translated to
+	// VALUES("column"). Since MySQL assigns the columns from left to right, the updated columns referenced by
+	// the condition are set last, and the condition should reference at most one updated column.
+	UpsertWhere() string
+}
+
 // TableNamer implements the TableName method,
 // which returns the table of the object.
 type TableNamer interface {
@@ -48,6 +70,32 @@ type Scoper interface {
 	Scope() any
 }
 
+// QueryHinter is implemented by tables whose SELECT queries built by BuildSelectStmt should carry optimizer hints,
+// e.g. to bound heavy queries on the server side, see DB.AddHints.
+type QueryHinter interface {
+	// QueryHints returns the optimizer hints, e.g. MAX_EXECUTION_TIME(1000) for MySQL.
+	QueryHints() []string
+}
+
+// Versioner is implemented by entities with an integer version column for optimistic concurrency control.
+// BuildUpdateStmt then renders statements that only update the row if its version still matches the entity's
+// and increment the version, so that NamedBulkExecTx, and thus UpdateStreamed,
+// can detect concurrent modifications, which are reported as *StaleUpdateError. Once the transaction has been
+// committed, NamedBulkExecTx increments the version field of the updated entities, if they are pointers to structs,
+// so that they can be updated again.
+type Versioner interface {
+	// VersionColumn returns the name of the version column.
+	VersionColumn() string
+}
+
+// CompositeKeyer is implemented by entities whose table has a primary key consisting of multiple columns.
+// BuildUpdateStmt then matches rows by all of these columns instead of the id column, and
+// BuildDeleteStmt renders statements that delete rows by CompositeKey values, which DeleteStreamed expects as IDs.
+type CompositeKeyer interface {
+	// KeyColumns returns the columns of the primary key.
+	KeyColumns() []string
+}
+
 // PgsqlOnConflictConstrainter implements the PgsqlOnConflictConstraint method,
 // which returns the primary or unique key constraint name of the PostgreSQL table.
 type PgsqlOnConflictConstrainter interface {
@@ -6,8 +6,8 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"github.com/go-sql-driver/mysql"
-	"github.com/icinga/icinga-go-library/backoff"
 	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/config"
 	"github.com/icinga/icinga-go-library/logging"
 	"github.com/icinga/icinga-go-library/periodic"
 	"github.com/icinga/icinga-go-library/retry"
@@ -17,16 +17,20 @@ import (
 	"github.com/jmoiron/sqlx/reflectx"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 	"net"
 	"net/url"
+	"reflect"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -40,8 +44,27 @@ type DB struct {
 	addr              string
 	columnMap         ColumnMap
 	logger            *logging.Logger
-	tableSemaphores   map[string]*semaphore.Weighted
+	tableSemaphores   map[tableOp]*semaphore.Weighted
 	tableSemaphoresMu sync.Mutex
+	batchSizes        map[string]*adaptiveBatchSize
+	batchSizesMu      sync.Mutex
+	stmtCache         *stmtCache
+	writeLocks        *writeLocks
+	semaphoreWaits    semaphoreWaits
+
+	// replicas are the read replicas of the primary database, see Reader.
+	replicas     []*DB
+	replicasNext atomic.Uint64
+
+	// health is only set for replicas.
+	health *replicaHealth
+}
+
+// tableOp identifies a semaphore of GetSemaphoreForTableAndOp.
+// op is empty for the semaphore shared by all operations on the table.
+type tableOp struct {
+	table string
+	op    string
 }
 
 // Options define user configurable database options.
@@ -49,55 +72,281 @@ type Options struct {
 	// Maximum number of open connections to the database.
 	MaxConnections int `yaml:"max_connections" env:"MAX_CONNECTIONS" default:"16"`
 
+	// Maximum number of open connections to each of the read replicas, see Config.Replicas.
+	MaxReplicaConnections int `yaml:"max_replica_connections" env:"MAX_REPLICA_CONNECTIONS" default:"16"`
+
+	// ReplicaFailbackInterval is the time after which a replica that failed due to a lost connection
+	// is used for reads again. In the meantime, reads go to the other replicas or, if none is left, to the primary.
+	ReplicaFailbackInterval time.Duration `yaml:"replica_failback_interval" env:"REPLICA_FAILBACK_INTERVAL" default:"30s" validate:"min=0"`
+
+	// ReplicaConnectTimeout is the time after which connecting to a read replica is given up, so that an unavailable
+	// replica is marked as unhealthy quickly instead of blocking reads for the full retry timeout of the primary.
+	ReplicaConnectTimeout time.Duration `yaml:"replica_connect_timeout" env:"REPLICA_CONNECT_TIMEOUT" default:"5s" validate:"min=0"`
+
 	// Maximum number of connections per table,
 	// regardless of what the connection is actually doing,
 	// e.g. INSERT, UPDATE, DELETE.
-	MaxConnectionsPerTable int `yaml:"max_connections_per_table" env:"MAX_CONNECTIONS_PER_TABLE" default:"8"`
+	MaxConnectionsPerTable int `yaml:"max_connections_per_table" env:"MAX_CONNECTIONS_PER_TABLE" default:"8" validate:"min=1"`
 
 	// MaxPlaceholdersPerStatement defines the maximum number of placeholders in an
 	// INSERT, UPDATE or DELETE statement. Theoretically, MySQL can handle up to 2^16-1 placeholders,
 	// but this increases the execution time of queries and thus reduces the number of queries
 	// that can be executed in parallel in a given time.
 	// The default is 2^13, which in our tests showed the best performance in terms of execution time and parallelism.
-	MaxPlaceholdersPerStatement int `yaml:"max_placeholders_per_statement" env:"MAX_PLACEHOLDERS_PER_STATEMENT" default:"8192"`
+	MaxPlaceholdersPerStatement int `yaml:"max_placeholders_per_statement" env:"MAX_PLACEHOLDERS_PER_STATEMENT" default:"8192" validate:"min=1"`
 
 	// MaxRowsPerTransaction defines the maximum number of rows per transaction.
 	// The default is 2^13, which in our tests showed the best performance in terms of execution time and parallelism.
-	MaxRowsPerTransaction int `yaml:"max_rows_per_transaction" env:"MAX_ROWS_PER_TRANSACTION" default:"8192"`
+	MaxRowsPerTransaction int `yaml:"max_rows_per_transaction" env:"MAX_ROWS_PER_TRANSACTION" default:"8192" validate:"min=1"`
+
+	// ChunksPerTransaction, if greater than 1, lets NamedBulkExec execute up to that many consecutive chunks
+	// in a single transaction with at most MaxRowsPerTransaction rows instead of committing each chunk on its own,
+	// which reduces the pressure of many commits on the database, e.g. fsyncs on PostgreSQL. Only chunks that are
+	// ready at the same time are grouped, so that streaming entities are not delayed.
+	// It has no effect on queries executed in a transaction set via WithQuerier.
+	ChunksPerTransaction int `yaml:"chunks_per_transaction" env:"CHUNKS_PER_TRANSACTION" default:"0" validate:"min=0"`
+
+	// MaxUpsertsPerTable, MaxUpdatesPerTable and MaxDeletesPerTable define separate limits of connections
+	// per table for INSERT and upsert, UPDATE and DELETE statements respectively, as used by
+	// GetSemaphoreForTableAndOp. If 0, the operation shares the MaxConnectionsPerTable limit
+	// with all other operations on the table that don't have a separate limit.
+	MaxUpsertsPerTable int `yaml:"max_upserts_per_table" env:"MAX_UPSERTS_PER_TABLE" default:"0" validate:"min=0"`
+	MaxUpdatesPerTable int `yaml:"max_updates_per_table" env:"MAX_UPDATES_PER_TABLE" default:"0" validate:"min=0"`
+	MaxDeletesPerTable int `yaml:"max_deletes_per_table" env:"MAX_DELETES_PER_TABLE" default:"0" validate:"min=0"`
+
+	// SemaphoreWaitWarning is the time after which waiting for one of the connections per table limited by
+	// the above options in BulkExec, NamedBulkExec and NamedBulkExecTx is logged as a warning, as it indicates
+	// that the limits are too low for the workload, see also DB.SemaphoreWaitStats. 0 disables the warning.
+	SemaphoreWaitWarning time.Duration `yaml:"semaphore_wait_warning" env:"SEMAPHORE_WAIT_WARNING" default:"10s" validate:"min=0"`
+
+	// BatchTargetLatency, if greater than 0, enables adaptive batch sizing in NamedBulkExec:
+	// Instead of always using chunks as large as the count passed to it, e.g. by BatchSizeByPlaceholders,
+	// the number of rows per chunk is shrunk or grown based on the observed execution time of previous chunks
+	// of the same statement, so that executing a chunk takes about the given time.
+	// This helps on databases on which large statements are slow, e.g. Galera clusters.
+	BatchTargetLatency time.Duration `yaml:"batch_target_latency" env:"BATCH_TARGET_LATENCY" validate:"min=0"`
+
+	// MinBatchSize is the number of rows per chunk adaptive batch sizing never falls below.
+	MinBatchSize int `yaml:"min_batch_size" env:"MIN_BATCH_SIZE" default:"1" validate:"min=1"`
+
+	// StatementCacheSize, if greater than 0, enables caching of up to that many prepared statements per connection,
+	// which are then reused by NamedBulkExec, NamedBulkExecTx and ExecTx instead of being prepared over and over,
+	// see WithStatementCache. Note that the database may need to allow up to
+	// StatementCacheSize * MaxConnections prepared statements.
+	StatementCacheSize int `yaml:"statement_cache_size" env:"STATEMENT_CACHE_SIZE" default:"0" validate:"min=0"`
+
+	// SerializeWrites lists tables whose UPDATE and DELETE statements executed by BulkExec, NamedBulkExec and
+	// NamedBulkExecTx, e.g. via UpdateStreamed and DeleteStreamed, and whose ExecTx transactions declared via
+	// ExecTxTables are serialized, i.e. only one statement or transaction at a time is executed per table.
+	// This avoids Galera certification failures, which show up as deadlocks, for workloads in which
+	// concurrent writes to the same table keep conflicting with each other.
+	// Note that writes are only serialized within this process.
+	SerializeWrites []string `yaml:"serialize_writes" env:"SERIALIZE_WRITES"`
+
+	// RetryTimeouts overrides the time after which BulkExec, NamedBulkExec, NamedBulkExecTx, YieldAll and
+	// CleanupOlderThan stop retrying failed queries, which is retry.DefaultTimeout by default, per table or
+	// per operation on a table, i.e. select, insert, update or delete, with keys in the form of table or table/op,
+	// e.g. a long timeout for history/delete, see also WithRetryPolicy.
+	RetryTimeouts map[string]time.Duration `yaml:"retry_timeouts" env:"RETRY_TIMEOUTS"`
 
 	// WsrepSyncWait enforces Galera cluster nodes to perform strict cluster-wide causality checks
 	// before executing specific SQL queries determined by the number you provided.
 	// Please refer to the below link for a detailed description.
 	// https://icinga.com/docs/icinga-db/latest/doc/03-Configuration/#galera-cluster
 	WsrepSyncWait int `yaml:"wsrep_sync_wait" env:"WSREP_SYNC_WAIT" default:"7"`
+
+	// SessionVariables are set for each new connection via SET SESSION, e.g. innodb_lock_wait_timeout for MySQL
+	// or statement_timeout for PostgreSQL. Numeric values are set as numbers, any other values as strings.
+	// They take precedence over the variables set by default, i.e. wsrep_sync_wait for MySQL, see WsrepSyncWait.
+	// For MySQL, variables unknown to the server are ignored.
+	SessionVariables map[string]string `yaml:"session_variables" env:"SESSION_VARIABLES"`
+
+	// TracerProvider, if set, is used to create OpenTelemetry spans for the chunks of
+	// BulkExec, NamedBulkExec and NamedBulkExecTx and for the queries of YieldAll.
+	// It can only be set programmatically, not via YAML or environment variables.
+	TracerProvider trace.TracerProvider `yaml:"-"`
+
+	// RetryRegistry, if set, keeps track of connection attempts and queries while they are being retried,
+	// registered as "database connect" and "database query" respectively, e.g. for health.RetryChecker.
+	// It can only be set programmatically, not via YAML or environment variables.
+	RetryRegistry *retry.Registry `yaml:"-"`
+
+	// LogQueries enables logging of each executed statement with its arguments and execution duration
+	// at debug level, which is intended for debugging only.
+	LogQueries bool `yaml:"log_queries" env:"LOG_QUERIES" default:"false"`
+
+	// LogQueriesRedact lists words which, if contained in the name of a column, cause the arguments bound to
+	// that column to be redacted from logged statements. Defaults to DefaultQueryLogRedact if empty.
+	LogQueriesRedact []string `yaml:"log_queries_redact" env:"LOG_QUERIES_REDACT"`
+
+	// DryRun, if enabled, causes statements to be logged with their estimated number of rows written instead of
+	// being executed, while queries are still performed. This allows validating a new synchronization against
+	// production data without writing anything. Note that RowsAffected then reports the estimated number of rows
+	// and LastInsertId reports 0.
+	DryRun bool `yaml:"dry_run" env:"DRY_RUN" default:"false"`
 }
 
 // Validate checks constraints in the supplied database options and returns an error if they are violated.
 func (o *Options) Validate() error {
+	if err := config.ValidateTags(o); err != nil {
+		return err
+	}
 	if o.MaxConnections == 0 {
 		return errors.New("max_connections cannot be 0. Configure a value greater than zero, or use -1 for no connection limit")
 	}
-	if o.MaxConnectionsPerTable < 1 {
-		return errors.New("max_connections_per_table must be at least 1")
-	}
-	if o.MaxPlaceholdersPerStatement < 1 {
-		return errors.New("max_placeholders_per_statement must be at least 1")
-	}
-	if o.MaxRowsPerTransaction < 1 {
-		return errors.New("max_rows_per_transaction must be at least 1")
+	if err := validateRetryTimeouts(o.RetryTimeouts); err != nil {
+		return err
 	}
 	if o.WsrepSyncWait < 0 || o.WsrepSyncWait > 15 {
 		return errors.New("wsrep_sync_wait can only be set to a number between 0 and 15")
 	}
+	for name, value := range o.SessionVariables {
+		if !sessionVariableNameRegex.MatchString(name) {
+			return errors.Errorf("session_variables: invalid variable name %q", name)
+		}
+		if strings.ContainsAny(value, `'\`) {
+			return errors.Errorf("session_variables: value of %q must not contain quotes or backslashes", name)
+		}
+	}
 
 	return nil
 }
 
 // NewDbFromConfig returns a new DB from Config.
+// If Config.Replicas is not empty, reads performed by YieldAll and YieldAllPaginated are routed to the replicas,
+// see DB.Reader.
 func NewDbFromConfig(c *Config, logger *logging.Logger, connectorCallbacks RetryConnectorCallbacks) (*DB, error) {
-	var addr string
-	var db *sqlx.DB
+	var stmtCache *stmtCache
+	if c.Options.StatementCacheSize > 0 {
+		stmtCache = newStmtCache(c.Options.StatementCacheSize)
+	}
+
+	db, addr, err := openDb(c, logger, connectorCallbacks, stmtCache, retry.DefaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxIdleConns(c.Options.MaxConnections / 3)
+	db.SetMaxOpenConns(c.Options.MaxConnections)
+
+	primary := newDb(db, &c.Options, addr, logger)
+	primary.stmtCache = stmtCache
+
+	for _, replica := range c.Replicas {
+		rc := *c
+		rc.Host, rc.Hosts = replica, nil
+
+		rdb, raddr, err := openDb(&rc, logger, connectorCallbacks, nil, c.Options.ReplicaConnectTimeout)
+		if err != nil {
+			_ = primary.Close()
+
+			return nil, errors.Wrapf(err, "can't open database replica %q", replica)
+		}
+
+		rdb.SetMaxIdleConns(c.Options.MaxReplicaConnections / 3)
+		rdb.SetMaxOpenConns(c.Options.MaxReplicaConnections)
+
+		r := newDb(rdb, &c.Options, raddr, logger)
+		r.health = &replicaHealth{}
+		primary.replicas = append(primary.replicas, r)
+	}
+
+	return primary, nil
+}
+
+// newDb returns a new DB wrapping the given sqlx.DB.
+func newDb(db *sqlx.DB, options *Options, addr string, logger *logging.Logger) *DB {
+	db.Mapper = reflectx.NewMapperFunc("db", strcase.Snake)
+
+	return &DB{
+		DB:              db,
+		Options:         options,
+		columnMap:       NewColumnMap(db.Mapper),
+		addr:            addr,
+		logger:          logger,
+		tableSemaphores: make(map[tableOp]*semaphore.Weighted),
+		batchSizes:      make(map[string]*adaptiveBatchSize),
+		writeLocks:      newWriteLocks(options.SerializeWrites),
+	}
+}
+
+// openDb opens the database of the given Config and returns it along with its address as returned by DB.GetAddr.
+// If the Config specifies multiple hosts, connections fail over between them, see Config.Hosts.
+// If stmtCache is not nil, its connections cache prepared statements, see WithStatementCache.
+// Connecting is retried until connectTimeout has elapsed.
+func openDb(
+	c *Config, logger *logging.Logger, connectorCallbacks RetryConnectorCallbacks, stmtCache *stmtCache,
+	connectTimeout time.Duration,
+) (*sqlx.DB, string, error) {
+	var connectors []driver.Connector
+	var addrs []string
+
+	for _, host := range c.hosts() {
+		hc := *c
+		hc.Host, hc.Port = splitAddr(host, c.Port)
+
+		connector, addr, err := newDriverConnector(&hc, logger)
+		if err != nil {
+			return nil, "", err
+		}
+
+		connectors = append(connectors, connector)
+		addrs = append(addrs, addr)
+	}
+
+	connector := connectors[0]
+	if len(connectors) > 1 {
+		connector = newFailoverConnector(connectors, addrs, logger)
+	}
+
+	var driverName string
+	switch c.Type {
+	case "mysql":
+		driverName = MySQL
+	case "pgsql":
+		driverName = PostgreSQL
+	}
+
+	if variables := c.Options.sessionVariables(driverName); len(variables) > 0 {
+		onInitConn := connectorCallbacks.OnInitConn
+		connectorCallbacks.OnInitConn = func(ctx context.Context, conn driver.Conn) error {
+			if onInitConn != nil {
+				if err := onInitConn(ctx, conn); err != nil {
+					return err
+				}
+			}
+
+			for _, v := range variables {
+				if err := unsafeSetSessionVariableIfExists(ctx, conn, v.name, v.value); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
 
+	retryConnector := NewConnector(connector, logger, connectorCallbacks)
+	retryConnector.addr = addrs[0]
+	retryConnector.timeout = connectTimeout
+	retryConnector.registry = c.Options.RetryRegistry
+
+	connector = withStatementCache(retryConnector, stmtCache)
+	connector = withDryRun(withQueryLogging(connector, logger, c.Options), logger, c.Options)
+	db := sqlx.NewDb(sql.OpenDB(connector), driverName)
+
+	addr := strings.Join(addrs, ",")
+	if c.TlsOptions.Enable {
+		addr = fmt.Sprintf("%s+tls://%s@%s/%s", c.Type, c.User, addr, c.Database)
+	} else {
+		addr = fmt.Sprintf("%s://%s@%s/%s", c.Type, c.User, addr, c.Database)
+	}
+
+	return db, addr, nil
+}
+
+// newDriverConnector returns a driver.Connector for the host of the given Config along with its address.
+func newDriverConnector(c *Config, logger *logging.Logger) (driver.Connector, string, error) {
 	switch c.Type {
 	case "mysql":
 		config := mysql.NewConfig()
@@ -106,6 +355,7 @@ func NewDbFromConfig(c *Config, logger *logging.Logger, connectorCallbacks Retry
 		config.Passwd = c.Password
 		config.Logger = MysqlFuncLogger(logger.Debug)
 
+		var addr string
 		if utils.IsUnixAddr(c.Host) {
 			config.Net = "unix"
 			config.Addr = c.Host
@@ -126,34 +376,17 @@ func NewDbFromConfig(c *Config, logger *logging.Logger, connectorCallbacks Retry
 
 		tlsConfig, err := c.TlsOptions.MakeConfig(c.Host)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		config.TLS = tlsConfig
 
 		connector, err := mysql.NewConnector(config)
 		if err != nil {
-			return nil, errors.Wrap(err, "can't open mysql database")
+			return nil, "", errors.Wrap(err, "can't open mysql database")
 		}
 
-		onInitConn := connectorCallbacks.OnInitConn
-		connectorCallbacks.OnInitConn = func(ctx context.Context, conn driver.Conn) error {
-			if onInitConn != nil {
-				if err := onInitConn(ctx, conn); err != nil {
-					return err
-				}
-			}
-
-			// Set the "wsrep_sync_wait" variable for each session and ensures that causality checks are performed
-			// before execution and that each statement is executed on a fully synchronized node. Doing so prevents
-			// foreign key violation when inserting into dependent tables on different MariaDB/MySQL nodes. When using
-			// MySQL single nodes, the "SET SESSION" command will fail with "Unknown system variable (1193)" and will
-			// therefore be silently dropped.
-			// https://mariadb.com/kb/en/galera-cluster-system-variables/#wsrep_sync_wait
-			return unsafeSetSessionVariableIfExists(ctx, conn, "wsrep_sync_wait", fmt.Sprint(c.Options.WsrepSyncWait))
-		}
-
-		db = sqlx.NewDb(sql.OpenDB(NewConnector(connector, logger, connectorCallbacks)), MySQL)
+		return connector, addr, nil
 	case "pgsql":
 		uri := &url.URL{
 			Scheme: "postgres",
@@ -178,7 +411,7 @@ func NewDbFromConfig(c *Config, logger *logging.Logger, connectorCallbacks Retry
 		query["port"] = []string{strconv.FormatInt(int64(port), 10)}
 
 		if _, err := c.TlsOptions.MakeConfig(c.Host); err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		if c.TlsOptions.Enable {
@@ -207,39 +440,21 @@ func NewDbFromConfig(c *Config, logger *logging.Logger, connectorCallbacks Retry
 
 		connector, err := pq.NewConnector(uri.String())
 		if err != nil {
-			return nil, errors.Wrap(err, "can't open pgsql database")
+			return nil, "", errors.Wrap(err, "can't open pgsql database")
 		}
 
+		var addr string
 		if utils.IsUnixAddr(c.Host) {
 			// https://www.postgresql.org/docs/17/runtime-config-connection.html#GUC-UNIX-SOCKET-DIRECTORIES
 			addr = fmt.Sprintf("(%s/.s.PGSQL.%d)", strings.TrimRight(c.Host, "/"), port)
 		} else {
 			addr = utils.JoinHostPort(c.Host, port)
 		}
-		db = sqlx.NewDb(sql.OpenDB(NewConnector(connector, logger, connectorCallbacks)), PostgreSQL)
-	default:
-		return nil, unknownDbType(c.Type)
-	}
 
-	if c.TlsOptions.Enable {
-		addr = fmt.Sprintf("%s+tls://%s@%s/%s", c.Type, c.User, addr, c.Database)
-	} else {
-		addr = fmt.Sprintf("%s://%s@%s/%s", c.Type, c.User, addr, c.Database)
+		return connector, addr, nil
+	default:
+		return nil, "", unknownDbType(c.Type)
 	}
-
-	db.SetMaxIdleConns(c.Options.MaxConnections / 3)
-	db.SetMaxOpenConns(c.Options.MaxConnections)
-
-	db.Mapper = reflectx.NewMapperFunc("db", strcase.Snake)
-
-	return &DB{
-		DB:              db,
-		Options:         &c.Options,
-		columnMap:       NewColumnMap(db.Mapper),
-		addr:            addr,
-		logger:          logger,
-		tableSemaphores: make(map[string]*semaphore.Weighted),
-	}, nil
 }
 
 // GetAddr returns a URI-like database connection string.
@@ -264,7 +479,17 @@ func (db *DB) BuildColumns(subject interface{}) []string {
 }
 
 // BuildDeleteStmt returns a DELETE statement for the given struct.
+// If from implements CompositeKeyer, rows are matched by all of its key columns,
+// i.e. WHERE ("a", "b") IN (?), and the statement must be executed with CompositeKey arguments.
 func (db *DB) BuildDeleteStmt(from interface{}) string {
+	if keyer, ok := from.(CompositeKeyer); ok {
+		return fmt.Sprintf(
+			`DELETE FROM "%s" WHERE ("%s") IN (?)`,
+			TableName(from),
+			strings.Join(keyer.KeyColumns(), `", "`),
+		)
+	}
+
 	return fmt.Sprintf(
 		`DELETE FROM "%s" WHERE id IN (?)`,
 		TableName(from),
@@ -316,6 +541,7 @@ func (db *DB) BuildInsertIgnoreStmt(into interface{}) (string, int) {
 
 // BuildSelectStmt returns a SELECT query that creates the FROM part from the given table struct
 // and the column list from the specified columns struct.
+// If table implements QueryHinter, its hints are added to the query, see AddHints.
 func (db *DB) BuildSelectStmt(table interface{}, columns interface{}) string {
 	q := fmt.Sprintf(
 		`SELECT "%s" FROM "%s"`,
@@ -328,23 +554,60 @@ func (db *DB) BuildSelectStmt(table interface{}, columns interface{}) string {
 		q += ` WHERE ` + where
 	}
 
+	if hinter, ok := table.(QueryHinter); ok {
+		q = db.AddHints(q, hinter.QueryHints()...)
+	}
+
 	return q
 }
 
 // BuildUpdateStmt returns an UPDATE statement for the given struct.
+// If update implements CompositeKeyer, the row is matched by all of its key columns, i.e. WHERE "a" = :a AND "b" = :b.
+// If update implements Versioner, the version column is incremented instead of set and
+// the row is only updated if its version matches, i.e. WHERE id = :id AND "version" = :version.
 func (db *DB) BuildUpdateStmt(update interface{}) (string, int) {
 	columns := db.columnMap.Columns(update)
 	set := make([]string, 0, len(columns))
 
+	var versionColumn string
+	if versioner, ok := update.(Versioner); ok {
+		versionColumn = versioner.VersionColumn()
+	}
+
 	for _, col := range columns {
+		if col == versionColumn {
+			continue
+		}
+
 		set = append(set, fmt.Sprintf(`"%s" = :%s`, col, col))
 	}
 
+	where := `id = :id`
+	placeholders := len(set) + 1 // +1 because of WHERE id = :id
+
+	if keyer, ok := update.(CompositeKeyer); ok {
+		keys := keyer.KeyColumns()
+		conditions := make([]string, 0, len(keys))
+		for _, key := range keys {
+			conditions = append(conditions, fmt.Sprintf(`"%s" = :%s`, key, key))
+		}
+
+		where = strings.Join(conditions, " AND ")
+		placeholders = len(set) + len(keys)
+	}
+
+	if versionColumn != "" {
+		set = append(set, fmt.Sprintf(`"%s" = "%s" + 1`, versionColumn, versionColumn))
+		where += fmt.Sprintf(` AND "%s" = :%s`, versionColumn, versionColumn)
+		placeholders++
+	}
+
 	return fmt.Sprintf(
-		`UPDATE "%s" SET %s WHERE id = :id`,
+		`UPDATE "%s" SET %s WHERE %s`,
 		TableName(update),
 		strings.Join(set, ", "),
-	), len(columns) + 1 // +1 because of WHERE id = :id
+		where,
+	), placeholders
 }
 
 // BuildUpsertStmt returns an upsert statement for the given struct.
@@ -376,6 +639,39 @@ func (db *DB) BuildUpsertStmt(subject interface{}) (stmt string, placeholders in
 		setFormat = `"%[1]s" = EXCLUDED."%[1]s"`
 	}
 
+	var where string
+	if wherer, ok := subject.(UpsertWherer); ok {
+		switch cond := wherer.UpsertWhere(); db.DriverName() {
+		case MySQL:
+			columns := make(map[string]struct{})
+			for _, m := range conditionColumnRegex.FindAllStringSubmatch(cond, -1) {
+				columns[m[2]] = struct{}{}
+			}
+
+			referenced := func(col string) int {
+				if _, ok := columns[col]; ok {
+					return 1
+				}
+
+				return 0
+			}
+
+			updateColumns = slices.Clone(updateColumns)
+			slices.SortStableFunc(updateColumns, func(a, b string) int { return referenced(a) - referenced(b) })
+
+			cond = excludedColumnRegex.ReplaceAllString(cond, `VALUES("$1")`)
+			setFormat = `"%[1]s" = IF(` + strings.ReplaceAll(cond, "%", "%%") + `, VALUES("%[1]s"), "%[1]s")`
+		case PostgreSQL:
+			where = " WHERE " + conditionColumnRegex.ReplaceAllStringFunc(cond, func(ref string) string {
+				if m := conditionColumnRegex.FindStringSubmatch(ref); m[1] == "" {
+					return fmt.Sprintf(`"%s"."%s"`, table, m[2])
+				}
+
+				return ref
+			})
+		}
+	}
+
 	set := make([]string, 0, len(updateColumns))
 
 	for _, col := range updateColumns {
@@ -383,15 +679,23 @@ func (db *DB) BuildUpsertStmt(subject interface{}) (stmt string, placeholders in
 	}
 
 	return fmt.Sprintf(
-		`INSERT INTO "%s" ("%s") VALUES (%s) %s %s`,
+		`INSERT INTO "%s" ("%s") VALUES (%s) %s %s%s`,
 		table,
 		strings.Join(insertColumns, `", "`),
 		fmt.Sprintf(":%s", strings.Join(insertColumns, ",:")),
 		clause,
 		strings.Join(set, ","),
+		where,
 	), len(insertColumns)
 }
 
+// excludedColumnRegex matches EXCLUDED."column" references of UpsertWherer conditions.
+var excludedColumnRegex = regexp.MustCompile(`(?i)\bEXCLUDED\."([^"]+)"`)
+
+// conditionColumnRegex matches the column references of UpsertWherer conditions, i.e. "column", "table"."column" and
+// EXCLUDED."column", with the qualifier, if any, as its first and the column as its second submatch.
+var conditionColumnRegex = regexp.MustCompile(`(?i)(\bEXCLUDED\.|"[^"]+"\.)?"([^"]+)"`)
+
 // BuildWhere returns a WHERE clause with named placeholder conditions built from the specified struct
 // combined with the AND operator.
 func (db *DB) BuildWhere(subject interface{}) (string, int) {
@@ -429,6 +733,7 @@ func OnSuccessSendTo[T any](ch chan<- T) OnSuccess[T] {
 }
 
 // BulkExec bulk executes queries with a single slice placeholder in the form of `IN (?)`.
+// If the arguments are CompositeKeys, the placeholder is expanded to row values, i.e. `IN ((?, ?), (?, ?))`.
 // Takes in up to the number of arguments specified in count from the arg stream,
 // derives and expands a query and executes it with this set of arguments until the arg stream has been processed.
 // The derived queries are executed in a separate goroutine with a weighting of 1
@@ -440,6 +745,13 @@ func (db *DB) BulkExec(
 	var counter com.Counter
 	defer db.Log(ctx, query, &counter).Stop()
 
+	op, table := parseQuery(query)
+
+	q, custom := db.querier(ctx)
+	if custom {
+		sem = semaphore.NewWeighted(1)
+	}
+
 	g, ctx := errgroup.WithContext(ctx)
 	// Use context from group.
 	bulk := com.Bulk(ctx, arg, count, com.NeverSplit[any])
@@ -448,26 +760,39 @@ func (db *DB) BulkExec(
 		g, ctx := errgroup.WithContext(ctx)
 
 		for b := range bulk {
-			if err := sem.Acquire(ctx, 1); err != nil {
-				return errors.Wrap(err, "can't acquire semaphore")
+			if err := db.acquireSemaphore(ctx, sem, query); err != nil {
+				return err
 			}
 
 			g.Go(func(b []interface{}) func() error {
-				return func() error {
+				return func() (err error) {
 					defer sem.Release(1)
 
+					ctx, span := db.startSpan(ctx, "BulkExec", query, len(b))
+					defer func() { endSpan(span, err) }()
+
 					return retry.WithBackoff(
 						ctx,
 						func(context.Context) error {
-							stmt, args, err := sqlx.In(query, b)
+							if err := waitRateLimiter(ctx); err != nil {
+								return err
+							}
+
+							stmt, args, err := bindIn(query, b)
 							if err != nil {
 								return errors.Wrapf(err, "can't build placeholders for %q", query)
 							}
 
+							unlock, err := db.writeLocks.lock(ctx, query)
+							if err != nil {
+								return errors.Wrap(err, "can't acquire write lock")
+							}
+
 							stmt = db.Rebind(stmt)
-							_, err = db.ExecContext(ctx, stmt, args...)
+							_, err = q.ExecContext(ctx, stmt, args...)
+							unlock()
 							if err != nil {
-								return CantPerformQuery(err, query)
+								return cantPerformQueryWith(custom, err, query)
 							}
 
 							counter.Add(uint64(len(b)))
@@ -481,8 +806,8 @@ func (db *DB) BulkExec(
 							return nil
 						},
 						retry.Retryable,
-						backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
-						db.GetDefaultRetrySettings(),
+						db.retryBackoff(ctx),
+						db.retrySettings(ctx, table, op),
 					)
 				}
 			}(b))
@@ -501,6 +826,9 @@ func (db *DB) BulkExec(
 // The queries are executed in a separate goroutine with a weighting of 1
 // and can be executed concurrently to the extent allowed by the semaphore passed in sem.
 // Entities for which the query ran successfully will be passed to onSuccess.
+// If Options.BatchTargetLatency is set, chunks may be smaller than count, as described there.
+// If Options.ChunksPerTransaction is set, consecutive chunks may be executed in a single transaction,
+// in which case their entities are passed to onSuccess once it has been committed.
 func (db *DB) NamedBulkExec(
 	ctx context.Context, query string, count int, sem *semaphore.Weighted, arg <-chan Entity,
 	splitPolicyFactory com.BulkChunkSplitPolicyFactory[Entity], onSuccess ...OnSuccess[Entity],
@@ -508,49 +836,96 @@ func (db *DB) NamedBulkExec(
 	var counter com.Counter
 	defer db.Log(ctx, query, &counter).Stop()
 
+	op, table := parseQuery(query)
+
+	ctx = WithStatementCache(ctx)
+
+	batchSize := db.getBatchSize(query, count)
+	if batchSize != nil {
+		splitPolicyFactory = batchSize.SplitPolicyFactory(count, splitPolicyFactory)
+	}
+
+	q, custom := db.querier(ctx)
+	if custom {
+		sem = semaphore.NewWeighted(1)
+	}
+
+	chunksPerTx := 1
+	if !custom {
+		chunksPerTx = max(db.Options.ChunksPerTransaction, 1)
+	}
+
 	g, ctx := errgroup.WithContext(ctx)
-	bulk := com.Bulk(ctx, arg, count, splitPolicyFactory)
+	bulk := groupChunks(ctx, com.Bulk(ctx, arg, count, splitPolicyFactory), chunksPerTx, db.Options.MaxRowsPerTransaction)
 
 	g.Go(func() error {
 		for {
 			select {
-			case b, ok := <-bulk:
+			case chunks, ok := <-bulk:
 				if !ok {
 					return nil
 				}
 
-				if err := sem.Acquire(ctx, 1); err != nil {
-					return errors.Wrap(err, "can't acquire semaphore")
+				if err := db.acquireSemaphore(ctx, sem, query); err != nil {
+					return err
 				}
 
-				g.Go(func(b []Entity) func() error {
-					return func() error {
+				g.Go(func(chunks [][]Entity) func() error {
+					return func() (err error) {
 						defer sem.Release(1)
 
+						rows := 0
+						for _, b := range chunks {
+							rows += len(b)
+						}
+
+						ctx, span := db.startSpan(ctx, "NamedBulkExec", query, rows)
+						defer func() { endSpan(span, err) }()
+
 						return retry.WithBackoff(
 							ctx,
 							func(ctx context.Context) error {
-								_, err := db.NamedExecContext(ctx, query, b)
-								if err != nil {
-									return CantPerformQuery(err, query)
+								if err := waitRateLimiter(ctx); err != nil {
+									return err
 								}
 
-								counter.Add(uint64(len(b)))
+								unlock, err := db.writeLocks.lock(ctx, query)
+								if err != nil {
+									return errors.Wrap(err, "can't acquire write lock")
+								}
 
-								for _, onSuccess := range onSuccess {
-									if err := onSuccess(ctx, b); err != nil {
+								if len(chunks) == 1 {
+									err = db.namedExecChunk(ctx, q, query, chunks[0], batchSize, count)
+									unlock()
+									if err != nil {
+										return cantPerformQueryWith(custom, err, query)
+									}
+								} else {
+									err = db.namedExecChunksTx(ctx, query, chunks, batchSize, count)
+									unlock()
+									if err != nil {
 										return err
 									}
 								}
 
+								for _, b := range chunks {
+									counter.Add(uint64(len(b)))
+
+									for _, onSuccess := range onSuccess {
+										if err := onSuccess(ctx, b); err != nil {
+											return err
+										}
+									}
+								}
+
 								return nil
 							},
 							retry.Retryable,
-							backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
-							db.GetDefaultRetrySettings(),
+							db.retryBackoff(ctx),
+							db.retrySettings(ctx, table, op),
 						)
 					}
-				}(b))
+				}(chunks))
 			case <-ctx.Done():
 				return ctx.Err()
 			}
@@ -560,6 +935,118 @@ func (db *DB) NamedBulkExec(
 	return g.Wait()
 }
 
+// namedExecChunk executes the query of NamedBulkExec for a chunk of entities using q
+// and observes its execution time for adaptive batch sizing, if enabled.
+func (db *DB) namedExecChunk(
+	ctx context.Context, q Querier, query string, b []Entity, batchSize *adaptiveBatchSize, count int,
+) error {
+	start := time.Now()
+	if _, err := q.NamedExecContext(ctx, query, b); err != nil {
+		return err
+	}
+
+	if batchSize != nil {
+		batchSize.Observe(len(b), time.Since(start), count)
+	}
+
+	return nil
+}
+
+// namedExecChunksTx executes the query of NamedBulkExec for each of the given chunks in a single transaction,
+// see Options.ChunksPerTransaction.
+func (db *DB) namedExecChunksTx(
+	ctx context.Context, query string, chunks [][]Entity, batchSize *adaptiveBatchSize, count int,
+) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "can't start transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, b := range chunks {
+		if err := db.namedExecChunk(ctx, tx, query, b, batchSize, count); err != nil {
+			return CantPerformQuery(err, query)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "can't commit transaction")
+	}
+
+	return nil
+}
+
+// groupChunks groups consecutive chunks that are ready at the same time, i.e. that can be received without blocking,
+// into groups of up to maxChunks chunks with a total of up to maxRows entities. A single chunk with more entities
+// forms a group on its own. Each group is sent as soon as no further chunk is ready.
+func groupChunks(ctx context.Context, chunks <-chan []Entity, maxChunks, maxRows int) <-chan [][]Entity {
+	groups := make(chan [][]Entity)
+
+	go func() {
+		defer close(groups)
+
+		// pending is a chunk that didn't fit into the previous group.
+		var pending []Entity
+
+		for {
+			first := pending
+			pending = nil
+
+			if first == nil {
+				select {
+				case b, ok := <-chunks:
+					if !ok {
+						return
+					}
+
+					first = b
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			group := [][]Entity{first}
+			rows := len(first)
+			closed := false
+
+		collect:
+			for len(group) < maxChunks {
+				select {
+				case b, ok := <-chunks:
+					if !ok {
+						closed = true
+
+						break collect
+					}
+
+					if rows+len(b) > maxRows {
+						pending = b
+
+						break collect
+					}
+
+					group = append(group, b)
+					rows += len(b)
+				default:
+					break collect
+				}
+			}
+
+			select {
+			case groups <- group:
+			case <-ctx.Done():
+				return
+			}
+
+			if closed {
+				return
+			}
+		}
+	}()
+
+	return groups
+}
+
 // NamedBulkExecTx bulk executes queries with named placeholders in separate transactions.
 // Takes in up to the number of entities specified in count from the arg stream and
 // executes a new transaction that runs a new query for each entity in this set of arguments,
@@ -568,13 +1055,27 @@ func (db *DB) NamedBulkExec(
 // The transactions are executed in a separate goroutine with a weighting of 1
 // and can be executed concurrently to the extent allowed by the semaphore passed in sem.
 //
+// Entities of transactions that have been committed successfully will be passed to onSuccess.
+//
+// If a *sqlx.Tx has been set via WithQuerier, the entities are executed sequentially in that transaction instead.
+//
 // Note that committing the transaction may not honor the context provided, as described further in [DB.ExecTx].
 func (db *DB) NamedBulkExecTx(
 	ctx context.Context, query string, count int, sem *semaphore.Weighted, arg <-chan Entity,
+	onSuccess ...OnSuccess[Entity],
 ) error {
 	var counter com.Counter
 	defer db.Log(ctx, query, &counter).Stop()
 
+	op, table := parseQuery(query)
+
+	ctx = WithStatementCache(ctx)
+
+	_, custom := db.querier(ctx)
+	if custom {
+		sem = semaphore.NewWeighted(1)
+	}
+
 	g, ctx := errgroup.WithContext(ctx)
 	bulk := com.Bulk(ctx, arg, count, com.NeverSplit[Entity])
 
@@ -586,45 +1087,93 @@ func (db *DB) NamedBulkExecTx(
 					return nil
 				}
 
-				if err := sem.Acquire(ctx, 1); err != nil {
-					return errors.Wrap(err, "can't acquire semaphore")
+				if err := db.acquireSemaphore(ctx, sem, query); err != nil {
+					return err
 				}
 
 				g.Go(func(b []Entity) func() error {
-					return func() error {
+					return func() (err error) {
 						defer sem.Release(1)
 
+						ctx, span := db.startSpan(ctx, "NamedBulkExecTx", query, len(b))
+						defer func() { endSpan(span, err) }()
+
 						return retry.WithBackoff(
 							ctx,
 							func(ctx context.Context) error {
-								tx, err := db.BeginTxx(ctx, nil)
+								if err := waitRateLimiter(ctx); err != nil {
+									return err
+								}
+
+								unlock, err := db.writeLocks.lock(ctx, query)
+								if err != nil {
+									return errors.Wrap(err, "can't acquire write lock")
+								}
+								defer unlock()
+
+								tx, own, err := db.beginTx(ctx)
 								if err != nil {
-									return errors.Wrap(err, "can't start transaction")
+									return err
+								}
+								if own {
+									defer func() { _ = tx.Rollback() }()
+								}
+
+								// A failed statement may have aborted the transaction set via WithQuerier,
+								// so it must not be retried.
+								permanent := func(err error) error {
+									if custom {
+										return retry.MarkPermanent(err)
+									}
+
+									return err
 								}
-								defer func() { _ = tx.Rollback() }()
 
 								stmt, err := tx.PrepareNamedContext(ctx, query)
 								if err != nil {
-									return errors.Wrap(err, "can't prepare named statement with context in transaction")
+									return permanent(errors.Wrap(
+										err, "can't prepare named statement with context in transaction"))
 								}
+								defer func() { _ = stmt.Close() }()
 
 								for _, arg := range b {
-									if _, err := stmt.ExecContext(ctx, arg); err != nil {
-										return errors.Wrap(err, "can't execute statement in transaction")
+									res, err := stmt.ExecContext(ctx, arg)
+									if err != nil {
+										return permanent(errors.Wrap(err, "can't execute statement in transaction"))
+									}
+
+									if _, ok := arg.(Versioner); ok {
+										if err := checkVersionedUpdate(res, arg); err != nil {
+											return err
+										}
 									}
 								}
 
-								if err := tx.Commit(); err != nil {
-									return errors.Wrap(err, "can't commit transaction")
+								if own {
+									if err := tx.Commit(); err != nil {
+										return errors.Wrap(err, "can't commit transaction")
+									}
+								}
+
+								for _, arg := range b {
+									if versioner, ok := arg.(Versioner); ok {
+										db.incrementVersion(arg, versioner.VersionColumn())
+									}
 								}
 
 								counter.Add(uint64(len(b)))
 
+								for _, onSuccess := range onSuccess {
+									if err := onSuccess(ctx, b); err != nil {
+										return err
+									}
+								}
+
 								return nil
 							},
 							retry.Retryable,
-							backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
-							db.GetDefaultRetrySettings(),
+							db.retryBackoff(ctx),
+							db.retrySettings(ctx, table, op),
 						)
 					}
 				}(b))
@@ -637,6 +1186,28 @@ func (db *DB) NamedBulkExecTx(
 	return g.Wait()
 }
 
+// incrementVersion increments the version column of the given Versioner after it has been updated successfully,
+// so that it matches the version in the database and can be updated again.
+// The entity is left as it is if it isn't a pointer or its version field isn't an integer.
+func (db *DB) incrementVersion(entity Entity, column string) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	field := db.Mapper.FieldByName(v, column)
+	if !field.IsValid() || !field.CanSet() {
+		return
+	}
+
+	switch {
+	case field.CanInt():
+		field.SetInt(field.Int() + 1)
+	case field.CanUint():
+		field.SetUint(field.Uint() + 1)
+	}
+}
+
 // BatchSizeByPlaceholders returns how often the specified number of placeholders fits
 // into Options.MaxPlaceholdersPerStatement, but at least 1.
 func (db *DB) BatchSizeByPlaceholders(n int) int {
@@ -648,21 +1219,69 @@ func (db *DB) BatchSizeByPlaceholders(n int) int {
 	return 1
 }
 
+// YieldAllOption configures YieldAll.
+type YieldAllOption interface {
+	apply(*yieldAllOptions)
+}
+
+// YieldResume lets YieldAll resume the stream after a retryable error, e.g. if the connection was reset,
+// instead of failing. To do so, the query is wrapped in a derived table ordered by the id column,
+// and after an error, it is re-issued for the rows following the id of the last yielded entity, like
+// YieldAllPaginated does, with backoff until it succeeds or the default retry timeout expires.
+// Thus, the query must select the id column and must not contain an ORDER BY or LIMIT clause,
+// and the rows are yielded in id order exactly once, but not necessarily from a single snapshot.
+func YieldResume() YieldAllOption {
+	return yieldAllOptionFunc(func(o *yieldAllOptions) {
+		o.resume = true
+	})
+}
+
+// YieldFromPrimary lets YieldAll execute the query on the primary database instead of DB.Reader,
+// e.g. if the rows must reflect all previous writes, which a lagging replica may not have applied yet.
+func YieldFromPrimary() YieldAllOption {
+	return yieldAllOptionFunc(func(o *yieldAllOptions) {
+		o.primary = true
+	})
+}
+
 // YieldAll executes the query with the supplied scope,
 // scans each resulting row into an entity returned by the factory function,
-// and streams them into a returned channel.
-func (db *DB) YieldAll(ctx context.Context, factoryFunc EntityFactoryFunc, query string, scope interface{}) (<-chan Entity, <-chan error) {
+// and streams them into a returned channel. The query is executed on DB.Reader, unless set otherwise via WithQuerier
+// or YieldFromPrimary. By default, the stream fails on any error, see YieldResume for resuming it.
+func (db *DB) YieldAll(
+	ctx context.Context, factoryFunc EntityFactoryFunc, query string, scope interface{}, options ...YieldAllOption,
+) (<-chan Entity, <-chan error) {
+	var opts yieldAllOptions
+	for _, option := range options {
+		option.apply(&opts)
+	}
+
+	if opts.primary {
+		ctx = context.WithValue(ctx, primaryKey{}, true)
+	}
+
+	if opts.resume {
+		return db.yieldAllResuming(ctx, factoryFunc, query, scope)
+	}
+
 	entities := make(chan Entity, 1)
 	g, ctx := errgroup.WithContext(ctx)
 
-	g.Go(func() error {
+	g.Go(func() (err error) {
 		var counter com.Counter
 		defer db.Log(ctx, query, &counter).Stop()
 		defer close(entities)
 
-		rows, err := db.NamedQueryContext(ctx, query, scope)
+		ctx, span := db.startSpan(ctx, "YieldAll", query, 0)
+		defer func() { endSpan(span, err) }()
+
+		q, reader := db.readQuerier(ctx)
+		rows, err := sqlx.NamedQueryContext(ctx, q, query, scope)
 		if err != nil {
-			return CantPerformQuery(err, query)
+			err = CantPerformQuery(err, query)
+			reader.checkReplica(err)
+
+			return err
 		}
 		defer rows.Close()
 
@@ -687,6 +1306,279 @@ func (db *DB) YieldAll(ctx context.Context, factoryFunc EntityFactoryFunc, query
 	return entities, com.WaitAsync(g)
 }
 
+// YieldAllPaginated works like YieldAll, but instead of streaming the result of one big query,
+// it transparently issues successive queries of at most pageSize rows each using keyset pagination on the id column.
+// Thus, a connection is only held while a page is being read and the server doesn't need to materialize the whole result.
+// The query must select the id column and must not contain an ORDER BY or LIMIT clause,
+// as it is wrapped in a derived table which is ordered and limited instead.
+// Rows are yielded in id order exactly once. All pages are read in a single read-only transaction with
+// the REPEATABLE READ isolation level, so that they are consistent with each other even if the table is modified
+// concurrently, unless a Querier has been set via WithQuerier, which is then used instead.
+// If pageSize is less than 1, an error is returned via the error channel without yielding anything.
+func (db *DB) YieldAllPaginated(
+	ctx context.Context, factoryFunc EntityFactoryFunc, query string, scope interface{}, pageSize int,
+) (<-chan Entity, <-chan error) {
+	entities := make(chan Entity, 1)
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		if pageSize < 1 {
+			close(entities)
+
+			return errors.Errorf("page size must be at least 1, got %d", pageSize)
+		}
+
+		var counter com.Counter
+		defer db.Log(ctx, query, &counter).Stop()
+		defer close(entities)
+
+		q, reader := db.readQuerier(ctx)
+		if _, custom := db.querier(ctx); !custom {
+			tx, err := reader.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+			if err != nil {
+				reader.checkReplica(err)
+
+				return errors.Wrap(err, "can't start transaction")
+			}
+			// The transaction is read-only, so there's nothing to commit.
+			defer func() { _ = tx.Rollback() }()
+
+			q = tx
+		}
+
+		var after ID
+		for {
+			n, last, err := db.yieldPage(
+				ctx, "YieldAllPaginated", q, reader, factoryFunc, query, scope, pageSize, after, entities)
+			counter.Add(uint64(n))
+			if err != nil {
+				return err
+			}
+
+			if n < pageSize {
+				return nil
+			}
+
+			after = last.ID()
+		}
+	})
+
+	return entities, com.WaitAsync(g)
+}
+
+// yieldAllResuming implements YieldAll with YieldResume.
+func (db *DB) yieldAllResuming(
+	ctx context.Context, factoryFunc EntityFactoryFunc, query string, scope interface{},
+) (<-chan Entity, <-chan error) {
+	entities := make(chan Entity, 1)
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var counter com.Counter
+		defer db.Log(ctx, query, &counter).Stop()
+		defer close(entities)
+
+		op, table := parseQuery(query)
+		var after ID
+
+		return retry.WithBackoff(
+			ctx,
+			func(ctx context.Context) error {
+				q, reader := db.readQuerier(ctx)
+				n, last, err := db.yieldPage(ctx, "YieldAll", q, reader, factoryFunc, query, scope, 0, after, entities)
+				counter.Add(uint64(n))
+				if last != nil {
+					after = last.ID()
+				}
+
+				return err
+			},
+			retry.Retryable,
+			db.retryBackoff(ctx),
+			db.retrySettings(ctx, table, op),
+		)
+	})
+
+	return entities, com.WaitAsync(g)
+}
+
+// yieldPage executes the query of YieldAllPaginated for the page following the after ID, or the first page if nil,
+// and streams the resulting entities into the given channel. If pageSize is 0, all following rows are yielded.
+// Returns the number of entities yielded and the last one. op is the name of the calling operation for tracing.
+// The query is executed using q, and errors are reported to the checkReplica method of reader, see readQuerier.
+func (db *DB) yieldPage(
+	ctx context.Context, op string, q Querier, reader *DB, factoryFunc EntityFactoryFunc, query string, scope interface{},
+	pageSize int, after ID, entities chan<- Entity,
+) (n int, last Entity, err error) {
+	page, args, err := db.buildPageQuery(query, scope, pageSize, after)
+	if err != nil {
+		return 0, nil, CantPerformQuery(err, query)
+	}
+
+	ctx, span := db.startSpan(ctx, op, page, 0)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := q.QueryxContext(ctx, page, args...)
+	if err != nil {
+		err = CantPerformQuery(err, page)
+		reader.checkReplica(err)
+
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := factoryFunc()
+
+		if err := rows.StructScan(e); err != nil {
+			return n, last, errors.Wrapf(err, "can't store query result into a %T: %s", e, page)
+		}
+
+		select {
+		case entities <- e:
+			n++
+			last = e
+		case <-ctx.Done():
+			return n, last, ctx.Err()
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		err = CantPerformQuery(err, page)
+		reader.checkReplica(err)
+
+		return n, last, err
+	}
+
+	return n, last, nil
+}
+
+// buildPageQuery returns the query of YieldAllPaginated for the page following the after ID, or the first page if nil,
+// with the driver's placeholders and the arguments to bind. If pageSize is 0, the query is not limited.
+func (db *DB) buildPageQuery(query string, scope interface{}, pageSize int, after ID) (string, []interface{}, error) {
+	var args []interface{}
+	inner := query
+	if scope != nil {
+		var err error
+		inner, args, err = db.BindNamed(query, scope)
+		if err != nil {
+			return "", nil, err
+		}
+	} else {
+		inner = db.Rebind(inner)
+	}
+
+	page := `SELECT * FROM (` + inner + `) AS "page"`
+	if after != nil {
+		args = append(args, after)
+
+		if sqlx.BindType(db.DriverName()) == sqlx.DOLLAR {
+			page += fmt.Sprintf(` WHERE "id" > $%d`, len(args))
+		} else {
+			page += ` WHERE "id" > ?`
+		}
+	}
+	page += ` ORDER BY "id"`
+	if pageSize > 0 {
+		page += fmt.Sprintf(` LIMIT %d`, pageSize)
+	}
+
+	return page, args, nil
+}
+
+// yieldAllOptions stores the options of YieldAll.
+type yieldAllOptions struct {
+	resume  bool
+	primary bool
+}
+
+// ExecTxOption configures ExecTx.
+type ExecTxOption interface {
+	apply(*execTxOptions)
+}
+
+// ExecTxTables declares the tables the transaction of ExecTx writes to, so that it is guarded like the bulk helpers.
+// Helpers called by the function of ExecTx should then execute their statements in its transaction via WithQuerier,
+// as they may otherwise have to wait for the connections and locks held by the transaction.
+func ExecTxTables(tables ...string) ExecTxOption {
+	return execTxOptionFunc(func(o *execTxOptions) {
+		o.tables = append(o.tables, tables...)
+	})
+}
+
+// execTxOptions stores the options of ExecTx.
+type execTxOptions struct {
+	tables []string
+}
+
+// execTxOptionFunc is a function that implements ExecTxOption.
+type execTxOptionFunc func(*execTxOptions)
+
+// apply implements the ExecTxOption interface.
+func (f execTxOptionFunc) apply(o *execTxOptions) {
+	f(o)
+}
+
+// guardTables acquires the semaphores of all write operations and, if configured in Options.SerializeWrites,
+// the write lock of each table in the order of their names, so that concurrent callers can't deadlock each other.
+// These are the same semaphores the bulk helpers acquire, see GetSemaphoreForTableAndOp.
+// It returns a copy of ctx, which lets the bulk helpers skip the write locks already held,
+// and a function that releases all of them.
+func (db *DB) guardTables(ctx context.Context, tables []string) (context.Context, func(), error) {
+	tables = slices.Clone(tables)
+	slices.Sort(tables)
+	tables = slices.Compact(tables)
+
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+
+	for _, table := range tables {
+		var sems []*semaphore.Weighted
+		for _, op := range []string{OpInsert, OpUpdate, OpDelete} {
+			// Operations without their own limit share the semaphore of the table.
+			if sem := db.GetSemaphoreForTableAndOp(table, op); !slices.Contains(sems, sem) {
+				sems = append(sems, sem)
+			}
+		}
+
+		for _, sem := range sems {
+			if err := db.acquireSemaphore(ctx, sem, "ExecTx on "+table); err != nil {
+				release()
+
+				return nil, nil, err
+			}
+			releases = append(releases, func() { sem.Release(1) })
+		}
+
+		unlock, err := db.writeLocks.lockTable(ctx, table)
+		if err != nil {
+			release()
+
+			return nil, nil, errors.Wrap(err, "can't acquire write lock")
+		}
+		releases = append(releases, unlock)
+	}
+
+	if len(tables) > 0 {
+		held, _ := ctx.Value(heldWriteLocksKey{}).([]string)
+		ctx = context.WithValue(ctx, heldWriteLocksKey{}, append(slices.Clip(held), tables...))
+	}
+
+	return ctx, release, nil
+}
+
+// yieldAllOptionFunc is a function that implements YieldAllOption.
+type yieldAllOptionFunc func(*yieldAllOptions)
+
+// apply implements the YieldAllOption interface.
+func (f yieldAllOptionFunc) apply(o *yieldAllOptions) {
+	f(o)
+}
+
 // CreateStreamed bulk creates the specified entities via NamedBulkExec.
 // The insert statement is created using BuildInsertStmt with the first entity from the entities stream.
 // Bulk size is controlled via Options.MaxPlaceholdersPerStatement and
@@ -700,7 +1592,7 @@ func (db *DB) CreateStreamed(
 		return errors.Wrap(err, "can't copy first entity")
 	}
 
-	sem := db.GetSemaphoreForTable(TableName(first))
+	sem := db.GetSemaphoreForTableAndOp(TableName(first), OpInsert)
 	stmt, placeholders := db.BuildInsertStmt(first)
 
 	return db.NamedBulkExec(
@@ -722,7 +1614,7 @@ func (db *DB) CreateIgnoreStreamed(
 		return errors.Wrap(err, "can't copy first entity")
 	}
 
-	sem := db.GetSemaphoreForTable(TableName(first))
+	sem := db.GetSemaphoreForTableAndOp(TableName(first), OpInsert)
 	stmt, placeholders := db.BuildInsertIgnoreStmt(first)
 
 	return db.NamedBulkExec(
@@ -744,7 +1636,7 @@ func (db *DB) UpsertStreamed(
 		return errors.Wrap(err, "can't copy first entity")
 	}
 
-	sem := db.GetSemaphoreForTable(TableName(first))
+	sem := db.GetSemaphoreForTableAndOp(TableName(first), OpInsert)
 	stmt, placeholders := db.BuildUpsertStmt(first)
 
 	return db.NamedBulkExec(
@@ -757,28 +1649,37 @@ func (db *DB) UpsertStreamed(
 // The update statement is created using BuildUpdateStmt with the first entity from the entities stream.
 // Bulk size is controlled via Options.MaxRowsPerTransaction and
 // concurrency is controlled via Options.MaxConnectionsPerTable.
-func (db *DB) UpdateStreamed(ctx context.Context, entities <-chan Entity) error {
+// If the entities implement Versioner, a *StaleUpdateError is returned for the first entity
+// whose row was modified concurrently, and the transaction of its bulk is rolled back.
+// Entities for which the transaction has been committed successfully will be passed to onSuccess.
+func (db *DB) UpdateStreamed(ctx context.Context, entities <-chan Entity, onSuccess ...OnSuccess[Entity]) error {
 	first, forward, err := com.CopyFirst(ctx, entities)
 	if err != nil {
 		return errors.Wrap(err, "can't copy first entity")
 	}
-	sem := db.GetSemaphoreForTable(TableName(first))
+	sem := db.GetSemaphoreForTableAndOp(TableName(first), OpUpdate)
 	stmt, _ := db.BuildUpdateStmt(first)
 
-	return db.NamedBulkExecTx(ctx, stmt, db.Options.MaxRowsPerTransaction, sem, forward)
+	return db.NamedBulkExecTx(ctx, stmt, db.Options.MaxRowsPerTransaction, sem, forward, onSuccess...)
 }
 
 // DeleteStreamed bulk deletes the specified ids via BulkExec.
 // The delete statement is created using BuildDeleteStmt with the passed entityType.
+// If entityType implements CompositeKeyer, the ids must be CompositeKey values.
 // Bulk size is controlled via Options.MaxPlaceholdersPerStatement and
 // concurrency is controlled via Options.MaxConnectionsPerTable.
 // IDs for which the query ran successfully will be passed to onSuccess.
 func (db *DB) DeleteStreamed(
 	ctx context.Context, entityType Entity, ids <-chan interface{}, onSuccess ...OnSuccess[any],
 ) error {
-	sem := db.GetSemaphoreForTable(TableName(entityType))
+	count := db.Options.MaxPlaceholdersPerStatement
+	if keyer, ok := entityType.(CompositeKeyer); ok {
+		count = db.BatchSizeByPlaceholders(len(keyer.KeyColumns()))
+	}
+
+	sem := db.GetSemaphoreForTableAndOp(TableName(entityType), OpDelete)
 	return db.BulkExec(
-		ctx, db.BuildDeleteStmt(entityType), db.Options.MaxPlaceholdersPerStatement, sem, ids, onSuccess...,
+		ctx, db.BuildDeleteStmt(entityType), count, sem, ids, onSuccess...,
 	)
 }
 
@@ -801,17 +1702,48 @@ func (db *DB) Delete(
 //
 // Starts a new transaction, executes the provided function, and commits the transaction
 // if the function succeeds. If the function returns an error, the transaction is rolled back.
+// If a *sqlx.Tx has been set via WithQuerier, the function is executed in that transaction instead,
+// which is neither committed nor rolled back, see WithQuerier.
+//
+// If the tables the transaction writes to are declared via ExecTxTables, it is guarded like the bulk helpers:
+// It acquires a connection from the semaphore of each table, see GetSemaphoreForTable, and holds the write lock of
+// the tables listed in Options.SerializeWrites until the transaction has finished.
 //
 // Returns an error if starting the transaction, executing the function, or committing the transaction fails.
 //
 // Note that committing the transaction may not honor the context provided. For some database drivers, once a COMMIT
 // query is started, it will block until the database responds. Therefore, for time-critical scenarios, it is
 // recommended to add a select wrapper against the context.
-func (db *DB) ExecTx(ctx context.Context, fn func(context.Context, *sqlx.Tx) error) error {
-	tx, err := db.BeginTxx(ctx, nil)
+func (db *DB) ExecTx(ctx context.Context, fn func(context.Context, *sqlx.Tx) error, options ...ExecTxOption) error {
+	var o execTxOptions
+	for _, option := range options {
+		option.apply(&o)
+	}
+
+	ctx = WithStatementCache(ctx)
+
+	if q, custom := db.querier(ctx); !custom || !isTx(q) {
+		// Guarding a transaction set via WithQuerier is up to the ExecTx call that started it.
+		var release func()
+		var err error
+
+		ctx, release, err = db.guardTables(ctx, o.tables)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	tx, own, err := db.beginTx(ctx)
 	if err != nil {
-		return errors.Wrap(err, "can't start transaction")
+		return err
+	}
+
+	if !own {
+		// fn becomes part of the transaction set via WithQuerier, which is committed by its owner.
+		return errors.WithStack(fn(ctx, tx))
 	}
+
 	// We don't expect meaningful errors from rolling back the tx other than the sql.ErrTxDone, so just ignore it.
 	defer func() { _ = tx.Rollback() }()
 
@@ -826,22 +1758,72 @@ func (db *DB) ExecTx(ctx context.Context, fn func(context.Context, *sqlx.Tx) err
 	return nil
 }
 
+// GetSemaphoreForTable returns the semaphore limiting the connections of all operations on the table
+// to Options.MaxConnectionsPerTable.
 func (db *DB) GetSemaphoreForTable(table string) *semaphore.Weighted {
+	return db.getSemaphore(tableOp{table: table}, db.Options.MaxConnectionsPerTable)
+}
+
+// GetSemaphoreForTableAndOp returns the semaphore limiting the connections of the given operation on the table,
+// i.e. OpInsert, OpUpdate or OpDelete, to Options.MaxUpsertsPerTable, Options.MaxUpdatesPerTable or
+// Options.MaxDeletesPerTable respectively. If the limit of the operation is 0 or the operation is unknown,
+// the semaphore returned by GetSemaphoreForTable is returned.
+func (db *DB) GetSemaphoreForTableAndOp(table, op string) *semaphore.Weighted {
+	var limit int
+	switch op {
+	case OpInsert:
+		limit = db.Options.MaxUpsertsPerTable
+	case OpUpdate:
+		limit = db.Options.MaxUpdatesPerTable
+	case OpDelete:
+		limit = db.Options.MaxDeletesPerTable
+	}
+
+	if limit < 1 {
+		return db.GetSemaphoreForTable(table)
+	}
+
+	return db.getSemaphore(tableOp{table: table, op: op}, limit)
+}
+
+// getBatchSize returns the adaptiveBatchSize for the given query, starting with count rows per chunk,
+// or nil if adaptive batch sizing is disabled.
+func (db *DB) getBatchSize(query string, count int) *adaptiveBatchSize {
+	if db.Options.BatchTargetLatency <= 0 {
+		return nil
+	}
+
+	db.batchSizesMu.Lock()
+	defer db.batchSizesMu.Unlock()
+
+	batchSize, ok := db.batchSizes[query]
+	if !ok {
+		batchSize = newAdaptiveBatchSize(count, db.Options.MinBatchSize, db.Options.BatchTargetLatency)
+		db.batchSizes[query] = batchSize
+	}
+
+	return batchSize
+}
+
+// getSemaphore returns the semaphore for key, creating it with the given limit if necessary.
+func (db *DB) getSemaphore(key tableOp, limit int) *semaphore.Weighted {
 	db.tableSemaphoresMu.Lock()
 	defer db.tableSemaphoresMu.Unlock()
 
-	if sem, ok := db.tableSemaphores[table]; ok {
-		return sem
-	} else {
-		sem = semaphore.NewWeighted(int64(db.Options.MaxConnectionsPerTable))
-		db.tableSemaphores[table] = sem
-		return sem
+	sem, ok := db.tableSemaphores[key]
+	if !ok {
+		sem = semaphore.NewWeighted(int64(limit))
+		db.tableSemaphores[key] = sem
 	}
+
+	return sem
 }
 
 func (db *DB) GetDefaultRetrySettings() retry.Settings {
 	return retry.Settings{
-		Timeout: retry.DefaultTimeout,
+		Timeout:  retry.DefaultTimeout,
+		Registry: db.Options.RetryRegistry,
+		Name:     "database query",
 		OnRetryableError: func(_ time.Duration, _ uint64, err, lastErr error) {
 			if lastErr == nil || err.Error() != lastErr.Error() {
 				db.logger.Warnw("Can't execute query. Retrying", zap.Error(err))
@@ -860,8 +1842,8 @@ func (db *DB) GetDefaultRetrySettings() retry.Settings {
 
 func (db *DB) Log(ctx context.Context, query string, counter *com.Counter) periodic.Stopper {
 	return periodic.Start(ctx, db.logger.Interval(), func(tick periodic.Tick) {
-		if count := counter.Reset(); count > 0 {
-			db.logger.Debugf("Executed %q with %d rows", query, count)
+		if s := counter.ResetSnapshot(); s.Delta > 0 {
+			db.logger.Debugf("Executed %q with %d rows (%.2f rows/s)", query, s.Delta, s.Rate())
 		}
 	}, periodic.OnStop(func(tick periodic.Tick) {
 		db.logger.Debugf("Finished executing %q with %d rows in %s", query, counter.Total(), tick.Elapsed)
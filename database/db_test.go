@@ -1,11 +1,25 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/com"
 	"github.com/icinga/icinga-go-library/config"
 	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
+	"golang.org/x/sync/semaphore"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestNewDbFromConfig_GetAddr(t *testing.T) {
@@ -122,3 +136,608 @@ func TestNewDbFromConfig_GetAddr(t *testing.T) {
 		})
 	}
 }
+
+// testHost has only a single column, since the order of columns returned by ColumnMap is not deterministic.
+type testHost struct {
+	Id string
+}
+
+func TestDB_BuildInsertIgnoreStmt(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL:      `INSERT INTO "test_host" ("id") VALUES (:id) ON DUPLICATE KEY UPDATE "id" = "id"`,
+		PostgreSQL: `INSERT INTO "test_host" ("id") VALUES (:id) ON CONFLICT ON CONSTRAINT pk_test_host DO NOTHING`,
+	}, func(t *testing.T, driver string) string {
+		stmt, _ := newTestDb(t, driver).BuildInsertIgnoreStmt(testHost{})
+		return stmt
+	})
+}
+
+func TestDB_BuildUpsertStmt(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL:      `INSERT INTO "test_host" ("id") VALUES (:id) ON DUPLICATE KEY UPDATE "id" = VALUES("id")`,
+		PostgreSQL: `INSERT INTO "test_host" ("id") VALUES (:id) ON CONFLICT ON CONSTRAINT pk_test_host DO UPDATE SET "id" = EXCLUDED."id"`,
+	}, func(t *testing.T, driver string) string {
+		stmt, _ := newTestDb(t, driver).BuildUpsertStmt(testHost{})
+		return stmt
+	})
+}
+
+// testConditionalHost has only a single column, since the order of columns returned by ColumnMap is not deterministic.
+type testConditionalHost struct {
+	Checksum string
+}
+
+// UpsertWhere implements the UpsertWherer interface.
+func (testConditionalHost) UpsertWhere() string {
+	return `EXCLUDED."checksum" <> "checksum"`
+}
+
+func TestDB_BuildUpsertStmt_UpsertWherer(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL: `INSERT INTO "test_conditional_host" ("checksum") VALUES (:checksum) ON DUPLICATE KEY UPDATE` +
+			` "checksum" = IF(VALUES("checksum") <> "checksum", VALUES("checksum"), "checksum")`,
+		PostgreSQL: `INSERT INTO "test_conditional_host" ("checksum") VALUES (:checksum)` +
+			` ON CONFLICT ON CONSTRAINT pk_test_conditional_host DO UPDATE SET "checksum" = EXCLUDED."checksum"` +
+			` WHERE EXCLUDED."checksum" <> "test_conditional_host"."checksum"`,
+	}, func(t *testing.T, driver string) string {
+		stmt, _ := newTestDb(t, driver).BuildUpsertStmt(testConditionalHost{})
+		return stmt
+	})
+
+	t.Run("MySQL sets referenced columns last", func(t *testing.T) {
+		stmt, _ := newTestDb(t, MySQL).BuildUpsertStmt(testConditionalUpserter{})
+		require.Equal(t, `INSERT INTO "test_conditional_upserter" ("checksum") VALUES (:checksum) ON DUPLICATE KEY UPDATE`+
+			` "name" = IF(VALUES("checksum") <> "checksum", VALUES("name"), "name"),`+
+			`"checksum" = IF(VALUES("checksum") <> "checksum", VALUES("checksum"), "checksum")`, stmt)
+	})
+
+	t.Run("MySQL matches whole column names", func(t *testing.T) {
+		stmt, _ := newTestDb(t, MySQL).BuildUpsertStmt(testPropertiesUpserter{})
+		require.Equal(t, `INSERT INTO "test_properties_upserter" ("checksum") VALUES (:checksum) ON DUPLICATE KEY UPDATE`+
+			` "checksum" = IF(VALUES("properties_checksum") <> "test_properties_upserter"."properties_checksum",`+
+			` VALUES("checksum"), "checksum"),`+
+			`"properties_checksum" = IF(VALUES("properties_checksum") <> "test_properties_upserter"."properties_checksum",`+
+			` VALUES("properties_checksum"), "properties_checksum")`, stmt)
+	})
+
+	t.Run("PostgreSQL keeps qualified columns", func(t *testing.T) {
+		stmt, _ := newTestDb(t, PostgreSQL).BuildUpsertStmt(testPropertiesUpserter{})
+		require.Contains(t, stmt,
+			` WHERE EXCLUDED."properties_checksum" <> "test_properties_upserter"."properties_checksum"`)
+	})
+}
+
+// testPropertiesUpserter updates the checksum and the properties_checksum column, of which only the latter is
+// referenced by the condition, so the former must be set first although its name is contained in the latter.
+type testPropertiesUpserter struct {
+	testConditionalHost
+}
+
+// Upsert implements the Upserter interface.
+func (testPropertiesUpserter) Upsert() any {
+	return struct {
+		PropertiesChecksum string
+		Checksum           string
+	}{}
+}
+
+// UpsertWhere implements the UpsertWherer interface.
+func (testPropertiesUpserter) UpsertWhere() string {
+	return `EXCLUDED."properties_checksum" <> "test_properties_upserter"."properties_checksum"`
+}
+
+// testConditionalUpserter updates the name and the checksum column, of which only the latter is referenced by the
+// condition and is therefore always set last, regardless of the order of columns returned by ColumnMap.
+type testConditionalUpserter struct {
+	testConditionalHost
+}
+
+// Upsert implements the Upserter interface.
+func (testConditionalUpserter) Upsert() any {
+	return struct {
+		Name     string
+		Checksum string
+	}{}
+}
+
+// testVersionedHost has only the id and the version column,
+// since the order of columns returned by ColumnMap is not deterministic.
+type testVersionedHost struct {
+	Id      string
+	Version int64
+}
+
+// VersionColumn implements the Versioner interface.
+func (testVersionedHost) VersionColumn() string {
+	return "version"
+}
+
+func TestDB_BuildUpdateStmt(t *testing.T) {
+	t.Run("Unversioned", func(t *testing.T) {
+		stmt, placeholders := newTestDb(t, MySQL).BuildUpdateStmt(testHost{})
+		require.Equal(t, `UPDATE "test_host" SET "id" = :id WHERE id = :id`, stmt)
+		require.Equal(t, 2, placeholders)
+	})
+
+	t.Run("Versioned", func(t *testing.T) {
+		stmt, placeholders := newTestDb(t, MySQL).BuildUpdateStmt(testVersionedHost{})
+		require.Equal(t, `UPDATE "test_versioned_host" SET "id" = :id, "version" = "version" + 1`+
+			` WHERE id = :id AND "version" = :version`, stmt)
+		require.Equal(t, 3, placeholders)
+	})
+
+	t.Run("Composite", func(t *testing.T) {
+		stmt, placeholders := newTestDb(t, MySQL).BuildUpdateStmt(testCustomvarFlat{})
+		require.Equal(t, `UPDATE "test_customvar_flat" SET "flat_value" = :flat_value`+
+			` WHERE "customvar_id" = :customvar_id AND "flatname_checksum" = :flatname_checksum`, stmt)
+		require.Equal(t, 3, placeholders)
+	})
+}
+
+// testCustomvarFlat has only a single column, since the order of columns returned by ColumnMap is not deterministic,
+// but a composite primary key.
+type testCustomvarFlat struct {
+	FlatValue string
+}
+
+// KeyColumns implements the CompositeKeyer interface.
+func (testCustomvarFlat) KeyColumns() []string {
+	return []string{"customvar_id", "flatname_checksum"}
+}
+
+func TestDB_BuildDeleteStmt(t *testing.T) {
+	t.Run("ID", func(t *testing.T) {
+		require.Equal(t, `DELETE FROM "test_host" WHERE id IN (?)`, newTestDb(t, MySQL).BuildDeleteStmt(testHost{}))
+	})
+
+	t.Run("Composite", func(t *testing.T) {
+		require.Equal(t,
+			`DELETE FROM "test_customvar_flat" WHERE ("customvar_id", "flatname_checksum") IN (?)`,
+			newTestDb(t, MySQL).BuildDeleteStmt(testCustomvarFlat{}))
+	})
+}
+
+func TestDB_YieldAll_YieldResume(t *testing.T) {
+	c := &resumeTestConnector{ids: []string{"1", "2", "3", "4", "5"}, failAfter: 2}
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(c), MySQL),
+		&Options{},
+		"test",
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour))
+	t.Cleanup(func() { _ = db.Close() })
+
+	entities, errs := db.YieldAll(context.Background(), func() Entity { return &testEntity{} },
+		`SELECT "id" FROM "test_entity"`, nil, YieldResume())
+
+	var ids []string
+	for e := range entities {
+		ids = append(ids, e.ID().String())
+	}
+
+	require.NoError(t, <-errs)
+	require.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+	require.Equal(t, []string{
+		`SELECT * FROM (SELECT "id" FROM "test_entity") AS "page" ORDER BY "id"`,
+		`SELECT * FROM (SELECT "id" FROM "test_entity") AS "page" WHERE "id" > ? ORDER BY "id"`,
+	}, c.queries)
+}
+
+func TestDB_YieldAllPaginated(t *testing.T) {
+	c := &resumeTestConnector{ids: []string{"1", "2", "3", "4", "5"}, failAfter: -1}
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(c), MySQL),
+		&Options{},
+		"test",
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour))
+	t.Cleanup(func() { _ = db.Close() })
+
+	entities, errs := db.YieldAllPaginated(context.Background(), func() Entity { return &testEntity{} },
+		`SELECT "id" FROM "test_entity"`, nil, 2)
+
+	var ids []string
+	for e := range entities {
+		ids = append(ids, e.ID().String())
+	}
+
+	require.NoError(t, <-errs)
+	require.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+	require.Equal(t, []string{
+		`SELECT * FROM (SELECT "id" FROM "test_entity") AS "page" ORDER BY "id" LIMIT 2`,
+		`SELECT * FROM (SELECT "id" FROM "test_entity") AS "page" WHERE "id" > ? ORDER BY "id" LIMIT 2`,
+		`SELECT * FROM (SELECT "id" FROM "test_entity") AS "page" WHERE "id" > ? ORDER BY "id" LIMIT 2`,
+	}, c.queries)
+	require.Equal(t, []driver.TxOptions{{
+		Isolation: driver.IsolationLevel(sql.LevelRepeatableRead),
+		ReadOnly:  true,
+	}}, c.txs, "pages must be read in a single read-only REPEATABLE READ transaction")
+	require.Equal(t, 3, c.queriesInTx)
+	require.False(t, c.inTx, "transaction must be finished")
+
+	t.Run("invalid-page-size", func(t *testing.T) {
+		entities, errs := db.YieldAllPaginated(context.Background(), func() Entity { return &testEntity{} },
+			`SELECT "id" FROM "test_entity"`, nil, 0)
+
+		_, ok := <-entities
+		require.False(t, ok, "nothing must be yielded")
+		require.ErrorContains(t, <-errs, "page size must be at least 1")
+	})
+}
+
+func TestDB_NamedBulkExecTx_OnSuccess(t *testing.T) {
+	db, _ := newStmtTestDb(t, 0)
+
+	entities := make(chan Entity, 3)
+	for _, id := range []testID{"1", "2", "3"} {
+		entities <- &testEntity{Id: id}
+	}
+	close(entities)
+
+	var counter com.Counter
+	var batches [][]Entity
+
+	require.NoError(t, db.NamedBulkExecTx(
+		context.Background(), `UPDATE "test" SET "id" = :id WHERE "id" = :id`, 2, semaphore.NewWeighted(1), entities,
+		OnSuccessIncrement[Entity](&counter),
+		func(_ context.Context, affectedRows []Entity) error {
+			batches = append(batches, affectedRows)
+
+			return nil
+		},
+	))
+	require.Equal(t, uint64(3), counter.Total())
+	require.Equal(t, [][]Entity{
+		{&testEntity{Id: "1"}, &testEntity{Id: "2"}},
+		{&testEntity{Id: "3"}},
+	}, batches)
+}
+
+func TestDB_NamedBulkExecTx_Versioner(t *testing.T) {
+	db, d := newStmtTestDb(t, 0)
+
+	hosts := []*testVersionedEntity{{testEntity: testEntity{Id: "1"}, Version: 1}, {testEntity: testEntity{Id: "2"}}}
+	stmt, _ := db.BuildUpdateStmt(hosts[0])
+
+	for range 2 {
+		entities := make(chan Entity, len(hosts))
+		for _, h := range hosts {
+			entities <- h
+		}
+		close(entities)
+
+		require.NoError(t, db.NamedBulkExecTx(context.Background(), stmt, 2, semaphore.NewWeighted(1), entities))
+	}
+
+	require.Equal(t, 2, d.committed)
+	require.Equal(t, int64(3), hosts[0].Version, "versions must be incremented after each update")
+	require.Equal(t, int64(2), hosts[1].Version, "versions must be incremented after each update")
+}
+
+// testVersionedEntity is a testEntity with a version column.
+type testVersionedEntity struct {
+	testEntity
+	Version int64
+}
+
+// VersionColumn implements the Versioner interface.
+func (*testVersionedEntity) VersionColumn() string {
+	return "version"
+}
+
+func TestDB_NamedBulkExec_ChunksPerTransaction(t *testing.T) {
+	db, d := newStmtTestDb(t, 0)
+	db.Options.ChunksPerTransaction = 3
+	db.Options.MaxRowsPerTransaction = 4
+
+	entities := make(chan Entity, 5)
+	for _, id := range []testID{"1", "2", "3", "4", "5"} {
+		entities <- &testEntity{Id: id}
+	}
+	close(entities)
+
+	var counter com.Counter
+	var ids []testID
+
+	require.NoError(t, db.NamedBulkExec(
+		context.Background(), `INSERT INTO "test" ("id") VALUES (:id)`, 2, semaphore.NewWeighted(1), entities,
+		com.NeverSplit[Entity],
+		func(_ context.Context, affectedRows []Entity) error {
+			for _, e := range affectedRows {
+				ids = append(ids, e.(*testEntity).Id)
+			}
+
+			return nil
+		},
+		OnSuccessIncrement[Entity](&counter),
+	))
+	require.Equal(t, uint64(5), counter.Total())
+	require.ElementsMatch(t, []testID{"1", "2", "3", "4", "5"}, ids)
+	require.Equal(t, 3, d.executed, "each chunk must be executed once")
+	require.LessOrEqual(t, d.committed, 1, "only the first two chunks fit into a transaction together")
+}
+
+func TestGroupChunks(t *testing.T) {
+	chunk := func(ids ...testID) []Entity {
+		b := make([]Entity, 0, len(ids))
+		for _, id := range ids {
+			b = append(b, &testEntity{Id: id})
+		}
+
+		return b
+	}
+
+	tests := []struct {
+		name      string
+		chunks    [][]Entity
+		maxChunks int
+		maxRows   int
+		groups    [][][]Entity
+	}{
+		{"empty", nil, 3, 10, nil},
+		{
+			"disabled",
+			[][]Entity{chunk("1", "2"), chunk("3")},
+			1, 10,
+			[][][]Entity{{chunk("1", "2")}, {chunk("3")}},
+		},
+		{
+			"max chunks",
+			[][]Entity{chunk("1"), chunk("2"), chunk("3"), chunk("4")},
+			3, 10,
+			[][][]Entity{{chunk("1"), chunk("2"), chunk("3")}, {chunk("4")}},
+		},
+		{
+			"max rows",
+			[][]Entity{chunk("1", "2"), chunk("3", "4"), chunk("5", "6", "7"), chunk("8")},
+			3, 4,
+			[][][]Entity{{chunk("1", "2"), chunk("3", "4")}, {chunk("5", "6", "7"), chunk("8")}},
+		},
+		{
+			"oversized chunk",
+			[][]Entity{chunk("1", "2", "3"), chunk("4")},
+			3, 2,
+			[][][]Entity{{chunk("1", "2", "3")}, {chunk("4")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// All chunks are ready at once, so that they are grouped deterministically.
+			chunks := make(chan []Entity, len(tt.chunks))
+			for _, b := range tt.chunks {
+				chunks <- b
+			}
+			close(chunks)
+
+			var groups [][][]Entity
+			for g := range groupChunks(context.Background(), chunks, tt.maxChunks, tt.maxRows) {
+				groups = append(groups, g)
+			}
+
+			require.Equal(t, tt.groups, groups)
+		})
+	}
+}
+
+func TestDB_buildPageQuery(t *testing.T) {
+	query := `SELECT "id" FROM "test_host" WHERE "environment_id" = :environment_id`
+	scope := struct{ EnvironmentId string }{"env"}
+
+	t.Run("first", func(t *testing.T) {
+		testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+			MySQL:      `SELECT * FROM (SELECT "id" FROM "test_host" WHERE "environment_id" = ?) AS "page" ORDER BY "id" LIMIT 100`,
+			PostgreSQL: `SELECT * FROM (SELECT "id" FROM "test_host" WHERE "environment_id" = $1) AS "page" ORDER BY "id" LIMIT 100`,
+		}, func(t *testing.T, driver string) string {
+			page, args, err := newTestDb(t, driver).buildPageQuery(query, scope, 100, nil)
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{"env"}, args)
+
+			return page
+		})
+	})
+
+	t.Run("next", func(t *testing.T) {
+		after := types.Binary{0xca, 0xfe}
+
+		testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+			MySQL: `SELECT * FROM (SELECT "id" FROM "test_host" WHERE "environment_id" = ?) AS "page"` +
+				` WHERE "id" > ? ORDER BY "id" LIMIT 100`,
+			PostgreSQL: `SELECT * FROM (SELECT "id" FROM "test_host" WHERE "environment_id" = $1) AS "page"` +
+				` WHERE "id" > $2 ORDER BY "id" LIMIT 100`,
+		}, func(t *testing.T, driver string) string {
+			page, args, err := newTestDb(t, driver).buildPageQuery(query, scope, 100, after)
+			require.NoError(t, err)
+			require.Equal(t, []interface{}{"env", after}, args)
+
+			return page
+		})
+	})
+
+	t.Run("unscoped", func(t *testing.T) {
+		testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+			MySQL:      `SELECT * FROM (SELECT "id" FROM "test_host") AS "page" WHERE "id" > ? ORDER BY "id" LIMIT 10`,
+			PostgreSQL: `SELECT * FROM (SELECT "id" FROM "test_host") AS "page" WHERE "id" > $1 ORDER BY "id" LIMIT 10`,
+		}, func(t *testing.T, driver string) string {
+			page, _, err := newTestDb(t, driver).buildPageQuery(`SELECT "id" FROM "test_host"`, nil, 10, types.Binary{1})
+			require.NoError(t, err)
+
+			return page
+		})
+	})
+}
+
+func TestDB_GetSemaphoreForTableAndOp(t *testing.T) {
+	db := newTestDb(t, MySQL)
+	db.Options.MaxConnectionsPerTable = 4
+	db.Options.MaxDeletesPerTable = 1
+
+	shared := db.GetSemaphoreForTable("host")
+	require.Same(t, shared, db.GetSemaphoreForTable("host"))
+	require.NotSame(t, shared, db.GetSemaphoreForTable("service"))
+
+	t.Run("shared", func(t *testing.T) {
+		require.Same(t, shared, db.GetSemaphoreForTableAndOp("host", OpInsert))
+		require.Same(t, shared, db.GetSemaphoreForTableAndOp("host", OpUpdate))
+		require.Same(t, shared, db.GetSemaphoreForTableAndOp("host", "unknown"))
+	})
+
+	t.Run("separate", func(t *testing.T) {
+		sem := db.GetSemaphoreForTableAndOp("host", OpDelete)
+		require.NotSame(t, shared, sem)
+		require.Same(t, sem, db.GetSemaphoreForTableAndOp("host", OpDelete))
+		require.NotSame(t, sem, db.GetSemaphoreForTableAndOp("service", OpDelete))
+
+		require.True(t, sem.TryAcquire(1))
+		require.False(t, sem.TryAcquire(1), "max_deletes_per_table must be respected")
+		sem.Release(1)
+	})
+}
+
+// newTestDb returns a DB for the given driver, i.e. MySQL or PostgreSQL, that is not connected to any database,
+// which is sufficient to test statement building.
+func newTestDb(t *testing.T, driver string) *DB {
+	typ := "mysql"
+	if driver == PostgreSQL {
+		typ = "pgsql"
+	}
+
+	db, err := NewDbFromConfig(
+		&Config{Type: typ, Host: "localhost", Database: "db", User: "user", Options: Options{MaxConnections: 1}},
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0),
+		RetryConnectorCallbacks{})
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestNewDbFromConfig_Hosts(t *testing.T) {
+	db, err := NewDbFromConfig(
+		&Config{
+			Type: "mysql", Host: "node1", Hosts: []string{"node2:3307", "/run/mysqld/mysqld.sock"},
+			Database: "db", User: "user", Options: Options{MaxConnections: 1},
+		},
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0),
+		RetryConnectorCallbacks{})
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	require.Equal(t, "mysql://user@node1:3306,node2:3307,(/run/mysqld/mysqld.sock)/db", db.GetAddr())
+}
+
+// resumeTestConnector is a driver.Connector whose connections yield the ids greater than the first query argument,
+// if any, and fail with ECONNRESET after yielding failAfter rows for the first query.
+type resumeTestConnector struct {
+	ids       []string
+	failAfter int
+
+	mu      sync.Mutex
+	queries []string
+	txs     []driver.TxOptions
+	inTx    bool
+
+	// queriesInTx is the number of queries executed in a transaction.
+	queriesInTx int
+}
+
+func (c *resumeTestConnector) Connect(context.Context) (driver.Conn, error) {
+	return resumeTestConn{c}, nil
+}
+
+func (c *resumeTestConnector) Driver() driver.Driver {
+	return nil
+}
+
+type resumeTestConn struct {
+	c *resumeTestConnector
+}
+
+func (resumeTestConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (resumeTestConn) Close() error {
+	return nil
+}
+
+func (resumeTestConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c resumeTestConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	c.c.txs = append(c.c.txs, opts)
+	c.c.inTx = true
+
+	return c, nil
+}
+
+func (c resumeTestConn) Commit() error {
+	return c.Rollback()
+}
+
+func (c resumeTestConn) Rollback() error {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	c.c.inTx = false
+
+	return nil
+}
+
+func (c resumeTestConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	c.c.queries = append(c.c.queries, query)
+	if c.c.inTx {
+		c.c.queriesInTx++
+	}
+
+	rows := &resumeTestRows{failAfter: -1}
+	if len(c.c.queries) == 1 {
+		rows.failAfter = c.c.failAfter
+	}
+
+	limit := len(c.c.ids)
+	if _, l, ok := strings.Cut(query, " LIMIT "); ok {
+		limit, _ = strconv.Atoi(l)
+	}
+
+	for _, id := range c.c.ids {
+		if (len(args) == 0 || id > args[0].Value.(string)) && len(rows.ids) < limit {
+			rows.ids = append(rows.ids, id)
+		}
+	}
+
+	return rows, nil
+}
+
+type resumeTestRows struct {
+	ids       []string
+	failAfter int
+}
+
+func (*resumeTestRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (*resumeTestRows) Close() error {
+	return nil
+}
+
+func (r *resumeTestRows) Next(dest []driver.Value) error {
+	if r.failAfter == 0 {
+		return syscall.ECONNRESET
+	}
+
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+
+	dest[0] = r.ids[0]
+	r.ids = r.ids[1:]
+	r.failAfter--
+
+	return nil
+}
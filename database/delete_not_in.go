@@ -0,0 +1,244 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// deleteNotInStmts are the statements executed by DeleteNotInStreamed.
+type deleteNotInStmts struct {
+	// create creates the temporary table holding the IDs to keep.
+	create string
+
+	// insert inserts IDs into the temporary table and lacks the row values to be appended.
+	insert string
+
+	// delete deletes the rows whose key isn't in the temporary table.
+	delete string
+
+	// drop drops the temporary table if it exists, e.g. because a previous transaction on the same connection has
+	// been rolled back, which doesn't undo its creation for MySQL.
+	drop string
+
+	// keyColumns are the columns of the key.
+	keyColumns []string
+}
+
+// DeleteNotInOption configures DeleteNotInStreamed.
+type DeleteNotInOption interface {
+	apply(*deleteNotInOptions)
+}
+
+// DeleteNotInAllowEmpty lets DeleteNotInStreamed delete all rows if keep is closed without any ID.
+// Otherwise, DeleteNotInStreamed refuses to do so, as an empty keep set usually indicates a failed producer.
+func DeleteNotInAllowEmpty() DeleteNotInOption {
+	return deleteNotInOptionFunc(func(o *deleteNotInOptions) {
+		o.allowEmpty = true
+	})
+}
+
+// DeleteNotInStreamed deletes all rows of the table of entityType whose key is not among the IDs from keep,
+// e.g. to remove the rows that no longer exist in the source after a full sync, and returns the number of deleted rows.
+// If entityType implements CompositeKeyer, the IDs must be CompositeKey values.
+//
+// keepErrs, if not nil, is the error channel of the producer of keep. If it yields an error, nothing is deleted
+// and the error is returned. Otherwise, the rows are only deleted once both keep and keepErrs are closed.
+// If keep is closed without any ID, an error is returned instead of deleting all rows,
+// unless DeleteNotInAllowEmpty is passed.
+//
+// Instead of building a possibly huge NOT IN list, the IDs are inserted into a temporary table in chunks of
+// Options.MaxPlaceholdersPerStatement placeholders, which the table is then anti-joined with to delete the rows.
+// All of this happens in a single transaction, which is rolled back if any statement fails or ctx is canceled,
+// so that either all or no rows are deleted. Since the IDs can't be read again, the operation is not retried.
+// Concurrency is controlled via Options.MaxDeletesPerTable, see GetSemaphoreForTableAndOp.
+func (db *DB) DeleteNotInStreamed(
+	ctx context.Context, entityType Entity, keep <-chan any, keepErrs <-chan error, options ...DeleteNotInOption,
+) (int64, error) {
+	var opts deleteNotInOptions
+	for _, option := range options {
+		option.apply(&opts)
+	}
+
+	table := TableName(entityType)
+	stmts := db.buildDeleteNotInStmts(entityType)
+	chunkSize := db.BatchSizeByPlaceholders(len(stmts.keyColumns))
+
+	sem := db.GetSemaphoreForTableAndOp(table, OpDelete)
+	if err := db.acquireSemaphore(ctx, sem, stmts.delete); err != nil {
+		return 0, err
+	}
+	defer sem.Release(1)
+
+	var deleted int64
+	err := db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		for _, stmt := range []string{stmts.drop, stmts.create} {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return CantPerformQuery(err, stmt)
+			}
+		}
+
+		insert := func(ids []any) error {
+			if len(ids) == 0 {
+				return nil
+			}
+
+			stmt, args, err := buildInsertValues(stmts.insert, ids, len(stmts.keyColumns))
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, db.Rebind(stmt), args...); err != nil {
+				return CantPerformQuery(err, stmts.insert)
+			}
+
+			return nil
+		}
+
+		ids := make([]any, 0, chunkSize)
+		received := 0
+
+		// Receive until both channels are closed. A nil keepErrs channel is never selected.
+		for keep != nil || keepErrs != nil {
+			select {
+			case id, ok := <-keep:
+				if !ok {
+					keep = nil
+
+					continue
+				}
+
+				received++
+				ids = append(ids, id)
+				if len(ids) == chunkSize {
+					if err := insert(ids); err != nil {
+						return err
+					}
+
+					ids = ids[:0]
+				}
+			case err, ok := <-keepErrs:
+				if !ok {
+					keepErrs = nil
+
+					continue
+				}
+
+				if err != nil {
+					return errors.Wrapf(err, "can't receive IDs of %q to keep", table)
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if received == 0 && !opts.allowEmpty {
+			return errors.Errorf("refusing to delete all rows of %q, as there are no IDs to keep", table)
+		}
+
+		if err := insert(ids); err != nil {
+			return err
+		}
+
+		rs, err := tx.ExecContext(ctx, stmts.delete)
+		if err != nil {
+			return CantPerformQuery(err, stmts.delete)
+		}
+
+		if deleted, err = rs.RowsAffected(); err != nil {
+			return errors.Wrap(err, "can't get number of deleted rows")
+		}
+
+		if _, err := tx.ExecContext(ctx, stmts.drop); err != nil {
+			return CantPerformQuery(err, stmts.drop)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// deleteNotInOptions stores the options of DeleteNotInStreamed.
+type deleteNotInOptions struct {
+	allowEmpty bool
+}
+
+// deleteNotInOptionFunc is a function that implements DeleteNotInOption.
+type deleteNotInOptionFunc func(*deleteNotInOptions)
+
+// apply implements the DeleteNotInOption interface.
+func (f deleteNotInOptionFunc) apply(o *deleteNotInOptions) {
+	f(o)
+}
+
+// buildDeleteNotInStmts returns the statements for DeleteNotInStreamed with the given struct.
+func (db *DB) buildDeleteNotInStmts(from interface{}) deleteNotInStmts {
+	table := TableName(from)
+	tmp := "keep_" + table
+
+	keyColumns := []string{"id"}
+	if keyer, ok := from.(CompositeKeyer); ok {
+		keyColumns = keyer.KeyColumns()
+	}
+
+	columns := `"` + strings.Join(keyColumns, `", "`) + `"`
+
+	joins := make([]string, 0, len(keyColumns))
+	for _, col := range keyColumns {
+		joins = append(joins, fmt.Sprintf(`"%[1]s"."%[3]s" = "%[2]s"."%[3]s"`, tmp, table, col))
+	}
+
+	stmts := deleteNotInStmts{
+		insert: fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES `, tmp, columns),
+		delete: fmt.Sprintf(
+			`DELETE FROM "%s" WHERE NOT EXISTS (SELECT 1 FROM "%s" WHERE %s)`, table, tmp, strings.Join(joins, " AND "),
+		),
+		keyColumns: keyColumns,
+	}
+
+	switch db.DriverName() {
+	case MySQL:
+		// Unlike PostgreSQL, MySQL doesn't use hash anti-joins, so the temporary table needs an index.
+		stmts.create = fmt.Sprintf(
+			`CREATE TEMPORARY TABLE "%s" (INDEX (%s)) SELECT %s FROM "%s" LIMIT 0`, tmp, columns, columns, table,
+		)
+		stmts.drop = fmt.Sprintf(`DROP TEMPORARY TABLE IF EXISTS "%s"`, tmp)
+	default:
+		stmts.create = fmt.Sprintf(`CREATE TEMPORARY TABLE "%s" AS SELECT %s FROM "%s" WITH NO DATA`, tmp, columns, table)
+		// Qualified with pg_temp, so that a regular table of the same name is never dropped.
+		stmts.drop = fmt.Sprintf(`DROP TABLE IF EXISTS pg_temp."%s"`, tmp)
+	}
+
+	return stmts
+}
+
+// buildInsertValues appends a row of n placeholders per ID to the INSERT statement prefix and returns it with
+// the flattened IDs. If n is greater than 1, the IDs must be CompositeKeys with n values.
+func buildInsertValues(prefix string, ids []any, n int) (string, []any, error) {
+	row := "(" + strings.Repeat("?, ", n-1) + "?)"
+	rows := make([]string, 0, len(ids))
+	args := make([]any, 0, len(ids)*n)
+
+	for _, id := range ids {
+		if n > 1 {
+			key, ok := id.(CompositeKey)
+			if !ok || len(key) != n {
+				return "", nil, errors.Errorf("expected composite key with %d values, got %v (%T)", n, id, id)
+			}
+
+			args = append(args, key...)
+		} else {
+			args = append(args, id)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return prefix + strings.Join(rows, ", "), args, nil
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/icinga/icinga-go-library/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDB_buildDeleteNotInStmts(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     any
+		expected testutils.PerDriver[deleteNotInStmts]
+	}{
+		{
+			name: "id",
+			from: testHost{},
+			expected: testutils.PerDriver[deleteNotInStmts]{
+				MySQL: {
+					create: `CREATE TEMPORARY TABLE "keep_test_host" (INDEX ("id")) SELECT "id" FROM "test_host" LIMIT 0`,
+					insert: `INSERT INTO "keep_test_host" ("id") VALUES `,
+					delete: `DELETE FROM "test_host" WHERE NOT EXISTS` +
+						` (SELECT 1 FROM "keep_test_host" WHERE "keep_test_host"."id" = "test_host"."id")`,
+					drop:       `DROP TEMPORARY TABLE IF EXISTS "keep_test_host"`,
+					keyColumns: []string{"id"},
+				},
+				PostgreSQL: {
+					create: `CREATE TEMPORARY TABLE "keep_test_host" AS SELECT "id" FROM "test_host" WITH NO DATA`,
+					insert: `INSERT INTO "keep_test_host" ("id") VALUES `,
+					delete: `DELETE FROM "test_host" WHERE NOT EXISTS` +
+						` (SELECT 1 FROM "keep_test_host" WHERE "keep_test_host"."id" = "test_host"."id")`,
+					drop:       `DROP TABLE IF EXISTS pg_temp."keep_test_host"`,
+					keyColumns: []string{"id"},
+				},
+			},
+		},
+		{
+			name: "composite-key",
+			from: testCustomvarFlat{},
+			expected: testutils.PerDriver[deleteNotInStmts]{
+				MySQL: {
+					create: `CREATE TEMPORARY TABLE "keep_test_customvar_flat" (INDEX ("customvar_id", "flatname_checksum"))` +
+						` SELECT "customvar_id", "flatname_checksum" FROM "test_customvar_flat" LIMIT 0`,
+					insert: `INSERT INTO "keep_test_customvar_flat" ("customvar_id", "flatname_checksum") VALUES `,
+					delete: `DELETE FROM "test_customvar_flat" WHERE NOT EXISTS (SELECT 1 FROM "keep_test_customvar_flat"` +
+						` WHERE "keep_test_customvar_flat"."customvar_id" = "test_customvar_flat"."customvar_id"` +
+						` AND "keep_test_customvar_flat"."flatname_checksum" = "test_customvar_flat"."flatname_checksum")`,
+					drop:       `DROP TEMPORARY TABLE IF EXISTS "keep_test_customvar_flat"`,
+					keyColumns: []string{"customvar_id", "flatname_checksum"},
+				},
+				PostgreSQL: {
+					create: `CREATE TEMPORARY TABLE "keep_test_customvar_flat" AS` +
+						` SELECT "customvar_id", "flatname_checksum" FROM "test_customvar_flat" WITH NO DATA`,
+					insert: `INSERT INTO "keep_test_customvar_flat" ("customvar_id", "flatname_checksum") VALUES `,
+					delete: `DELETE FROM "test_customvar_flat" WHERE NOT EXISTS (SELECT 1 FROM "keep_test_customvar_flat"` +
+						` WHERE "keep_test_customvar_flat"."customvar_id" = "test_customvar_flat"."customvar_id"` +
+						` AND "keep_test_customvar_flat"."flatname_checksum" = "test_customvar_flat"."flatname_checksum")`,
+					drop:       `DROP TABLE IF EXISTS pg_temp."keep_test_customvar_flat"`,
+					keyColumns: []string{"customvar_id", "flatname_checksum"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.RunPerDriver(t, tt.expected, func(t *testing.T, driver string, expected deleteNotInStmts) {
+				require.Equal(t, expected, newTestDb(t, driver).buildDeleteNotInStmts(tt.from))
+			})
+		})
+	}
+}
+
+func TestDB_DeleteNotInStreamed(t *testing.T) {
+	db, d := newStmtTestDb(t, 0)
+	db.Options.MaxConnectionsPerTable = 1
+	db.Options.MaxPlaceholdersPerStatement = 2
+
+	keepErrs := make(chan error)
+	close(keepErrs)
+
+	deleted, err := db.DeleteNotInStreamed(
+		context.Background(), &testEntity{}, utils.ChanFromSlice([]any{1, 2, 3, 4, 5}), keepErrs)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	stmts := db.buildDeleteNotInStmts(&testEntity{})
+	require.Equal(t, map[string]int{
+		stmts.drop:                2,
+		stmts.create:              1,
+		stmts.insert + "(?), (?)": 2,
+		stmts.insert + "(?)":      1,
+		stmts.delete:              1,
+	}, d.prepared)
+	require.Equal(t, 1, d.committed)
+
+	t.Run("producer-error", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 0)
+		db.Options.MaxConnectionsPerTable = 1
+
+		keep := make(chan any, 1)
+		keep <- 1
+		close(keep)
+
+		keepErrs := make(chan error, 1)
+		keepErrs <- errors.New("producer failed")
+		close(keepErrs)
+
+		_, err := db.DeleteNotInStreamed(context.Background(), &testEntity{}, keep, keepErrs)
+		require.ErrorContains(t, err, "producer failed")
+		require.Zero(t, d.prepared[db.buildDeleteNotInStmts(&testEntity{}).delete], "nothing must be deleted")
+		require.Zero(t, d.committed)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 0)
+		db.Options.MaxConnectionsPerTable = 1
+
+		_, err := db.DeleteNotInStreamed(context.Background(), &testEntity{}, utils.ChanFromSlice([]any{}), nil)
+		require.ErrorContains(t, err, "no IDs to keep")
+		require.Zero(t, d.prepared[db.buildDeleteNotInStmts(&testEntity{}).delete], "nothing must be deleted")
+		require.Zero(t, d.committed)
+	})
+
+	t.Run("empty-allowed", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 0)
+		db.Options.MaxConnectionsPerTable = 1
+
+		_, err := db.DeleteNotInStreamed(
+			context.Background(), &testEntity{}, utils.ChanFromSlice([]any{}), nil, DeleteNotInAllowEmpty())
+		require.NoError(t, err)
+		require.Equal(t, 1, d.prepared[db.buildDeleteNotInStmts(&testEntity{}).delete])
+		require.Equal(t, 1, d.committed)
+	})
+}
+
+func TestBuildInsertValues(t *testing.T) {
+	stmt, args, err := buildInsertValues("INSERT INTO t (a, b) VALUES ", []any{CompositeKey{1, 2}, CompositeKey{3, 4}}, 2)
+	require.NoError(t, err)
+	require.Equal(t, "INSERT INTO t (a, b) VALUES (?, ?), (?, ?)", stmt)
+	require.Equal(t, []any{1, 2, 3, 4}, args)
+
+	_, _, err = buildInsertValues("INSERT INTO t (a, b) VALUES ", []any{CompositeKey{1}}, 2)
+	require.Error(t, err)
+
+	_, _, err = buildInsertValues("INSERT INTO t (a, b) VALUES ", []any{1}, 2)
+	require.Error(t, err)
+}
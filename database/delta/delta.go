@@ -0,0 +1,169 @@
+// Package delta computes the differences between the entities actually stored in the database and
+// the desired ones, e.g. from Redis, and applies them using the streamed CRUD functions of database.DB.
+package delta
+
+import (
+	"bytes"
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+// EntitiesById maps entities by the string representation of their ID.
+type EntitiesById map[string]database.Entity
+
+// Entities streams the entities into a returned channel.
+func (ebi EntitiesById) Entities(ctx context.Context) <-chan database.Entity {
+	entities := make(chan database.Entity)
+
+	go func() {
+		defer close(entities)
+
+		for _, e := range ebi {
+			select {
+			case entities <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return entities
+}
+
+// IDs streams the IDs of the entities into a returned channel.
+func (ebi EntitiesById) IDs(ctx context.Context) <-chan interface{} {
+	ids := make(chan interface{})
+
+	go func() {
+		defer close(ids)
+
+		for _, e := range ebi {
+			select {
+			case ids <- e.ID():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ids
+}
+
+// Delta holds the entities that need to be created, updated and deleted
+// in order to turn the actual entities into the desired ones.
+type Delta struct {
+	// Create contains the desired entities that don't exist yet.
+	Create EntitiesById
+
+	// Update contains the desired entities whose checksum differs from the actual ones.
+	// Entities whose Fingerprint doesn't implement database.Checksummer are never updated.
+	Update EntitiesById
+
+	// Delete contains the actual entities that are no longer desired.
+	Delete EntitiesById
+}
+
+// Compute consumes the actual entities, e.g. from database.DB.YieldAll, and the desired entities concurrently
+// until both channels are closed and returns their Delta based on the entities' IDs and checksums.
+// Entities that are contained in both streams are held in memory only until their counterpart is received.
+func Compute(ctx context.Context, actual, desired <-chan database.Entity) (*Delta, error) {
+	d := &Delta{Update: EntitiesById{}}
+
+	// Entities of one stream for which the counterpart of the other stream has not been received yet.
+	pendingActual := EntitiesById{}
+	pendingDesired := EntitiesById{}
+
+	for actual != nil || desired != nil {
+		select {
+		case a, ok := <-actual:
+			if !ok {
+				actual = nil
+				continue
+			}
+
+			id := a.ID().String()
+			if w, ok := pendingDesired[id]; ok {
+				delete(pendingDesired, id)
+				d.compare(id, a, w)
+			} else {
+				pendingActual[id] = a
+			}
+		case w, ok := <-desired:
+			if !ok {
+				desired = nil
+				continue
+			}
+
+			id := w.ID().String()
+			if a, ok := pendingActual[id]; ok {
+				delete(pendingActual, id)
+				d.compare(id, a, w)
+			} else {
+				pendingDesired[id] = w
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	d.Create = pendingDesired
+	d.Delete = pendingActual
+
+	return d, nil
+}
+
+// Empty returns whether there is nothing to create, update or delete.
+func (d *Delta) Empty() bool {
+	return len(d.Create) == 0 && len(d.Update) == 0 && len(d.Delete) == 0
+}
+
+// Apply creates, updates and deletes the entities of the Delta concurrently
+// using db's CreateStreamed, UpdateStreamed and DeleteStreamed.
+func (d *Delta) Apply(ctx context.Context, db *database.DB) error {
+	g, ctx := errgroup.WithContext(ctx)
+
+	if len(d.Create) > 0 {
+		g.Go(func() error {
+			return errors.Wrap(db.CreateStreamed(ctx, d.Create.Entities(ctx)), "can't create entities")
+		})
+	}
+
+	if len(d.Update) > 0 {
+		g.Go(func() error {
+			return errors.Wrap(db.UpdateStreamed(ctx, d.Update.Entities(ctx)), "can't update entities")
+		})
+	}
+
+	if len(d.Delete) > 0 {
+		g.Go(func() error {
+			var entityType database.Entity
+			for _, e := range d.Delete {
+				entityType = e
+				break
+			}
+
+			return errors.Wrap(db.DeleteStreamed(ctx, entityType, d.Delete.IDs(ctx)), "can't delete entities")
+		})
+	}
+
+	return g.Wait()
+}
+
+// compare adds the desired entity to the updates if the checksum of its Fingerprint differs from the actual one.
+func (d *Delta) compare(id string, actual, desired database.Entity) {
+	a, ok := actual.Fingerprint().(database.Checksummer)
+	if !ok {
+		return
+	}
+
+	w, ok := desired.Fingerprint().(database.Checksummer)
+	if !ok {
+		return
+	}
+
+	if !bytes.Equal(a.Checksum(), w.Checksum()) {
+		d.Update[id] = desired
+	}
+}
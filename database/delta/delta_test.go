@@ -0,0 +1,155 @@
+package delta
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+)
+
+type testID string
+
+func (id testID) String() string {
+	return string(id)
+}
+
+type testEntity struct {
+	Id  testID
+	Sum []byte
+}
+
+func (e *testEntity) Fingerprint() database.Fingerprinter {
+	return e
+}
+
+func (e *testEntity) ID() database.ID {
+	return e.Id
+}
+
+func (e *testEntity) SetID(id database.ID) {
+	e.Id = id.(testID)
+}
+
+// testFingerprint is the Fingerprint of testChecksumEntity, which provides its checksum.
+type testFingerprint struct {
+	sum []byte
+}
+
+func (f testFingerprint) Fingerprint() database.Fingerprinter {
+	return f
+}
+
+func (f testFingerprint) Checksum() []byte {
+	return f.sum
+}
+
+type testChecksumEntity struct {
+	testEntity
+}
+
+func (e *testChecksumEntity) Fingerprint() database.Fingerprinter {
+	return testFingerprint{sum: e.Sum}
+}
+
+func TestCompute(t *testing.T) {
+	entity := func(id, checksum string) database.Entity {
+		return &testChecksumEntity{testEntity{Id: testID(id), Sum: []byte(checksum)}}
+	}
+
+	subtests := []struct {
+		name    string
+		actual  []database.Entity
+		desired []database.Entity
+		create  []string
+		update  []string
+		delete  []string
+	}{
+		{name: "empty"},
+		{
+			name:    "create",
+			desired: []database.Entity{entity("a", "1"), entity("b", "1")},
+			create:  []string{"a", "b"},
+		},
+		{
+			name:   "delete",
+			actual: []database.Entity{entity("a", "1"), entity("b", "1")},
+			delete: []string{"a", "b"},
+		},
+		{
+			name:    "unchanged",
+			actual:  []database.Entity{entity("a", "1"), entity("b", "2")},
+			desired: []database.Entity{entity("b", "2"), entity("a", "1")},
+		},
+		{
+			name:    "mixed",
+			actual:  []database.Entity{entity("a", "1"), entity("b", "1"), entity("c", "1")},
+			desired: []database.Entity{entity("d", "1"), entity("c", "1"), entity("b", "2")},
+			create:  []string{"d"},
+			update:  []string{"b"},
+			delete:  []string{"a"},
+		},
+		{
+			name:    "without-checksum",
+			actual:  []database.Entity{&testEntity{Id: "a", Sum: []byte("1")}},
+			desired: []database.Entity{&testEntity{Id: "a", Sum: []byte("2")}},
+		},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			d, err := Compute(context.Background(), stream(st.actual), stream(st.desired))
+			require.NoError(t, err)
+
+			require.ElementsMatch(t, st.create, ids(d.Create))
+			require.ElementsMatch(t, st.update, ids(d.Update))
+			require.ElementsMatch(t, st.delete, ids(d.Delete))
+			require.Equal(t, len(st.create)+len(st.update)+len(st.delete) == 0, d.Empty())
+
+			for id, e := range d.Update {
+				i := slices.IndexFunc(st.desired, func(e database.Entity) bool { return e.ID().String() == id })
+				require.Same(t, st.desired[i], e, "updates must contain the desired entity")
+			}
+		})
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := Compute(ctx, make(chan database.Entity), make(chan database.Entity))
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestEntitiesById_IDs(t *testing.T) {
+	ebi := EntitiesById{"a": &testEntity{Id: "a"}, "b": &testEntity{Id: "b"}}
+
+	var actual []interface{}
+	for id := range ebi.IDs(context.Background()) {
+		actual = append(actual, id)
+	}
+
+	require.ElementsMatch(t, []interface{}{testID("a"), testID("b")}, actual)
+}
+
+// stream returns a closed channel containing the given entities.
+func stream(entities []database.Entity) <-chan database.Entity {
+	ch := make(chan database.Entity, len(entities))
+	for _, e := range entities {
+		ch <- e
+	}
+	close(ch)
+
+	return ch
+}
+
+// ids returns the keys of ebi.
+func ids(ebi EntitiesById) []string {
+	keys := make([]string, 0, len(ebi))
+	for id := range ebi {
+		keys = append(keys, id)
+	}
+
+	return keys
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"strings"
+)
+
+// TableJob is a unit of work on a table, e.g. streaming entities into it via UpsertStreamed,
+// that depends on other tables via foreign keys.
+type TableJob struct {
+	// Table is the name of the table the job works on.
+	Table string
+
+	// DependsOn lists the tables referenced by foreign keys of Table.
+	// Tables that are not part of the jobs passed to ExecInDependencyOrder or
+	// ExecInReverseDependencyOrder are ignored, i.e. assumed to be complete.
+	DependsOn []string
+
+	// Exec performs the work on the table.
+	Exec func(context.Context) error
+}
+
+// ExecInDependencyOrder executes the given jobs concurrently, but starts a job only after
+// all jobs of the tables it depends on have completed successfully, so that rows referenced by
+// foreign keys are always inserted before the rows referencing them.
+// Note that a job's Exec is not called before its dependencies are complete,
+// so its input stream, if any, should be buffered or produced lazily.
+// Returns an error if the dependencies are cyclic or if any job fails, in which case all others are canceled.
+func ExecInDependencyOrder(ctx context.Context, jobs []TableJob) error {
+	return execOrdered(ctx, jobs, false)
+}
+
+// ExecInReverseDependencyOrder works like ExecInDependencyOrder, but starts a job only after
+// all jobs of the tables depending on it have completed successfully,
+// so that rows referencing others are always deleted before the rows they reference.
+func ExecInReverseDependencyOrder(ctx context.Context, jobs []TableJob) error {
+	return execOrdered(ctx, jobs, true)
+}
+
+// execOrdered implements ExecInDependencyOrder and, if reverse is true, ExecInReverseDependencyOrder.
+func execOrdered(ctx context.Context, jobs []TableJob, reverse bool) error {
+	// waitFor maps each table to the tables whose jobs must be completed first.
+	waitFor := make(map[string][]string, len(jobs))
+	done := make(map[string]chan struct{}, len(jobs))
+
+	for _, job := range jobs {
+		if _, ok := done[job.Table]; ok {
+			return errors.Errorf("duplicate job for table %q", job.Table)
+		}
+
+		done[job.Table] = make(chan struct{})
+		waitFor[job.Table] = nil
+	}
+
+	for _, job := range jobs {
+		for _, dep := range job.DependsOn {
+			if _, ok := done[dep]; !ok || dep == job.Table {
+				continue
+			}
+
+			if reverse {
+				waitFor[dep] = append(waitFor[dep], job.Table)
+			} else {
+				waitFor[job.Table] = append(waitFor[job.Table], dep)
+			}
+		}
+	}
+
+	if cycle := findCycle(waitFor); cycle != nil {
+		return errors.Errorf("cyclic table dependencies: %s", strings.Join(cycle, " -> "))
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	for _, job := range jobs {
+		g.Go(func() error {
+			for _, dep := range waitFor[job.Table] {
+				select {
+				case <-done[dep]:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			if err := job.Exec(ctx); err != nil {
+				return errors.Wrapf(err, "can't execute job for table %q", job.Table)
+			}
+
+			close(done[job.Table])
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// findCycle returns the tables forming a cycle in the given graph, or nil if there is none.
+func findCycle(graph map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(graph))
+	var path []string
+
+	var visit func(string) []string
+	visit = func(node string) []string {
+		switch state[node] {
+		case visiting:
+			for i, n := range path {
+				if n == node {
+					return append(path[i:len(path):len(path)], node)
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[node] = visiting
+		path = append(path, node)
+
+		for _, next := range graph[node] {
+			if cycle := visit(next); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[node] = visited
+
+		return nil
+	}
+
+	for node := range graph {
+		if cycle := visit(node); cycle != nil {
+			return cycle
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestExecInDependencyOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+
+	job := func(table string, dependsOn ...string) TableJob {
+		return TableJob{Table: table, DependsOn: dependsOn, Exec: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			order = append(order, table)
+
+			return nil
+		}}
+	}
+
+	jobs := []TableJob{
+		job("host_customvar", "host", "customvar"),
+		job("host", "environment"),
+		job("customvar", "environment"),
+		job("service", "host", "unknown"),
+		job("environment"),
+	}
+
+	before := func(t *testing.T, a, b string) {
+		require.Less(t, slices.Index(order, a), slices.Index(order, b), "%s must be executed before %s", a, b)
+	}
+
+	t.Run("forward", func(t *testing.T) {
+		order = nil
+		require.NoError(t, ExecInDependencyOrder(context.Background(), jobs))
+		require.Len(t, order, len(jobs))
+
+		before(t, "environment", "host")
+		before(t, "environment", "customvar")
+		before(t, "host", "host_customvar")
+		before(t, "customvar", "host_customvar")
+		before(t, "host", "service")
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		order = nil
+		require.NoError(t, ExecInReverseDependencyOrder(context.Background(), jobs))
+		require.Len(t, order, len(jobs))
+
+		before(t, "host", "environment")
+		before(t, "customvar", "environment")
+		before(t, "host_customvar", "host")
+		before(t, "host_customvar", "customvar")
+		before(t, "service", "host")
+	})
+}
+
+func TestExecInDependencyOrder_Errors(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	t.Run("cycle", func(t *testing.T) {
+		err := ExecInDependencyOrder(context.Background(), []TableJob{
+			{Table: "a", DependsOn: []string{"b"}, Exec: noop},
+			{Table: "b", DependsOn: []string{"a"}, Exec: noop},
+		})
+		require.ErrorContains(t, err, "cyclic table dependencies")
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		err := ExecInDependencyOrder(context.Background(), []TableJob{
+			{Table: "a", Exec: noop},
+			{Table: "a", Exec: noop},
+		})
+		require.ErrorContains(t, err, "duplicate job")
+	})
+
+	t.Run("failing-parent", func(t *testing.T) {
+		errFailed := errors.New("failed")
+		childExecuted := false
+
+		err := ExecInDependencyOrder(context.Background(), []TableJob{
+			{Table: "parent", Exec: func(context.Context) error { return errFailed }},
+			{Table: "child", DependsOn: []string{"parent"}, Exec: func(context.Context) error {
+				childExecuted = true
+				return nil
+			}},
+		})
+		require.ErrorIs(t, err, errFailed)
+		require.False(t, childExecuted, "child must not be executed if its parent failed")
+	})
+}
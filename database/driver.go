@@ -8,6 +8,7 @@ import (
 	"github.com/icinga/icinga-go-library/retry"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,11 +22,41 @@ const (
 // It will be called after successfully initiated a new connection using the connector's Connect method.
 type OnInitConnFunc func(context.Context, driver.Conn) error
 
+// ConnectionEvent describes a change of the database connectivity, as passed to the ConnectionEventFunc callbacks
+// of RetryConnectorCallbacks.
+type ConnectionEvent struct {
+	// Addr is the address of the database host, i.e. host:port or the path of the Unix domain socket.
+	// It may be empty if the RetryConnector has not been created by NewDbFromConfig.
+	Addr string
+
+	// Attempt is the number of the connection attempt within the current call of RetryConnector.Connect.
+	Attempt uint64
+
+	// Elapsed is the time spent in the current call of RetryConnector.Connect.
+	Elapsed time.Duration
+
+	// Err is the error of the failed connection attempt. It is only set for OnConnectionLost.
+	Err error
+}
+
+// ConnectionEventFunc is called upon a change of the database connectivity.
+type ConnectionEventFunc func(ConnectionEvent)
+
 // RetryConnectorCallbacks specifies callbacks that are executed upon certain events.
+//
+// OnConnectionEstablished, OnConnectionLost and OnReconnected allow applications to track the database connectivity,
+// e.g. to update their health state. OnConnectionEstablished is called for each new connection.
+// OnConnectionLost is called once if connecting fails after the previous attempt succeeded or no attempt was made yet,
+// and OnReconnected is called once connecting succeeds again afterwards.
+// The callbacks may be called concurrently and should return quickly, as they block connecting.
 type RetryConnectorCallbacks struct {
 	OnInitConn       OnInitConnFunc
 	OnRetryableError retry.OnRetryableErrorFunc
 	OnSuccess        retry.OnSuccessFunc
+
+	OnConnectionEstablished ConnectionEventFunc
+	OnConnectionLost        ConnectionEventFunc
+	OnReconnected           ConnectionEventFunc
 }
 
 // RetryConnector wraps driver.Connector with retry logic.
@@ -35,19 +66,43 @@ type RetryConnector struct {
 	logger *logging.Logger
 
 	callbacks RetryConnectorCallbacks
+
+	// addr is the address of the database host for ConnectionEvent, unless Connector provides it.
+	addr string
+
+	// timeout is the time after which connecting is given up. Defaults to retry.DefaultTimeout if zero.
+	timeout time.Duration
+
+	// registry, if set, keeps track of retried connection attempts as "database connect", see Options.RetryRegistry.
+	registry *retry.Registry
+
+	// lost is true once connecting has failed until it succeeds again. It's nil if not created by NewConnector.
+	lost *atomic.Bool
 }
 
 // NewConnector creates a fully initialized RetryConnector from the given args.
 func NewConnector(c driver.Connector, logger *logging.Logger, callbacks RetryConnectorCallbacks) *RetryConnector {
-	return &RetryConnector{Connector: c, logger: logger, callbacks: callbacks}
+	return &RetryConnector{Connector: c, logger: logger, callbacks: callbacks, lost: &atomic.Bool{}}
 }
 
 // Connect implements part of the driver.Connector interface.
 func (c RetryConnector) Connect(ctx context.Context) (driver.Conn, error) {
 	var conn driver.Conn
+	start := time.Now()
+
+	timeout := c.timeout
+	if timeout == 0 {
+		timeout = retry.DefaultTimeout
+	}
+
 	err := errors.Wrap(retry.WithBackoff(
 		ctx,
 		func(ctx context.Context) (err error) {
+			event := ConnectionEvent{Addr: c.currentAddr()}
+			if a, ok := retry.AttemptFromContext(ctx); ok {
+				event.Attempt = a.Number
+			}
+
 			conn, err = c.Connector.Connect(ctx)
 			if err == nil && c.callbacks.OnInitConn != nil {
 				if err = c.callbacks.OnInitConn(ctx, conn); err != nil {
@@ -56,12 +111,18 @@ func (c RetryConnector) Connect(ctx context.Context) (driver.Conn, error) {
 				}
 			}
 
+			event.Elapsed = time.Since(start)
+			event.Err = err
+			c.notify(event)
+
 			return
 		},
 		retry.Retryable,
 		backoff.NewExponentialWithJitter(128*time.Millisecond, 1*time.Minute),
 		retry.Settings{
-			Timeout: retry.DefaultTimeout,
+			Timeout:  timeout,
+			Registry: c.registry,
+			Name:     "database connect",
 			OnRetryableError: func(elapsed time.Duration, attempt uint64, err, lastErr error) {
 				if c.callbacks.OnRetryableError != nil {
 					c.callbacks.OnRetryableError(elapsed, attempt, err, lastErr)
@@ -91,6 +152,77 @@ func (c RetryConnector) Driver() driver.Driver {
 	return c.Connector.Driver()
 }
 
+// currentAddr returns the address of the database host the next connection attempt is made to.
+func (c RetryConnector) currentAddr() string {
+	if a, ok := c.Connector.(interface{ currentAddr() string }); ok {
+		return a.currentAddr()
+	}
+
+	return c.addr
+}
+
+// notify calls the callbacks for the result of a connection attempt, which failed if event.Err is set.
+func (c RetryConnector) notify(event ConnectionEvent) {
+	if event.Err != nil {
+		if c.lost != nil && c.lost.CompareAndSwap(false, true) && c.callbacks.OnConnectionLost != nil {
+			c.callbacks.OnConnectionLost(event)
+		}
+
+		return
+	}
+
+	if c.callbacks.OnConnectionEstablished != nil {
+		c.callbacks.OnConnectionEstablished(event)
+	}
+
+	if c.lost != nil && c.lost.CompareAndSwap(true, false) && c.callbacks.OnReconnected != nil {
+		c.callbacks.OnReconnected(event)
+	}
+}
+
+// failoverConnector is a driver.Connector that connects to one of multiple hosts.
+// It sticks to the host that last succeeded and switches to the next one if connecting fails,
+// so that the retries of RetryConnector try the hosts in turn with backoff.
+type failoverConnector struct {
+	connectors []driver.Connector
+	addrs      []string
+	logger     *logging.Logger
+	current    atomic.Uint64
+}
+
+// newFailoverConnector returns a new failoverConnector for the given connectors and their addresses.
+func newFailoverConnector(connectors []driver.Connector, addrs []string, logger *logging.Logger) *failoverConnector {
+	return &failoverConnector{connectors: connectors, addrs: addrs, logger: logger}
+}
+
+// Connect implements part of the driver.Connector interface.
+func (c *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	i := c.current.Load()
+
+	conn, err := c.connectors[i].Connect(ctx)
+	if err != nil {
+		next := (i + 1) % uint64(len(c.connectors))
+		if c.current.CompareAndSwap(i, next) {
+			c.logger.Warnw("Can't connect to database host. Switching to next host",
+				zap.String("from", c.addrs[i]), zap.String("to", c.addrs[next]), zap.Error(err))
+		}
+
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// currentAddr returns the address of the host the next connection attempt is made to.
+func (c *failoverConnector) currentAddr() string {
+	return c.addrs[c.current.Load()]
+}
+
+// Driver implements part of the driver.Connector interface.
+func (c *failoverConnector) Driver() driver.Driver {
+	return c.connectors[0].Driver()
+}
+
 // MysqlFuncLogger is an adapter that allows ordinary functions to be used as a logger for mysql.SetLogger.
 type MysqlFuncLogger func(v ...interface{})
 
@@ -98,3 +230,9 @@ type MysqlFuncLogger func(v ...interface{})
 func (log MysqlFuncLogger) Print(v ...interface{}) {
 	log(v)
 }
+
+// Assert interface compliance.
+var (
+	_ driver.Connector = RetryConnector{}
+	_ driver.Connector = (*failoverConnector)(nil)
+)
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testConnector is a driver.Connector which fails to connect while its err is set.
+type testConnector struct {
+	driver.Connector
+
+	err      error
+	attempts int
+}
+
+func (c *testConnector) Connect(context.Context) (driver.Conn, error) {
+	c.attempts++
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	return testConn{}, nil
+}
+
+// testConn is a driver.Conn which must not be used.
+type testConn struct {
+	driver.Conn
+}
+
+func TestFailoverConnector(t *testing.T) {
+	errDown := errors.New("down")
+	a, b, c := &testConnector{}, &testConnector{err: errDown}, &testConnector{}
+
+	connector := newFailoverConnector(
+		[]driver.Connector{a, b, c}, []string{"a", "b", "c"}, logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0))
+
+	connect := func() error {
+		_, err := connector.Connect(context.Background())
+		return err
+	}
+
+	require.NoError(t, connect())
+	require.NoError(t, connect())
+	require.Equal(t, []int{2, 0, 0}, []int{a.attempts, b.attempts, c.attempts}, "must stick to the first host")
+
+	a.err = errDown
+	require.ErrorIs(t, connect(), errDown)
+	require.ErrorIs(t, connect(), errDown)
+	require.NoError(t, connect())
+	require.NoError(t, connect())
+	require.Equal(t, []int{3, 1, 2}, []int{a.attempts, b.attempts, c.attempts}, "must try the hosts in turn")
+
+	a.err = nil
+	c.err = errDown
+	require.ErrorIs(t, connect(), errDown)
+	require.NoError(t, connect())
+	require.Equal(t, []int{4, 1, 3}, []int{a.attempts, b.attempts, c.attempts}, "must wrap around to the first host")
+}
+
+func TestRetryConnector_ConnectionEvents(t *testing.T) {
+	a, b := &testConnector{}, &testConnector{}
+	logger := logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0)
+
+	var events []string
+	record := func(name string) ConnectionEventFunc {
+		return func(e ConnectionEvent) {
+			require.LessOrEqual(t, e.Elapsed, time.Minute)
+			events = append(events, fmt.Sprintf("%s %s #%d %v", name, e.Addr, e.Attempt, e.Err))
+		}
+	}
+
+	connector := NewConnector(
+		newFailoverConnector([]driver.Connector{a, b}, []string{"a", "b"}, logger), logger, RetryConnectorCallbacks{
+			OnConnectionEstablished: record("established"),
+			OnConnectionLost:        record("lost"),
+			OnReconnected:           record("reconnected"),
+		})
+
+	_, err := connector.Connect(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"established a #1 <nil>"}, events)
+
+	events = nil
+	a.err = syscall.ECONNREFUSED
+
+	_, err = connector.Connect(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"lost a #1 connection refused",
+		"established b #2 <nil>",
+		"reconnected b #2 <nil>",
+	}, events)
+}
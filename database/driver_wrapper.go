@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/pkg/errors"
+)
+
+// wrappedConn is the base of the driver.Conn wrappers, such as queryLoggingConn, dryRunConn and stmtCacheConn.
+// It implements the optional interfaces used by database/sql by passing through to the wrapped connection or,
+// if it doesn't implement them, by behaving like database/sql does without them,
+// so that wrappers only have to override the methods that differ.
+type wrappedConn struct {
+	driver.Conn
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface.
+func (c wrappedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if cpc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return cpc.PrepareContext(ctx, query)
+	}
+
+	return c.Conn.Prepare(query)
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c wrappedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		// database/sql falls back to preparing the statement.
+		return nil, driver.ErrSkip
+	}
+
+	return execer.ExecContext(ctx, query, args)
+}
+
+// QueryContext implements the driver.QueryerContext interface.
+func (c wrappedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		// database/sql falls back to preparing the statement.
+		return nil, driver.ErrSkip
+	}
+
+	return queryer.QueryContext(ctx, query, args)
+}
+
+// BeginTx implements the driver.ConnBeginTx interface.
+func (c wrappedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if cbt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return cbt.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != driver.IsolationLevel(0) || opts.ReadOnly {
+		return nil, errors.New("driver does not support non-default transaction options")
+	}
+
+	return c.Conn.Begin()
+}
+
+// Ping implements the driver.Pinger interface.
+func (c wrappedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+// ResetSession implements the driver.SessionResetter interface.
+func (c wrappedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+// IsValid implements the driver.Validator interface.
+func (c wrappedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface.
+func (c wrappedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return driver.ErrSkip
+}
+
+// wrappedStmt is the base of the driver.Stmt wrappers, such as queryLoggingStmt, dryRunStmt and cachedStmt.
+// Like wrappedConn, it passes through to the wrapped statement.
+type wrappedStmt struct {
+	driver.Stmt
+
+	// conn is the connection the statement has been prepared on.
+	conn wrappedConn
+}
+
+// ExecContext implements the driver.StmtExecContext interface.
+func (s wrappedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if sec, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return sec.ExecContext(ctx, args)
+	}
+
+	return s.Stmt.Exec(namedValuesToValues(args))
+}
+
+// QueryContext implements the driver.StmtQueryContext interface.
+func (s wrappedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if sqc, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return sqc.QueryContext(ctx, args)
+	}
+
+	return s.Stmt.Query(namedValuesToValues(args))
+}
+
+// CheckNamedValue implements the driver.NamedValueChecker interface
+// by passing through to the wrapped statement or, if it doesn't implement it, to the connection.
+func (s wrappedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+
+	return s.conn.CheckNamedValue(nv)
+}
+
+// namedValuesToValues returns the values of args.
+func namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+
+	return values
+}
+
+// Assert interface compliance.
+var (
+	_ driver.Conn               = wrappedConn{}
+	_ driver.ConnPrepareContext = wrappedConn{}
+	_ driver.ExecerContext      = wrappedConn{}
+	_ driver.QueryerContext     = wrappedConn{}
+	_ driver.ConnBeginTx        = wrappedConn{}
+	_ driver.Pinger             = wrappedConn{}
+	_ driver.SessionResetter    = wrappedConn{}
+	_ driver.Validator          = wrappedConn{}
+	_ driver.NamedValueChecker  = wrappedConn{}
+	_ driver.Stmt               = wrappedStmt{}
+	_ driver.StmtExecContext    = wrappedStmt{}
+	_ driver.StmtQueryContext   = wrappedStmt{}
+	_ driver.NamedValueChecker  = wrappedStmt{}
+)
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/logging"
+	"go.uber.org/zap"
+	"regexp"
+)
+
+// insertValuesRegex matches the VALUES keyword of an INSERT or REPLACE statement.
+var insertValuesRegex = regexp.MustCompile(`(?is)^\s*(?:INSERT|REPLACE)\b.*?\bVALUES\b`)
+
+// dryRunRows estimates the number of rows the given statement would write, i.e. the number of VALUES tuples of
+// an INSERT or REPLACE statement and 1 for any other statement, so that callers checking the rows affected,
+// e.g. by versioned updates, don't fail in dry-run mode.
+func dryRunRows(query string) int64 {
+	loc := insertValuesRegex.FindStringIndex(query)
+	if loc == nil {
+		return 1
+	}
+
+	var rows int64
+	depth := 0
+
+scan:
+	for _, r := range query[loc[1]:] {
+		switch r {
+		case '(':
+			if depth == 0 {
+				rows++
+			}
+			depth++
+		case ')':
+			depth--
+		case ',', ' ', '\t', '\r', '\n':
+		default:
+			if depth == 0 {
+				// End of the VALUES list, e.g. ON DUPLICATE KEY UPDATE or ON CONFLICT.
+				break scan
+			}
+		}
+	}
+
+	return max(rows, 1)
+}
+
+// withDryRun wraps connector so that statements are only logged instead of being executed if enabled in the options.
+func withDryRun(connector driver.Connector, logger *logging.Logger, o Options) driver.Connector {
+	if !o.DryRun {
+		return connector
+	}
+
+	return dryRunConnector{Connector: connector, logger: logger}
+}
+
+// dryRunConnector wraps a driver.Connector so that statements executed on its connections are only logged.
+type dryRunConnector struct {
+	driver.Connector
+	logger *logging.Logger
+}
+
+// Connect implements part of the driver.Connector interface.
+func (c dryRunConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dryRunConn{wrappedConn: wrappedConn{conn}, logger: c.logger}, nil
+}
+
+// dryRunConn logs the statements executed directly on it or via its prepared statements instead of executing them.
+// Queries are still performed.
+type dryRunConn struct {
+	wrappedConn
+	logger *logging.Logger
+}
+
+// skip logs the statement that would have been executed with args and returns its estimated result.
+func (c *dryRunConn) skip(query string, args []driver.NamedValue) driver.Result {
+	rows := dryRunRows(query)
+	c.logger.Infow("Dry run: Not executing statement",
+		zap.String("query", query), zap.Int("args", len(args)), zap.Int64("rows", rows))
+
+	return dryRunResult(rows)
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface.
+func (c *dryRunConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.wrappedConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dryRunStmt{wrappedStmt: wrappedStmt{Stmt: stmt, conn: c.wrappedConn}, dryRun: c, query: query}, nil
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c *dryRunConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.skip(query, args), nil
+}
+
+// dryRunStmt logs the executions of a prepared statement instead of executing it.
+type dryRunStmt struct {
+	wrappedStmt
+	dryRun *dryRunConn
+	query  string
+}
+
+// ExecContext implements the driver.StmtExecContext interface.
+func (s *dryRunStmt) ExecContext(_ context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.dryRun.skip(s.query, args), nil
+}
+
+// dryRunResult is the driver.Result of a statement that has not been executed, i.e. its estimated number of rows.
+type dryRunResult int64
+
+// LastInsertId implements part of the driver.Result interface.
+// It returns 0, as no row has been inserted, instead of an error,
+// so that callers reading back the ID of an inserted row don't fail in dry-run mode.
+func (dryRunResult) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+// RowsAffected implements part of the driver.Result interface.
+func (r dryRunResult) RowsAffected() (int64, error) {
+	return int64(r), nil
+}
+
+// Assert interface compliance.
+var (
+	_ driver.Connector          = dryRunConnector{}
+	_ driver.ConnPrepareContext = (*dryRunConn)(nil)
+	_ driver.ExecerContext      = (*dryRunConn)(nil)
+	_ driver.StmtExecContext    = (*dryRunStmt)(nil)
+	_ driver.Result             = dryRunResult(0)
+)
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"testing"
+	"time"
+)
+
+func TestDryRunRows(t *testing.T) {
+	subtests := []struct {
+		name  string
+		query string
+		rows  int64
+	}{
+		{"insert", `INSERT INTO "host" ("id", "name") VALUES (?, ?)`, 1},
+		{"insert-multiple-rows", `INSERT INTO "host" ("id", "name") VALUES (?, ?),(?, ?), (?, ?)`, 3},
+		{"insert-function", `INSERT INTO "host" ("id", "name") VALUES (?, LOWER(?)), (?, LOWER(?))`, 2},
+		{
+			"upsert-mysql",
+			`INSERT INTO "host" ("id", "name") VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE "name" = VALUES("name")`,
+			2,
+		},
+		{
+			"upsert-pgsql",
+			`INSERT INTO "host" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`,
+			1,
+		},
+		{"update", `UPDATE "host" SET "name" = ? WHERE "id" = ?`, 1},
+		{"delete", `DELETE FROM "host" WHERE "id" IN (?, ?)`, 1},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			require.Equal(t, st.rows, dryRunRows(st.query))
+		})
+	}
+}
+
+func TestDB_DryRun(t *testing.T) {
+	d := &stmtTestDriver{prepared: map[string]int{}}
+	logger := logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour)
+
+	db := sqlx.NewDb(sql.OpenDB(withDryRun(d, logger, Options{DryRun: true})), MySQL)
+	t.Cleanup(func() { _ = db.Close() })
+
+	res, err := db.ExecContext(context.Background(), `INSERT INTO "host" ("id") VALUES (?), (?)`, 1, 2)
+	require.NoError(t, err)
+	rows, err := res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rows)
+	id, err := res.LastInsertId()
+	require.NoError(t, err)
+	require.Zero(t, id)
+
+	stmt, err := db.PrepareContext(context.Background(), `UPDATE "host" SET "name" = ? WHERE "id" = ?`)
+	require.NoError(t, err)
+	defer func() { _ = stmt.Close() }()
+
+	res, err = stmt.ExecContext(context.Background(), "a", 1)
+	require.NoError(t, err)
+	rows, err = res.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	require.Equal(t, 0, d.executed, "statements must not be executed")
+
+	_, err = db.QueryContext(context.Background(), `SELECT "id" FROM "host"`)
+	require.ErrorContains(t, err, "not implemented", "queries must be passed to the driver")
+}
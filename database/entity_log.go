@@ -0,0 +1,56 @@
+package database
+
+import (
+	"encoding/hex"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"reflect"
+)
+
+// LogEntity returns a zap field named "entity", which logs e as LoggableEntity,
+// so that entities are identified consistently in log messages.
+// Note that this can't be part of the logging package, as the database package depends on it.
+func LogEntity(e Entity) zap.Field {
+	return zap.Object("entity", LoggableEntity{e})
+}
+
+// LoggableEntity wraps an Entity to implement [zapcore.ObjectMarshaler].
+// If the Entity implements zapcore.ObjectMarshaler itself, it is used instead.
+// Otherwise, the table name, the ID and the hex-encoded checksum, if the Entity implements Checksummer, are logged.
+type LoggableEntity struct {
+	Entity
+}
+
+// MarshalLogObject implements the [zapcore.ObjectMarshaler] interface.
+func (e LoggableEntity) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	if m, ok := e.Entity.(zapcore.ObjectMarshaler); ok {
+		return m.MarshalLogObject(encoder)
+	}
+
+	encoder.AddString("table", TableName(e.Entity))
+
+	if id := e.ID(); !isNilID(id) {
+		encoder.AddString("id", id.String())
+	}
+
+	if c, ok := e.Entity.(Checksummer); ok && c.Checksum() != nil {
+		encoder.AddString("checksum", hex.EncodeToString(c.Checksum()))
+	}
+
+	return nil
+}
+
+// isNilID returns whether id is nil, including a nil pointer in a non-nil interface, e.g. a nil *types.UUID,
+// whose String method would panic.
+func isNilID(id ID) bool {
+	if id == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(id)
+
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// Assert interface compliance.
+var _ zapcore.ObjectMarshaler = LoggableEntity{}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+// testChecksumEntity is a testEntity providing a checksum.
+type testChecksumEntity struct {
+	testEntity
+}
+
+func (e *testChecksumEntity) Checksum() []byte {
+	return []byte{0xca, 0xfe}
+}
+
+// testNilIdEntity is a testEntity without an ID.
+type testNilIdEntity struct {
+	testEntity
+}
+
+func (e *testNilIdEntity) ID() ID {
+	return nil
+}
+
+// testPtrID is an ID implemented by a pointer type.
+type testPtrID struct {
+	id string
+}
+
+func (id *testPtrID) String() string {
+	return id.id
+}
+
+// testNilPtrIdEntity is a testEntity with a nil testPtrID.
+type testNilPtrIdEntity struct {
+	testEntity
+}
+
+func (e *testNilPtrIdEntity) ID() ID {
+	return (*testPtrID)(nil)
+}
+
+func TestLogEntity(t *testing.T) {
+	tests := []struct {
+		name     string
+		entity   Entity
+		expected map[string]any
+	}{
+		{"id", &testEntity{Id: "42"}, map[string]any{"table": "test_entity", "id": "42"}},
+		{"nil-id", &testNilIdEntity{}, map[string]any{"table": "test_nil_id_entity"}},
+		{"nil-pointer-id", &testNilPtrIdEntity{}, map[string]any{"table": "test_nil_ptr_id_entity"}},
+		{"checksum", &testChecksumEntity{testEntity{Id: "42"}}, map[string]any{
+			"table": "test_checksum_entity", "id": "42", "checksum": "cafe",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := zapcore.NewMapObjectEncoder()
+			LogEntity(tt.entity).AddTo(enc)
+
+			require.Equal(t, map[string]any{"entity": tt.expected}, enc.Fields)
+		})
+	}
+}
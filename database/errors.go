@@ -0,0 +1,270 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Query operation kinds as reported by QueryError.Op.
+const (
+	OpSelect = "select"
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpDelete = "delete"
+	OpOther  = "other"
+)
+
+// Error categories of QueryError as detected from MySQL error numbers and PostgreSQL error codes,
+// e.g. to decide whether to skip an entity or to abort. Use errors.Is to check the category of an error.
+var (
+	// ErrConstraintViolation indicates a violated integrity constraint, e.g. a duplicate key or a missing reference.
+	ErrConstraintViolation = errors.New("constraint violation")
+
+	// ErrDeadlock indicates that the transaction was rolled back because of a deadlock.
+	ErrDeadlock = errors.New("deadlock")
+
+	// ErrConnectionLost indicates that the connection to the database was lost.
+	ErrConnectionLost = errors.New("connection lost")
+
+	// ErrSyntax indicates a syntax error in the query.
+	ErrSyntax = errors.New("syntax error")
+
+	// ErrDataTooLong indicates that a value is too long for its column.
+	ErrDataTooLong = errors.New("data too long")
+)
+
+// QueryError is returned by CantPerformQuery and describes a query that could not be executed.
+// Use errors.As to retrieve it from an error chain.
+type QueryError struct {
+	// Query is the query that could not be executed.
+	Query string
+
+	// Op is the kind of operation derived from the query, i.e. one of the Op* constants.
+	Op string
+
+	// Table is the table targeted by the query, or empty if it could not be determined.
+	Table string
+
+	// Driver is the name of the database driver that returned the error,
+	// i.e. MySQL or PostgreSQL, or empty if the error does not originate from a known driver.
+	Driver string
+
+	// SQLState is the five-character SQLSTATE code of the error, or empty if not available.
+	SQLState string
+
+	// Number is the MySQL error number. It is always 0 for PostgreSQL errors.
+	Number uint16
+
+	// Retryable reports whether the error was classified as retryable by retry.Retryable.
+	Retryable bool
+
+	// Category is the category of the error, i.e. one of ErrConstraintViolation, ErrDeadlock, ErrConnectionLost,
+	// ErrSyntax and ErrDataTooLong, or nil if the error does not fall into any of them.
+	Category error
+
+	err error
+}
+
+// Error implements the error interface.
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("can't perform %q: %s", e.Query, e.err)
+}
+
+// Is returns true if target is the Category of the error.
+func (e *QueryError) Is(target error) bool {
+	return e.Category != nil && target == e.Category
+}
+
+// Unwrap returns the underlying error.
+func (e *QueryError) Unwrap() error {
+	return e.err
+}
+
+// MarshalLogObject implements [zapcore.ObjectMarshaler], so that query errors can be logged with
+// all of their metadata as structured fields.
+func (e *QueryError) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddString("query", e.Query)
+	encoder.AddString("op", e.Op)
+
+	if e.Table != "" {
+		encoder.AddString("table", e.Table)
+	}
+	if e.Driver != "" {
+		encoder.AddString("driver", e.Driver)
+	}
+	if e.SQLState != "" {
+		encoder.AddString("sqlstate", e.SQLState)
+	}
+	if e.Number != 0 {
+		encoder.AddUint16("number", e.Number)
+	}
+
+	if e.Category != nil {
+		encoder.AddString("category", e.Category.Error())
+	}
+
+	encoder.AddBool("retryable", e.Retryable)
+	encoder.AddString("error", e.err.Error())
+
+	return nil
+}
+
+// CantPerformQuery wraps the given error with the specified query that cannot be executed
+// into a *QueryError carrying the operation kind, target table and driver error details.
+func CantPerformQuery(err error, q string) error {
+	op, table := parseQuery(q)
+
+	qe := &QueryError{
+		Query:     q,
+		Op:        op,
+		Table:     table,
+		Retryable: retry.Retryable(err),
+		err:       err,
+	}
+
+	var mye *mysql.MySQLError
+	var pqe *pq.Error
+	if errors.As(err, &mye) {
+		qe.Driver = MySQL
+		qe.Number = mye.Number
+
+		if mye.SQLState != [5]byte{} {
+			qe.SQLState = string(mye.SQLState[:])
+		}
+	} else if errors.As(err, &pqe) {
+		qe.Driver = PostgreSQL
+		qe.SQLState = string(pqe.Code)
+	}
+
+	qe.Category = categorize(err, qe)
+
+	return errors.WithStack(qe)
+}
+
+// categorize returns the category of the given error, whose driver details have already been extracted into qe.
+func categorize(err error, qe *QueryError) error {
+	switch qe.Driver {
+	case MySQL:
+		switch qe.Number {
+		case 1022, 1048, 1062, 1169, 1216, 1217, 1451, 1452, 1557, 1586, 3819:
+			return ErrConstraintViolation
+		case 1213:
+			return ErrDeadlock
+		case 1053, 1927:
+			// Client-side errors such as 2006 (server has gone away) and 2013 (lost connection) are never
+			// reported as *mysql.MySQLError, but as mysql.ErrInvalidConn or driver.ErrBadConn, see below.
+			return ErrConnectionLost
+		case 1064, 1149:
+			return ErrSyntax
+		case 1406:
+			return ErrDataTooLong
+		}
+	case PostgreSQL:
+		switch {
+		case strings.HasPrefix(qe.SQLState, "23"): // Class 23 - Integrity Constraint Violation
+			return ErrConstraintViolation
+		case qe.SQLState == "40P01": // deadlock_detected
+			return ErrDeadlock
+		case strings.HasPrefix(qe.SQLState, "08"), // Class 08 - Connection Exception
+			qe.SQLState == "57P01", // admin_shutdown
+			qe.SQLState == "57P02", // crash_shutdown
+			qe.SQLState == "57P03": // cannot_connect_now
+			return ErrConnectionLost
+		case qe.SQLState == "42601": // syntax_error
+			return ErrSyntax
+		case qe.SQLState == "22001": // string_data_right_truncation
+			return ErrDataTooLong
+		}
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) || isConnectError(err) {
+		return ErrConnectionLost
+	}
+
+	return nil
+}
+
+// isConnectError returns whether err indicates that the database could not be connected to,
+// e.g. because dialing it failed.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+
+	return errors.As(err, &opErr)
+}
+
+// ErrStaleUpdate is matched by *StaleUpdateError via errors.Is.
+var ErrStaleUpdate = errors.New("stale update")
+
+// StaleUpdateError is returned if the update of a Versioner did not affect any rows,
+// i.e. the row was modified concurrently, so its version no longer matches, or deleted.
+type StaleUpdateError struct {
+	Table string // Table is the table of the entity.
+	ID    ID     // ID is the ID of the entity.
+}
+
+// Error implements the error interface.
+func (e *StaleUpdateError) Error() string {
+	return fmt.Sprintf("stale update of %q with ID %s: row was modified concurrently or deleted", e.Table, e.ID)
+}
+
+// Is returns true if target is ErrStaleUpdate.
+func (e *StaleUpdateError) Is(target error) bool {
+	return target == ErrStaleUpdate
+}
+
+// checkVersionedUpdate returns a *StaleUpdateError if updating entity did not affect any rows.
+func checkVersionedUpdate(res sql.Result, entity Entity) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "can't get affected rows")
+	}
+
+	if affected == 0 {
+		return errors.WithStack(&StaleUpdateError{Table: TableName(entity), ID: entity.ID()})
+	}
+
+	return nil
+}
+
+// tableRegexp matches the (possibly quoted) table name following the keyword that introduces it.
+var tableRegexp = regexp.MustCompile(`(?is)\b(?:FROM|INTO|UPDATE)\s+["` + "`" + `]?([\w.]+)`)
+
+// parseQuery derives the operation kind and target table from the given query.
+func parseQuery(q string) (op, table string) {
+	keyword, _, _ := strings.Cut(strings.TrimSpace(q), " ")
+
+	switch strings.ToUpper(keyword) {
+	case "SELECT":
+		op = OpSelect
+	case "INSERT":
+		op = OpInsert
+	case "UPDATE":
+		op = OpUpdate
+	case "DELETE":
+		op = OpDelete
+	default:
+		return OpOther, ""
+	}
+
+	if m := tableRegexp.FindStringSubmatch(q); m != nil {
+		table = m[1]
+	}
+
+	return op, table
+}
+
+// Assert interface compliance.
+var (
+	_ error                   = (*QueryError)(nil)
+	_ error                   = (*StaleUpdateError)(nil)
+	_ zapcore.ObjectMarshaler = (*QueryError)(nil)
+)
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestCantPerformQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		query string
+		want  QueryError
+	}{
+		{
+			name:  "select",
+			err:   io.EOF,
+			query: `SELECT "id", "name" FROM "host" WHERE "environment_id" = :environment_id`,
+			want:  QueryError{Op: OpSelect, Table: "host", Retryable: true},
+		},
+		{
+			name:  "insert-mysql",
+			err:   &mysql.MySQLError{Number: 1062, SQLState: [5]byte{'2', '3', '0', '0', '0'}},
+			query: `INSERT INTO "host" ("id") VALUES (:id)`,
+			want:  QueryError{Op: OpInsert, Table: "host", Driver: MySQL, SQLState: "23000", Number: 1062, Retryable: true},
+		},
+		{
+			name:  "update-pgsql",
+			err:   &pq.Error{Code: "40P01"},
+			query: `UPDATE "service" SET "name" = :name WHERE id = :id`,
+			want:  QueryError{Op: OpUpdate, Table: "service", Driver: PostgreSQL, SQLState: "40P01", Retryable: true},
+		},
+		{
+			name:  "delete",
+			err:   errors.New("not retryable"),
+			query: `DELETE FROM "comment" WHERE id IN (?)`,
+			want:  QueryError{Op: OpDelete, Table: "comment"},
+		},
+		{
+			name:  "other",
+			err:   errors.New("not retryable"),
+			query: "SET SESSION wsrep_sync_wait=7",
+			want:  QueryError{Op: OpOther},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CantPerformQuery(tt.err, tt.query)
+
+			var qe *QueryError
+			require.ErrorAs(t, err, &qe)
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.query, qe.Query)
+			require.Equal(t, tt.want.Op, qe.Op)
+			require.Equal(t, tt.want.Table, qe.Table)
+			require.Equal(t, tt.want.Driver, qe.Driver)
+			require.Equal(t, tt.want.SQLState, qe.SQLState)
+			require.Equal(t, tt.want.Number, qe.Number)
+			require.Equal(t, tt.want.Retryable, qe.Retryable)
+			require.Equal(t, fmt.Sprintf("can't perform %q: %s", tt.query, tt.err), err.Error())
+		})
+	}
+}
+
+func TestCantPerformQuery_Category(t *testing.T) {
+	mysqlError := func(number uint16) error {
+		return &mysql.MySQLError{Number: number}
+	}
+
+	pqError := func(code pq.ErrorCode) error {
+		return &pq.Error{Code: code}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"mysql-duplicate", mysqlError(1062), ErrConstraintViolation},
+		{"mysql-foreign-key", mysqlError(1452), ErrConstraintViolation},
+		{"mysql-deadlock", mysqlError(1213), ErrDeadlock},
+		{"mysql-shutdown", mysqlError(1053), ErrConnectionLost},
+		{"mysql-gone-away", errors.Wrap(driver.ErrBadConn, "can't execute"), ErrConnectionLost},
+		{"mysql-invalid-conn", mysql.ErrInvalidConn, ErrConnectionLost},
+		{"mysql-syntax", mysqlError(1064), ErrSyntax},
+		{"mysql-too-long", mysqlError(1406), ErrDataTooLong},
+		{"mysql-other", mysqlError(1205), nil},
+		{"pgsql-unique", pqError("23505"), ErrConstraintViolation},
+		{"pgsql-not-null", pqError("23502"), ErrConstraintViolation},
+		{"pgsql-deadlock", pqError("40P01"), ErrDeadlock},
+		{"pgsql-connection", pqError("08006"), ErrConnectionLost},
+		{"pgsql-shutdown", pqError("57P01"), ErrConnectionLost},
+		{"pgsql-syntax", pqError("42601"), ErrSyntax},
+		{"pgsql-too-long", pqError("22001"), ErrDataTooLong},
+		{"pgsql-other", pqError("42P01"), nil},
+		{"bad-conn", errors.Wrap(driver.ErrBadConn, "wrapped"), ErrConnectionLost},
+		{
+			"dial",
+			errors.Wrap(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, "can't connect to database"),
+			ErrConnectionLost,
+		},
+		{"other", io.EOF, nil},
+	}
+
+	categories := []error{ErrConstraintViolation, ErrDeadlock, ErrConnectionLost, ErrSyntax, ErrDataTooLong}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CantPerformQuery(tt.err, `INSERT INTO "host" ("id") VALUES (:id)`)
+
+			var qe *QueryError
+			require.ErrorAs(t, err, &qe)
+			require.Equal(t, tt.want, qe.Category)
+
+			for _, category := range categories {
+				require.Equal(t, category == tt.want, errors.Is(err, category), "errors.Is(err, %v)", category)
+			}
+		})
+	}
+}
+
+func TestStaleUpdateError(t *testing.T) {
+	var err error = &StaleUpdateError{Table: "host", ID: testID("1")}
+	require.ErrorIs(t, errors.WithStack(err), ErrStaleUpdate)
+	require.Equal(t, `stale update of "host" with ID 1: row was modified concurrently or deleted`, err.Error())
+}
+
+type testID string
+
+func (id testID) String() string {
+	return string(id)
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"slices"
+	"sync"
+)
+
+var (
+	factories   = make(map[string]EntityFactoryFunc)
+	factoriesMu sync.RWMutex
+)
+
+// RegisterFactory registers factory for creating the entities of the given name, e.g. their table name or the
+// name of their Redis key, so that components can look up factories by name instead of maintaining their own maps:
+//
+//	func init() {
+//		database.RegisterFactory("host", v1.NewHost)
+//	}
+//
+// A factory registered for name before is replaced.
+func RegisterFactory[T Entity](name string, factory func() T) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	factories[name] = func() Entity {
+		return factory()
+	}
+}
+
+// LookupFactory returns the factory registered for name, if any.
+func LookupFactory(name string) (EntityFactoryFunc, bool) {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	factory, ok := factories[name]
+
+	return factory, ok
+}
+
+// RegisteredFactories returns the names of all registered factories in ascending order.
+func RegisteredFactories() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRegisterFactory(t *testing.T) {
+	t.Cleanup(func() {
+		factoriesMu.Lock()
+		defer factoriesMu.Unlock()
+
+		delete(factories, "test_entity")
+		delete(factories, "test_other")
+	})
+
+	_, ok := LookupFactory("test_entity")
+	require.False(t, ok)
+
+	RegisterFactory("test_entity", func() *testEntity { return &testEntity{Id: "first"} })
+	RegisterFactory("test_other", func() *testEntity { return &testEntity{} })
+	RegisterFactory("test_entity", func() *testEntity { return &testEntity{Id: "second"} })
+
+	factory, ok := LookupFactory("test_entity")
+	require.True(t, ok)
+	require.Equal(t, &testEntity{Id: "second"}, factory())
+
+	require.Subset(t, RegisteredFactories(), []string{"test_entity", "test_other"})
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"strconv"
+	"strings"
+)
+
+// FilterExisting splits the IDs from ids into those of rows that exist in the table of entityType and
+// those that are missing, e.g. so that an initial sync can decide whether to insert or update entities
+// instead of blindly upserting them. If entityType implements CompositeKeyer, the IDs must be CompositeKey values.
+//
+// The IDs are checked in batches of Options.MaxPlaceholdersPerStatement placeholders using queries in the form of
+// SELECT "id" FROM ... WHERE "id" IN (?). Unless set otherwise via WithQuerier, they are executed on the primary
+// instead of DB.Reader, as the result must not lag behind the writes it is used for.
+// The IDs of a batch are streamed in their original order once it has been checked,
+// so both returned channels have to be received from concurrently.
+func (db *DB) FilterExisting(
+	ctx context.Context, entityType Entity, ids <-chan any,
+) (existing, missing <-chan any, errs <-chan error) {
+	query, keyColumns := db.buildFilterExistingStmt(entityType)
+
+	count := db.Options.MaxPlaceholdersPerStatement
+	if keyColumns > 1 {
+		count = db.BatchSizeByPlaceholders(keyColumns)
+	}
+
+	existingCh := make(chan any)
+	missingCh := make(chan any)
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var counter com.Counter
+		defer db.Log(ctx, query, &counter).Stop()
+		defer close(existingCh)
+		defer close(missingCh)
+
+		for b := range com.Bulk(ctx, ids, count, com.NeverSplit[any]) {
+			found, err := db.selectExistingKeys(ctx, query, b)
+			if err != nil {
+				return err
+			}
+
+			for _, id := range b {
+				ch := missingCh
+				if _, ok := found[presenceKey(id)]; ok {
+					ch = existingCh
+				}
+
+				select {
+				case ch <- id:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			counter.Add(uint64(len(b)))
+		}
+
+		return ctx.Err()
+	})
+
+	return existingCh, missingCh, com.WaitAsync(g)
+}
+
+// buildFilterExistingStmt returns the SELECT statement for FilterExisting with the given struct
+// and the number of its key columns.
+func (db *DB) buildFilterExistingStmt(from interface{}) (string, int) {
+	keyColumns := []string{"id"}
+	if keyer, ok := from.(CompositeKeyer); ok {
+		keyColumns = keyer.KeyColumns()
+	}
+
+	columns := `"` + strings.Join(keyColumns, `", "`) + `"`
+	key := columns
+	if len(keyColumns) > 1 {
+		key = "(" + columns + ")"
+	}
+
+	return fmt.Sprintf(`SELECT %s FROM "%s" WHERE %s IN (?)`, columns, TableName(from), key), len(keyColumns)
+}
+
+// selectExistingKeys executes the query of FilterExisting with the given IDs
+// and returns the presenceKey of each row found.
+func (db *DB) selectExistingKeys(ctx context.Context, query string, ids []any) (map[string]struct{}, error) {
+	// The rows are queried on the primary, as deciding whether to insert or update rows based on
+	// a lagging replica would lead to duplicate key errors or missed updates.
+	q, custom := db.querier(ctx)
+	op, table := parseQuery(query)
+
+	var found map[string]struct{}
+	err := retry.WithBackoff(
+		ctx,
+		func(ctx context.Context) error {
+			found = make(map[string]struct{}, len(ids))
+
+			stmt, args, err := bindIn(query, ids)
+			if err != nil {
+				return retry.MarkPermanent(errors.Wrapf(err, "can't build placeholders for %q", query))
+			}
+
+			rows, err := q.QueryxContext(ctx, db.Rebind(stmt), args...)
+			if err != nil {
+				return cantPerformQueryWith(custom, err, query)
+			}
+			defer func() { _ = rows.Close() }()
+
+			for rows.Next() {
+				key, err := rows.SliceScan()
+				if err != nil {
+					return errors.Wrapf(err, "can't scan query result: %s", query)
+				}
+
+				found[presenceKey(key...)] = struct{}{}
+			}
+
+			if err := rows.Err(); err != nil {
+				return cantPerformQueryWith(custom, err, query)
+			}
+
+			return nil
+		},
+		retry.Retryable,
+		db.retryBackoff(ctx),
+		db.retrySettings(ctx, table, op),
+	)
+
+	return found, err
+}
+
+// presenceKey returns a comparable representation of the given key values, so that the IDs passed to FilterExisting
+// can be matched with the key values scanned from the database, which may be of different types,
+// e.g. []byte instead of string. A CompositeKey is expanded to its values.
+func presenceKey(values ...any) string {
+	if len(values) == 1 {
+		if key, ok := values[0].(CompositeKey); ok {
+			values = key
+		}
+	}
+
+	var b strings.Builder
+	for i, v := range values {
+		if valuer, ok := v.(driver.Valuer); ok {
+			if dv, err := valuer.Value(); err == nil {
+				v = dv
+			}
+		}
+
+		if bs, ok := v.([]byte); ok {
+			v = string(bs)
+		}
+
+		if i > 0 {
+			b.WriteString(", ")
+		}
+
+		b.WriteString(strconv.Quote(fmt.Sprint(v)))
+	}
+
+	return b.String()
+}
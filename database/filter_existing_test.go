@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/icinga/icinga-go-library/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDB_FilterExisting(t *testing.T) {
+	c := &existsTestConnector{existing: map[string]bool{"1": true, "3": true, "4": true}}
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(c), MySQL),
+		&Options{MaxPlaceholdersPerStatement: 2},
+		"test",
+		logging.NewLogger(zap.NewNop().Sugar(), time.Hour))
+	t.Cleanup(func() { _ = db.Close() })
+
+	// The replica lacks all rows, as if it lagged behind, and must not be queried.
+	rc := &existsTestConnector{existing: map[string]bool{}}
+	replica := newDb(sqlx.NewDb(sql.OpenDB(rc), MySQL), db.Options, "test", db.logger)
+	replica.health = &replicaHealth{}
+	db.replicas = []*DB{replica}
+	t.Cleanup(func() { _ = replica.Close() })
+
+	existing, missing, errs := db.FilterExisting(
+		context.Background(), &testEntity{}, utils.ChanFromSlice([]any{"1", "2", "3", "4", "5"}))
+
+	var existingIds, missingIds []any
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for id := range missing {
+			missingIds = append(missingIds, id)
+		}
+	}()
+
+	for id := range existing {
+		existingIds = append(existingIds, id)
+	}
+	wg.Wait()
+
+	require.NoError(t, <-errs)
+	require.Equal(t, []any{"1", "3", "4"}, existingIds)
+	require.Equal(t, []any{"2", "5"}, missingIds)
+	require.Equal(t, []string{
+		`SELECT "id" FROM "test_entity" WHERE "id" IN (?, ?)`,
+		`SELECT "id" FROM "test_entity" WHERE "id" IN (?, ?)`,
+		`SELECT "id" FROM "test_entity" WHERE "id" IN (?)`,
+	}, c.queries)
+	require.Empty(t, rc.queries, "replica must not be queried")
+}
+
+func TestDB_buildFilterExistingStmt(t *testing.T) {
+	db := newTestDb(t, MySQL)
+
+	t.Run("Simple", func(t *testing.T) {
+		stmt, keyColumns := db.buildFilterExistingStmt(testHost{})
+		require.Equal(t, `SELECT "id" FROM "test_host" WHERE "id" IN (?)`, stmt)
+		require.Equal(t, 1, keyColumns)
+	})
+
+	t.Run("Composite", func(t *testing.T) {
+		stmt, keyColumns := db.buildFilterExistingStmt(testCustomvarFlat{})
+		require.Equal(t, `SELECT "customvar_id", "flatname_checksum" FROM "test_customvar_flat"`+
+			` WHERE ("customvar_id", "flatname_checksum") IN (?)`, stmt)
+		require.Equal(t, 2, keyColumns)
+	})
+}
+
+func TestPresenceKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      any
+		scanned []any
+	}{
+		{"string", "host", []any{[]byte("host")}},
+		{"int", 42, []any{int64(42)}},
+		{"binary", types.Binary{0xde, 0xad}, []any{[]byte{0xde, 0xad}}},
+		{"composite", CompositeKey{"a", types.Binary{0xbe, 0xef}}, []any{[]byte("a"), []byte{0xbe, 0xef}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, presenceKey(tt.id), presenceKey(tt.scanned...))
+		})
+	}
+
+	require.NotEqual(t, presenceKey(CompositeKey{"a, b"}), presenceKey("a", "b"))
+}
+
+// existsTestConnector is a driver.Connector whose connections yield the query arguments that are existing.
+type existsTestConnector struct {
+	existing map[string]bool
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func (c *existsTestConnector) Connect(context.Context) (driver.Conn, error) {
+	return existsTestConn{c}, nil
+}
+
+func (c *existsTestConnector) Driver() driver.Driver {
+	return nil
+}
+
+type existsTestConn struct {
+	c *existsTestConnector
+}
+
+func (existsTestConn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (existsTestConn) Close() error {
+	return nil
+}
+
+func (existsTestConn) Begin() (driver.Tx, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c existsTestConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	c.c.queries = append(c.c.queries, query)
+
+	rows := &existsTestRows{}
+	for _, arg := range args {
+		if id := arg.Value.(string); c.c.existing[id] {
+			// Like MySQL's text protocol, return the ids as bytes.
+			rows.ids = append(rows.ids, []byte(id))
+		}
+	}
+
+	return rows, nil
+}
+
+type existsTestRows struct {
+	ids [][]byte
+}
+
+func (*existsTestRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (*existsTestRows) Close() error {
+	return nil
+}
+
+func (r *existsTestRows) Next(dest []driver.Value) error {
+	if len(r.ids) == 0 {
+		return io.EOF
+	}
+
+	dest[0] = r.ids[0]
+	r.ids = r.ids[1:]
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package database
+
+import (
+	"regexp"
+	"strings"
+)
+
+// hintableKeywordRegex matches the leading keyword of a statement after which MySQL expects optimizer hints.
+var hintableKeywordRegex = regexp.MustCompile(`(?i)^\s*(SELECT|INSERT|REPLACE|UPDATE|DELETE)\b`)
+
+// AddHints returns the query with the given optimizer hints, e.g. MAX_EXECUTION_TIME(1000) for MySQL,
+// rendered as a /*+ ... */ comment, so that, for example, heavy SELECTs can be bounded on the server side.
+//
+// For MySQL, the comment is inserted after the leading SELECT, INSERT, REPLACE, UPDATE or DELETE keyword,
+// where the server expects optimizer hints. The query is returned unchanged if it doesn't start with one of them.
+// For PostgreSQL, which itself ignores hints, the comment is prepended to the query,
+// where extensions such as pg_hint_plan expect it.
+//
+// Since the hints become part of the query, they must not contain colons if the query is used with
+// named parameters, as sqlx would take them for parameters. AddHints panics if a hint contains "*/".
+func (db *DB) AddHints(query string, hints ...string) string {
+	if len(hints) == 0 {
+		return query
+	}
+
+	for _, hint := range hints {
+		if strings.Contains(hint, "*/") {
+			panic("hint must not contain */: " + hint)
+		}
+	}
+
+	comment := "/*+ " + strings.Join(hints, " ") + " */"
+
+	if db.DriverName() == MySQL {
+		loc := hintableKeywordRegex.FindStringIndex(query)
+		if loc == nil {
+			return query
+		}
+
+		return query[:loc[1]] + " " + comment + query[loc[1]:]
+	}
+
+	return comment + " " + query
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// testHintedHost is a testHost whose SELECT queries carry hints.
+type testHintedHost struct {
+	testHost
+}
+
+func (testHintedHost) TableName() string {
+	return "test_host"
+}
+
+func (testHintedHost) QueryHints() []string {
+	return []string{"MAX_EXECUTION_TIME(1000)", "NO_INDEX_MERGE(test_host)"}
+}
+
+func TestDB_AddHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		hints    []string
+		expected testutils.PerDriver[string]
+	}{
+		{
+			name:  "no-hints",
+			query: `SELECT "id" FROM "host"`,
+			expected: testutils.PerDriver[string]{
+				MySQL:      `SELECT "id" FROM "host"`,
+				PostgreSQL: `SELECT "id" FROM "host"`,
+			},
+		},
+		{
+			name:  "select",
+			query: `SELECT "id" FROM "host"`,
+			hints: []string{"MAX_EXECUTION_TIME(1000)"},
+			expected: testutils.PerDriver[string]{
+				MySQL:      `SELECT /*+ MAX_EXECUTION_TIME(1000) */ "id" FROM "host"`,
+				PostgreSQL: `/*+ MAX_EXECUTION_TIME(1000) */ SELECT "id" FROM "host"`,
+			},
+		},
+		{
+			name:  "delete-multiple",
+			query: "\n  delete FROM \"host\"",
+			hints: []string{"BKA(host)", "NO_ICP(host)"},
+			expected: testutils.PerDriver[string]{
+				MySQL:      "\n  delete /*+ BKA(host) NO_ICP(host) */ FROM \"host\"",
+				PostgreSQL: "/*+ BKA(host) NO_ICP(host) */ \n  delete FROM \"host\"",
+			},
+		},
+		{
+			name:  "unsupported",
+			query: `WITH "h" AS (SELECT 1) SELECT * FROM "h"`,
+			hints: []string{"MAX_EXECUTION_TIME(1000)"},
+			expected: testutils.PerDriver[string]{
+				MySQL:      `WITH "h" AS (SELECT 1) SELECT * FROM "h"`,
+				PostgreSQL: `/*+ MAX_EXECUTION_TIME(1000) */ WITH "h" AS (SELECT 1) SELECT * FROM "h"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.RunPerDriver(t, tt.expected, func(t *testing.T, driver string, expected string) {
+				require.Equal(t, expected, newTestDb(t, driver).AddHints(tt.query, tt.hints...))
+			})
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		require.Panics(t, func() { newTestDb(t, MySQL).AddHints("SELECT 1", "*/ DROP TABLE host; /*") })
+	})
+}
+
+func TestDB_BuildSelectStmt_QueryHinter(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL:      `SELECT /*+ MAX_EXECUTION_TIME(1000) NO_INDEX_MERGE(test_host) */ "id" FROM "test_host"`,
+		PostgreSQL: `/*+ MAX_EXECUTION_TIME(1000) NO_INDEX_MERGE(test_host) */ SELECT "id" FROM "test_host"`,
+	}, func(t *testing.T, driver string) string {
+		return newTestDb(t, driver).BuildSelectStmt(testHintedHost{}, testHost{})
+	})
+}
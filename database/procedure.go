@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// CallProcedure calls the stored procedure name with the given IN arguments and,
+// if out is not nil, scans its OUT parameters into out.
+//
+// out must be a pointer to a struct whose columns, as determined by BuildColumns, name the OUT parameters
+// in the order in which they follow the IN parameters in the procedure's signature.
+// For MySQL, the OUT parameters are passed as session variables, which are selected afterwards on the same connection.
+// For PostgreSQL, the OUT parameters are passed as NULL and returned by CALL as a single row.
+func (db *DB) CallProcedure(ctx context.Context, name string, args []any, out any) error {
+	var columns []string
+	if out != nil {
+		columns = db.columnMap.Columns(out)
+	}
+
+	call, sel := db.buildCallStmt(name, len(args), columns)
+
+	// The session variables of MySQL require the statements to be executed on the same connection,
+	// which is the case for a Querier set via WithQuerier, i.e. a transaction.
+	var conn sqlx.ExecerContext
+	var queryRow func(ctx context.Context, query string, args ...any) *sqlx.Row
+
+	if q, ok := db.querier(ctx); ok {
+		conn, queryRow = q, q.QueryRowxContext
+	} else {
+		c, err := db.Connx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "can't get database connection")
+		}
+		defer func() { _ = c.Close() }()
+
+		conn, queryRow = c, c.QueryRowxContext
+	}
+
+	if out == nil {
+		if _, err := conn.ExecContext(ctx, call, args...); err != nil {
+			return CantPerformQuery(err, call)
+		}
+
+		return nil
+	}
+
+	switch db.DriverName() {
+	case MySQL:
+		if _, err := conn.ExecContext(ctx, call, args...); err != nil {
+			return CantPerformQuery(err, call)
+		}
+
+		if err := queryRow(ctx, sel).StructScan(out); err != nil {
+			return CantPerformQuery(err, sel)
+		}
+	default:
+		if err := queryRow(ctx, call, args...).StructScan(out); err != nil {
+			return CantPerformQuery(err, call)
+		}
+	}
+
+	return nil
+}
+
+// CallFunction calls the stored function name with the given arguments and scans the single resulting row into dest,
+// which can be a pointer to a scalar or to a struct in the same way as for sqlx.Get.
+// For PostgreSQL, the function is selected FROM, so that functions with OUT parameters or
+// composite results can be scanned into a struct.
+func (db *DB) CallFunction(ctx context.Context, name string, args []any, dest any) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)), ", ")
+
+	var query string
+	switch db.DriverName() {
+	case MySQL:
+		query = fmt.Sprintf(`SELECT %s(%s)`, quoteRoutineName(name), placeholders)
+	default:
+		query = fmt.Sprintf(`SELECT * FROM %s(%s)`, quoteRoutineName(name), placeholders)
+	}
+
+	query = db.Rebind(query)
+	q, _ := db.querier(ctx)
+	if err := sqlx.GetContext(ctx, q, dest, query, args...); err != nil {
+		return CantPerformQuery(err, query)
+	}
+
+	return nil
+}
+
+// buildCallStmt returns the CALL statement for the procedure name with nArgs IN placeholders and
+// the given OUT parameters, and for MySQL, the SELECT statement to retrieve the OUT parameters.
+func (db *DB) buildCallStmt(name string, nArgs int, outColumns []string) (call, sel string) {
+	params := make([]string, 0, nArgs+len(outColumns))
+	for i := 0; i < nArgs; i++ {
+		params = append(params, "?")
+	}
+
+	switch db.DriverName() {
+	case MySQL:
+		vars := make([]string, 0, len(outColumns))
+		for _, col := range outColumns {
+			params = append(params, "@out_"+col)
+			vars = append(vars, fmt.Sprintf(`@out_%[1]s AS "%[1]s"`, col))
+		}
+
+		if len(vars) > 0 {
+			sel = "SELECT " + strings.Join(vars, ", ")
+		}
+	default:
+		for range outColumns {
+			params = append(params, "NULL")
+		}
+	}
+
+	call = db.Rebind(fmt.Sprintf(`CALL %s(%s)`, quoteRoutineName(name), strings.Join(params, ", ")))
+
+	return call, sel
+}
+
+// quoteRoutineName quotes each part of the possibly schema-qualified routine name.
+func quoteRoutineName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + part + `"`
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDB_buildCallStmt(t *testing.T) {
+	type stmts struct {
+		call string
+		sel  string
+	}
+
+	tests := []struct {
+		name     string
+		routine  string
+		nArgs    int
+		out      []string
+		expected testutils.PerDriver[stmts]
+	}{
+		{
+			name:    "no-out",
+			routine: "cleanup",
+			nArgs:   2,
+			expected: testutils.PerDriver[stmts]{
+				MySQL:      {call: `CALL "cleanup"(?, ?)`},
+				PostgreSQL: {call: `CALL "cleanup"($1, $2)`},
+			},
+		},
+		{
+			name:    "out",
+			routine: "icinga.cleanup",
+			nArgs:   1,
+			out:     []string{"deleted", "took"},
+			expected: testutils.PerDriver[stmts]{
+				MySQL: {
+					call: `CALL "icinga"."cleanup"(?, @out_deleted, @out_took)`,
+					sel:  `SELECT @out_deleted AS "deleted", @out_took AS "took"`,
+				},
+				PostgreSQL: {call: `CALL "icinga"."cleanup"($1, NULL, NULL)`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.RunPerDriver(t, tt.expected, func(t *testing.T, driver string, expected stmts) {
+				call, sel := newTestDb(t, driver).buildCallStmt(tt.routine, tt.nArgs, tt.out)
+				require.Equal(t, expected.call, call)
+				require.Equal(t, expected.sel, sel)
+			})
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Querier executes queries. It is implemented by *sqlx.DB, *sqlx.Tx and thus by DB,
+// so that the helpers of DB can execute their queries in an existing transaction, see WithQuerier.
+type Querier interface {
+	sqlx.ExtContext
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+}
+
+// querierKey is the context key of WithQuerier.
+type querierKey struct{}
+
+// WithQuerier returns a copy of ctx which causes the helpers of DB, e.g. BulkExec, NamedBulkExec, YieldAll,
+// Count, CleanupOlderThan and those based on them, such as UpsertStreamed, to execute their queries using q instead
+// of the database, e.g. using the *sqlx.Tx passed to the function of ExecTx, so that they become part of that
+// transaction:
+//
+//	err := db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+//		return db.UpsertStreamed(database.WithQuerier(ctx, tx), entities)
+//	})
+//
+// The helpers that start transactions on their own, i.e. ExecTx and NamedBulkExecTx, execute their statements
+// in q instead if it is a *sqlx.Tx, which they then neither commit nor roll back. Otherwise, they start their
+// transactions using q, if it supports that like *sqlx.DB does, or on the database.
+//
+// As a transaction can only execute one statement at a time, the queries are then executed sequentially.
+// Failed queries are not retried either, as a failed statement may have aborted the transaction.
+// Also, the entities of YieldAll and YieldAllPaginated should be received completely
+// before executing other queries in the transaction, as not all drivers support interleaving them.
+func WithQuerier(ctx context.Context, q Querier) context.Context {
+	return context.WithValue(ctx, querierKey{}, q)
+}
+
+// querier returns the Querier set via WithQuerier, if any, or db with false otherwise.
+func (db *DB) querier(ctx context.Context) (Querier, bool) {
+	if q, ok := ctx.Value(querierKey{}).(Querier); ok {
+		return q, true
+	}
+
+	return db, false
+}
+
+// txBeginner is implemented by Queriers that can start transactions, e.g. *sqlx.DB.
+type txBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// beginTx returns the *sqlx.Tx set via WithQuerier, if any, along with false, as it must neither be committed
+// nor rolled back by the caller. Otherwise, it starts a new transaction using the Querier set via WithQuerier,
+// if it supports that, or on db and returns it along with true.
+func (db *DB) beginTx(ctx context.Context) (*sqlx.Tx, bool, error) {
+	var beginner txBeginner = db
+
+	if q, ok := db.querier(ctx); ok {
+		switch q := q.(type) {
+		case *sqlx.Tx:
+			return q, false, nil
+		case txBeginner:
+			beginner = q
+		}
+	}
+
+	tx, err := beginner.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "can't start transaction")
+	}
+
+	return tx, true, nil
+}
+
+// isTx returns whether q is a transaction.
+func isTx(q Querier) bool {
+	_, ok := q.(*sqlx.Tx)
+
+	return ok
+}
+
+// primaryKey is the context key which lets readQuerier return the primary database, see YieldFromPrimary.
+type primaryKey struct{}
+
+// readQuerier returns the Querier set via WithQuerier, if any, or DB.Reader otherwise,
+// unless the primary database has been requested via YieldFromPrimary.
+// The returned DB is the one whose checkReplica must be called with query errors.
+func (db *DB) readQuerier(ctx context.Context) (Querier, *DB) {
+	if q, ok := db.querier(ctx); ok {
+		return q, db
+	}
+
+	if primary, _ := ctx.Value(primaryKey{}).(bool); primary {
+		return db, db
+	}
+
+	reader := db.Reader()
+
+	return reader, reader
+}
+
+// cantPerformQueryWith wraps the error of the query like CantPerformQuery. If the query has been executed using
+// a Querier set via WithQuerier, the error is marked as permanent, so that the query is not retried.
+func cantPerformQueryWith(custom bool, err error, q string) error {
+	err = CantPerformQuery(err, q)
+	if custom {
+		err = retry.MarkPermanent(err)
+	}
+
+	return err
+}
+
+// Assert interface compliance.
+var (
+	_ Querier = (*DB)(nil)
+	_ Querier = (*sqlx.DB)(nil)
+	_ Querier = (*sqlx.Tx)(nil)
+)
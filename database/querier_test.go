@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/icinga/icinga-go-library/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+	"testing"
+	"time"
+)
+
+func TestWithQuerier(t *testing.T) {
+	// The DB has a single connection, which is held by the transaction,
+	// so BulkExec would block forever if it didn't use the transaction.
+	db, d := newStmtTestDb(t, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		return db.BulkExec(
+			WithQuerier(ctx, tx), `DELETE FROM "test" WHERE "id" IN (?)`, 2, semaphore.NewWeighted(8),
+			utils.ChanFromSlice([]any{1, 2, 3}),
+		)
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, d.executed)
+}
+
+func TestWithQuerier_Tx(t *testing.T) {
+	// As in TestWithQuerier, the helpers starting transactions on their own would block forever
+	// if they didn't use the transaction.
+	db, d := newStmtTestDb(t, 0)
+	db.Options.MaxConnectionsPerTable = 1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		ctx = WithQuerier(ctx, tx)
+
+		err := db.NamedBulkExecTx(
+			ctx, `INSERT INTO "test" ("id") VALUES (:id)`, 2, semaphore.NewWeighted(8),
+			utils.ChanFromSlice([]Entity{&testEntity{Id: "1"}, &testEntity{Id: "2"}, &testEntity{Id: "3"}}),
+		)
+		if err != nil {
+			return err
+		}
+
+		return db.ExecTx(ctx, func(ctx context.Context, nested *sqlx.Tx) error {
+			require.Same(t, tx, nested, "transaction set via WithQuerier must be used")
+
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, d.executed)
+	require.Equal(t, 1, d.committed, "only the outer transaction must be committed")
+}
+
+func TestCantPerformQueryWith(t *testing.T) {
+	err := errors.New("test")
+
+	require.False(t, errors.Is(cantPerformQueryWith(false, err, "q"), retry.ErrNotRetryable))
+	require.ErrorIs(t, cantPerformQueryWith(true, err, "q"), retry.ErrNotRetryable)
+	require.ErrorIs(t, cantPerformQueryWith(true, err, "q"), err)
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultQueryLogRedact is the deny-list used for query logging if Options.LogQueriesRedact is empty.
+var DefaultQueryLogRedact = []string{"password", "passwd", "secret", "token"}
+
+// redacted replaces the values of arguments bound to columns in the deny-list.
+const redacted = "<redacted>"
+
+// queryLogger logs executed statements with their arguments and execution duration at debug level.
+type queryLogger struct {
+	logger *logging.Logger
+	deny   []string
+}
+
+// newQueryLogger returns a new queryLogger that redacts the arguments bound to
+// columns whose name contains any of the words in deny, compared case-insensitively.
+func newQueryLogger(logger *logging.Logger, deny []string) *queryLogger {
+	if len(deny) == 0 {
+		deny = DefaultQueryLogRedact
+	}
+
+	lower := make([]string, 0, len(deny))
+	for _, d := range deny {
+		lower = append(lower, strings.ToLower(d))
+	}
+
+	return &queryLogger{logger: logger, deny: lower}
+}
+
+// log logs the statement executed with args, which took the specified duration, and its error, if any.
+func (l *queryLogger) log(query string, args []driver.NamedValue, took time.Duration, err error) {
+	fields := []any{
+		zap.String("query", query),
+		zap.Any("args", l.redact(query, args)),
+		zap.Duration("took", took),
+	}
+
+	if err != nil && !errors.Is(err, driver.ErrSkip) {
+		fields = append(fields, zap.Error(err))
+	}
+
+	l.logger.Debugw("Executed query", fields...)
+}
+
+// placeholderRegex matches the MySQL (?) and PostgreSQL ($1) placeholders.
+var placeholderRegex = regexp.MustCompile(`\?|\$\d+`)
+
+// comparedColumnRegex matches a column followed by a comparison or assignment operator at the end of a string,
+// i.e. before a placeholder.
+var comparedColumnRegex = regexp.MustCompile(`([\w."]+)\s*(?:=|<>|!=|<=|>=|<|>)\s*$`)
+
+// insertColumnsRegex matches an INSERT statement with a column list followed by VALUES.
+var insertColumnsRegex = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+\S+\s*\(([^)]*)\)\s*VALUES\b`)
+
+// redact returns the values of args, replacing those bound to columns in the deny-list.
+// The column of an argument is determined by its name, if any, or otherwise heuristically from the statement,
+// i.e. from the column list of an INSERT statement or from a comparison or assignment like "col = ?".
+// Arguments whose column cannot be determined are logged as is.
+func (l *queryLogger) redact(query string, args []driver.NamedValue) []any {
+	columns := l.columnsByOrdinal(query)
+	values := make([]any, 0, len(args))
+
+	for _, arg := range args {
+		column := arg.Name
+		if column == "" {
+			column = columns[arg.Ordinal]
+		}
+
+		if l.denied(column) {
+			values = append(values, redacted)
+		} else {
+			values = append(values, arg.Value)
+		}
+	}
+
+	return values
+}
+
+// columnsByOrdinal maps the ordinal positions of the placeholders in query to their column, if it can be determined.
+func (l *queryLogger) columnsByOrdinal(query string) map[int]string {
+	columns := make(map[int]string)
+
+	var insertColumns []string
+	valuesStart := -1
+	if match := insertColumnsRegex.FindStringSubmatchIndex(query); match != nil {
+		for _, c := range strings.Split(query[match[2]:match[3]], ",") {
+			insertColumns = append(insertColumns, strings.TrimSpace(c))
+		}
+
+		valuesStart = match[1]
+	}
+
+	for i, loc := range placeholderRegex.FindAllStringIndex(query, -1) {
+		ordinal := i + 1
+		if p := query[loc[0]:loc[1]]; p != "?" {
+			ordinal, _ = strconv.Atoi(p[1:])
+		}
+
+		if valuesStart >= 0 && loc[0] >= valuesStart && len(insertColumns) > 0 {
+			// Placeholders of multiple VALUES tuples repeat the column list.
+			columns[ordinal] = insertColumns[i%len(insertColumns)]
+		} else if match := comparedColumnRegex.FindStringSubmatch(query[:loc[0]]); match != nil {
+			columns[ordinal] = match[1]
+		}
+	}
+
+	return columns
+}
+
+// denied returns whether the unqualified and unquoted column contains any word of the deny-list.
+func (l *queryLogger) denied(column string) bool {
+	if column == "" {
+		return false
+	}
+
+	column = strings.ToLower(strings.Trim(column[strings.LastIndex(column, ".")+1:], `"`+"`"))
+	for _, d := range l.deny {
+		if strings.Contains(column, d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withQueryLogging wraps connector so that all executed statements are logged if enabled in the options.
+func withQueryLogging(connector driver.Connector, logger *logging.Logger, o Options) driver.Connector {
+	if !o.LogQueries {
+		return connector
+	}
+
+	return queryLoggingConnector{Connector: connector, logger: newQueryLogger(logger, o.LogQueriesRedact)}
+}
+
+// queryLoggingConnector wraps a driver.Connector so that all statements executed on its connections are logged.
+type queryLoggingConnector struct {
+	driver.Connector
+	logger *queryLogger
+}
+
+// Connect implements part of the driver.Connector interface.
+func (c queryLoggingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queryLoggingConn{wrappedConn: wrappedConn{conn}, logger: c.logger}, nil
+}
+
+// queryLoggingConn logs the statements executed directly on it or via its prepared statements.
+type queryLoggingConn struct {
+	wrappedConn
+	logger *queryLogger
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface.
+func (c *queryLoggingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.wrappedConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &queryLoggingStmt{
+		wrappedStmt: wrappedStmt{Stmt: stmt, conn: c.wrappedConn}, logger: c.logger, query: query,
+	}, nil
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c *queryLoggingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	// If the wrapped connection doesn't support it, database/sql falls back to preparing the statement,
+	// which is then logged.
+	start := time.Now()
+	res, err := c.wrappedConn.ExecContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.logger.log(query, args, time.Since(start), err)
+	}
+
+	return res, err
+}
+
+// QueryContext implements the driver.QueryerContext interface.
+func (c *queryLoggingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	// If the wrapped connection doesn't support it, database/sql falls back to preparing the statement,
+	// which is then logged.
+	start := time.Now()
+	rows, err := c.wrappedConn.QueryContext(ctx, query, args)
+	if !errors.Is(err, driver.ErrSkip) {
+		c.logger.log(query, args, time.Since(start), err)
+	}
+
+	return rows, err
+}
+
+// queryLoggingStmt logs the executions of a prepared statement.
+type queryLoggingStmt struct {
+	wrappedStmt
+	logger *queryLogger
+	query  string
+}
+
+// ExecContext implements the driver.StmtExecContext interface.
+func (s *queryLoggingStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := s.wrappedStmt.ExecContext(ctx, args)
+	s.logger.log(s.query, args, time.Since(start), err)
+
+	return res, err
+}
+
+// QueryContext implements the driver.StmtQueryContext interface.
+func (s *queryLoggingStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.wrappedStmt.QueryContext(ctx, args)
+	s.logger.log(s.query, args, time.Since(start), err)
+
+	return rows, err
+}
+
+// Assert interface compliance.
+var (
+	_ driver.Connector          = queryLoggingConnector{}
+	_ driver.ConnPrepareContext = (*queryLoggingConn)(nil)
+	_ driver.ExecerContext      = (*queryLoggingConn)(nil)
+	_ driver.QueryerContext     = (*queryLoggingConn)(nil)
+	_ driver.StmtExecContext    = (*queryLoggingStmt)(nil)
+	_ driver.StmtQueryContext   = (*queryLoggingStmt)(nil)
+)
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"database/sql/driver"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestQueryLogger_Redact(t *testing.T) {
+	args := func(values ...any) []driver.NamedValue {
+		nvs := make([]driver.NamedValue, 0, len(values))
+		for i, v := range values {
+			nvs = append(nvs, driver.NamedValue{Ordinal: i + 1, Value: v})
+		}
+
+		return nvs
+	}
+
+	subtests := []struct {
+		name   string
+		query  string
+		args   []driver.NamedValue
+		output []any
+	}{
+		{
+			name:   "insert",
+			query:  `INSERT INTO "user" ("name", "password_hash") VALUES (?, ?)`,
+			args:   args("jdoe", "hash"),
+			output: []any{"jdoe", redacted},
+		},
+		{
+			name:   "insert-multiple-rows",
+			query:  `INSERT INTO "user" ("password", "name") VALUES ($1, $2), ($3, $4)`,
+			args:   args("s1", "a", "s2", "b"),
+			output: []any{redacted, "a", redacted, "b"},
+		},
+		{
+			name:   "update",
+			query:  `UPDATE "user" SET "name" = ?, "api_token"=? WHERE "id" = ?`,
+			args:   args("jdoe", "t", 1),
+			output: []any{"jdoe", redacted, 1},
+		},
+		{
+			name:   "qualified",
+			query:  `SELECT * FROM "user" u WHERE u.Secret = $1 AND u.id > $2`,
+			args:   args("s", 1),
+			output: []any{redacted, 1},
+		},
+		{
+			name:   "unknown-column",
+			query:  `SELECT * FROM "user" WHERE "id" IN (?, ?)`,
+			args:   args(1, 2),
+			output: []any{1, 2},
+		},
+		{
+			name:   "named",
+			query:  `CALL set_credentials(?)`,
+			args:   []driver.NamedValue{{Name: "password", Ordinal: 1, Value: "s"}},
+			output: []any{redacted},
+		},
+	}
+
+	l := newQueryLogger(nil, nil)
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			require.Equal(t, st.output, l.redact(st.query, st.args))
+		})
+	}
+
+	t.Run("custom-deny-list", func(t *testing.T) {
+		l := newQueryLogger(nil, []string{"PIN"})
+		require.Equal(t, []any{redacted, "hash"}, l.redact(`UPDATE "card" SET "pin" = ?, "password" = ?`, args("1234", "hash")))
+	})
+}
@@ -0,0 +1,26 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/com"
+)
+
+// rateLimiterKey is the context key of WithRateLimiter.
+type rateLimiterKey struct{}
+
+// WithRateLimiter returns a copy of ctx which causes BulkExec, NamedBulkExec and NamedBulkExecTx and
+// the helpers based on them, such as UpsertStreamed and DeleteStreamed, to wait for l before each attempt
+// to execute a query, e.g. to cap the load on a database shared with other applications:
+//
+//	limiter := com.NewRateLimiter(50, 10, com.RateLimiterLogger(logger))
+//	err := db.UpsertStreamed(database.WithRateLimiter(ctx, limiter), entities)
+func WithRateLimiter(ctx context.Context, l *com.RateLimiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, l)
+}
+
+// waitRateLimiter waits for the RateLimiter set via WithRateLimiter, if any.
+func waitRateLimiter(ctx context.Context) error {
+	l, _ := ctx.Value(rateLimiterKey{}).(*com.RateLimiter)
+
+	return l.Wait(ctx)
+}
@@ -0,0 +1,20 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWaitRateLimiter(t *testing.T) {
+	require.NoError(t, waitRateLimiter(context.Background()))
+
+	ctx, cancel := context.WithCancel(WithRateLimiter(context.Background(), com.NewRateLimiter(0.001, 1)))
+	defer cancel()
+
+	require.NoError(t, waitRateLimiter(ctx))
+
+	cancel()
+	require.ErrorIs(t, waitRateLimiter(ctx), context.Canceled)
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	stderrors "errors"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// replicaHealth tracks whether a replica can be used for reads.
+type replicaHealth struct {
+	mu       sync.Mutex
+	failedAt time.Time
+}
+
+// healthy returns whether the replica hasn't failed within the given failback interval.
+func (h *replicaHealth) healthy(failback time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.failedAt.IsZero() || time.Since(h.failedAt) >= failback
+}
+
+// fail marks the replica as failed and returns whether it was considered healthy before.
+func (h *replicaHealth) fail(failback time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	wasHealthy := h.failedAt.IsZero() || time.Since(h.failedAt) >= failback
+	h.failedAt = time.Now()
+
+	return wasHealthy
+}
+
+// Reader returns the DB to perform reads on. If read replicas are configured, see Config.Replicas,
+// it returns one of the healthy replicas in a round-robin fashion, otherwise the primary DB itself.
+// A replica is considered unhealthy for Options.ReplicaFailbackInterval after a read on it failed
+// because of a lost connection or because connecting to it failed within Options.ReplicaConnectTimeout.
+// If no replica is healthy, reads fall back to the primary.
+// Writes must always be performed on the primary DB.
+func (db *DB) Reader() *DB {
+	if len(db.replicas) == 0 {
+		return db
+	}
+
+	start := db.replicasNext.Add(1)
+	for i := range uint64(len(db.replicas)) {
+		r := db.replicas[(start+i)%uint64(len(db.replicas))]
+		if r.health.healthy(db.Options.ReplicaFailbackInterval) {
+			return r
+		}
+	}
+
+	return db
+}
+
+// Close closes the database and all of its read replicas.
+func (db *DB) Close() error {
+	var errs []error
+	for _, r := range db.replicas {
+		if err := r.DB.Close(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "can't close database replica %s", r.GetAddr()))
+		}
+	}
+
+	if err := db.DB.Close(); err != nil {
+		errs = append(errs, errors.Wrap(err, "can't close database"))
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// checkReplica marks db as unhealthy if it is a replica and err indicates a lost connection
+// or a failure to connect, so that Reader doesn't return it until the failback interval has elapsed.
+func (db *DB) checkReplica(err error) {
+	if db.health == nil || !(errors.Is(err, ErrConnectionLost) || isConnectError(err)) {
+		return
+	}
+
+	if db.health.fail(db.Options.ReplicaFailbackInterval) {
+		db.logger.Warnw("Lost connection to database replica, reading from others until failback",
+			zap.String("replica", db.GetAddr()),
+			zap.Duration("failback_interval", db.Options.ReplicaFailbackInterval),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDB_Reader(t *testing.T) {
+	t.Run("no-replicas", func(t *testing.T) {
+		db := newTestDb(t, MySQL)
+		require.Same(t, db, db.Reader())
+	})
+
+	db, err := NewDbFromConfig(
+		&Config{
+			Type: "pgsql", Host: "primary", Database: "db", User: "user",
+			Options:  Options{MaxConnections: 1, MaxReplicaConnections: 1, ReplicaFailbackInterval: time.Hour},
+			Replicas: []string{"replica1", "replica2:5433"},
+		},
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0),
+		RetryConnectorCallbacks{})
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	require.Len(t, db.replicas, 2)
+	replica1, replica2 := db.replicas[0], db.replicas[1]
+	require.Equal(t, "pgsql://user@primary:5432/db", db.GetAddr())
+	require.Equal(t, "pgsql://user@replica1:5432/db", replica1.GetAddr())
+	require.Equal(t, "pgsql://user@replica2:5433/db", replica2.GetAddr())
+
+	readers := func() map[*DB]int {
+		seen := map[*DB]int{}
+		for range 4 {
+			seen[db.Reader()]++
+		}
+
+		return seen
+	}
+
+	require.Equal(t, map[*DB]int{replica1: 2, replica2: 2}, readers(), "reads must be balanced across replicas")
+	require.Same(t, replica1, replica1.Reader(), "replicas must read from themselves")
+
+	replica1.checkReplica(CantPerformQuery(errors.New("syntax error"), "SELECT"))
+	require.Equal(t, map[*DB]int{replica1: 2, replica2: 2}, readers(), "only lost connections must fail replicas")
+
+	replica1.checkReplica(CantPerformQuery(driver.ErrBadConn, "SELECT"))
+	require.Equal(t, map[*DB]int{replica2: 4}, readers(), "failed replicas must not be read from")
+
+	replica2.checkReplica(CantPerformQuery(driver.ErrBadConn, "SELECT"))
+	require.Equal(t, map[*DB]int{db: 4}, readers(), "reads must fall back to the primary")
+
+	db.checkReplica(CantPerformQuery(driver.ErrBadConn, "SELECT"))
+	require.Nil(t, db.health, "the primary must not track health")
+
+	replica1.health.failedAt = time.Now().Add(-time.Hour)
+	require.Equal(t, map[*DB]int{replica1: 4}, readers(), "replicas must be failed back after the interval")
+}
+
+func TestDB_Reader_DialFailure(t *testing.T) {
+	// Reserve a port and close it again, so that connecting to it is refused.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	db, err := NewDbFromConfig(
+		&Config{
+			Type: "pgsql", Host: "primary", Database: "db", User: "user",
+			Options: Options{
+				MaxConnections: 1, MaxReplicaConnections: 1,
+				ReplicaFailbackInterval: time.Hour, ReplicaConnectTimeout: 100 * time.Millisecond,
+			},
+			Replicas: []string{addr},
+		},
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0),
+		RetryConnectorCallbacks{})
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	replica := db.replicas[0]
+	require.Same(t, replica, db.Reader())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = db.Count(ctx, testHost{})
+	}()
+
+	// The primary is not reachable either, so Count only returns once canceled.
+	require.Eventually(t, func() bool {
+		return !replica.health.healthy(time.Hour)
+	}, 2*time.Second, 10*time.Millisecond, "replicas that can't be connected to must be failed quickly")
+	require.Same(t, db, db.Reader(), "reads must fall back to the primary")
+
+	cancel()
+	<-done
+}
+
+func TestDB_YieldAll_Replica(t *testing.T) {
+	pc := &existsTestConnector{}
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(pc), MySQL), &Options{}, "test", logging.NewLogger(zap.NewNop().Sugar(), time.Hour))
+	t.Cleanup(func() { _ = db.Close() })
+
+	rc := &existsTestConnector{}
+	replica := newDb(sqlx.NewDb(sql.OpenDB(rc), MySQL), db.Options, "test", db.logger)
+	replica.health = &replicaHealth{}
+	db.replicas = []*DB{replica}
+	t.Cleanup(func() { _ = replica.Close() })
+
+	yield := func(options ...YieldAllOption) {
+		entities, errs := db.YieldAll(
+			context.Background(), func() Entity { return &testEntity{} }, "SELECT id FROM test_entity", struct{}{},
+			options...)
+
+		for range entities {
+		}
+		require.NoError(t, <-errs)
+	}
+
+	yield()
+	require.Len(t, rc.queries, 1, "query must be executed on the replica")
+	require.Empty(t, pc.queries)
+
+	yield(YieldFromPrimary())
+	require.Len(t, pc.queries, 1, "query must be executed on the primary")
+	require.Len(t, rc.queries, 1)
+}
+
+func TestDB_Close(t *testing.T) {
+	db, err := NewDbFromConfig(
+		&Config{
+			Type: "pgsql", Host: "primary", Database: "db", User: "user",
+			Options:  Options{MaxConnections: 1, MaxReplicaConnections: 1},
+			Replicas: []string{"replica1", "replica2"},
+		},
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0),
+		RetryConnectorCallbacks{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+// RetryPolicy overrides how failed queries are retried, see WithRetryPolicy.
+type RetryPolicy struct {
+	// Timeout, if greater than 0, overrides the time after which retrying stops,
+	// which is retry.DefaultTimeout unless configured otherwise via Options.RetryTimeouts.
+	Timeout time.Duration
+
+	// Backoff, if not nil, overrides the backoff between the attempts.
+	Backoff backoff.Backoff
+}
+
+// retryPolicyKey is the context key of WithRetryPolicy.
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a copy of ctx which causes BulkExec, NamedBulkExec, NamedBulkExecTx, YieldAll,
+// CleanupOlderThan and the helpers based on them, such as UpsertStreamed and DeleteStreamed,
+// to retry failed queries according to p instead of the defaults and Options.RetryTimeouts,
+// e.g. so that upserts of a heartbeat fail fast:
+//
+//	err := db.UpsertStreamed(database.WithRetryPolicy(ctx, database.RetryPolicy{Timeout: 5 * time.Second}), entities)
+func WithRetryPolicy(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+// retryBackoff returns the backoff between the attempts of failed queries,
+// which can be overridden via WithRetryPolicy.
+func (db *DB) retryBackoff(ctx context.Context) backoff.Backoff {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok && p.Backoff != nil {
+		return p.Backoff
+	}
+
+	return backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second)
+}
+
+// retrySettings returns GetDefaultRetrySettings for queries of the given operation on table
+// with the timeout overridden via WithRetryPolicy or Options.RetryTimeouts, in that order.
+func (db *DB) retrySettings(ctx context.Context, table, op string) retry.Settings {
+	settings := db.GetDefaultRetrySettings()
+
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok && p.Timeout > 0 {
+		settings.Timeout = p.Timeout
+	} else if timeout, ok := db.Options.RetryTimeouts[table+"/"+op]; ok {
+		settings.Timeout = timeout
+	} else if timeout, ok := db.Options.RetryTimeouts[table]; ok {
+		settings.Timeout = timeout
+	}
+
+	return settings
+}
+
+// validateRetryTimeouts checks the keys and values of Options.RetryTimeouts.
+func validateRetryTimeouts(timeouts map[string]time.Duration) error {
+	for key, timeout := range timeouts {
+		table, op, ok := strings.Cut(key, "/")
+		if table == "" {
+			return errors.Errorf("retry_timeouts: table missing in %q", key)
+		}
+
+		if ok {
+			switch op {
+			case OpSelect, OpInsert, OpUpdate, OpDelete:
+			default:
+				return errors.Errorf("retry_timeouts: unknown operation %q in %q", op, key)
+			}
+		}
+
+		if timeout <= 0 {
+			return errors.Errorf("retry_timeouts: timeout of %q must be positive", key)
+		}
+	}
+
+	return nil
+}
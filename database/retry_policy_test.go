@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDB_retrySettings(t *testing.T) {
+	db := newTestDb(t, MySQL)
+	db.Options.RetryTimeouts = map[string]time.Duration{
+		"history":        time.Hour,
+		"history/delete": 2 * time.Hour,
+		"heartbeat":      time.Second,
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		table   string
+		op      string
+		timeout time.Duration
+	}{
+		{"default", context.Background(), "host", OpInsert, retry.DefaultTimeout},
+		{"table", context.Background(), "history", OpInsert, time.Hour},
+		{"table and op", context.Background(), "history", OpDelete, 2 * time.Hour},
+		{"policy", WithRetryPolicy(context.Background(), RetryPolicy{Timeout: time.Minute}), "history", OpDelete, time.Minute},
+		{"policy without timeout", WithRetryPolicy(context.Background(), RetryPolicy{}), "heartbeat", OpInsert, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.timeout, db.retrySettings(tt.ctx, tt.table, tt.op).Timeout)
+		})
+	}
+}
+
+func TestDB_retryBackoff(t *testing.T) {
+	db := newTestDb(t, MySQL)
+	ctx := WithRetryPolicy(context.Background(), RetryPolicy{Backoff: func(uint64) time.Duration { return time.Minute }})
+
+	require.Equal(t, time.Minute, db.retryBackoff(ctx)(1))
+	require.LessOrEqual(t, db.retryBackoff(context.Background())(1), time.Second)
+}
+
+func TestValidateRetryTimeouts(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeouts map[string]time.Duration
+		error    string
+	}{
+		{"valid", map[string]time.Duration{"history": time.Hour, "history/delete": time.Hour}, ""},
+		{"table missing", map[string]time.Duration{"/delete": time.Hour}, `table missing in "/delete"`},
+		{"unknown op", map[string]time.Duration{"history/truncate": time.Hour}, `unknown operation "truncate"`},
+		{"not positive", map[string]time.Duration{"history": 0}, `timeout of "history" must be positive`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRetryTimeouts(tt.timeouts)
+			if tt.error == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tt.error)
+			}
+		})
+	}
+}
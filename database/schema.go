@@ -0,0 +1,143 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// schemaQuery identifies a query of the schema introspection API.
+type schemaQuery int
+
+const (
+	schemaListTables schemaQuery = iota
+	schemaHasTable
+	schemaColumnType
+	schemaHasIndex
+)
+
+// HasTable reports whether the table exists in the current database or, for PostgreSQL, the current schema.
+func (db *DB) HasTable(ctx context.Context, table string) (bool, error) {
+	var n int
+	if err := db.querySchema(ctx, sqlx.GetContext, &n, schemaHasTable, table); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// HasColumn reports whether the table has the column.
+func (db *DB) HasColumn(ctx context.Context, table, column string) (bool, error) {
+	typ, err := db.ColumnType(ctx, table, column)
+
+	return typ != "", err
+}
+
+// ColumnType returns the data type of the column as reported by information_schema.columns, e.g. "bigint" or
+// "character varying", or an empty string if the table or the column does not exist.
+func (db *DB) ColumnType(ctx context.Context, table, column string) (string, error) {
+	var typ string
+	err := db.querySchema(ctx, sqlx.GetContext, &typ, schemaColumnType, table, column)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return typ, err
+}
+
+// HasIndex reports whether the table has an index with the given name.
+// For MySQL, the primary key is the index named "PRIMARY".
+func (db *DB) HasIndex(ctx context.Context, table, index string) (bool, error) {
+	var n int
+	if err := db.querySchema(ctx, sqlx.GetContext, &n, schemaHasIndex, table, index); err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// ListTables returns the names of all tables, excluding views, in the current database or,
+// for PostgreSQL, the current schema in alphabetical order.
+func (db *DB) ListTables(ctx context.Context) ([]string, error) {
+	var tables []string
+	if err := db.querySchema(ctx, sqlx.SelectContext, &tables, schemaListTables); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// querySchema performs the schema query q with args using get, i.e. sqlx.GetContext or sqlx.SelectContext,
+// retrying on retryable errors, unless executed using a Querier set via WithQuerier.
+// sql.ErrNoRows is returned as is.
+func (db *DB) querySchema(
+	ctx context.Context, get func(context.Context, sqlx.QueryerContext, any, string, ...any) error, dest any,
+	q schemaQuery, args ...any,
+) error {
+	query := db.buildSchemaQuery(q)
+	querier, custom := db.querier(ctx)
+
+	var noRows bool
+	err := retry.WithBackoff(
+		ctx,
+		func(ctx context.Context) error {
+			if err := get(ctx, querier, dest, query, args...); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					noRows = true
+
+					return nil
+				}
+
+				return cantPerformQueryWith(custom, err, query)
+			}
+
+			return nil
+		},
+		retry.Retryable,
+		backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
+		db.GetDefaultRetrySettings(),
+	)
+	if err == nil && noRows {
+		return sql.ErrNoRows
+	}
+
+	return err
+}
+
+// buildSchemaQuery returns the driver-specific query for q.
+func (db *DB) buildSchemaQuery(q schemaQuery) string {
+	schema := "current_schema()"
+	if db.DriverName() == MySQL {
+		schema = "DATABASE()"
+	}
+
+	var query string
+	switch q {
+	case schemaListTables:
+		query = fmt.Sprintf(`SELECT table_name FROM information_schema.tables`+
+			` WHERE table_schema = %s AND table_type = 'BASE TABLE' ORDER BY table_name`, schema)
+	case schemaHasTable:
+		query = fmt.Sprintf(`SELECT COUNT(*) FROM information_schema.tables`+
+			` WHERE table_schema = %s AND table_name = ?`, schema)
+	case schemaColumnType:
+		query = fmt.Sprintf(`SELECT data_type FROM information_schema.columns`+
+			` WHERE table_schema = %s AND table_name = ? AND column_name = ?`, schema)
+	case schemaHasIndex:
+		if db.DriverName() == MySQL {
+			query = `SELECT COUNT(*) FROM information_schema.statistics` +
+				` WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`
+		} else {
+			query = `SELECT COUNT(*) FROM pg_indexes` +
+				` WHERE schemaname = current_schema() AND tablename = ? AND indexname = ?`
+		}
+	default:
+		panic(fmt.Sprintf("unknown schema query %d", q))
+	}
+
+	return db.Rebind(query)
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDB_buildSchemaQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    schemaQuery
+		expected testutils.PerDriver[string]
+	}{
+		{
+			name:  "ListTables",
+			query: schemaListTables,
+			expected: testutils.PerDriver[string]{
+				MySQL: `SELECT table_name FROM information_schema.tables` +
+					` WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name`,
+				PostgreSQL: `SELECT table_name FROM information_schema.tables` +
+					` WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name`,
+			},
+		},
+		{
+			name:  "HasTable",
+			query: schemaHasTable,
+			expected: testutils.PerDriver[string]{
+				MySQL:      `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`,
+				PostgreSQL: `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`,
+			},
+		},
+		{
+			name:  "ColumnType",
+			query: schemaColumnType,
+			expected: testutils.PerDriver[string]{
+				MySQL: `SELECT data_type FROM information_schema.columns` +
+					` WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`,
+				PostgreSQL: `SELECT data_type FROM information_schema.columns` +
+					` WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2`,
+			},
+		},
+		{
+			name:  "HasIndex",
+			query: schemaHasIndex,
+			expected: testutils.PerDriver[string]{
+				MySQL: `SELECT COUNT(*) FROM information_schema.statistics` +
+					` WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`,
+				PostgreSQL: `SELECT COUNT(*) FROM pg_indexes` +
+					` WHERE schemaname = current_schema() AND tablename = $1 AND indexname = $2`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testutils.RunPerDriver(t, tt.expected, func(t *testing.T, driver string, expected string) {
+				require.Equal(t, expected, newTestDb(t, driver).buildSchemaQuery(tt.query))
+			})
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+	"sync/atomic"
+	"time"
+)
+
+// SemaphoreWaitStats provides statistics of waiting for the semaphores limiting the connections per table
+// in BulkExec, NamedBulkExec and NamedBulkExecTx, see Options.MaxConnectionsPerTable.
+type SemaphoreWaitStats struct {
+	// Acquisitions is the number of times a semaphore has been acquired.
+	Acquisitions uint64
+
+	// Slow is the number of acquisitions that took longer than Options.SemaphoreWaitWarning.
+	Slow uint64
+
+	// Wait is the total time spent waiting for semaphores.
+	Wait time.Duration
+}
+
+// SemaphoreWaitStats returns statistics of waiting for the semaphores limiting the connections per table.
+func (db *DB) SemaphoreWaitStats() SemaphoreWaitStats {
+	return SemaphoreWaitStats{
+		Acquisitions: db.semaphoreWaits.acquisitions.Load(),
+		Slow:         db.semaphoreWaits.slow.Load(),
+		Wait:         time.Duration(db.semaphoreWaits.wait.Load()),
+	}
+}
+
+// semaphoreWaits collects the SemaphoreWaitStats.
+type semaphoreWaits struct {
+	acquisitions atomic.Uint64
+	slow         atomic.Uint64
+	wait         atomic.Int64
+
+	// unlogged is the number of slow acquisitions since the last warning.
+	unlogged atomic.Uint64
+
+	// lastWarning is the time of the last warning in Unix nanoseconds.
+	lastWarning atomic.Int64
+}
+
+// acquireSemaphore acquires sem with a weight of 1 for executing query.
+// If waiting takes longer than Options.SemaphoreWaitWarning, a warning is logged,
+// at most once per logging interval to avoid flooding the log if the semaphore is permanently exhausted.
+func (db *DB) acquireSemaphore(ctx context.Context, sem *semaphore.Weighted, query string) error {
+	start := time.Now()
+	if err := sem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "can't acquire semaphore")
+	}
+
+	wait := time.Since(start)
+	db.semaphoreWaits.acquisitions.Add(1)
+	db.semaphoreWaits.wait.Add(int64(wait))
+
+	if threshold := db.Options.SemaphoreWaitWarning; threshold > 0 && wait > threshold {
+		db.semaphoreWaits.slow.Add(1)
+		db.semaphoreWaits.unlogged.Add(1)
+
+		now := time.Now().UnixNano()
+		last := db.semaphoreWaits.lastWarning.Load()
+		if now-last >= int64(db.logger.Interval()) && db.semaphoreWaits.lastWarning.CompareAndSwap(last, now) {
+			db.logger.Warnw("Waited long for a free database connection, consider increasing max_connections_per_table",
+				zap.String("query", query),
+				zap.Duration("wait", wait),
+				zap.Uint64("slow_acquisitions", db.semaphoreWaits.unlogged.Swap(0)))
+		}
+	}
+
+	return nil
+}
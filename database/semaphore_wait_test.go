@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/sync/semaphore"
+	"testing"
+	"time"
+)
+
+func TestDB_acquireSemaphore(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	db := newTestDb(t, MySQL)
+	db.logger = logging.NewLogger(zap.New(core).Sugar(), time.Hour)
+	db.Options.SemaphoreWaitWarning = time.Millisecond
+
+	sem := semaphore.NewWeighted(1)
+	ctx := context.Background()
+
+	// Not slow.
+	require.NoError(t, db.acquireSemaphore(ctx, sem, "q"))
+
+	for range 2 {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			sem.Release(1)
+		}()
+
+		// Slow, as it's released only after 10ms.
+		require.NoError(t, db.acquireSemaphore(ctx, sem, "q"))
+	}
+
+	stats := db.SemaphoreWaitStats()
+	require.Equal(t, uint64(3), stats.Acquisitions)
+	require.Equal(t, uint64(2), stats.Slow)
+	require.GreaterOrEqual(t, stats.Wait, 20*time.Millisecond)
+
+	// The second slow acquisition is not logged within the logging interval.
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	require.Equal(t, "q", entries[0].ContextMap()["query"])
+	require.Equal(t, uint64(1), entries[0].ContextMap()["slow_acquisitions"])
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, db.acquireSemaphore(canceled, sem, "q"), context.Canceled)
+}
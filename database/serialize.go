@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"slices"
+)
+
+// heldWriteLocksKey is the context key of the tables whose writeLocks are held by ExecTx, see ExecTxTables.
+type heldWriteLocksKey struct{}
+
+// writeLocks serializes the UPDATE and DELETE statements on the tables listed in Options.SerializeWrites.
+// Each table has its own lock, which is held for the execution of a single statement or transaction,
+// so that it is never held together with another one and thus can't cause deadlocks itself.
+type writeLocks struct {
+	// locks maps table names to a channel with a buffer of one, which is full while the lock is held.
+	locks map[string]chan struct{}
+}
+
+// newWriteLocks returns writeLocks for the given tables or nil if there are none.
+func newWriteLocks(tables []string) *writeLocks {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	l := &writeLocks{locks: make(map[string]chan struct{}, len(tables))}
+	for _, table := range tables {
+		l.locks[table] = make(chan struct{}, 1)
+	}
+
+	return l
+}
+
+// lock blocks until the lock of the table of query is acquired, if the query is an UPDATE or DELETE statement
+// on a table to be serialized, or until ctx is canceled. The returned function releases the lock.
+func (l *writeLocks) lock(ctx context.Context, query string) (func(), error) {
+	op, table := parseQuery(query)
+	if op != OpUpdate && op != OpDelete {
+		return func() {}, nil
+	}
+
+	return l.lockTable(ctx, table)
+}
+
+// lockTable blocks until the lock of table is acquired, if it is to be serialized, or until ctx is canceled.
+// The returned function releases the lock. If the lock is already held by the transaction of ExecTx
+// that ctx has been derived from, it is not acquired again.
+func (l *writeLocks) lockTable(ctx context.Context, table string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	lock, ok := l.locks[table]
+	if !ok {
+		return func() {}, nil
+	}
+
+	if held, _ := ctx.Value(heldWriteLocksKey{}).([]string); slices.Contains(held, table) {
+		return func() {}, nil
+	}
+
+	select {
+	case lock <- struct{}{}:
+		return func() { <-lock }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
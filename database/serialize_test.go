@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/semaphore"
+	"testing"
+	"time"
+)
+
+func TestWriteLocks_Lock(t *testing.T) {
+	l := newWriteLocks([]string{"host_state"})
+
+	unlock, err := l.lock(context.Background(), `UPDATE "host_state" SET "state" = :state WHERE "id" = :id`)
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		`SELECT "id" FROM "host_state"`,
+		`INSERT INTO "host_state" ("id") VALUES (:id)`,
+		`UPDATE "host" SET "name" = :name WHERE "id" = :id`,
+	} {
+		u, err := l.lock(context.Background(), query)
+		require.NoError(t, err, "%q must not be serialized", query)
+		u()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = l.lock(ctx, `DELETE FROM "host_state" WHERE "id" IN (?)`)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "lock must be held until released")
+
+	unlock()
+
+	unlock, err = l.lock(context.Background(), `DELETE FROM "host_state" WHERE "id" IN (?)`)
+	require.NoError(t, err)
+	unlock()
+
+	var none *writeLocks
+	unlock, err = none.lock(context.Background(), `DELETE FROM "host_state" WHERE "id" IN (?)`)
+	require.NoError(t, err)
+	unlock()
+}
+
+func TestDB_ExecTx_ExecTxTables(t *testing.T) {
+	db, d := newStmtTestDb(t, 0)
+	db.Options.MaxConnectionsPerTable = 1
+	db.Options.MaxUpdatesPerTable = 1
+	db.Options.MaxDeletesPerTable = 1
+	db.writeLocks = newWriteLocks([]string{"host_state"})
+
+	// The semaphores used by the bulk helpers, i.e. the one of the table for inserts and those of updates and deletes.
+	semaphores := func(table string) []*semaphore.Weighted {
+		return []*semaphore.Weighted{
+			db.GetSemaphoreForTableAndOp(table, OpInsert),
+			db.GetSemaphoreForTableAndOp(table, OpUpdate),
+			db.GetSemaphoreForTableAndOp(table, OpDelete),
+		}
+	}
+
+	const update = `UPDATE "host_state" SET "state" = :state WHERE "id" = :id`
+
+	err := db.ExecTx(context.Background(), func(ctx context.Context, tx *sqlx.Tx) error {
+		for _, table := range []string{"host_state", "host"} {
+			for _, sem := range semaphores(table) {
+				require.False(t, sem.TryAcquire(1), "semaphore of %s must be held", table)
+			}
+		}
+
+		timeout, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := db.writeLocks.lock(timeout, update)
+		require.ErrorIs(t, err, context.DeadlineExceeded, "write lock must be held")
+
+		unlock, err := db.writeLocks.lock(ctx, update)
+		require.NoError(t, err, "write lock held by the transaction must not be acquired again")
+		unlock()
+
+		return nil
+	}, ExecTxTables("host_state", "host"), ExecTxTables("host_state"))
+	require.NoError(t, err)
+	require.Equal(t, 1, d.committed)
+
+	for _, table := range []string{"host_state", "host"} {
+		for _, sem := range semaphores(table) {
+			require.True(t, sem.TryAcquire(1), "semaphore of %s must be released", table)
+			sem.Release(1)
+		}
+	}
+
+	unlock, err := db.writeLocks.lock(context.Background(), update)
+	require.NoError(t, err, "write lock must be released")
+	unlock()
+}
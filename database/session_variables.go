@@ -0,0 +1,57 @@
+package database
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// sessionVariableNameRegex matches the valid names of Options.SessionVariables,
+// including the dotted names of custom PostgreSQL variables.
+var sessionVariableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// sessionVariableNumberRegex matches the values of Options.SessionVariables which are set as numbers.
+var sessionVariableNumberRegex = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// sessionVariable is a session variable with its value formatted as SQL literal.
+type sessionVariable struct {
+	name  string
+	value string
+}
+
+// sessionVariables returns the variables to set for each new connection of the given driver,
+// i.e. its defaults overridden by Options.SessionVariables, ordered by name.
+// The options must have been validated, as the values are not escaped.
+func (o *Options) sessionVariables(driverName string) []sessionVariable {
+	variables := make(map[string]string)
+
+	if driverName == MySQL {
+		// Set the "wsrep_sync_wait" variable for each session and ensures that causality checks are performed
+		// before execution and that each statement is executed on a fully synchronized node. Doing so prevents
+		// foreign key violation when inserting into dependent tables on different MariaDB/MySQL nodes. When using
+		// MySQL single nodes, the "SET SESSION" command will fail with "Unknown system variable (1193)" and will
+		// therefore be silently dropped.
+		// https://mariadb.com/kb/en/galera-cluster-system-variables/#wsrep_sync_wait
+		variables["wsrep_sync_wait"] = fmt.Sprint(o.WsrepSyncWait)
+	}
+
+	for name, value := range o.SessionVariables {
+		variables[name] = value
+	}
+
+	result := make([]sessionVariable, 0, len(variables))
+	for name, value := range variables {
+		if !sessionVariableNumberRegex.MatchString(value) {
+			value = "'" + value + "'"
+		}
+
+		result = append(result, sessionVariable{name: name, value: value})
+	}
+
+	slices.SortFunc(result, func(a, b sessionVariable) int {
+		return cmp.Compare(a.name, b.name)
+	})
+
+	return result
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestOptions_sessionVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		driver    string
+		variables map[string]string
+		expected  []sessionVariable
+	}{
+		{
+			name:     "mysql-defaults",
+			driver:   MySQL,
+			expected: []sessionVariable{{"wsrep_sync_wait", "7"}},
+		},
+		{
+			name:     "pgsql-defaults",
+			driver:   PostgreSQL,
+			expected: []sessionVariable{},
+		},
+		{
+			name:   "mysql",
+			driver: MySQL,
+			variables: map[string]string{
+				"wsrep_sync_wait":          "1",
+				"innodb_lock_wait_timeout": "10",
+				"time_zone":                "+00:00",
+			},
+			expected: []sessionVariable{
+				{"innodb_lock_wait_timeout", "10"},
+				{"time_zone", "'+00:00'"},
+				{"wsrep_sync_wait", "1"},
+			},
+		},
+		{
+			name:   "pgsql",
+			driver: PostgreSQL,
+			variables: map[string]string{
+				"statement_timeout": "30s",
+				"app.ratio":         "-0.5",
+			},
+			expected: []sessionVariable{
+				{"app.ratio", "-0.5"},
+				{"statement_timeout", "'30s'"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Options{WsrepSyncWait: 7, SessionVariables: tt.variables}
+			require.Equal(t, tt.expected, o.sessionVariables(tt.driver))
+		})
+	}
+}
+
+func TestOptions_Validate_SessionVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables map[string]string
+		error     string
+	}{
+		{"valid", map[string]string{"statement_timeout": "30s", "app.user": "icinga"}, ""},
+		{"empty-name", map[string]string{"": "1"}, `invalid variable name ""`},
+		{"injection-name", map[string]string{"a=1; DROP TABLE host; SET b": "1"}, "invalid variable name"},
+		{"trailing-dot", map[string]string{"app.": "1"}, "invalid variable name"},
+		{"quote", map[string]string{"time_zone": "'; DROP TABLE host; --"}, "must not contain quotes or backslashes"},
+		{"backslash", map[string]string{"time_zone": `\`}, "must not contain quotes or backslashes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Options{
+				MaxConnections:              1,
+				MaxConnectionsPerTable:      1,
+				MaxPlaceholdersPerStatement: 1,
+				MaxRowsPerTransaction:       1,
+				MinBatchSize:                1,
+				SessionVariables:            tt.variables,
+			}
+
+			if tt.error == "" {
+				require.NoError(t, o.Validate())
+			} else {
+				require.ErrorContains(t, o.Validate(), tt.error)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"database/sql/driver"
+	"sync/atomic"
+)
+
+// StatementCacheStats provides statistics of the prepared statement cache, see Options.StatementCacheSize.
+type StatementCacheStats struct {
+	// Hits is the number of times a cached statement has been reused.
+	Hits uint64
+
+	// Misses is the number of times a statement had to be prepared because it was not cached.
+	Misses uint64
+
+	// Evictions is the number of statements that have been evicted from the cache because it was full.
+	Evictions uint64
+
+	// Size is the number of statements currently cached across all connections.
+	Size int
+}
+
+// stmtCacheKey is the context key of WithStatementCache.
+type stmtCacheKey struct{}
+
+// WithStatementCache returns a copy of ctx which causes statements prepared with it, and statements executed with it
+// with arguments, to be served from the prepared statement cache of the connection, if enabled via
+// Options.StatementCacheSize. NamedBulkExec, NamedBulkExecTx and ExecTx already use such a context, so that,
+// for example, statements prepared with the context passed to the function of ExecTx are reused across transactions.
+func WithStatementCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stmtCacheKey{}, true)
+}
+
+// usesStatementCache returns whether ctx has been created by WithStatementCache.
+func usesStatementCache(ctx context.Context) bool {
+	use, _ := ctx.Value(stmtCacheKey{}).(bool)
+
+	return use
+}
+
+// StatementCacheStats returns statistics of the prepared statement cache.
+// All values are 0 if the cache is disabled, see Options.StatementCacheSize.
+func (db *DB) StatementCacheStats() StatementCacheStats {
+	if db.stmtCache == nil {
+		return StatementCacheStats{}
+	}
+
+	return db.stmtCache.stats()
+}
+
+// stmtCache defines the size of the prepared statement caches of connections and collects their statistics.
+type stmtCache struct {
+	size int
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+	cached    atomic.Int64
+}
+
+// newStmtCache returns a new stmtCache for caching up to size statements per connection.
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size}
+}
+
+// stats returns the current StatementCacheStats.
+func (c *stmtCache) stats() StatementCacheStats {
+	return StatementCacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      int(c.cached.Load()),
+	}
+}
+
+// withStatementCache wraps connector so that its connections cache prepared statements if cache is not nil.
+func withStatementCache(connector driver.Connector, cache *stmtCache) driver.Connector {
+	if cache == nil {
+		return connector
+	}
+
+	return stmtCacheConnector{Connector: connector, cache: cache}
+}
+
+// stmtCacheConnector wraps a driver.Connector so that its connections cache prepared statements.
+type stmtCacheConnector struct {
+	driver.Connector
+	cache *stmtCache
+}
+
+// Connect implements part of the driver.Connector interface.
+func (c stmtCacheConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stmtCacheConn{
+		wrappedConn: wrappedConn{conn}, cache: c.cache, entries: make(map[string]*list.Element), lru: list.New(),
+	}, nil
+}
+
+// stmtCacheConn is a connection with a least recently used cache of prepared statements keyed by query,
+// which is used for contexts created by WithStatementCache.
+// No locking is required, as database/sql never uses a connection and its statements concurrently.
+type stmtCacheConn struct {
+	wrappedConn
+	cache   *stmtCache
+	entries map[string]*list.Element
+	lru     *list.List // Of *stmtCacheEntry, most recently used first.
+}
+
+// stmtCacheEntry is a cached statement which is only closed once it has been evicted and is no longer in use.
+type stmtCacheEntry struct {
+	query   string
+	stmt    driver.Stmt
+	refs    int
+	evicted bool
+}
+
+// PrepareContext implements the driver.ConnPrepareContext interface.
+func (c *stmtCacheConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if usesStatementCache(ctx) {
+		return c.cached(ctx, query)
+	}
+
+	return c.wrappedConn.PrepareContext(ctx, query)
+}
+
+// ExecContext implements the driver.ExecerContext interface.
+func (c *stmtCacheConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if usesStatementCache(ctx) && len(args) > 0 {
+		stmt, err := c.cached(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = stmt.Close() }()
+
+		return stmt.ExecContext(ctx, args)
+	}
+
+	return c.wrappedConn.ExecContext(ctx, query, args)
+}
+
+// Close closes all cached statements and the connection.
+func (c *stmtCacheConn) Close() error {
+	for e := c.lru.Front(); e != nil; e = e.Next() {
+		_ = e.Value.(*stmtCacheEntry).stmt.Close()
+	}
+
+	c.cache.cached.Add(-int64(c.lru.Len()))
+	c.entries = nil
+	c.lru.Init()
+
+	return c.Conn.Close()
+}
+
+// cached returns the cached statement for the given query, preparing it if necessary.
+// The returned statement must be closed once no longer used.
+func (c *stmtCacheConn) cached(ctx context.Context, query string) (*cachedStmt, error) {
+	if e, ok := c.entries[query]; ok {
+		c.cache.hits.Add(1)
+		c.lru.MoveToFront(e)
+
+		entry := e.Value.(*stmtCacheEntry)
+		entry.refs++
+
+		return &cachedStmt{wrappedStmt: wrappedStmt{Stmt: entry.stmt, conn: c.wrappedConn}, entry: entry}, nil
+	}
+
+	c.cache.misses.Add(1)
+
+	stmt, err := c.wrappedConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &stmtCacheEntry{query: query, stmt: stmt, refs: 1}
+	c.entries[query] = c.lru.PushFront(entry)
+	c.cache.cached.Add(1)
+
+	for c.lru.Len() > c.cache.size {
+		evicted := c.lru.Remove(c.lru.Back()).(*stmtCacheEntry)
+		delete(c.entries, evicted.query)
+		evicted.evicted = true
+
+		c.cache.evictions.Add(1)
+		c.cache.cached.Add(-1)
+
+		if evicted.refs == 0 {
+			_ = evicted.stmt.Close()
+		}
+	}
+
+	return &cachedStmt{wrappedStmt: wrappedStmt{Stmt: stmt, conn: c.wrappedConn}, entry: entry}, nil
+}
+
+// cachedStmt is a handle to a cached statement, which is only closed once it has been evicted and
+// all of its handles have been closed.
+type cachedStmt struct {
+	wrappedStmt
+	entry *stmtCacheEntry
+}
+
+// Close implements part of the driver.Stmt interface.
+func (s *cachedStmt) Close() error {
+	s.entry.refs--
+	if s.entry.evicted && s.entry.refs == 0 {
+		return s.Stmt.Close()
+	}
+
+	return nil
+}
+
+// Assert interface compliance.
+var (
+	_ driver.Connector          = stmtCacheConnector{}
+	_ driver.ConnPrepareContext = (*stmtCacheConn)(nil)
+	_ driver.ExecerContext      = (*stmtCacheConn)(nil)
+	_ driver.Stmt               = (*cachedStmt)(nil)
+)
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stmtTestDriver is a driver.Connector whose connections count the statements prepared, executed and closed on them
+// and the transactions committed on them.
+type stmtTestDriver struct {
+	mu        sync.Mutex
+	prepared  map[string]int
+	executed  int
+	closed    int
+	committed int
+}
+
+func (d *stmtTestDriver) Connect(context.Context) (driver.Conn, error) {
+	return stmtTestConn{d}, nil
+}
+
+func (d *stmtTestDriver) Driver() driver.Driver {
+	return nil
+}
+
+type stmtTestConn struct {
+	d *stmtTestDriver
+}
+
+func (c stmtTestConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+
+	c.d.prepared[query]++
+
+	return stmtTestStmt(c), nil
+}
+
+func (stmtTestConn) Close() error {
+	return nil
+}
+
+func (c stmtTestConn) Begin() (driver.Tx, error) {
+	return stmtTestTx(c), nil
+}
+
+type stmtTestStmt struct {
+	d *stmtTestDriver
+}
+
+func (s stmtTestStmt) Close() error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.closed++
+
+	return nil
+}
+
+func (stmtTestStmt) NumInput() int {
+	return -1
+}
+
+func (s stmtTestStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	s.d.executed++
+
+	return driver.RowsAffected(1), nil
+}
+
+func (stmtTestStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+type stmtTestTx struct {
+	d *stmtTestDriver
+}
+
+func (tx stmtTestTx) Commit() error {
+	tx.d.mu.Lock()
+	defer tx.d.mu.Unlock()
+
+	tx.d.committed++
+
+	return nil
+}
+
+func (stmtTestTx) Rollback() error {
+	return nil
+}
+
+// newStmtTestDb returns a DB with a single connection to a stmtTestDriver,
+// caching cacheSize statements if greater than 0.
+func newStmtTestDb(t *testing.T, cacheSize int) (*DB, *stmtTestDriver) {
+	d := &stmtTestDriver{prepared: map[string]int{}}
+
+	var cache *stmtCache
+	if cacheSize > 0 {
+		cache = newStmtCache(cacheSize)
+	}
+
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(withStatementCache(d, cache)), MySQL),
+		&Options{StatementCacheSize: cacheSize},
+		"test",
+		// DB.Log may still log asynchronously after the bulk helpers have returned,
+		// which zaptest doesn't allow once the test has completed.
+		logging.NewLogger(zap.NewNop().Sugar(), time.Hour))
+	db.stmtCache = cache
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db, d
+}
+
+func TestStmtCacheConn(t *testing.T) {
+	d := &stmtTestDriver{prepared: map[string]int{}}
+	cache := newStmtCache(2)
+	ctx := WithStatementCache(context.Background())
+
+	conn, err := withStatementCache(d, cache).Connect(ctx)
+	require.NoError(t, err)
+
+	prepare := func(ctx context.Context, query string) driver.Stmt {
+		stmt, err := conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+		require.NoError(t, err)
+
+		return stmt
+	}
+
+	require.NoError(t, prepare(ctx, "a").Close())
+	a := prepare(ctx, "a")
+	require.Equal(t, map[string]int{"a": 1}, d.prepared)
+	require.Equal(t, StatementCacheStats{Hits: 1, Misses: 1, Size: 1}, cache.stats())
+
+	require.NoError(t, prepare(ctx, "b").Close())
+	require.NoError(t, prepare(ctx, "c").Close())
+	require.Equal(t, StatementCacheStats{Hits: 1, Misses: 3, Evictions: 1, Size: 2}, cache.stats())
+	require.Equal(t, 0, d.closed, "statements in use must not be closed")
+
+	require.NoError(t, a.Close())
+	require.Equal(t, 1, d.closed, "evicted statements must be closed once no longer in use")
+
+	require.NoError(t, prepare(context.Background(), "c").Close())
+	require.Equal(t, map[string]int{"a": 1, "b": 1, "c": 2}, d.prepared, "statements must only be cached if requested")
+	require.Equal(t, 2, d.closed)
+
+	_, err = conn.(driver.ExecerContext).ExecContext(ctx, "c", []driver.NamedValue{{Ordinal: 1, Value: int64(1)}})
+	require.NoError(t, err)
+	require.Equal(t, StatementCacheStats{Hits: 2, Misses: 3, Evictions: 1, Size: 2}, cache.stats())
+
+	require.NoError(t, conn.Close())
+	require.Equal(t, 4, d.closed, "cached statements must be closed with the connection")
+	require.Equal(t, 0, cache.stats().Size)
+}
+
+func TestDB_ExecTx_StatementCache(t *testing.T) {
+	exec := func(t *testing.T, db *DB) {
+		for range 3 {
+			require.NoError(t, db.ExecTx(context.Background(), func(ctx context.Context, tx *sqlx.Tx) error {
+				_, err := tx.ExecContext(ctx, "a", 1)
+
+				return err
+			}))
+		}
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 1)
+		exec(t, db)
+		require.Equal(t, map[string]int{"a": 1}, d.prepared)
+		require.Equal(t, StatementCacheStats{Hits: 2, Misses: 1, Size: 1}, db.StatementCacheStats())
+	})
+
+	t.Run("uncached", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 0)
+		exec(t, db)
+		require.Equal(t, map[string]int{"a": 3}, d.prepared)
+		require.Equal(t, StatementCacheStats{}, db.StatementCacheStats())
+	})
+}
+
+func TestDB_NamedBulkExec_StatementCache(t *testing.T) {
+	entities := func() <-chan Entity {
+		ch := make(chan Entity, 5)
+		for _, id := range []testID{"1", "2", "3", "4", "5"} {
+			ch <- &testEntity{Id: id}
+		}
+		close(ch)
+
+		return ch
+	}
+
+	t.Run("NamedBulkExec", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 2)
+
+		require.NoError(t, db.NamedBulkExec(
+			context.Background(), `INSERT INTO "test" ("id") VALUES (:id)`, 2, semaphore.NewWeighted(1), entities(),
+			com.NeverSplit[Entity]))
+		require.Equal(t, map[string]int{
+			`INSERT INTO "test" ("id") VALUES (?),(?)`: 1,
+			`INSERT INTO "test" ("id") VALUES (?)`:     1,
+		}, d.prepared)
+		require.Equal(t, StatementCacheStats{Hits: 1, Misses: 2, Size: 2}, db.StatementCacheStats())
+	})
+
+	t.Run("NamedBulkExecTx", func(t *testing.T) {
+		db, d := newStmtTestDb(t, 1)
+
+		require.NoError(t, db.NamedBulkExecTx(
+			context.Background(), `UPDATE "test" SET "id" = :id WHERE "id" = :id`, 2, semaphore.NewWeighted(1), entities()))
+		require.Equal(t, map[string]int{`UPDATE "test" SET "id" = ? WHERE "id" = ?`: 1}, d.prepared)
+		require.Equal(t, StatementCacheStats{Hits: 2, Misses: 1, Size: 1}, db.StatementCacheStats())
+	})
+}
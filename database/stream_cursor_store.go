@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// StreamCursorStore stores the last acknowledged IDs of Redis streams in a database table and
+// implements the CursorStore interface of the redis package, so that it can be used for its StreamCursor.
+// The table must consist of the columns name, stream and last_id with the primary key (name, stream),
+// which is expected to be named pk_<table> for PostgreSQL, e.g.:
+//
+//	CREATE TABLE stream_cursor (
+//		name varchar(255) NOT NULL,
+//		stream varchar(255) NOT NULL,
+//		last_id varchar(255) NOT NULL,
+//		CONSTRAINT pk_stream_cursor PRIMARY KEY (name, stream)
+//	);
+//
+// Use DB.NewStreamCursorStore to create a StreamCursorStore.
+type StreamCursorStore struct {
+	db    *DB
+	table string
+	name  string
+}
+
+// NewStreamCursorStore returns a new StreamCursorStore that stores the IDs in the rows of table with the given name,
+// so that a single table can hold the IDs of multiple cursors.
+func (db *DB) NewStreamCursorStore(table, name string) *StreamCursorStore {
+	return &StreamCursorStore{db: db, table: table, name: name}
+}
+
+// Load returns the IDs of all streams stored for the cursor.
+func (s *StreamCursorStore) Load(ctx context.Context) (map[string]string, error) {
+	var rows []struct {
+		Stream string `db:"stream"`
+		LastId string `db:"last_id"`
+	}
+
+	query := s.db.Rebind(fmt.Sprintf(`SELECT "stream", "last_id" FROM "%s" WHERE "name" = ?`, s.table))
+	q, _ := s.db.querier(ctx)
+	if err := sqlx.SelectContext(ctx, q, &rows, query, s.name); err != nil {
+		return nil, CantPerformQuery(err, query)
+	}
+
+	ids := make(map[string]string, len(rows))
+	for _, row := range rows {
+		ids[row.Stream] = row.LastId
+	}
+
+	return ids, nil
+}
+
+// Save upserts the given IDs of the cursor in a single transaction.
+func (s *StreamCursorStore) Save(ctx context.Context, ids map[string]string) error {
+	stmt := s.db.Rebind(s.buildUpsertStmt())
+
+	err := s.db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		for stream, id := range ids {
+			if _, err := tx.ExecContext(ctx, stmt, s.name, stream, id); err != nil {
+				return CantPerformQuery(err, stmt)
+			}
+		}
+
+		return nil
+	})
+
+	return errors.Wrap(err, "can't save stream cursor")
+}
+
+// buildUpsertStmt returns the statement that upserts a single row of the cursor.
+func (s *StreamCursorStore) buildUpsertStmt() string {
+	insert := fmt.Sprintf(`INSERT INTO "%s" ("name", "stream", "last_id") VALUES (?, ?, ?)`, s.table)
+
+	switch s.db.DriverName() {
+	case MySQL:
+		return insert + ` ON DUPLICATE KEY UPDATE "last_id" = VALUES("last_id")`
+	default:
+		return insert + fmt.Sprintf(` ON CONFLICT ON CONSTRAINT pk_%s DO UPDATE SET "last_id" = EXCLUDED."last_id"`, s.table)
+	}
+}
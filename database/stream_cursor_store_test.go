@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/testutils"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamCursorStore(t *testing.T) {
+	c := &cursorTestConnector{rows: map[[2]string]string{{"other", "icinga:state"}: "9-0"}}
+	db := newDb(
+		sqlx.NewDb(sql.OpenDB(c), MySQL),
+		&Options{MaxConnectionsPerTable: 1},
+		"test",
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour))
+	t.Cleanup(func() { _ = db.Close() })
+
+	ctx := context.Background()
+	store := db.NewStreamCursorStore("stream_cursor", "runtime")
+
+	ids, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Empty(t, ids, "IDs of other cursors must not be loaded")
+
+	require.NoError(t, store.Save(ctx, map[string]string{"icinga:state": "5-0", "icinga:history": "7-0"}))
+	require.NoError(t, store.Save(ctx, map[string]string{"icinga:state": "6-0"}))
+	require.Equal(t, 2, c.committed, "each save must be committed")
+
+	ids, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"icinga:state": "6-0", "icinga:history": "7-0"}, ids)
+
+	other, err := db.NewStreamCursorStore("stream_cursor", "other").Load(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"icinga:state": "9-0"}, other)
+
+	c.err = errors.New("test")
+	require.ErrorIs(t, store.Save(ctx, map[string]string{"icinga:state": "8-0"}), c.err)
+	require.Equal(t, 2, c.committed, "failed saves must not be committed")
+}
+
+func TestStreamCursorStore_buildUpsertStmt(t *testing.T) {
+	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+		MySQL: `INSERT INTO "stream_cursor" ("name", "stream", "last_id") VALUES (?, ?, ?)` +
+			` ON DUPLICATE KEY UPDATE "last_id" = VALUES("last_id")`,
+		PostgreSQL: `INSERT INTO "stream_cursor" ("name", "stream", "last_id") VALUES (?, ?, ?)` +
+			` ON CONFLICT ON CONSTRAINT pk_stream_cursor DO UPDATE SET "last_id" = EXCLUDED."last_id"`,
+	}, func(t *testing.T, driver string) string {
+		return newTestDb(t, driver).NewStreamCursorStore("stream_cursor", "runtime").buildUpsertStmt()
+	})
+}
+
+// cursorTestConnector is a driver.Connector whose connections emulate a stream cursor table
+// for the statements of StreamCursorStore. Rows are keyed by name and stream.
+// Upserts fail with err, if set, and are only applied once their transaction has been committed.
+type cursorTestConnector struct {
+	mu        sync.Mutex
+	rows      map[[2]string]string
+	pending   map[[2]string]string
+	committed int
+	err       error
+}
+
+func (c *cursorTestConnector) Connect(context.Context) (driver.Conn, error) {
+	return cursorTestConn{c}, nil
+}
+
+func (c *cursorTestConnector) Driver() driver.Driver {
+	return nil
+}
+
+type cursorTestConn struct {
+	c *cursorTestConnector
+}
+
+func (cursorTestConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (cursorTestConn) Close() error {
+	return nil
+}
+
+func (c cursorTestConn) Begin() (driver.Tx, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	c.c.pending = map[[2]string]string{}
+
+	return c, nil
+}
+
+func (c cursorTestConn) Commit() error {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	for k, v := range c.c.pending {
+		c.c.rows[k] = v
+	}
+
+	c.c.pending = nil
+	c.c.committed++
+
+	return nil
+}
+
+func (c cursorTestConn) Rollback() error {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	c.c.pending = nil
+
+	return nil
+}
+
+func (c cursorTestConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	if !strings.HasPrefix(query, `INSERT INTO "stream_cursor" ("name", "stream", "last_id") VALUES (?, ?, ?)`) {
+		return nil, errors.Errorf("unexpected statement %q", query)
+	}
+
+	if c.c.err != nil {
+		return nil, c.c.err
+	}
+
+	if c.c.pending == nil {
+		return nil, errors.New("upsert outside of transaction")
+	}
+
+	c.c.pending[[2]string{args[0].Value.(string), args[1].Value.(string)}] = args[2].Value.(string)
+
+	return driver.RowsAffected(1), nil
+}
+
+func (c cursorTestConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.c.mu.Lock()
+	defer c.c.mu.Unlock()
+
+	if query != `SELECT "stream", "last_id" FROM "stream_cursor" WHERE "name" = ?` {
+		return nil, errors.Errorf("unexpected query %q", query)
+	}
+
+	rows := &cursorTestRows{}
+	for k, v := range c.c.rows {
+		if k[0] == args[0].Value.(string) {
+			rows.rows = append(rows.rows, [2]string{k[1], v})
+		}
+	}
+
+	sort.Slice(rows.rows, func(i, j int) bool { return rows.rows[i][0] < rows.rows[j][0] })
+
+	return rows, nil
+}
+
+type cursorTestRows struct {
+	rows [][2]string
+}
+
+func (*cursorTestRows) Columns() []string {
+	return []string{"stream", "last_id"}
+}
+
+func (*cursorTestRows) Close() error {
+	return nil
+}
+
+func (r *cursorTestRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+
+	dest[0], dest[1] = r.rows[0][0], r.rows[0][1]
+	r.rows = r.rows[1:]
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the name of the OpenTelemetry tracer that creates the spans of database operations.
+const tracerName = "github.com/icinga/icinga-go-library/database"
+
+// startSpan starts a span for the query using the tracer provider from Options.TracerProvider, if any.
+// rows is the number of rows processed by the query at once, if known, and 0 otherwise.
+func (db *DB) startSpan(ctx context.Context, name, query string, rows int) (context.Context, trace.Span) {
+	tp := db.Options.TracerProvider
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+
+	system := db.DriverName()
+	if system == PostgreSQL {
+		// OpenTelemetry semantic conventions call it postgresql, not postgres.
+		system = "postgresql"
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", system),
+		attribute.String("db.statement", query),
+	}
+	if rows > 0 {
+		attrs = append(attrs, attribute.Int("db.operation.batch.size", rows))
+	}
+
+	return tp.Tracer(tracerName).Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, in span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"testing"
+)
+
+func TestDB_startSpan(t *testing.T) {
+	for _, driver := range []string{MySQL, PostgreSQL} {
+		t.Run(driver, func(t *testing.T) {
+			tp := &testTracerProvider{}
+			db := newTestDb(t, driver)
+			db.Options.TracerProvider = tp
+
+			_, span := db.startSpan(context.Background(), "BulkExec", "DELETE FROM host", 42)
+			endSpan(span, errors.New("failed"))
+
+			system := driver
+			if driver == PostgreSQL {
+				system = "postgresql"
+			}
+
+			require.Len(t, tp.spans, 1)
+			require.Equal(t, "BulkExec", tp.spans[0].name)
+			require.ElementsMatch(t, []attribute.KeyValue{
+				attribute.String("db.system", system),
+				attribute.String("db.statement", "DELETE FROM host"),
+				attribute.Int("db.operation.batch.size", 42),
+			}, tp.spans[0].attrs)
+			require.Equal(t, codes.Error, tp.spans[0].status)
+			require.True(t, tp.spans[0].ended)
+		})
+	}
+
+	t.Run("without-provider", func(t *testing.T) {
+		_, span := newTestDb(t, MySQL).startSpan(context.Background(), "YieldAll", "SELECT 1", 0)
+		require.False(t, span.IsRecording())
+		endSpan(span, nil)
+	})
+}
+
+// testTracerProvider is a trace.TracerProvider that records the spans started by its tracers.
+type testTracerProvider struct {
+	noop.TracerProvider
+	spans []*testSpan
+}
+
+func (tp *testTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return testTracer{tp: tp}
+}
+
+type testTracer struct {
+	noop.Tracer
+	tp *testTracerProvider
+}
+
+func (t testTracer) Start(
+	ctx context.Context, name string, options ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(options...)
+	span := &testSpan{name: name, attrs: config.Attributes()}
+	t.tp.spans = append(t.tp.spans, span)
+
+	return ctx, span
+}
+
+type testSpan struct {
+	noop.Span
+	name   string
+	attrs  []attribute.KeyValue
+	status codes.Code
+	ended  bool
+}
+
+func (s *testSpan) SetStatus(code codes.Code, _ string) {
+	s.status = code
+}
+
+func (s *testSpan) End(...trace.SpanEndOption) {
+	s.ended = true
+}
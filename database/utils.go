@@ -11,11 +11,6 @@ import (
 	"github.com/pkg/errors"
 )
 
-// CantPerformQuery wraps the given error with the specified query that cannot be executed.
-func CantPerformQuery(err error, q string) error {
-	return errors.Wrapf(err, "can't perform %q", q)
-}
-
 // TableName returns the table of t.
 func TableName(t interface{}) string {
 	if tn, ok := t.(TableNamer); ok {
@@ -44,13 +39,13 @@ func SplitOnDupId[T IDer]() com.BulkChunkSplitPolicy[T] {
 	}
 }
 
-// unsafeSetSessionVariableIfExists sets the given MySQL/MariaDB system variable for the specified database session.
+// unsafeSetSessionVariableIfExists sets the given system variable for the specified database session.
 //
 // NOTE: It is unsafe to use this function with untrusted/user supplied inputs and poses an SQL injection,
 // because it doesn't use a prepared statement, but executes the SQL command directly with the provided inputs.
 //
-// When the "SET SESSION" command fails with "Unknown system variable (1193)", the error will be silently
-// dropped but returns all other database errors.
+// When the "SET SESSION" command fails with the MySQL/MariaDB error "Unknown system variable (1193)",
+// the error will be silently dropped but returns all other database errors.
 func unsafeSetSessionVariableIfExists(ctx context.Context, conn driver.Conn, variable, value string) error {
 	stmt := fmt.Sprintf("SET SESSION %s=%s", variable, value)
 
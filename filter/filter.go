@@ -0,0 +1,184 @@
+// Package filter parses Icinga filter expressions, e.g. "host=web*&(state=down|!acknowledged)",
+// and evaluates them against objects, so that it can be decided locally whether an object matches a filter.
+package filter
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Filterable is an object that filters are evaluated against.
+type Filterable interface {
+	// Lookup returns the value of the attribute or tag key and whether the object has it.
+	Lookup(key string) (value string, ok bool)
+}
+
+// Map is a Filterable consisting of attributes or tags.
+type Map map[string]string
+
+// Lookup implements the Filterable interface.
+func (m Map) Lookup(key string) (string, bool) {
+	v, ok := m[key]
+
+	return v, ok
+}
+
+// Filter is a parsed filter expression.
+type Filter interface {
+	// Eval reports whether the object matches the filter.
+	Eval(Filterable) bool
+}
+
+// All matches if all of its filters match, i.e. also if there are none. It is the result of "a&b".
+type All []Filter
+
+// Eval implements the Filter interface.
+func (a All) Eval(f Filterable) bool {
+	for _, filter := range a {
+		if !filter.Eval(f) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Any matches if any of its filters matches, i.e. never if there are none. It is the result of "a|b".
+type Any []Filter
+
+// Eval implements the Filter interface.
+func (a Any) Eval(f Filterable) bool {
+	for _, filter := range a {
+		if filter.Eval(f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Not negates its filter. It is the result of "!a".
+type Not struct {
+	Filter Filter
+}
+
+// Eval implements the Filter interface.
+func (n Not) Eval(f Filterable) bool {
+	return !n.Filter.Eval(f)
+}
+
+// Exists matches if the object has the column. It is the result of a column without an operator.
+type Exists struct {
+	Column string
+}
+
+// Eval implements the Filter interface.
+func (e Exists) Eval(f Filterable) bool {
+	_, ok := f.Lookup(e.Column)
+
+	return ok
+}
+
+// Operator is the comparison operator of a Condition.
+type Operator string
+
+const (
+	Equal              Operator = "="
+	Unequal            Operator = "!="
+	Like               Operator = "~"
+	Unlike             Operator = "!~"
+	LessThan           Operator = "<"
+	LessThanOrEqual    Operator = "<="
+	GreaterThan        Operator = ">"
+	GreaterThanOrEqual Operator = ">="
+)
+
+// Condition compares the value of a column with Value.
+//
+// Like and Unlike treat * in Value as a wildcard for any number of characters. As in Icinga Web,
+// so do Equal and Unequal, i.e. "host=web*" is the same as "host~web*".
+// The ordering operators compare numerically if both values are numbers and lexicographically otherwise.
+// If the object doesn't have the column, only the negated operators Unequal and Unlike match.
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    string
+}
+
+// Eval implements the Filter interface.
+func (c Condition) Eval(f Filterable) bool {
+	value, ok := f.Lookup(c.Column)
+	if !ok {
+		return c.Operator == Unequal || c.Operator == Unlike
+	}
+
+	switch c.Operator {
+	case Equal, Like:
+		return matchWildcard(c.Value, value)
+	case Unequal, Unlike:
+		return !matchWildcard(c.Value, value)
+	case LessThan:
+		return compare(value, c.Value) < 0
+	case LessThanOrEqual:
+		return compare(value, c.Value) <= 0
+	case GreaterThan:
+		return compare(value, c.Value) > 0
+	case GreaterThanOrEqual:
+		return compare(value, c.Value) >= 0
+	default:
+		return false
+	}
+}
+
+// compare compares a and b numerically if both are numbers and lexicographically otherwise.
+func compare(a, b string) int {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// matchWildcard reports whether s matches pattern, in which * matches any number of characters.
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+
+		s = s[i+len(part):]
+	}
+
+	return len(s) >= len(last) && strings.HasSuffix(s, last)
+}
+
+// Assert interface compliance.
+var (
+	_ Filterable = Map(nil)
+	_ Filter     = All(nil)
+	_ Filter     = Any(nil)
+	_ Filter     = Not{}
+	_ Filter     = Exists{}
+	_ Filter     = Condition{}
+)
@@ -0,0 +1,164 @@
+package filter
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected Filter
+	}{
+		{"Empty", "", All{}},
+		{"Condition", "host=web", Condition{Column: "host", Operator: Equal, Value: "web"}},
+		{"Exists", "acknowledged", Exists{Column: "acknowledged"}},
+		{"Empty value", "host=", Condition{Column: "host", Operator: Equal}},
+		{"URL-encoded", "service%20name=disk%20%2F%21", Condition{Column: "service name", Operator: Equal, Value: "disk /!"}},
+		{"Plus sign", "tag=a+b", Condition{Column: "tag", Operator: Equal, Value: "a+b"}},
+		{
+			name: "Operators",
+			expr: "a!=1&b~2*&c!~3&d<4&e<=5&f>6&g>=7",
+			expected: All{
+				Condition{Column: "a", Operator: Unequal, Value: "1"},
+				Condition{Column: "b", Operator: Like, Value: "2*"},
+				Condition{Column: "c", Operator: Unlike, Value: "3"},
+				Condition{Column: "d", Operator: LessThan, Value: "4"},
+				Condition{Column: "e", Operator: LessThanOrEqual, Value: "5"},
+				Condition{Column: "f", Operator: GreaterThan, Value: "6"},
+				Condition{Column: "g", Operator: GreaterThanOrEqual, Value: "7"},
+			},
+		},
+		{
+			name: "Precedence",
+			expr: "a=1|b=2&c=3",
+			expected: Any{
+				Condition{Column: "a", Operator: Equal, Value: "1"},
+				All{
+					Condition{Column: "b", Operator: Equal, Value: "2"},
+					Condition{Column: "c", Operator: Equal, Value: "3"},
+				},
+			},
+		},
+		{
+			name: "Groups and negation",
+			expr: "!(a=1|b)&!!c=3",
+			expected: All{
+				Not{Filter: Any{Condition{Column: "a", Operator: Equal, Value: "1"}, Exists{Column: "b"}}},
+				Not{Filter: Not{Filter: Condition{Column: "c", Operator: Equal, Value: "3"}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, f)
+		})
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"Missing column", "=web"},
+		{"Trailing and", "host=web&"},
+		{"Leading or", "|host=web"},
+		{"Unclosed group", "(host=web"},
+		{"Unopened group", "host=web)"},
+		{"Empty group", "()"},
+		{"Double operator", "host=web=db"},
+		{"Group after column", "host(web)"},
+		{"Invalid encoding", "host=%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestFilter_Eval(t *testing.T) {
+	object := Map{"host": "web01", "service": "disk /", "state": "2", "load": "10", "tag": ""}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"", true},
+		{"host=web01", true},
+		{"host=web", false},
+		{"host!=web", true},
+		{"host=web*", true},
+		{"host=*01", true},
+		{"host=web*02", false},
+		{"host!=web*", false},
+		{"host!=db*", true},
+		{"host~web*", true},
+		{"host~*01", true},
+		{"host~w*b*1", true},
+		{"host~*eb0*", true},
+		{"host~web", false},
+		{"host~web*02", false},
+		{"host!~db*", true},
+		{"service=disk%20%2F", true},
+		{"state>1", true},
+		{"state>=2", true},
+		{"state<2", false},
+		{"load>9", true}, // Numeric, not lexicographic.
+		{"host<x", true},
+		{"tag", true},
+		{"tag=", true},
+		{"missing", false},
+		{"missing=x", false},
+		{"missing!=x", true},
+		{"missing~*", false},
+		{"missing!~x", true},
+		{"missing<x", false},
+		{"!missing", true},
+		{"host=db|state=2", true},
+		{"host=db|state=1", false},
+		{"host=web01&state=1", false},
+		{"host=web01&(state=1|load=10)", true},
+		{"!(host=web01&state=2)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Parse(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, f.Eval(object))
+		})
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{"", "", true},
+		{"*", "", true},
+		{"*", "abc", true},
+		{"a*", "a", true},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"*a*a*", "aba", true},
+		{"a**c", "abc", true},
+		{"ab*bc", "abc", false},
+		{"abc", "abd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.s, func(t *testing.T) {
+			require.Equal(t, tt.expected, matchWildcard(tt.pattern, tt.s))
+		})
+	}
+}
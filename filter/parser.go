@@ -0,0 +1,181 @@
+package filter
+
+import (
+	"github.com/pkg/errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// operators are the Condition operators, two-character ones first, so that they take precedence.
+var operators = []Operator{Unequal, Unlike, LessThanOrEqual, GreaterThanOrEqual, Equal, Like, LessThan, GreaterThan}
+
+// specialChars terminate columns and values, so they must be URL-encoded if they are part of one.
+const specialChars = "!&|()=~<>"
+
+// Parse parses an Icinga filter expression.
+//
+// An expression consists of conditions such as "column=value", combined with & (and) and | (or), where & takes
+// precedence over |, negated with ! and grouped with parentheses. A column without an operator tests whether
+// the column exists. See Operator for the supported operators. Columns and values are URL-encoded,
+// e.g. "service=disk%20%2F". An empty expression results in a filter that matches all objects.
+func Parse(expr string) (Filter, error) {
+	if expr == "" {
+		return All{}, nil
+	}
+
+	p := &parser{expr: expr}
+
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't parse filter %q", expr)
+	}
+
+	if p.pos < len(p.expr) {
+		return nil, errors.Wrapf(p.unexpected(), "can't parse filter %q", expr)
+	}
+
+	return f, nil
+}
+
+// parser is a recursive descent parser of filter expressions.
+type parser struct {
+	expr string
+	pos  int
+}
+
+// parseOr parses filters separated by |.
+func (p *parser) parseOr() (Filter, error) {
+	var filters Any
+	for {
+		f, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, f)
+
+		if !p.consume("|") {
+			break
+		}
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return filters, nil
+}
+
+// parseAnd parses filters separated by &.
+func (p *parser) parseAnd() (Filter, error) {
+	var filters All
+	for {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, f)
+
+		if !p.consume("&") {
+			break
+		}
+	}
+
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+
+	return filters, nil
+}
+
+// parseUnary parses a negated filter, a group in parentheses or a condition.
+func (p *parser) parseUnary() (Filter, error) {
+	switch {
+	case p.consume("!"):
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return Not{Filter: f}, nil
+	case p.consume("("):
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.consume(")") {
+			return nil, p.unexpected()
+		}
+
+		return f, nil
+	default:
+		return p.parseCondition()
+	}
+}
+
+// parseCondition parses a condition or, if the column isn't followed by an operator, an existence test.
+func (p *parser) parseCondition() (Filter, error) {
+	start := p.pos
+	column, err := p.parseToken()
+	if err != nil {
+		return nil, err
+	}
+	if column == "" {
+		return nil, p.unexpected()
+	}
+
+	for _, op := range operators {
+		if p.consume(string(op)) {
+			value, err := p.parseToken()
+			if err != nil {
+				return nil, err
+			}
+
+			return Condition{Column: column, Operator: op, Value: value}, nil
+		}
+	}
+
+	if p.pos < len(p.expr) && !strings.ContainsAny(p.expr[p.pos:p.pos+1], "&|)") {
+		return nil, errors.Errorf("invalid condition at position %d", start)
+	}
+
+	return Exists{Column: column}, nil
+}
+
+// parseToken parses a URL-encoded column or value up to the next special character.
+func (p *parser) parseToken() (string, error) {
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(specialChars, rune(p.expr[p.pos])) {
+		p.pos++
+	}
+
+	token, err := url.PathUnescape(p.expr[start:p.pos])
+	if err != nil {
+		return "", errors.Wrapf(err, "can't decode %q at position %d", p.expr[start:p.pos], start)
+	}
+
+	return token, nil
+}
+
+// consume advances past s and returns true if the expression continues with s.
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+
+		return true
+	}
+
+	return false
+}
+
+// unexpected returns an error about the character at the current position.
+func (p *parser) unexpected() error {
+	if p.pos >= len(p.expr) {
+		return errors.New("unexpected end of expression")
+	}
+
+	return errors.Errorf("unexpected %s at position %d", strconv.QuoteRune(rune(p.expr[p.pos])), p.pos)
+}
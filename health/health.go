@@ -0,0 +1,247 @@
+// Package health provides an HTTP handler that reports the results of registered health checks as JSON,
+// e.g. for Kubernetes liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/icinga/icinga-go-library/redis/heartbeat"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status values of Report.Status and CheckResult.Status.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Checker checks the health of a component.
+type Checker interface {
+	// Check returns an error if the component is unhealthy.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc implements Checker using a function.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements the Checker interface.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult is the result of a single check.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Report is the JSON document returned by Handler.
+type Report struct {
+	// Status is StatusOK if all checks succeeded, StatusFailed otherwise.
+	Status string `json:"status"`
+
+	// Checks maps the names of the checks to their results.
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Handler is an http.Handler that runs all registered checks concurrently on each request and responds
+// with a Report and status 200 if all checks succeeded, or 503 otherwise.
+// Use separate handlers for different probes, e.g. liveness and readiness.
+type Handler struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+// NewHandler returns a new Handler that cancels checks running longer than timeout.
+func NewHandler(timeout time.Duration) *Handler {
+	return &Handler{timeout: timeout, checkers: make(map[string]Checker)}
+}
+
+// Register registers the checker under the given name, replacing any checker previously registered under it.
+func (h *Handler) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checkers[name] = checker
+}
+
+// Check runs all registered checks concurrently and returns their Report.
+func (h *Handler) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(h.checkers))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, checker := range h.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := checker.Check(ctx)
+			result := CheckResult{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = StatusFailed
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = StatusFailed
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if report.Status != StatusOK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// ServeUnix serves handler on a Unix domain socket at path until ctx is canceled.
+// An existing socket file at path is replaced. The socket file is removed on return.
+func ServeUnix(ctx context.Context, path string, handler http.Handler) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrapf(err, "can't remove stale socket %q", path)
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return errors.Wrapf(err, "can't listen on %q", path)
+	}
+	defer func() { _ = os.Remove(path) }()
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	if err := server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return errors.Wrapf(err, "can't serve on %q", path)
+	}
+
+	return ctx.Err()
+}
+
+// DatabaseChecker returns a Checker that pings the database.
+func DatabaseChecker(db *database.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return errors.Wrap(db.PingContext(ctx), "can't ping database")
+	})
+}
+
+// RedisChecker returns a Checker that sends PING to Redis.
+func RedisChecker(client *redis.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if cmd := client.Ping(ctx); cmd.Err() != nil {
+			return redis.WrapCmdErr(cmd)
+		}
+
+		return nil
+	})
+}
+
+// RedisPoolChecker returns a Checker that fails if waiting for a free connection of the client's pool
+// timed out since the previous check, which indicates that the pool is exhausted, see redis.Client.PoolStats.
+func RedisPoolChecker(client *redis.Client) Checker {
+	var last atomic.Uint32
+	last.Store(client.PoolStats().Timeouts)
+
+	return CheckerFunc(func(context.Context) error {
+		s := client.PoolStats()
+		if timeouts := s.Timeouts - last.Swap(s.Timeouts); timeouts > 0 {
+			return errors.Errorf(
+				"%d timeouts waiting for a free connection, %d of %d connections in use", timeouts, s.InUse(), s.PoolSize,
+			)
+		}
+
+		return nil
+	})
+}
+
+// HeartbeatChecker returns a Checker that fails if the heartbeat failed, none was received yet
+// or the last one was received more than maxAge ago.
+func HeartbeatChecker(hb *heartbeat.Heartbeat, maxAge time.Duration) Checker {
+	return CheckerFunc(func(context.Context) error {
+		if err := hb.Err(); err != nil {
+			return errors.Wrap(err, "heartbeat failed")
+		}
+
+		last := hb.LastReceived()
+		if last == 0 {
+			return errors.New("no heartbeat received yet")
+		}
+
+		if age := time.Since(time.UnixMilli(last)); age > maxAge {
+			return errors.Errorf("last heartbeat received %s ago", age.Round(time.Millisecond))
+		}
+
+		return nil
+	})
+}
+
+// RetryChecker returns a Checker that fails if operations registered in registry,
+// e.g. via database.Options.RetryRegistry or redis.Options.RetryRegistry, have been retried for at least threshold.
+// Its error lists these operations, e.g. "database connect retrying for 3m0s, attempt 17: connection refused".
+func RetryChecker(registry *retry.Registry, threshold time.Duration) Checker {
+	return CheckerFunc(func(context.Context) error {
+		var retrying []string
+		for _, s := range registry.States() {
+			if elapsed := time.Since(s.Since); elapsed >= threshold {
+				retrying = append(retrying, fmt.Sprintf(
+					"%s retrying for %s, attempt %d: %v", s.Name, elapsed.Round(time.Second), s.Attempt, s.LastErr,
+				))
+			}
+		}
+
+		if len(retrying) > 0 {
+			return errors.New(strings.Join(retrying, "; "))
+		}
+
+		return nil
+	})
+}
+
+// Assert interface compliance.
+var (
+	_ http.Handler = (*Handler)(nil)
+	_ Checker      = CheckerFunc(nil)
+)
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	ok := CheckerFunc(func(context.Context) error { return nil })
+	failing := CheckerFunc(func(context.Context) error { return errors.New("down") })
+	blocking := CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	subtests := []struct {
+		name     string
+		checkers map[string]Checker
+		code     int
+		status   string
+		errors   map[string]string
+	}{
+		{"empty", nil, http.StatusOK, StatusOK, nil},
+		{"ok", map[string]Checker{"db": ok, "redis": ok}, http.StatusOK, StatusOK, nil},
+		{
+			"failed", map[string]Checker{"db": ok, "redis": failing},
+			http.StatusServiceUnavailable, StatusFailed, map[string]string{"redis": "down"},
+		},
+		{
+			"timeout", map[string]Checker{"db": blocking},
+			http.StatusServiceUnavailable, StatusFailed, map[string]string{"db": context.DeadlineExceeded.Error()},
+		},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			h := NewHandler(10 * time.Millisecond)
+			for name, checker := range st.checkers {
+				h.Register(name, checker)
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+			require.Equal(t, st.code, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			require.Equal(t, st.status, report.Status)
+			require.Len(t, report.Checks, len(st.checkers))
+
+			for name, result := range report.Checks {
+				require.Equal(t, st.errors[name], result.Error)
+
+				if st.errors[name] == "" {
+					require.Equal(t, StatusOK, result.Status)
+				} else {
+					require.Equal(t, StatusFailed, result.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestServeUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- ServeUnix(ctx, path, NewHandler(time.Second)) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+
+	require.Eventually(t, func() bool {
+		res, err := client.Get("http://localhost/")
+		if err != nil {
+			return false
+		}
+		_ = res.Body.Close()
+
+		return res.StatusCode == http.StatusOK
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.NoFileExists(t, path)
+}
+
+func TestRetryChecker(t *testing.T) {
+	// Listen on a random port and close it again, so that connecting to it is refused, which is retried.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	registry := &retry.Registry{}
+	checker := RetryChecker(registry, 0)
+	require.NoError(t, checker.Check(context.Background()), "nothing must be retried yet")
+
+	db, err := database.NewDbFromConfig(
+		&database.Config{
+			Type: "mysql", Host: "127.0.0.1", Port: port, Database: "db", User: "user",
+			Options: database.Options{MaxConnections: 1, RetryRegistry: registry},
+		},
+		logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour),
+		database.RetryConnectorCallbacks{})
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- db.PingContext(ctx) }()
+
+	require.Eventually(t, func() bool {
+		err := checker.Check(context.Background())
+
+		return err != nil && strings.Contains(err.Error(), "database connect retrying for")
+	}, 5*time.Second, 10*time.Millisecond, "retried database connect must be reported")
+
+	require.NoError(t, RetryChecker(registry, time.Hour).Check(context.Background()),
+		"operations retried for less than the threshold must not be reported")
+
+	cancel()
+	require.Error(t, <-done)
+	require.Empty(t, registry.States(), "operations must be removed once no longer retried")
+	require.NoError(t, checker.Check(context.Background()))
+}
@@ -0,0 +1,144 @@
+// Package httputil provides an HTTP client builder for API integrations that adds TLS configuration,
+// authentication, a User-Agent, retries of idempotent requests, rate limiting and logging to http.Client.
+package httputil
+
+import (
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/config"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"net/http"
+	"time"
+)
+
+// Option configures NewClient.
+type Option interface {
+	apply(*clientOptions)
+}
+
+// WithTLS configures the TLS settings of the client, see config.TLS.
+// The server name to verify is taken from the request URL.
+func WithTLS(tls *config.TLS) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.tls = tls
+	})
+}
+
+// WithBasicAuth authenticates all requests with the given username and password,
+// unless a request already has an Authorization header or follows a redirect to another host.
+func WithBasicAuth(username, password string) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.authorize = func(r *http.Request) {
+			r.SetBasicAuth(username, password)
+		}
+	})
+}
+
+// WithBearerToken authenticates all requests with the given bearer token,
+// unless a request already has an Authorization header or follows a redirect to another host.
+func WithBearerToken(token string) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.authorize = func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	})
+}
+
+// WithUserAgent sets the User-Agent header of all requests that don't set their own.
+func WithUserAgent(userAgent string) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.userAgent = userAgent
+	})
+}
+
+// WithTimeout limits the time of a request including any retries and reading the response body,
+// see http.Client.Timeout.
+func WithTimeout(timeout time.Duration) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.timeout = timeout
+	})
+}
+
+// WithRetry retries idempotent requests, i.e. GET, HEAD, OPTIONS, TRACE, PUT and DELETE requests and
+// requests with an Idempotency-Key header, on errors considered retryable by retry.Retryable and on
+// the status codes 429, 502, 503 and 504, using the given backoff and settings.
+// If all attempts fail with such a status code, the last response is returned.
+// Requests with a body are only retried if their body can be obtained again via http.Request.GetBody.
+func WithRetry(b backoff.Backoff, settings retry.Settings) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.backoff = b
+		o.settings = settings
+	})
+}
+
+// WithRateLimiter waits for l before sending each request, including each retry attempt,
+// e.g. to cap the load on an API shared with other clients.
+func WithRateLimiter(l *com.RateLimiter) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.rateLimiter = l
+	})
+}
+
+// WithLogger logs each request and its outcome at debug level and retried requests at warn level.
+func WithLogger(logger *logging.Logger) Option {
+	return optionFunc(func(o *clientOptions) {
+		o.logger = logger
+	})
+}
+
+// NewClient returns a new http.Client configured by the given options.
+// Returns an error if the TLS configuration is invalid.
+func NewClient(options ...Option) (*http.Client, error) {
+	var o clientOptions
+	for _, option := range options {
+		option.apply(&o)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.tls != nil {
+		tlsConfig, err := o.tls.MakeConfig("")
+		if err != nil {
+			return nil, errors.Wrap(err, "can't create TLS configuration")
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	var rt http.RoundTripper = transport
+	if o.rateLimiter != nil {
+		rt = &rateLimitTransport{next: rt, limiter: o.rateLimiter}
+	}
+	if o.backoff != nil {
+		rt = &retryTransport{next: rt, backoff: o.backoff, settings: o.settings, logger: o.logger}
+	}
+	if o.logger != nil {
+		rt = &loggingTransport{next: rt, logger: o.logger}
+	}
+	if o.authorize != nil || o.userAgent != "" {
+		rt = &headerTransport{next: rt, authorize: o.authorize, userAgent: o.userAgent}
+	}
+
+	return &http.Client{Transport: rt, Timeout: o.timeout}, nil
+}
+
+// clientOptions stores the options of NewClient.
+type clientOptions struct {
+	tls         *config.TLS
+	authorize   func(*http.Request)
+	userAgent   string
+	timeout     time.Duration
+	backoff     backoff.Backoff
+	settings    retry.Settings
+	rateLimiter *com.RateLimiter
+	logger      *logging.Logger
+}
+
+// optionFunc is a function that implements Option.
+type optionFunc func(*clientOptions)
+
+// apply implements the Option interface.
+func (f optionFunc) apply(o *clientOptions) {
+	f(o)
+}
@@ -0,0 +1,218 @@
+package httputil
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"time"
+)
+
+// headerTransport sets the Authorization and User-Agent headers of requests that don't have them.
+// The Authorization header is not set on requests following a redirect to another host than the original request,
+// from which http.Client deliberately strips credentials.
+type headerTransport struct {
+	next      http.RoundTripper
+	authorize func(*http.Request)
+	userAgent string
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	setAuth := t.authorize != nil && req.Header.Get("Authorization") == "" && !isCrossHostRedirect(req)
+	setUserAgent := t.userAgent != "" && req.Header.Get("User-Agent") == ""
+
+	if setAuth || setUserAgent {
+		// RoundTrippers must not modify the request.
+		req = req.Clone(req.Context())
+
+		if setAuth {
+			t.authorize(req)
+		}
+		if setUserAgent {
+			req.Header.Set("User-Agent", t.userAgent)
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// isCrossHostRedirect reports whether req follows a redirect to another host than the request the client started with.
+func isCrossHostRedirect(req *http.Request) bool {
+	initial := req
+	for initial.Response != nil && initial.Response.Request != nil {
+		initial = initial.Response.Request
+	}
+
+	return initial.URL.Host != req.URL.Host
+}
+
+// loggingTransport logs requests and their outcome.
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger *logging.Logger
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+
+	fields := []any{
+		zap.String("method", req.Method), zap.Stringer("url", redactedURL{req}), zap.Duration("took", time.Since(start)),
+	}
+	if err != nil {
+		t.logger.Debugw("HTTP request failed", append(fields, zap.Error(err))...)
+	} else {
+		t.logger.Debugw("Performed HTTP request", append(fields, zap.Int("status", res.StatusCode))...)
+	}
+
+	return res, err
+}
+
+// rateLimitTransport waits for a com.RateLimiter before sending requests.
+type rateLimitTransport struct {
+	next    http.RoundTripper
+	limiter *com.RateLimiter
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	return t.next.RoundTrip(req)
+}
+
+// retryTransport retries idempotent requests as described in WithRetry.
+type retryTransport struct {
+	next     http.RoundTripper
+	backoff  backoff.Backoff
+	settings retry.Settings
+	logger   *logging.Logger
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) || req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	settings := t.settings
+	if t.logger != nil {
+		onRetryableError := settings.OnRetryableError
+		settings.OnRetryableError = func(elapsed time.Duration, attempt uint64, err, lastErr error) {
+			if lastErr == nil || err.Error() != lastErr.Error() {
+				t.logger.Warnw("Can't perform HTTP request. Retrying", zap.String("method", req.Method),
+					zap.Stringer("url", redactedURL{req}), zap.Error(err))
+			}
+
+			if onRetryableError != nil {
+				onRetryableError(elapsed, attempt, err, lastErr)
+			}
+		}
+	}
+
+	// res is the response of the last attempt, if any.
+	var res *http.Response
+
+	err := retry.WithBackoff(
+		req.Context(),
+		func(ctx context.Context) error {
+			if res != nil {
+				discard(res)
+				res = nil
+			}
+
+			attempt := req
+			if a, _ := retry.AttemptFromContext(ctx); a.IsRetry() {
+				attempt = req.Clone(req.Context())
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return retry.MarkPermanent(errors.Wrap(err, "can't rewind request body"))
+					}
+
+					attempt.Body = body
+				}
+			}
+
+			var err error
+			res, err = t.next.RoundTrip(attempt)
+			if err != nil {
+				return err
+			}
+
+			if isRetryableStatus(res.StatusCode) {
+				return retry.MarkRetryable(errors.Errorf("server responded with %s", res.Status))
+			}
+
+			return nil
+		},
+		retry.Retryable,
+		t.backoff,
+		settings,
+	)
+
+	if res != nil {
+		if req.Context().Err() == nil {
+			// Either the last attempt succeeded or the server kept responding with a retryable status code,
+			// which is then reported to the caller as is.
+			return res, nil
+		}
+
+		discard(res)
+	}
+
+	return nil, err
+}
+
+// isIdempotent reports whether req can safely be sent multiple times.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return req.Header.Get("Idempotency-Key") != ""
+	}
+}
+
+// isRetryableStatus reports whether the status code indicates a temporary server-side condition.
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// discard reads and closes the body of res, so that its connection can be reused.
+func discard(res *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	_ = res.Body.Close()
+}
+
+// redactedURL is a fmt.Stringer of the request URL without the password, if any.
+type redactedURL struct {
+	req *http.Request
+}
+
+// String implements the fmt.Stringer interface.
+func (u redactedURL) String() string {
+	return u.req.URL.Redacted()
+}
+
+// Assert interface compliance.
+var (
+	_ http.RoundTripper = (*headerTransport)(nil)
+	_ http.RoundTripper = (*loggingTransport)(nil)
+	_ http.RoundTripper = (*rateLimitTransport)(nil)
+	_ http.RoundTripper = (*retryTransport)(nil)
+)
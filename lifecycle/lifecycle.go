@@ -0,0 +1,195 @@
+// Package lifecycle coordinates starting and gracefully stopping the components of a daemon.
+package lifecycle
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Component is a part of a daemon that runs in the background between Start and Stop.
+type Component interface {
+	// Start starts the component and returns once it is running.
+	// ctx is canceled when the Manager starts shutting down.
+	Start(ctx context.Context) error
+
+	// Stop stops the component and returns once it is stopped.
+	// ctx is canceled when the shutdown grace period is exceeded.
+	Stop(ctx context.Context) error
+}
+
+// ComponentFuncs implements Component using functions. Nil functions do nothing.
+type ComponentFuncs struct {
+	StartFunc func(ctx context.Context) error
+	StopFunc  func(ctx context.Context) error
+}
+
+// Start implements the Component interface.
+func (c ComponentFuncs) Start(ctx context.Context) error {
+	if c.StartFunc == nil {
+		return nil
+	}
+
+	return c.StartFunc(ctx)
+}
+
+// Stop implements the Component interface.
+func (c ComponentFuncs) Stop(ctx context.Context) error {
+	if c.StopFunc == nil {
+		return nil
+	}
+
+	return c.StopFunc(ctx)
+}
+
+// Manager starts registered components in dependency order, waits for a shutdown signal and
+// stops them in reverse dependency order within a grace period.
+type Manager struct {
+	logger      *logging.Logger
+	gracePeriod time.Duration
+	components  []registration
+}
+
+// registration is a Component registered with a Manager.
+type registration struct {
+	name      string
+	component Component
+	dependsOn []string
+}
+
+// NewManager returns a new Manager that allows components to stop for at most gracePeriod.
+func NewManager(logger *logging.Logger, gracePeriod time.Duration) *Manager {
+	return &Manager{logger: logger, gracePeriod: gracePeriod}
+}
+
+// Register registers the component under the given name.
+// It is started after and stopped before the components it depends on,
+// which must be registered by the time Run is called.
+func (m *Manager) Register(name string, component Component, dependsOn ...string) {
+	m.components = append(m.components, registration{name: name, component: component, dependsOn: dependsOn})
+}
+
+// Run starts all components and blocks until ctx is canceled or SIGINT or SIGTERM is received.
+// Then, all components are stopped in reverse dependency order.
+// If a component fails to start, the already started ones are stopped.
+// Returns the errors of starting and stopping the components, if any.
+func (m *Manager) Run(ctx context.Context) error {
+	order, err := m.order()
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancelRun := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer cancelRun()
+
+	var started []registration
+	var startErr error
+
+	for _, r := range order {
+		m.logger.Debugw("Starting component", zap.String("component", r.name))
+
+		if err := r.component.Start(runCtx); err != nil {
+			startErr = errors.Wrapf(err, "can't start component %q", r.name)
+			break
+		}
+
+		started = append(started, r)
+	}
+
+	if startErr == nil {
+		m.logger.Info("Started all components")
+		<-runCtx.Done()
+		m.logger.Info("Shutting down")
+	}
+
+	cancelRun()
+
+	return stderrors.Join(startErr, m.stop(started))
+}
+
+// stop stops the given components in reverse order within the grace period and returns their errors.
+func (m *Manager) stop(started []registration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.gracePeriod)
+	defer cancel()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		m.logger.Debugw("Stopping component", zap.String("component", r.name))
+
+		if err := r.component.Stop(ctx); err != nil {
+			m.logger.Errorw("Can't stop component", zap.String("component", r.name), zap.Error(err))
+			errs = append(errs, errors.Wrapf(err, "can't stop component %q", r.name))
+		}
+	}
+
+	return stderrors.Join(errs...)
+}
+
+// order returns the registered components in dependency order, keeping the registration order where possible.
+// Returns an error if a dependency is not registered or the dependencies are cyclic.
+func (m *Manager) order() ([]registration, error) {
+	byName := make(map[string]registration, len(m.components))
+	for _, r := range m.components {
+		if _, ok := byName[r.name]; ok {
+			return nil, errors.Errorf("duplicate component %q", r.name)
+		}
+
+		byName[r.name] = r
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int, len(m.components))
+	order := make([]registration, 0, len(m.components))
+	var path []string
+
+	var visit func(r registration) error
+	visit = func(r registration) error {
+		switch state[r.name] {
+		case visiting:
+			return errors.Errorf("cyclic component dependencies: %s -> %s", strings.Join(path, " -> "), r.name)
+		case visited:
+			return nil
+		}
+
+		state[r.name] = visiting
+		path = append(path, r.name)
+
+		for _, dep := range r.dependsOn {
+			d, ok := byName[dep]
+			if !ok {
+				return errors.Errorf("component %q depends on unknown component %q", r.name, dep)
+			}
+
+			if err := visit(d); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[r.name] = visited
+		order = append(order, r)
+
+		return nil
+	}
+
+	for _, r := range m.components {
+		if err := visit(r); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
@@ -0,0 +1,93 @@
+package lifecycle
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"testing"
+	"time"
+)
+
+func TestManager_Run(t *testing.T) {
+	var events []string
+	component := func(name string, startErr error) Component {
+		return ComponentFuncs{
+			StartFunc: func(context.Context) error {
+				events = append(events, "start "+name)
+				return startErr
+			},
+			StopFunc: func(context.Context) error {
+				events = append(events, "stop "+name)
+				return nil
+			},
+		}
+	}
+
+	newManager := func(t *testing.T) *Manager {
+		return NewManager(logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Second), time.Second)
+	}
+
+	t.Run("dependency-order", func(t *testing.T) {
+		events = nil
+
+		m := newManager(t)
+		m.Register("ha", component("ha", nil), "db", "redis")
+		m.Register("db", component("db", nil))
+		m.Register("redis", component("redis", nil))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		require.NoError(t, m.Run(ctx))
+		require.Equal(t, []string{"start db", "start redis", "start ha", "stop ha", "stop redis", "stop db"}, events)
+	})
+
+	t.Run("start-error", func(t *testing.T) {
+		events = nil
+		errFailed := errors.New("failed")
+
+		m := newManager(t)
+		m.Register("db", component("db", nil))
+		m.Register("redis", component("redis", errFailed))
+		m.Register("ha", component("ha", nil), "redis")
+
+		err := m.Run(context.Background())
+		require.ErrorIs(t, err, errFailed)
+		require.ErrorContains(t, err, `can't start component "redis"`)
+		require.Equal(t, []string{"start db", "start redis", "stop db"}, events)
+	})
+
+	t.Run("grace-period", func(t *testing.T) {
+		m := NewManager(logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Second), 10*time.Millisecond)
+		m.Register("slow", ComponentFuncs{StopFunc: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}})
+		m.Register("fast", ComponentFuncs{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := m.Run(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.ErrorContains(t, err, `can't stop component "slow"`)
+	})
+
+	t.Run("invalid-dependencies", func(t *testing.T) {
+		m := newManager(t)
+		m.Register("a", ComponentFuncs{}, "b")
+		m.Register("b", ComponentFuncs{}, "a")
+		require.ErrorContains(t, m.Run(context.Background()), "cyclic component dependencies: a -> b -> a")
+
+		m = newManager(t)
+		m.Register("a", ComponentFuncs{}, "unknown")
+		require.ErrorContains(t, m.Run(context.Background()), `depends on unknown component "unknown"`)
+
+		m = newManager(t)
+		m.Register("a", ComponentFuncs{})
+		m.Register("a", ComponentFuncs{})
+		require.ErrorContains(t, m.Run(context.Background()), `duplicate component "a"`)
+	})
+}
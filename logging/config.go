@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/creasty/defaults"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
 	"strings"
@@ -52,6 +53,40 @@ func (o *Options) UnmarshalYAML(unmarshal func(any) error) error {
 	return nil
 }
 
+// ComponentOutputs routes named child loggers to one or more outputs other than the default one.
+type ComponentOutputs map[string][]string
+
+// UnmarshalText implements encoding.TextUnmarshaler to allow ComponentOutputs to be parsed by env.
+// The expected format is a comma-separated list of entries like "database:console|systemd-journald".
+func (o *ComponentOutputs) UnmarshalText(text []byte) error {
+	outputsMap := make(map[string][]string)
+
+	for _, entry := range strings.Split(string(text), ",") {
+		key, valueStr, found := strings.Cut(entry, ":")
+		if !found || valueStr == "" {
+			return fmt.Errorf("entry %q cannot be unmarshalled as a ComponentOutputs entry", entry)
+		}
+
+		outputsMap[key] = strings.Split(valueStr, "|")
+	}
+
+	*o = outputsMap
+	return nil
+}
+
+// UnmarshalYAML implements yaml.InterfaceUnmarshaler to allow ComponentOutputs to be parsed go-yaml.
+func (o *ComponentOutputs) UnmarshalYAML(unmarshal func(any) error) error {
+	outputsMap := make(map[string][]string)
+
+	if err := unmarshal(&outputsMap); err != nil {
+		return err
+	}
+
+	*o = outputsMap
+
+	return nil
+}
+
 // Config defines Logger configuration.
 type Config struct {
 	// zapcore.Level at 0 is for info level.
@@ -60,6 +95,31 @@ type Config struct {
 	// Interval for periodic logging.
 	Interval time.Duration `yaml:"interval" env:"INTERVAL" default:"20s"`
 	Options  Options       `yaml:"options" env:"OPTIONS"`
+	// ComponentOutputs routes named child loggers to other outputs.
+	ComponentOutputs ComponentOutputs `yaml:"component_outputs" env:"COMPONENT_OUTPUTS"`
+	// Syslog configures the syslog output.
+	Syslog SyslogConfig `yaml:"syslog" envPrefix:"SYSLOG_"`
+	// RecentEntries, if greater than 0, is the number of the most recent log entries kept in memory per logger,
+	// which can be retrieved via Logging.Recent, e.g. to include the latest errors in a health report.
+	RecentEntries int `yaml:"recent_entries" env:"RECENT_ENTRIES" default:"0"`
+	// Caller, if true, annotates log entries with the file and line of the calling code.
+	Caller bool `yaml:"caller" env:"CALLER"`
+	// StacktraceLevel, if set, records a stacktrace for log entries at or above this level, e.g. error.
+	StacktraceLevel *zapcore.Level `yaml:"stacktrace_level" env:"STACKTRACE_LEVEL"`
+}
+
+// zapOptions returns the zap.Option for the caller annotation and stacktraces as configured.
+func (c *Config) zapOptions() []zap.Option {
+	var opts []zap.Option
+	if c.Caller {
+		opts = append(opts, zap.AddCaller())
+	}
+
+	if c.StacktraceLevel != nil {
+		opts = append(opts, zap.AddStacktrace(*c.StacktraceLevel))
+	}
+
+	return opts
 }
 
 // SetDefaults implements defaults.Setter to configure the log output if it is not set:
@@ -84,12 +144,40 @@ func (c *Config) Validate() error {
 		return errors.New("periodic logging interval must be positive")
 	}
 
-	return AssertOutput(c.Output)
+	if c.RecentEntries < 0 {
+		return errors.New("recent_entries cannot be negative")
+	}
+
+	if err := AssertOutput(c.Output); err != nil {
+		return err
+	}
+
+	syslog := c.Output == SYSLOG
+
+	for component, outputs := range c.ComponentOutputs {
+		if len(outputs) == 0 {
+			return fmt.Errorf("no outputs configured for component %q", component)
+		}
+
+		for _, output := range outputs {
+			if err := AssertOutput(output); err != nil {
+				return fmt.Errorf("invalid output for component %q: %w", component, err)
+			}
+
+			syslog = syslog || output == SYSLOG
+		}
+	}
+
+	if syslog {
+		return c.Syslog.Validate()
+	}
+
+	return nil
 }
 
 // AssertOutput returns an error if output is not a valid logger output.
 func AssertOutput(o string) error {
-	if o == CONSOLE || o == JOURNAL {
+	if o == CONSOLE || o == JOURNAL || o == EVENTLOG || o == SYSLOG {
 		return nil
 	}
 
@@ -97,5 +185,7 @@ func AssertOutput(o string) error {
 }
 
 func invalidOutput(o string) error {
-	return fmt.Errorf("%s is not a valid logger output. Must be either %q or %q", o, CONSOLE, JOURNAL)
+	return fmt.Errorf(
+		"%s is not a valid logger output. Must be one of %q, %q, %q or %q", o, CONSOLE, JOURNAL, EVENTLOG, SYSLOG,
+	)
 }
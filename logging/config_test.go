@@ -6,7 +6,9 @@ import (
 	"github.com/icinga/icinga-go-library/config"
 	"github.com/icinga/icinga-go-library/testutils"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 	"os"
 	"testing"
 	"time"
@@ -62,8 +64,71 @@ interval: 3m14s`,
 				Level:    zapcore.DebugLevel,
 				Output:   JOURNAL,
 				Interval: 3*time.Minute + 14*time.Second,
+				Syslog:   defaultConfig.Syslog,
 			},
 		},
+		{
+			Name: "Syslog",
+			Data: testutils.ConfigTestData{
+				Yaml: fmt.Sprintf(
+					`
+output: %s
+syslog:
+  network: udp
+  address: localhost:514
+  facility: local0`,
+					SYSLOG,
+				),
+				Env: map[string]string{
+					"OUTPUT":          SYSLOG,
+					"SYSLOG_NETWORK":  "udp",
+					"SYSLOG_ADDRESS":  "localhost:514",
+					"SYSLOG_FACILITY": "local0",
+				},
+			},
+			Expected: Config{
+				Output:   SYSLOG,
+				Interval: defaultConfig.Interval,
+				Syslog:   SyslogConfig{Network: "udp", Address: "localhost:514", Facility: "local0"},
+			},
+		},
+		{
+			Name: "Syslog address missing",
+			Data: testutils.ConfigTestData{
+				Yaml: fmt.Sprintf("output: %s\nsyslog:\n  network: tcp", SYSLOG),
+				Env:  map[string]string{"OUTPUT": SYSLOG, "SYSLOG_NETWORK": "tcp"},
+			},
+			Error: testutils.ErrorContains(`syslog address missing for network "tcp"`),
+		},
+		{
+			Name: "Invalid syslog facility",
+			Data: testutils.ConfigTestData{
+				Yaml: fmt.Sprintf("output: %s\nsyslog:\n  facility: invalid", SYSLOG),
+				Env:  map[string]string{"OUTPUT": SYSLOG, "SYSLOG_FACILITY": "invalid"},
+			},
+			Error: testutils.ErrorContains(`invalid syslog facility "invalid"`),
+		},
+		{
+			Name: "Recent entries",
+			Data: testutils.ConfigTestData{
+				Yaml: `recent_entries: 100`,
+				Env:  map[string]string{"RECENT_ENTRIES": "100"},
+			},
+			Expected: Config{
+				Output:        defaultConfig.Output,
+				Interval:      defaultConfig.Interval,
+				Syslog:        defaultConfig.Syslog,
+				RecentEntries: 100,
+			},
+		},
+		{
+			Name: "recent_entries cannot be negative",
+			Data: testutils.ConfigTestData{
+				Yaml: `recent_entries: -1`,
+				Env:  map[string]string{"RECENT_ENTRIES": "-1"},
+			},
+			Error: testutils.ErrorContains("recent_entries cannot be negative"),
+		},
 		{
 			Name: "Options",
 			Data: testutils.ConfigTestData{
@@ -77,6 +142,7 @@ options:
 			Expected: Config{
 				Output:   defaultConfig.Output,
 				Interval: defaultConfig.Interval,
+				Syslog:   defaultConfig.Syslog,
 				Options: map[string]zapcore.Level{
 					"foo": zapcore.DebugLevel,
 					"bar": zapcore.InfoLevel,
@@ -84,6 +150,61 @@ options:
 				},
 			},
 		},
+		{
+			Name: "Component outputs",
+			Data: testutils.ConfigTestData{
+				Yaml: fmt.Sprintf(`
+component_outputs:
+  database: [%s, %s]
+  redis: [%s]`, CONSOLE, JOURNAL, JOURNAL),
+				Env: map[string]string{
+					"COMPONENT_OUTPUTS": fmt.Sprintf("database:%s|%s,redis:%s", CONSOLE, JOURNAL, JOURNAL),
+				},
+			},
+			Expected: Config{
+				Output:   defaultConfig.Output,
+				Interval: defaultConfig.Interval,
+				Syslog:   defaultConfig.Syslog,
+				ComponentOutputs: ComponentOutputs{
+					"database": {CONSOLE, JOURNAL},
+					"redis":    {JOURNAL},
+				},
+			},
+		},
+		{
+			Name: "Component outputs with invalid output",
+			Data: testutils.ConfigTestData{
+				Yaml: `
+component_outputs:
+  database: [invalid]`,
+				Env: map[string]string{"COMPONENT_OUTPUTS": "database:invalid"},
+			},
+			Error: testutils.ErrorContains(`invalid output for component "database"`),
+		},
+		{
+			Name: "Caller and stacktraces",
+			Data: testutils.ConfigTestData{
+				Yaml: `
+caller: true
+stacktrace_level: error`,
+				Env: map[string]string{"CALLER": "true", "STACKTRACE_LEVEL": "error"},
+			},
+			Expected: Config{
+				Output:          defaultConfig.Output,
+				Interval:        defaultConfig.Interval,
+				Syslog:          defaultConfig.Syslog,
+				Caller:          true,
+				StacktraceLevel: ptrTo(zapcore.ErrorLevel),
+			},
+		},
+		{
+			Name: "Invalid stacktrace level",
+			Data: testutils.ConfigTestData{
+				Yaml: `stacktrace_level: foo`,
+				Env:  map[string]string{"STACKTRACE_LEVEL": "foo"},
+			},
+			Error: testutils.ErrorContains(`unrecognized level: "foo"`),
+		},
 		{
 			Name: "Options with invalid level",
 			Data: testutils.ConfigTestData{
@@ -123,3 +244,45 @@ options:
 		}
 	})
 }
+
+func TestConfig_zapOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		caller     bool
+		stacktrace []zapcore.Level
+	}{
+		{"defaults", Config{}, false, nil},
+		{"caller", Config{Caller: true}, true, nil},
+		{"stacktrace level", Config{StacktraceLevel: ptrTo(zapcore.ErrorLevel)}, false, []zapcore.Level{zapcore.ErrorLevel}},
+		{"stacktrace level debug", Config{StacktraceLevel: ptrTo(zapcore.DebugLevel)}, false, []zapcore.Level{
+			zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			logger := zap.New(core, tt.config.zapOptions()...)
+
+			levels := []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel}
+			for _, level := range levels {
+				logger.Log(level, level.String())
+			}
+
+			var stacktrace []zapcore.Level
+			for _, entry := range logs.All() {
+				require.Equal(t, tt.caller, entry.Caller.Defined, "caller of %s entry", entry.Level)
+				if entry.Stack != "" {
+					stacktrace = append(stacktrace, entry.Level)
+				}
+			}
+
+			require.Equal(t, tt.stacktrace, stacktrace)
+		})
+	}
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
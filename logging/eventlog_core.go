@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"slices"
+	"strings"
+)
+
+// eventLogWriter is implemented by *eventlog.Log, allowing the core to be tested on non-Windows platforms.
+type eventLogWriter interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+}
+
+// Event IDs used for log entries written to the Windows Event Log, one per event type.
+const (
+	eventIdInfo    uint32 = 1
+	eventIdWarning uint32 = 2
+	eventIdError   uint32 = 3
+)
+
+type eventLogCore struct {
+	zapcore.LevelEnabler
+	context    []zapcore.Field
+	identifier string
+	writer     eventLogWriter
+}
+
+// withLevelEnabler returns a copy of core, which must be an event log core, with the given zapcore.LevelEnabler.
+func withLevelEnabler(core zapcore.Core, enab zapcore.LevelEnabler) zapcore.Core {
+	cc := *core.(*eventLogCore)
+	cc.LevelEnabler = enab
+
+	return &cc
+}
+
+func (c *eventLogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *eventLogCore) Sync() error {
+	return nil
+}
+
+func (c *eventLogCore) With(fields []zapcore.Field) zapcore.Core {
+	cc := *c
+	cc.context = append(cc.context[:len(cc.context):len(cc.context)], fields...)
+
+	return &cc
+}
+
+// Write maps the level of the entry to an event type and writes the entry to the event log.
+// As the event log does not support structured data, all fields are flattened into the message.
+func (c *eventLogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	message := ent.Message
+	if ent.LoggerName != c.identifier {
+		message = ent.LoggerName + ": " + message
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	for _, field := range c.context {
+		field.AddTo(enc)
+	}
+
+	message = flattenFieldsIntoMessage(message, enc.Fields)
+
+	switch {
+	case ent.Level >= zapcore.ErrorLevel:
+		return c.writer.Error(eventIdError, message)
+	case ent.Level == zapcore.WarnLevel:
+		return c.writer.Warning(eventIdWarning, message)
+	default:
+		return c.writer.Info(eventIdInfo, message)
+	}
+}
+
+// flattenFieldsIntoMessage appends the given fields sorted by key to message, one key=value pair per line.
+func flattenFieldsIntoMessage(message string, fields map[string]any) string {
+	if len(fields) == 0 {
+		return message
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var sb strings.Builder
+	sb.WriteString(message)
+	sb.WriteString("\n")
+
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(&sb, "\n%s=%v", key, fields[key])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package logging
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewEventLogCore always returns an error as the Windows Event Log is only available on Windows.
+func NewEventLogCore(string, zapcore.LevelEnabler) (zapcore.Core, error) {
+	return nil, errors.New("the Windows Event Log is only available on Windows")
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"testing"
+)
+
+type eventLogRecord struct {
+	typ string
+	eid uint32
+	msg string
+}
+
+type testEventLogWriter struct {
+	records []eventLogRecord
+}
+
+func (w *testEventLogWriter) Info(eid uint32, msg string) error {
+	w.records = append(w.records, eventLogRecord{"info", eid, msg})
+	return nil
+}
+
+func (w *testEventLogWriter) Warning(eid uint32, msg string) error {
+	w.records = append(w.records, eventLogRecord{"warning", eid, msg})
+	return nil
+}
+
+func (w *testEventLogWriter) Error(eid uint32, msg string) error {
+	w.records = append(w.records, eventLogRecord{"error", eid, msg})
+	return nil
+}
+
+func TestEventLogCore(t *testing.T) {
+	w := &testEventLogWriter{}
+	core := &eventLogCore{LevelEnabler: zapcore.DebugLevel, identifier: "test", writer: w}
+
+	logger := zap.New(core).Named("test")
+	logger.Debug("debug")
+	logger.Named("child").With(zap.String("ctx", "value")).Warn("warning", zap.Int("count", 42))
+	logger.Error("error")
+
+	require.Equal(t, []eventLogRecord{
+		{"info", eventIdInfo, "debug"},
+		{"warning", eventIdWarning, "test.child: warning\n\ncount=42\nctx=value"},
+		{"error", eventIdError, "error"},
+	}, w.records)
+}
+
+func TestEventLogCore_withLevelEnabler(t *testing.T) {
+	w := &testEventLogWriter{}
+	core := withLevelEnabler(&eventLogCore{LevelEnabler: zapcore.DebugLevel, identifier: "test", writer: w}, zapcore.WarnLevel)
+
+	logger := zap.New(core).Named("test")
+	logger.Info("info")
+	logger.Warn("warning")
+
+	require.Equal(t, []eventLogRecord{{"warning", eventIdWarning, "warning"}}, w.records)
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package logging
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// NewEventLogCore returns a zapcore.Core that writes log entries to the Windows Event Log
+// using the given identifier as event source. Structured logging context is flattened into the message.
+// The event source should be registered beforehand, e.g. via eventlog.InstallAsEventCreate,
+// otherwise Windows prepends a notice about the missing event description to each message.
+func NewEventLogCore(identifier string, enab zapcore.LevelEnabler) (zapcore.Core, error) {
+	l, err := eventlog.Open(identifier)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't open Windows Event Log for source %q", identifier)
+	}
+
+	return &eventLogCore{
+		LevelEnabler: enab,
+		identifier:   identifier,
+		writer:       l,
+	}, nil
+}
@@ -1,3 +1,5 @@
+//go:build !windows
+
 package logging
 
 import (
@@ -64,6 +66,19 @@ func (c *journaldCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
 	c.addFields(enc, c.context)
 	enc.Fields["SYSLOG_IDENTIFIER"] = c.identifier
 
+	// Use the well-known journal fields for the caller, see systemd.journal-fields(7).
+	if ent.Caller.Defined {
+		enc.Fields["CODE_FILE"] = ent.Caller.File
+		enc.Fields["CODE_LINE"] = ent.Caller.Line
+		if ent.Caller.Function != "" {
+			enc.Fields["CODE_FUNC"] = ent.Caller.Function
+		}
+	}
+
+	if ent.Stack != "" {
+		enc.Fields[encodeJournaldFieldKey(c.identifier+"_stacktrace")] = ent.Stack
+	}
+
 	message := ent.Message
 	if ent.LoggerName != c.identifier {
 		message = ent.LoggerName + ": " + message
@@ -0,0 +1,37 @@
+//go:build windows
+
+package logging
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewJournaldCore returns a zapcore.Core whose writes always fail, as systemd-journald is not available on Windows.
+func NewJournaldCore(identifier string, enab zapcore.LevelEnabler) zapcore.Core {
+	return journaldCore{LevelEnabler: enab}
+}
+
+type journaldCore struct {
+	zapcore.LevelEnabler
+}
+
+func (c journaldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (journaldCore) Sync() error {
+	return nil
+}
+
+func (c journaldCore) With([]zapcore.Field) zapcore.Core {
+	return c
+}
+
+func (journaldCore) Write(zapcore.Entry, []zapcore.Field) error {
+	return errors.New("systemd-journald is not available on Windows")
+}
@@ -9,8 +9,10 @@ import (
 )
 
 const (
-	CONSOLE = "console"
-	JOURNAL = "systemd-journald"
+	CONSOLE  = "console"
+	JOURNAL  = "systemd-journald"
+	EVENTLOG = "windows-eventlog"
+	SYSLOG   = "syslog"
 )
 
 // defaultEncConfig defines the default zapcore.EncoderConfig for the logging package.
@@ -31,7 +33,8 @@ var defaultEncConfig = zapcore.EncoderConfig{
 // Logging implements access to a default logger and named child loggers.
 // Log levels can be configured per named child via Options which, if not configured,
 // fall back on a default log level.
-// Logs either to the console or to systemd-journald.
+// Logs either to the console, to systemd-journald, to the Windows Event Log or to syslog.
+// Named child loggers can be routed to one or more other outputs via ComponentOutputs.
 type Logging struct {
 	logger    *Logger
 	output    string
@@ -41,57 +44,164 @@ type Logging struct {
 	// coreFactory creates zapcore.Core based on the log level and the log output.
 	coreFactory func(zap.AtomicLevel) zapcore.Core
 
+	// componentCoreFactories creates zapcore.Core for the named child loggers routed via ComponentOutputs.
+	componentCoreFactories map[string]func(zap.AtomicLevel) zapcore.Core
+
+	// recent keeps the most recent log entries if enabled via Config.RecentEntries.
+	recent *recentEntries
+
 	mu      sync.Mutex
 	loggers map[string]*Logger
 
 	options Options
+
+	// zapOptions configure the caller annotation and stacktraces of the default and all child loggers.
+	zapOptions []zap.Option
 }
 
 // NewLogging takes the name and log level for the default logger,
 // output where log messages are written to,
 // options having log levels for named child loggers
 // and returns a new Logging.
+// The syslog output sends to the local syslog socket with the facility daemon.
+// Use NewLoggingFromConfig to configure it and to route named child loggers to other outputs.
 func NewLogging(name string, level zapcore.Level, output string, options Options, interval time.Duration) (*Logging, error) {
-	verbosity := zap.NewAtomicLevelAt(level)
+	return NewLoggingFromConfig(name, Config{
+		Level:    level,
+		Output:   output,
+		Interval: interval,
+		Options:  options,
+		Syslog:   SyslogConfig{Facility: "daemon"},
+	})
+}
+
+// NewLoggingFromConfig returns a new Logging from Config.
+func NewLoggingFromConfig(name string, c Config) (*Logging, error) {
+	verbosity := zap.NewAtomicLevelAt(c.Level)
+
+	// Create the core factory of each output only once, so that outputs shared by
+	// the default and multiple child loggers also share their connection or handle.
+	outputCoreFactories := make(map[string]func(zap.AtomicLevel) zapcore.Core)
+	getCoreFactory := func(output string) (func(zap.AtomicLevel) zapcore.Core, error) {
+		if factory, ok := outputCoreFactories[output]; ok {
+			return factory, nil
+		}
+
+		factory, err := newCoreFactory(name, output, verbosity, c.Syslog)
+		if err != nil {
+			return nil, err
+		}
+
+		outputCoreFactories[output] = factory
+
+		return factory, nil
+	}
+
+	coreFactory, err := getCoreFactory(c.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	componentCoreFactories := make(map[string]func(zap.AtomicLevel) zapcore.Core, len(c.ComponentOutputs))
+	for component, outputs := range c.ComponentOutputs {
+		factories := make([]func(zap.AtomicLevel) zapcore.Core, 0, len(outputs))
+		for _, output := range outputs {
+			factory, err := getCoreFactory(output)
+			if err != nil {
+				return nil, err
+			}
+
+			factories = append(factories, factory)
+		}
+
+		componentCoreFactories[component] = func(verbosity zap.AtomicLevel) zapcore.Core {
+			cores := make([]zapcore.Core, 0, len(factories))
+			for _, factory := range factories {
+				cores = append(cores, factory(verbosity))
+			}
+
+			return zapcore.NewTee(cores...)
+		}
+	}
+
+	var recent *recentEntries
+	if c.RecentEntries > 0 {
+		recent = newRecentEntries(c.RecentEntries)
+	}
+
+	core := withRecent(coreFactory(verbosity), recent, name, verbosity)
+	zapOptions := c.zapOptions()
+	logger := NewLogger(zap.New(core, zapOptions...).Named(name).Sugar(), c.Interval)
 
-	var coreFactory func(zap.AtomicLevel) zapcore.Core
+	return &Logging{
+			logger:                 logger,
+			output:                 c.Output,
+			verbosity:              verbosity,
+			interval:               c.Interval,
+			coreFactory:            coreFactory,
+			componentCoreFactories: componentCoreFactories,
+			recent:                 recent,
+			loggers:                make(map[string]*Logger),
+			options:                c.Options,
+			zapOptions:             zapOptions,
+		},
+		nil
+}
+
+// withRecent returns core teed with a core that keeps the recent entries of the given logger, if recent is not nil.
+func withRecent(core zapcore.Core, recent *recentEntries, component string, verbosity zap.AtomicLevel) zapcore.Core {
+	if recent == nil {
+		return core
+	}
+
+	return zapcore.NewTee(core, recent.core(component, verbosity))
+}
+
+// newCoreFactory returns a function that creates zapcore.Core for the given output based on the log level.
+func newCoreFactory(
+	name string, output string, verbosity zap.AtomicLevel, syslog SyslogConfig,
+) (func(zap.AtomicLevel) zapcore.Core, error) {
 	switch output {
 	case CONSOLE:
 		enc := zapcore.NewConsoleEncoder(defaultEncConfig)
 		ws := zapcore.Lock(os.Stderr)
-		coreFactory = func(verbosity zap.AtomicLevel) zapcore.Core {
+
+		return func(verbosity zap.AtomicLevel) zapcore.Core {
 			return zapcore.NewCore(enc, ws, verbosity)
-		}
+		}, nil
 	case JOURNAL:
-		coreFactory = func(verbosity zap.AtomicLevel) zapcore.Core {
+		return func(verbosity zap.AtomicLevel) zapcore.Core {
 			return NewJournaldCore(name, verbosity)
+		}, nil
+	case EVENTLOG:
+		// Open the event log only once and share the handle between the default and all child loggers.
+		core, err := NewEventLogCore(name, verbosity)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(verbosity zap.AtomicLevel) zapcore.Core {
+			return withLevelEnabler(core, verbosity)
+		}, nil
+	case SYSLOG:
+		// Share the connection between the default and all child loggers.
+		core, err := NewSyslogCore(name, verbosity, syslog)
+		if err != nil {
+			return nil, err
 		}
+
+		return func(verbosity zap.AtomicLevel) zapcore.Core {
+			return withSyslogLevelEnabler(core, verbosity)
+		}, nil
 	default:
 		return nil, invalidOutput(output)
 	}
-
-	logger := NewLogger(zap.New(coreFactory(verbosity)).Named(name).Sugar(), interval)
-
-	return &Logging{
-			logger:      logger,
-			output:      output,
-			verbosity:   verbosity,
-			interval:    interval,
-			coreFactory: coreFactory,
-			loggers:     make(map[string]*Logger),
-			options:     options,
-		},
-		nil
-}
-
-// NewLoggingFromConfig returns a new Logging from Config.
-func NewLoggingFromConfig(name string, c Config) (*Logging, error) {
-	return NewLogging(name, c.Level, c.Output, c.Options, c.Interval)
 }
 
 // GetChildLogger returns a named child logger.
 // Log levels for named child loggers are obtained from the logging options and, if not found,
 // set to the default log level.
+// Child loggers are routed to the outputs configured in ComponentOutputs and, if not found, to the default output.
 func (l *Logging) GetChildLogger(name string) *Logger {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -107,7 +217,13 @@ func (l *Logging) GetChildLogger(name string) *Logger {
 		verbosity = l.verbosity
 	}
 
-	logger := NewLogger(zap.New(l.coreFactory(verbosity)).Named(name).Sugar(), l.interval)
+	coreFactory := l.coreFactory
+	if factory, ok := l.componentCoreFactories[name]; ok {
+		coreFactory = factory
+	}
+
+	core := withRecent(coreFactory(verbosity), l.recent, name, verbosity)
+	logger := NewLogger(zap.New(core, l.zapOptions...).Named(name).Sugar(), l.interval)
 	l.loggers[name] = logger
 
 	return logger
@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"github.com/stretchr/testify/require"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestLogging_GetChildLogger(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	logging, err := NewLoggingFromConfig("test", Config{
+		Output:           CONSOLE,
+		Interval:         time.Second,
+		ComponentOutputs: ComponentOutputs{"routed": {CONSOLE, SYSLOG}},
+		Syslog:           SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: "daemon"},
+	})
+	require.NoError(t, err)
+
+	logging.GetLogger().Info("default")
+	logging.GetChildLogger("other").Info("other")
+	logging.GetChildLogger("routed").Info("routed")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Regexp(t, ` routed: routed$`, string(buf[:n]))
+}
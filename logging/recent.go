@@ -0,0 +1,198 @@
+package logging
+
+import (
+	"encoding/json"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is a log entry kept in memory, see Config.RecentEntries and Logging.Recent.
+type Entry struct {
+	Time      time.Time      `json:"time"`
+	Level     zapcore.Level  `json:"level"`
+	Component string         `json:"component"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// Recent returns the most recent log entries of the named child logger, or of the default logger if component
+// is its name, with at least the given level, oldest first. If component is empty, the entries of all loggers
+// are returned. Returns nil if keeping recent entries is disabled, see Config.RecentEntries.
+func (l *Logging) Recent(component string, level zapcore.Level) []Entry {
+	if l.recent == nil {
+		return nil
+	}
+
+	return l.recent.entries(component, level)
+}
+
+// RecentHandler returns an http.Handler that responds with the entries returned by Recent as JSON.
+// The component and the minimum level can be specified via the query parameters "component" and "level",
+// the latter defaulting to debug.
+func (l *Logging) RecentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		level := zapcore.DebugLevel
+		if s := r.URL.Query().Get("level"); s != "" {
+			var err error
+			if level, err = zapcore.ParseLevel(s); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+		}
+
+		entries := l.Recent(r.URL.Query().Get("component"), level)
+		if entries == nil {
+			entries = []Entry{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
+
+// recentEntries keeps the most recent log entries of each logger in ring buffers.
+type recentEntries struct {
+	size int
+
+	mu    sync.Mutex
+	rings map[string]*ring
+}
+
+// newRecentEntries returns a new recentEntries keeping up to size entries per logger.
+func newRecentEntries(size int) *recentEntries {
+	return &recentEntries{size: size, rings: make(map[string]*ring)}
+}
+
+// core returns a zapcore.Core that records the entries of the given logger enabled by enab.
+func (r *recentEntries) core(component string, enab zapcore.LevelEnabler) zapcore.Core {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rg, ok := r.rings[component]
+	if !ok {
+		rg = &ring{entries: make([]Entry, 0, r.size)}
+		r.rings[component] = rg
+	}
+
+	return &recentCore{LevelEnabler: enab, component: component, ring: rg}
+}
+
+// entries implements Logging.Recent.
+func (r *recentEntries) entries(component string, level zapcore.Level) []Entry {
+	r.mu.Lock()
+	rings := make([]*ring, 0, len(r.rings))
+	for c, rg := range r.rings {
+		if component == "" || c == component {
+			rings = append(rings, rg)
+		}
+	}
+	r.mu.Unlock()
+
+	var entries []Entry
+	for _, rg := range rings {
+		entries = append(entries, rg.snapshot(level)...)
+	}
+
+	if len(rings) > 1 {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	}
+
+	return entries
+}
+
+// ring is a fixed-size ring buffer of log entries.
+type ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+}
+
+// add adds e, overwriting the oldest entry if the ring is full.
+func (rg *ring) add(e Entry) {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	if len(rg.entries) < cap(rg.entries) {
+		rg.entries = append(rg.entries, e)
+
+		return
+	}
+
+	rg.entries[rg.next] = e
+	rg.next = (rg.next + 1) % len(rg.entries)
+}
+
+// snapshot returns the entries with at least the given level, oldest first.
+func (rg *ring) snapshot(level zapcore.Level) []Entry {
+	rg.mu.Lock()
+	defer rg.mu.Unlock()
+
+	entries := make([]Entry, 0, len(rg.entries))
+	for i := range len(rg.entries) {
+		if e := rg.entries[(rg.next+i)%len(rg.entries)]; e.Level >= level {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// recentCore is a zapcore.Core that records log entries in a ring.
+type recentCore struct {
+	zapcore.LevelEnabler
+	component string
+	fields    []zapcore.Field
+	ring      *ring
+}
+
+// With implements the zapcore.Core interface.
+func (c *recentCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = append(c.fields[:len(c.fields):len(c.fields)], fields...)
+
+	return &clone
+}
+
+// Check implements the zapcore.Core interface.
+func (c *recentCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+
+	return ce
+}
+
+// Write implements the zapcore.Core interface.
+func (c *recentCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	e := Entry{Time: entry.Time, Level: entry.Level, Component: c.component, Message: entry.Message}
+
+	if len(c.fields)+len(fields) > 0 {
+		enc := zapcore.NewMapObjectEncoder()
+		for _, field := range c.fields {
+			field.AddTo(enc)
+		}
+		for _, field := range fields {
+			field.AddTo(enc)
+		}
+
+		e.Fields = enc.Fields
+	}
+
+	c.ring.add(e)
+
+	return nil
+}
+
+// Sync implements the zapcore.Core interface.
+func (c *recentCore) Sync() error {
+	return nil
+}
+
+// Assert interface compliance.
+var (
+	_ zapcore.Core = (*recentCore)(nil)
+)
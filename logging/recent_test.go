@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLogging_Recent(t *testing.T) {
+	logging, err := NewLoggingFromConfig("test", Config{
+		Level:         zapcore.DebugLevel,
+		Output:        CONSOLE,
+		Interval:      time.Second,
+		RecentEntries: 2,
+	})
+	require.NoError(t, err)
+
+	database := logging.GetChildLogger("database")
+	database.Debug("first")
+	database.With("table", "host").Errorw("second", "attempt", 1)
+	database.Warn("third")
+	logging.GetLogger().Error("default")
+
+	messages := func(entries []Entry) []string {
+		var messages []string
+		for _, e := range entries {
+			messages = append(messages, e.Message)
+		}
+
+		return messages
+	}
+
+	recent := logging.Recent("database", zapcore.DebugLevel)
+	require.Equal(t, []string{"second", "third"}, messages(recent), "oldest entries must be overwritten")
+	require.Equal(t, map[string]any{"table": "host", "attempt": int64(1)}, recent[0].Fields)
+
+	require.Equal(t, []string{"second"}, messages(logging.Recent("database", zapcore.ErrorLevel)))
+	require.Equal(t, []string{"second", "default"}, messages(logging.Recent("", zapcore.ErrorLevel)))
+	require.Empty(t, logging.Recent("redis", zapcore.DebugLevel))
+
+	t.Run("RecentHandler", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		logging.RecentHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?component=test&level=error", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var entries []Entry
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+		require.Equal(t, []string{"default"}, messages(entries))
+		require.Equal(t, "test", entries[0].Component)
+		require.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+
+		rec = httptest.NewRecorder()
+		logging.RecentHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=invalid", nil))
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		logging, err := NewLoggingFromConfig("test", Config{Output: CONSOLE, Interval: time.Second})
+		require.NoError(t, err)
+
+		logging.GetLogger().Error("error")
+		require.Nil(t, logging.Recent("", zapcore.DebugLevel))
+	})
+}
@@ -0,0 +1,291 @@
+package logging
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogSeverities maps zapcore.Level to syslog severities as defined in RFC 5424.
+var syslogSeverities = map[zapcore.Level]int{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.FatalLevel:  2,
+	zapcore.PanicLevel:  2,
+	zapcore.DPanicLevel: 2,
+}
+
+// syslogFacilities maps facility names to their numerical codes as defined in RFC 5424.
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11, "local0": 16, "local1": 17, "local2": 18,
+	"local3": 19, "local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSdId is the SD-ID of the structured data element holding the zap fields.
+// 32473 is the private enterprise number reserved for documentation (RFC 5612).
+const syslogSdId = "fields@32473"
+
+// syslogLocalAddrs are the paths of the local syslog socket tried if no network is configured.
+var syslogLocalAddrs = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogConfig defines the destination of log messages for the syslog output.
+type SyslogConfig struct {
+	// Network is one of unix, unixgram, tcp or udp.
+	// If empty, the local syslog socket is used and Address is ignored.
+	Network  string `yaml:"network" env:"NETWORK"`
+	Address  string `yaml:"address" env:"ADDRESS"`
+	Facility string `yaml:"facility" env:"FACILITY" default:"daemon"`
+}
+
+// Validate checks constraints in the configuration and returns an error if they are violated.
+func (c *SyslogConfig) Validate() error {
+	if _, ok := syslogFacilities[c.Facility]; !ok {
+		return errors.Errorf("invalid syslog facility %q", c.Facility)
+	}
+
+	switch c.Network {
+	case "":
+	case "unix", "unixgram", "tcp", "udp":
+		if c.Address == "" {
+			return errors.Errorf("syslog address missing for network %q", c.Network)
+		}
+	default:
+		return errors.Errorf("invalid syslog network %q. Must be one of unix, unixgram, tcp or udp", c.Network)
+	}
+
+	return nil
+}
+
+// NewSyslogCore returns a zapcore.Core that sends log entries to syslog in the RFC 5424 format
+// with all fields as structured data, using the given identifier as APP-NAME.
+// The connection is established on the first write and re-established once per write if it fails.
+func NewSyslogCore(identifier string, enab zapcore.LevelEnabler, c SyslogConfig) (zapcore.Core, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	return &syslogCore{
+		LevelEnabler: enab,
+		identifier:   identifier,
+		hostname:     hostname,
+		facility:     syslogFacilities[c.Facility],
+		writer:       &syslogWriter{network: c.Network, address: c.Address},
+	}, nil
+}
+
+type syslogCore struct {
+	zapcore.LevelEnabler
+	context    []zapcore.Field
+	identifier string
+	hostname   string
+	facility   int
+	writer     *syslogWriter
+}
+
+func (c *syslogCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+func (c *syslogCore) With(fields []zapcore.Field) zapcore.Core {
+	cc := *c
+	cc.context = append(cc.context[:len(cc.context):len(cc.context)], fields...)
+
+	return &cc
+}
+
+func (c *syslogCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	severity, ok := syslogSeverities[ent.Level]
+	if !ok {
+		return errors.Errorf("unknown log level %q", ent.Level)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	for _, field := range c.context {
+		field.AddTo(enc)
+	}
+
+	message := ent.Message
+	if ent.LoggerName != c.identifier {
+		message = ent.LoggerName + ": " + message
+	}
+
+	return c.writer.write(formatRfc5424(
+		c.facility*8+severity, ent.Time, c.hostname, c.identifier, os.Getpid(), enc.Fields, message,
+	))
+}
+
+// withSyslogLevelEnabler returns a copy of core, which must be a syslog core, with the given zapcore.LevelEnabler.
+// The copy shares the connection with core.
+func withSyslogLevelEnabler(core zapcore.Core, enab zapcore.LevelEnabler) zapcore.Core {
+	cc := *core.(*syslogCore)
+	cc.LevelEnabler = enab
+
+	return &cc
+}
+
+// formatRfc5424 formats a syslog message as defined in RFC 5424 with fields as structured data sorted by key.
+func formatRfc5424(
+	pri int, ts time.Time, hostname, appName string, procId int, fields map[string]any, message string,
+) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "<%d>1 %s %s %s %d - ",
+		pri, ts.Format(time.RFC3339Nano), syslogHeaderField(hostname, 255), syslogHeaderField(appName, 48), procId)
+
+	if len(fields) == 0 {
+		sb.WriteString("-")
+	} else {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		sb.WriteString("[" + syslogSdId)
+		for _, key := range keys {
+			_, _ = fmt.Fprintf(&sb, ` %s="%s"`, syslogSdName(key), syslogSdValue(fmt.Sprint(fields[key])))
+		}
+		sb.WriteString("]")
+	}
+
+	sb.WriteString(" ")
+	sb.WriteString(message)
+
+	return sb.String()
+}
+
+// syslogHeaderField returns s truncated to maxLen characters with all characters
+// not allowed in header fields, i.e. not printable US-ASCII, replaced by "_", or "-" if s is empty.
+func syslogHeaderField(s string, maxLen int) string {
+	if s == "" {
+		return "-"
+	}
+
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, s)
+
+	return s[:min(len(s), maxLen)]
+}
+
+// syslogSdName returns s as a valid SD-NAME, i.e. at most 32 printable US-ASCII characters except '=', ' ', ']' and '"'.
+func syslogSdName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, s)
+
+	return s[:min(len(s), 32)]
+}
+
+// syslogSdValueEscaper escapes the characters which must be escaped in PARAM-VALUE.
+var syslogSdValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogSdValue returns s escaped as PARAM-VALUE.
+func syslogSdValue(s string) string {
+	return syslogSdValueEscaper.Replace(s)
+}
+
+// syslogWriter sends messages over a lazily established connection, which is re-established on errors.
+type syslogWriter struct {
+	network string
+	address string
+
+	mu sync.Mutex
+	// conn is the current connection, if any, and connNetwork its network.
+	conn        net.Conn
+	connNetwork string
+}
+
+// write sends the message, reconnecting once if sending fails.
+func (w *syslogWriter) write(message string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			if w.conn, w.connNetwork, err = w.dial(); err != nil {
+				continue
+			}
+		}
+
+		if _, err = w.conn.Write(w.frame(message)); err == nil {
+			return nil
+		}
+
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	return errors.Wrap(err, "can't write to syslog")
+}
+
+// frame returns the message as sent on the connection.
+// Stream connections require a framing: Octet counting as defined in RFC 6587 for TCP and,
+// as expected by local syslog daemons, a trailing newline for UNIX stream sockets.
+func (w *syslogWriter) frame(message string) []byte {
+	switch w.connNetwork {
+	case "tcp":
+		return []byte(strconv.Itoa(len(message)) + " " + message)
+	case "unix":
+		return []byte(message + "\n")
+	default:
+		return []byte(message)
+	}
+}
+
+// dial connects to the configured address or, if no network is configured, to the local syslog socket.
+// Returns the connection and its network.
+func (w *syslogWriter) dial() (net.Conn, string, error) {
+	if w.network != "" {
+		conn, err := net.DialTimeout(w.network, w.address, 10*time.Second)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "can't connect to syslog at %s://%s", w.network, w.address)
+		}
+
+		return conn, w.network, nil
+	}
+
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, addr := range syslogLocalAddrs {
+			if conn, err := net.Dial(network, addr); err == nil {
+				return conn, network, nil
+			}
+		}
+	}
+
+	return nil, "", errors.New("can't connect to local syslog socket")
+}
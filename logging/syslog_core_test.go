@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFormatRfc5424(t *testing.T) {
+	ts := time.Date(2024, time.January, 2, 3, 4, 5, 600000000, time.UTC)
+
+	require.Equal(t,
+		`<30>1 2024-01-02T03:04:05.6Z host app 42 - - message`,
+		formatRfc5424(30, ts, "host", "app", 42, nil, "message"),
+	)
+
+	require.Equal(t,
+		`<27>1 2024-01-02T03:04:05.6Z my_host - 42 - [fields@32473 a="1" b_c="x\"y\]z\\"] message`,
+		formatRfc5424(27, ts, "my host", "", 42, map[string]any{"b=c": `x"y]z\`, "a": 1}, "message"),
+	)
+}
+
+func TestSyslogCore(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	core, err := NewSyslogCore("test", zapcore.InfoLevel, SyslogConfig{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "local0",
+	})
+	require.NoError(t, err)
+
+	logger := zap.New(core).Named("test")
+	logger.Debug("debug")
+	logger.Named("child").Warn("warning", zap.Int("count", 42))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	// local0 (16) * 8 + warning (4)
+	require.Regexp(t,
+		`^<132>1 \S+ \S+ test `+strconv.Itoa(os.Getpid())+` - \[fields@32473 count="42"\] test\.child: warning$`,
+		string(buf[:n]),
+	)
+}
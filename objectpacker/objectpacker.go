@@ -2,6 +2,7 @@ package objectpacker
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 	"github.com/pkg/errors"
@@ -21,6 +22,23 @@ func MustPackSlice(items ...interface{}) []byte {
 	return buf.Bytes()
 }
 
+// PackAnyMust calls PackAny and panics if there was an error.
+func PackAnyMust(in interface{}, out io.Writer) {
+	if err := PackAny(in, out); err != nil {
+		panic(err)
+	}
+}
+
+// UnsupportedTypeError is returned by PackAny if the value to pack contains a type that can't be packed.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+// Error implements the error interface.
+func (e *UnsupportedTypeError) Error() string {
+	return "unsupported type " + e.Type.String()
+}
+
 // PackAny packs any JSON-encodable value (ex. structs, also ignores interfaces like encoding.TextMarshaler)
 // to a BSON-similar format suitable for consistent hashing. Spec:
 //
@@ -28,6 +46,7 @@ func MustPackSlice(items ...interface{}) []byte {
 // PackAny(false)          => 0x1
 // PackAny(true)           => 0x2
 // PackAny(float64(42))    => 0x3 ieee754_binary64_bigendian(42)
+// PackAny(int(42))        => PackAny(float64(42)), same for other integer and float types, like Icinga 2 does
 // PackAny("exämple")      => 0x4 uint64_bigendian(len([]byte("exämple"))) []byte("exämple")
 // PackAny([]uint8{0x42})  => 0x4 uint64_bigendian(len([]uint8{0x42})) []uint8{0x42}
 // PackAny([1]uint8{0x42}) => 0x4 uint64_bigendian(len([1]uint8{0x42})) [1]uint8{0x42}
@@ -35,19 +54,37 @@ func MustPackSlice(items ...interface{}) []byte {
 // PackAny(map[K]V{x:y})   => 0x6 uint64_bigendian(len(map[K]V{x:y})) len(map_key(x)) map_key(x) PackAny(y)
 // PackAny((*T)(nil))      => 0x0
 // PackAny((*T)(0x42))     => PackAny(*(*T)(0x42))
-// PackAny(x)              => panic()
+// PackAny(x)              => *UnsupportedTypeError
 //
 // map_key([1]uint8{0x42}) => [1]uint8{0x42}
 // map_key(x)              => []byte(fmt.Sprint(x))
 func PackAny(in interface{}, out io.Writer) error {
-	return errors.Wrapf(packValue(reflect.ValueOf(in), out), "can't pack %#v", in)
+	return PackAnyContext(context.Background(), in, out)
+}
+
+// PackAnyContext works like PackAny, but checks ctx between the elements of slices, arrays and maps,
+// so that packing huge nested structures can be canceled. If ctx is done, its error is returned.
+// As the packed data is written to out as it is generated, it can be streamed into a hash.Hash directly
+// without building an intermediate buffer.
+func PackAnyContext(ctx context.Context, in interface{}, out io.Writer) error {
+	if err := packValue(ctx, reflect.ValueOf(in), out); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return err
+		}
+
+		return errors.Wrapf(err, "can't pack %#v", in)
+	}
+
+	return nil
 }
 
 var tByte = reflect.TypeOf(byte(0))
 var tBytes = reflect.TypeOf([]uint8(nil))
 
 // packValue does the actual job of packAny and just exists for recursion w/o unnecessary reflect.ValueOf calls.
-func packValue(in reflect.Value, out io.Writer) error {
+// Empty slices and maps as well as nil pointers are checked for unsupported element types, too,
+// so that the result doesn't depend on whether there are any elements.
+func packValue(ctx context.Context, in reflect.Value, out io.Writer) error {
 	switch kind := in.Kind(); kind {
 	case reflect.Invalid: // nil
 		_, err := out.Write([]byte{0})
@@ -60,12 +97,12 @@ func packValue(in reflect.Value, out io.Writer) error {
 			_, err := out.Write([]byte{1})
 			return err
 		}
-	case reflect.Float64:
-		if _, err := out.Write([]byte{3}); err != nil {
-			return err
-		}
-
-		return binary.Write(out, binary.BigEndian, in.Float())
+	case reflect.Float32, reflect.Float64:
+		return packFloat(in.Float(), out)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return packFloat(float64(in.Int()), out)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return packFloat(float64(in.Uint()), out)
 	case reflect.Array, reflect.Slice:
 		if typ := in.Type(); typ.Elem() == tByte {
 			if kind == reflect.Array {
@@ -94,20 +131,24 @@ func packValue(in reflect.Value, out io.Writer) error {
 		}
 
 		for i := 0; i < l; i++ {
-			if err := packValue(in.Index(i), out); err != nil {
+			if err := checkContext(ctx); err != nil {
+				return err
+			}
+
+			if err := packValue(ctx, in.Index(i), out); err != nil {
 				return err
 			}
 		}
 
 		// If there aren't any values to pack, ...
 		if l < 1 {
-			// ... create one and pack it - panics on disallowed type.
-			_ = packValue(reflect.Zero(in.Type().Elem()), io.Discard)
+			// ... create one and pack it - fails on disallowed type.
+			return packValue(ctx, reflect.Zero(in.Type().Elem()), io.Discard)
 		}
 
 		return nil
 	case reflect.Interface:
-		return packValue(in.Elem(), out)
+		return packValue(ctx, in.Elem(), out)
 	case reflect.Map:
 		type kv struct {
 			key   []byte
@@ -140,14 +181,18 @@ func packValue(in reflect.Value, out io.Writer) error {
 
 						packedKey = key.Slice(0, key.Len()).Interface().([]byte)
 					} else {
-						// Not just stringify the key (below), but also pack it (here) - panics on disallowed type.
-						_ = packValue(iter.Key(), io.Discard)
+						// Not just stringify the key (below), but also pack it (here) - fails on disallowed type.
+						if err := packValue(ctx, iter.Key(), io.Discard); err != nil {
+							return err
+						}
 
 						packedKey = []byte(fmt.Sprint(key.Interface()))
 					}
 				} else {
-					// Not just stringify the key (below), but also pack it (here) - panics on disallowed type.
-					_ = packValue(iter.Key(), io.Discard)
+					// Not just stringify the key (below), but also pack it (here) - fails on disallowed type.
+					if err := packValue(ctx, iter.Key(), io.Discard); err != nil {
+						return err
+					}
 
 					packedKey = []byte(fmt.Sprint(key.Interface()))
 				}
@@ -159,6 +204,10 @@ func packValue(in reflect.Value, out io.Writer) error {
 		sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i].key, sorted[j].key) < 0 })
 
 		for _, kv := range sorted {
+			if err := checkContext(ctx); err != nil {
+				return err
+			}
+
 			if err := binary.Write(out, binary.BigEndian, uint64(len(kv.key))); err != nil {
 				return err
 			}
@@ -167,7 +216,7 @@ func packValue(in reflect.Value, out io.Writer) error {
 				return err
 			}
 
-			if err := packValue(kv.value, out); err != nil {
+			if err := packValue(ctx, kv.value, out); err != nil {
 				return err
 			}
 		}
@@ -176,30 +225,53 @@ func packValue(in reflect.Value, out io.Writer) error {
 		if l < 1 {
 			typ := in.Type()
 
-			// ... create one and pack it - panics on disallowed type.
-			_ = packValue(reflect.Zero(typ.Key()), io.Discard)
-			_ = packValue(reflect.Zero(typ.Elem()), io.Discard)
+			// ... create one and pack it - fails on disallowed type.
+			if err := packValue(ctx, reflect.Zero(typ.Key()), io.Discard); err != nil {
+				return err
+			}
+
+			return packValue(ctx, reflect.Zero(typ.Elem()), io.Discard)
 		}
 
 		return nil
 	case reflect.Ptr:
 		if in.IsNil() {
-			err := packValue(reflect.Value{}, out)
-
-			// Create a fictive referenced value and pack it - panics on disallowed type.
-			_ = packValue(reflect.Zero(in.Type().Elem()), io.Discard)
+			// Create a fictive referenced value and pack it - fails on disallowed type.
+			if err := packValue(ctx, reflect.Zero(in.Type().Elem()), io.Discard); err != nil {
+				return err
+			}
 
-			return err
+			return packValue(ctx, reflect.Value{}, out)
 		} else {
-			return packValue(in.Elem(), out)
+			return packValue(ctx, in.Elem(), out)
 		}
 	case reflect.String:
 		return packString([]byte(in.String()), out)
 	default:
-		panic("bad type: " + in.Kind().String())
+		return &UnsupportedTypeError{Type: in.Type()}
 	}
 }
 
+// checkContext returns ctx.Err() if ctx is done. Unlike calling ctx.Err() directly,
+// it doesn't acquire a lock, which matters as it's called for every element.
+func checkContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// packFloat deduplicates number packing of multiple locations in packValue.
+func packFloat(in float64, out io.Writer) error {
+	if _, err := out.Write([]byte{3}); err != nil {
+		return err
+	}
+
+	return binary.Write(out, binary.BigEndian, in)
+}
+
 // packString deduplicates string packing of multiple locations in packValue.
 func packString(in []byte, out io.Writer) error {
 	if _, err := out.Write([]byte{4}); err != nil {
@@ -2,6 +2,9 @@ package objectpacker
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
 	"github.com/icinga/icinga-go-library/types"
 	"github.com/pkg/errors"
 	"io"
@@ -62,24 +65,26 @@ func TestPackAny(t *testing.T) {
 	assertPackAny(t, false, []byte{1})
 	assertPackAny(t, true, []byte{2})
 
-	assertPackAnyPanic(t, -42, 0)
-	assertPackAnyPanic(t, int8(-42), 0)
-	assertPackAnyPanic(t, int16(-42), 0)
-	assertPackAnyPanic(t, int32(-42), 0)
-	assertPackAnyPanic(t, int64(-42), 0)
-
-	assertPackAnyPanic(t, uint(42), 0)
-	assertPackAnyPanic(t, uint8(42), 0)
-	assertPackAnyPanic(t, uint16(42), 0)
-	assertPackAnyPanic(t, uint32(42), 0)
-	assertPackAnyPanic(t, uint64(42), 0)
-	assertPackAnyPanic(t, uintptr(42), 0)
-
-	assertPackAnyPanic(t, float32(-42.5), 0)
+	minus42 := []byte{3, 0xc0, 0x45, 0, 0, 0, 0, 0, 0}
+	assertPackAny(t, -42, minus42)
+	assertPackAny(t, int8(-42), minus42)
+	assertPackAny(t, int16(-42), minus42)
+	assertPackAny(t, int32(-42), minus42)
+	assertPackAny(t, int64(-42), minus42)
+
+	plus42 := []byte{3, 0x40, 0x45, 0, 0, 0, 0, 0, 0}
+	assertPackAny(t, uint(42), plus42)
+	assertPackAny(t, uint8(42), plus42)
+	assertPackAny(t, uint16(42), plus42)
+	assertPackAny(t, uint32(42), plus42)
+	assertPackAny(t, uint64(42), plus42)
+	assertPackAnyError(t, uintptr(42), 0)
+
+	assertPackAny(t, float32(-42.5), []byte{3, 0xc0, 0x45, 0x40, 0, 0, 0, 0, 0})
 	assertPackAny(t, -42.5, []byte{3, 0xc0, 0x45, 0x40, 0, 0, 0, 0, 0})
 
-	assertPackAnyPanic(t, []struct{}(nil), 9)
-	assertPackAnyPanic(t, []struct{}{}, 9)
+	assertPackAnyError(t, []struct{}(nil), 9)
+	assertPackAnyError(t, []struct{}{}, 9)
 
 	assertPackAny(t, []interface{}{nil, true, -42.5}, []byte{
 		5, 0, 0, 0, 0, 0, 0, 0, 3,
@@ -94,10 +99,10 @@ func TestPackAny(t *testing.T) {
 		4, 0, 0, 0, 0, 0, 0, 0, 1, 'a',
 	})
 
-	assertPackAnyPanic(t, []interface{}{0 + 0i}, 9)
+	assertPackAnyError(t, []interface{}{0 + 0i}, 9)
 
-	assertPackAnyPanic(t, map[struct{}]struct{}(nil), 9)
-	assertPackAnyPanic(t, map[struct{}]struct{}{}, 9)
+	assertPackAnyError(t, map[struct{}]struct{}(nil), 9)
+	assertPackAnyError(t, map[struct{}]struct{}{}, 9)
 
 	assertPackAny(t, map[interface{}]interface{}{true: "", "nil": -42.5}, []byte{
 		6, 0, 0, 0, 0, 0, 0, 0, 2,
@@ -119,10 +124,11 @@ func TestPackAny(t *testing.T) {
 		2,
 	})
 
-	assertPackAnyPanic(t, map[struct{}]struct{}{{}: {}}, 9)
+	assertPackAnyError(t, map[struct{}]struct{}{{}: {}}, 9)
 
 	assertPackAny(t, (*string)(nil), []byte{0})
-	assertPackAnyPanic(t, (*int)(nil), 0)
+	assertPackAny(t, (*int)(nil), []byte{0})
+	assertPackAnyError(t, (*struct{})(nil), 0)
 	assertPackAny(t, new(float64), []byte{3, 0, 0, 0, 0, 0, 0, 0, 0})
 
 	assertPackAny(t, "", []byte{4, 0, 0, 0, 0, 0, 0, 0, 0})
@@ -142,15 +148,16 @@ func TestPackAny(t *testing.T) {
 
 	{
 		type myByte byte
-		assertPackAnyPanic(t, []myByte(nil), 9)
+		assertPackAny(t, []myByte(nil), []byte{5, 0, 0, 0, 0, 0, 0, 0, 0})
+		assertPackAny(t, []myByte{42}, append([]byte{5, 0, 0, 0, 0, 0, 0, 0, 1}, plus42...))
 	}
 
-	assertPackAnyPanic(t, complex64(0+0i), 0)
-	assertPackAnyPanic(t, 0+0i, 0)
-	assertPackAnyPanic(t, make(chan struct{}), 0)
-	assertPackAnyPanic(t, func() {}, 0)
-	assertPackAnyPanic(t, struct{}{}, 0)
-	assertPackAnyPanic(t, uintptr(0), 0)
+	assertPackAnyError(t, complex64(0+0i), 0)
+	assertPackAnyError(t, 0+0i, 0)
+	assertPackAnyError(t, make(chan struct{}), 0)
+	assertPackAnyError(t, func() {}, 0)
+	assertPackAnyError(t, struct{}{}, 0)
+	assertPackAnyError(t, uintptr(0), 0)
 }
 
 func assertPackAny(t *testing.T, in interface{}, out []byte) {
@@ -174,7 +181,7 @@ func assertPackAny(t *testing.T, in interface{}, out []byte) {
 	}
 }
 
-func assertPackAnyPanic(t *testing.T, in interface{}, allowToWrite int) {
+func assertPackAnyError(t *testing.T, in interface{}, allowToWrite int) {
 	t.Helper()
 
 	for i := 0; i < allowToWrite; i++ {
@@ -183,13 +190,96 @@ func assertPackAnyPanic(t *testing.T, in interface{}, allowToWrite int) {
 		}
 	}
 
-	defer func() {
-		t.Helper()
+	var ute *UnsupportedTypeError
+	if err := PackAny(in, &limitedWriter{allowToWrite}); !errors.As(err, &ute) {
+		t.Errorf("packAny(%#v, &limitedWriter{%d}) = %#v, want *UnsupportedTypeError", in, allowToWrite, err)
+	}
+}
 
+func TestPackAnyMust(t *testing.T) {
+	var buf bytes.Buffer
+	PackAnyMust(true, &buf)
+	if !bytes.Equal(buf.Bytes(), []byte{2}) {
+		t.Errorf("PackAnyMust(true, &buf); !bytes.Equal(buf.Bytes(), []byte{2})")
+	}
+
+	defer func() {
 		if r := recover(); r == nil {
-			t.Errorf("packAny(%#v, &limitedWriter{%d}) didn't panic", in, allowToWrite)
+			t.Error("PackAnyMust(struct{}{}, io.Discard) didn't panic")
 		}
 	}()
 
-	_ = PackAny(in, &limitedWriter{allowToWrite})
+	PackAnyMust(struct{}{}, io.Discard)
+}
+
+func TestPackAnyContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("scalar", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := PackAnyContext(ctx, "a", &buf); err != nil {
+			t.Errorf("PackAnyContext(canceled, %q, &buf) = %#v, want nil", "a", err)
+		}
+	})
+
+	for _, in := range []interface{}{[]string{"a"}, map[string]string{"a": "b"}} {
+		if err := PackAnyContext(ctx, in, io.Discard); !errors.Is(err, context.Canceled) {
+			t.Errorf("PackAnyContext(canceled, %#v, io.Discard) = %#v, want context.Canceled", in, err)
+		}
+	}
+}
+
+// newBenchmarkVars returns nested custom variables of the given breadth and depth.
+func newBenchmarkVars(breadth, depth int) interface{} {
+	if depth == 0 {
+		return "value"
+	}
+
+	vars := make(map[string]interface{}, breadth)
+	list := make([]interface{}, 0, breadth)
+	for i := range breadth {
+		vars[fmt.Sprintf("var%d", i)] = newBenchmarkVars(breadth, depth-1)
+		list = append(list, float64(i))
+	}
+	vars["list"] = list
+
+	return vars
+}
+
+func BenchmarkPackAny(b *testing.B) {
+	vars := newBenchmarkVars(10, 4)
+
+	b.Run("Buffer", func(b *testing.B) {
+		var buf bytes.Buffer
+		for range b.N {
+			buf.Reset()
+			if err := PackAny(vars, &buf); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Hash", func(b *testing.B) {
+		h := sha256.New()
+		for range b.N {
+			h.Reset()
+			if err := PackAny(vars, h); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Context", func(b *testing.B) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		h := sha256.New()
+		for range b.N {
+			h.Reset()
+			if err := PackAnyContext(ctx, vars, h); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
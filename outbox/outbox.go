@@ -0,0 +1,304 @@
+// Package outbox implements the transactional outbox pattern for events that accompany database writes.
+//
+// Events are written via Outbox.Add to an outbox table in the same database transaction as the state changes they
+// describe, so that they are persisted if and only if the transaction commits. Outbox.Relay forwards them to Redis
+// streams in the background and deletes them from the table afterwards. If the process crashes between forwarding
+// and deleting events, they are forwarded again, i.e. delivery is at least once. Each stream entry carries the ID
+// of its outbox row in the field "outbox_id", which consumers can use to detect duplicates.
+//
+// The outbox table must have the following columns, e.g. for MySQL:
+//
+//	CREATE TABLE outbox (
+//	  id bigint unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY,
+//	  stream varchar(255) NOT NULL,
+//	  payload mediumtext NOT NULL
+//	);
+//
+// And for PostgreSQL:
+//
+//	CREATE TABLE outbox (
+//	  id bigserial PRIMARY KEY,
+//	  stream varchar(255) NOT NULL,
+//	  payload text NOT NULL
+//	);
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/icinga/icinga-go-library/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTable is the default name of the outbox table.
+const DefaultTable = "outbox"
+
+// DefaultInterval is the default interval in which Relay polls the outbox table.
+const DefaultInterval = time.Second
+
+// DefaultBatchSize is the default maximum number of events Relay forwards at once.
+const DefaultBatchSize = 1000
+
+// Option configures New.
+type Option interface {
+	apply(*Outbox)
+}
+
+// WithTable sets the name of the outbox table. Defaults to DefaultTable.
+func WithTable(table string) Option {
+	return optionFunc(func(o *Outbox) {
+		o.table = table
+	})
+}
+
+// WithInterval sets the interval in which Relay polls the outbox table if it is empty. Defaults to DefaultInterval.
+// Call Outbox.Wake after committing a transaction with events to forward them without delay.
+func WithInterval(interval time.Duration) Option {
+	return optionFunc(func(o *Outbox) {
+		o.interval = interval
+	})
+}
+
+// WithBatchSize sets the maximum number of events Relay forwards at once. Defaults to DefaultBatchSize.
+func WithBatchSize(batchSize int) Option {
+	return optionFunc(func(o *Outbox) {
+		o.batchSize = batchSize
+	})
+}
+
+// Outbox writes events to the outbox table and relays them to Redis streams.
+// Use New to create an Outbox.
+type Outbox struct {
+	db        *database.DB
+	client    *redis.Client
+	logger    *logging.Logger
+	table     string
+	interval  time.Duration
+	batchSize int
+	wake      chan struct{}
+}
+
+// New returns a new Outbox that writes events to db and relays them to Redis via client.
+func New(db *database.DB, client *redis.Client, logger *logging.Logger, options ...Option) *Outbox {
+	o := &Outbox{
+		db:        db,
+		client:    client,
+		logger:    logger,
+		table:     DefaultTable,
+		interval:  DefaultInterval,
+		batchSize: DefaultBatchSize,
+		wake:      make(chan struct{}, 1),
+	}
+
+	for _, option := range options {
+		option.apply(o)
+	}
+
+	return o
+}
+
+// Add writes an event with the given field-value pairs for the Redis stream to the outbox table within tx,
+// so that it is only relayed if tx commits. The values are JSON-encoded and must therefore be
+// JSON-serializable scalars, which are converted to strings by Redis as usual.
+func (o *Outbox) Add(ctx context.Context, tx *sqlx.Tx, stream string, values map[string]any) error {
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "can't encode outbox event")
+	}
+
+	stmt := o.db.Rebind(fmt.Sprintf(`INSERT INTO "%s" ("stream", "payload") VALUES (?, ?)`, o.table))
+	if _, err := tx.ExecContext(ctx, stmt, stream, string(payload)); err != nil {
+		return database.CantPerformQuery(err, stmt)
+	}
+
+	return nil
+}
+
+// Wake lets a running Relay poll the outbox table immediately, e.g. after a transaction with events has committed.
+// Wake never blocks.
+func (o *Outbox) Wake() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Relay forwards the events from the outbox table to their Redis streams and deletes them from the table
+// until ctx is canceled or an error occurs. Only a single Relay must run for an outbox table at a time,
+// e.g. on the instance that is responsible in an HA setup.
+//
+// Events are forwarded in the order of their IDs, per stream in the same order. Note that IDs are assigned when
+// events are written, but transactions may commit in a different order, so events of concurrent transactions
+// may be forwarded in a different order than they were written.
+func (o *Outbox) Relay(ctx context.Context) error {
+	for {
+		n, err := o.relayBatch(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n == o.batchSize {
+			// There are probably more events, so continue without waiting.
+			continue
+		}
+
+		if err := o.wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// wait blocks until o.interval has elapsed, Wake is called or ctx is canceled.
+func (o *Outbox) wait(ctx context.Context) error {
+	timer := time.NewTimer(o.interval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-o.wake:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// event is an event as stored in the outbox table.
+type event struct {
+	Id      uint64 `db:"id"`
+	Stream  string `db:"stream"`
+	Payload string `db:"payload"`
+}
+
+// relayBatch forwards up to o.batchSize events and returns how many were forwarded.
+func (o *Outbox) relayBatch(ctx context.Context) (int, error) {
+	query := o.db.Rebind(fmt.Sprintf(
+		`SELECT "id", "stream", "payload" FROM "%s" ORDER BY "id" LIMIT %d`, o.table, o.batchSize,
+	))
+
+	var events []event
+	if err := o.withRetry(ctx, func(ctx context.Context) error {
+		events = events[:0]
+		if err := o.db.SelectContext(ctx, &events, query); err != nil {
+			return database.CantPerformQuery(err, query)
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Group the events by stream, retaining their order.
+	var streams []string
+	entries := make(map[string][]map[string]any)
+	ids := make([]uint64, 0, len(events))
+
+	for _, e := range events {
+		values, err := decodePayload(e)
+		if err != nil {
+			return 0, err
+		}
+
+		if _, ok := entries[e.Stream]; !ok {
+			streams = append(streams, e.Stream)
+		}
+
+		entries[e.Stream] = append(entries[e.Stream], values)
+		ids = append(ids, e.Id)
+	}
+
+	for _, stream := range streams {
+		if err := o.client.XAddBulk(
+			ctx, stream, utils.ChanFromSlice(entries[stream]), redis.XAddBatchSize(len(entries[stream])),
+		); err != nil {
+			return 0, errors.Wrapf(err, "can't relay outbox events to %s", stream)
+		}
+	}
+
+	del, args, err := sqlx.In(fmt.Sprintf(`DELETE FROM "%s" WHERE "id" IN (?)`, o.table), ids)
+	if err != nil {
+		return 0, errors.Wrap(err, "can't build placeholders")
+	}
+
+	del = o.db.Rebind(del)
+	if err := o.withRetry(ctx, func(ctx context.Context) error {
+		if _, err := o.db.ExecContext(ctx, del, args...); err != nil {
+			return database.CantPerformQuery(err, del)
+		}
+
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	o.logger.Debugw("Relayed outbox events", zap.Int("count", len(events)), zap.Strings("streams", streams))
+
+	return len(events), nil
+}
+
+// withRetry calls f, retrying it on retryable errors.
+func (o *Outbox) withRetry(ctx context.Context, f retry.RetryableFunc) error {
+	return retry.WithBackoff(
+		ctx,
+		f,
+		retry.Retryable,
+		backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
+		o.db.GetDefaultRetrySettings(),
+	)
+}
+
+// decodePayload returns the stream entry values of e, i.e. its decoded payload and the outbox_id field.
+// Numbers are passed on in their original notation instead of being converted to float64,
+// which would lose the precision of large integers such as IDs.
+func decodePayload(e event) (map[string]any, error) {
+	dec := json.NewDecoder(strings.NewReader(e.Payload))
+	dec.UseNumber()
+
+	var values map[string]any
+	if err := dec.Decode(&values); err != nil {
+		return nil, errors.Wrapf(err, "can't decode outbox event %d", e.Id)
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.Errorf("can't decode outbox event %d: unexpected data after payload", e.Id)
+	}
+
+	if values == nil {
+		values = make(map[string]any, 1)
+	}
+
+	for k, v := range values {
+		if n, ok := v.(json.Number); ok {
+			// Redis can't marshal json.Number, but numbers are sent as strings anyway.
+			values[k] = n.String()
+		}
+	}
+
+	values["outbox_id"] = strconv.FormatUint(e.Id, 10)
+
+	return values, nil
+}
+
+// optionFunc is a function that implements Option.
+type optionFunc func(*Outbox)
+
+// apply implements the Option interface.
+func (f optionFunc) apply(o *Outbox) {
+	f(o)
+}
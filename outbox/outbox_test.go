@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/testutils/dbtest"
+	"github.com/icinga/icinga-go-library/testutils/redistest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"testing"
+	"time"
+)
+
+func TestDecodePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected map[string]any
+		error    bool
+	}{
+		{"object", `{"host":"a","state":1}`, map[string]any{"host": "a", "state": "1", "outbox_id": "42"}, false},
+		{"large-integer", `{"id":9007199254740993}`, map[string]any{"id": "9007199254740993", "outbox_id": "42"}, false},
+		{"float", `{"value":1.5e3}`, map[string]any{"value": "1.5e3", "outbox_id": "42"}, false},
+		{"null", `null`, map[string]any{"outbox_id": "42"}, false},
+		{"invalid", `{`, nil, true},
+		{"trailing-data", `{}{}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := decodePayload(event{Id: 42, Stream: "test", Payload: tt.payload})
+			if tt.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.expected, values)
+			}
+		})
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping container test in short mode")
+	}
+
+	flavors := map[string]struct {
+		flavor dbtest.Flavor
+		schema string
+	}{
+		"mysql": {dbtest.MySQL, `CREATE TABLE "outbox" (` +
+			`"id" bigint unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY, "stream" varchar(255) NOT NULL, "payload" mediumtext NOT NULL)`},
+		"postgresql": {dbtest.PostgreSQL, `CREATE TABLE "outbox" (` +
+			`"id" bigserial PRIMARY KEY, "stream" varchar(255) NOT NULL, "payload" text NOT NULL)`},
+	}
+
+	for name, f := range flavors {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := dbtest.Start(t, f.flavor, dbtest.WithSchema(f.schema))
+			s := redistest.Start(t)
+			o := New(db, s.NewClient(t), logging.NewLogger(zaptest.NewLogger(t).Sugar(), 0),
+				WithBatchSize(2), WithInterval(time.Hour))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			require.NoError(t, db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+				for _, host := range []string{"a", "b", "c"} {
+					if err := o.Add(ctx, tx, "icinga:state", map[string]any{"host": host}); err != nil {
+						return err
+					}
+				}
+
+				return o.Add(ctx, tx, "icinga:history", map[string]any{"host": "a", "state": 2})
+			}))
+
+			// Rolled back events must never be relayed.
+			require.Error(t, db.ExecTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+				if err := o.Add(ctx, tx, "icinga:state", map[string]any{"host": "x"}); err != nil {
+					return err
+				}
+
+				return context.Canceled
+			}))
+
+			relayed := make(chan error, 1)
+			go func() { relayed <- o.Relay(ctx) }()
+
+			require.Eventually(t, func() bool {
+				var count int
+				require.NoError(t, db.Get(&count, `SELECT COUNT(*) FROM "outbox"`))
+
+				return count == 0
+			}, 10*time.Second, 10*time.Millisecond)
+
+			cancel()
+			require.ErrorIs(t, <-relayed, context.Canceled)
+
+			var ids []string
+			for _, msg := range s.Stream("icinga:state") {
+				ids = append(ids, msg.Values["outbox_id"].(string))
+			}
+
+			require.Len(t, ids, 3)
+			s.RequireStream(t, "icinga:state",
+				map[string]string{"host": "a", "outbox_id": ids[0]},
+				map[string]string{"host": "b", "outbox_id": ids[1]},
+				map[string]string{"host": "c", "outbox_id": ids[2]},
+			)
+			require.Len(t, s.Stream("icinga:history"), 1)
+		})
+	}
+}
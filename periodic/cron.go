@@ -0,0 +1,173 @@
+package periodic
+
+import (
+	"github.com/pkg/errors"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed cron expression with the five fields minute, hour, day of month, month and day of week.
+// Each field is a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domRestricted and dowRestricted are false if the respective field starts with "*", e.g. "*" or "*/2".
+	// If both are restricted, a day matches if either of them matches, as in cron(8).
+	// Otherwise, it must match both, i.e. the restricted one, if any.
+	domRestricted, dowRestricted bool
+}
+
+// cronMacros maps the supported nicknames to the expressions they stand for.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCron parses a cron expression consisting of the five fields minute, hour, day of month, month and day of week.
+// Each field may be "*", a value, a range like "1-5" or a list like "1,15", all optionally followed by a step like "/10".
+// Names of months and weekdays are not supported. The macros @yearly, @monthly, @weekly, @daily and @hourly are.
+func parseCron(expr string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("cron expression %q must have 5 fields, not %d", expr, len(fields))
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var sets [5]uint64
+
+	for i, field := range fields {
+		set, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't parse cron expression %q", expr)
+		}
+
+		sets[i] = set
+	}
+
+	// Both 0 and 7 mean Sunday.
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:        sets[0],
+		hour:          sets[1],
+		dom:           sets[2],
+		month:         sets[3],
+		dow:           sets[4],
+		domRestricted: !strings.HasPrefix(fields[2], "*"),
+		dowRestricted: !strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseCronField parses a comma-separated cron field whose values must be within [lo, hi].
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var set uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, errors.Errorf("invalid step %q", stepStr)
+			}
+		}
+
+		from, to := lo, hi
+		if rng != "*" {
+			fromStr, toStr, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if from, err = strconv.Atoi(fromStr); err != nil {
+				return 0, errors.Errorf("invalid value %q", fromStr)
+			}
+
+			if isRange {
+				if to, err = strconv.Atoi(toStr); err != nil {
+					return 0, errors.Errorf("invalid value %q", toStr)
+				}
+			} else if !hasStep {
+				to = from
+			}
+		}
+
+		if from < lo || to > hi || from > to {
+			return 0, errors.Errorf("%q out of range [%d, %d]", part, lo, hi)
+		}
+
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+
+	return set, nil
+}
+
+// next returns the first time after t that matches the schedule, in the location of t.
+// Returns the zero time if there is none within the next five years, e.g. for February 30.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !s.has(s.minute, t.Minute()) {
+			// Jump to the next matching minute of this hour, if any.
+			if rest := s.minute >> (t.Minute() + 1); rest != 0 {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)+1) * time.Minute)
+			} else {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			}
+
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchesDay returns whether the day of t matches the day of month and day of week fields.
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dom := s.has(s.dom, t.Day())
+	dow := s.has(s.dow, int(t.Weekday()))
+
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// has returns whether the bit set contains v.
+func (*cronSchedule) has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
@@ -0,0 +1,103 @@
+package periodic
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	subtests := []struct {
+		name  string
+		input string
+		error bool
+	}{
+		{"every-minute", "* * * * *", false},
+		{"steps-ranges-lists", "*/15 2-4 1,15 1-12/2 1-5", false},
+		{"sunday-as-7", "0 0 * * 7", false},
+		{"macro", "@daily", false},
+		{"too-few-fields", "* * * *", true},
+		{"out-of-range", "60 * * * *", true},
+		{"reverse-range", "5-1 * * * *", true},
+		{"zero-step", "*/0 * * * *", true},
+		{"name", "* * * JAN *", true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			_, err := parseCron(st.input)
+			if st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC)
+
+	subtests := []struct {
+		name   string
+		input  string
+		output time.Time
+	}{
+		{"every-minute", "* * * * *", time.Date(2024, time.January, 31, 10, 8, 0, 0, time.UTC)},
+		{"quarter-hour", "*/15 * * * *", time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC)},
+		{"next-hour", "5 * * * *", time.Date(2024, time.January, 31, 11, 5, 0, 0, time.UTC)},
+		{"next-month", "0 0 1 * *", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap-day", "0 12 29 2 *", time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC)},
+		{"weekday", "30 2 * * 1-5", time.Date(2024, time.February, 1, 2, 30, 0, 0, time.UTC)},
+		{"sunday", "0 0 * * 7", time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"dom-or-dow", "0 0 15 * 5", time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", time.Time{}},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			schedule, err := parseCron(st.input)
+			require.NoError(t, err)
+			require.Equal(t, st.output, schedule.next(now))
+		})
+	}
+}
+
+func TestCronSchedule_matchesDay(t *testing.T) {
+	// Wednesday the 31st, Thursday the 1st, Friday the 2nd and Thursday the 15th.
+	wed31 := time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC)
+	thu1 := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	fri2 := time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)
+	thu15 := time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)
+
+	subtests := []struct {
+		name    string
+		input   string
+		matches []time.Time
+		misses  []time.Time
+	}{
+		{"unrestricted", "0 0 * * *", []time.Time{wed31, thu1, fri2, thu15}, nil},
+		{"dom-only", "0 0 1,15 * *", []time.Time{thu1, thu15}, []time.Time{wed31, fri2}},
+		{"dow-only", "0 0 * * 5", []time.Time{fri2}, []time.Time{wed31, thu1, thu15}},
+		{"dom-or-dow", "0 0 1 * 5", []time.Time{thu1, fri2}, []time.Time{wed31, thu15}},
+		{"dom-or-dow-ranges", "0 0 31 * 4", []time.Time{wed31, thu1, thu15}, []time.Time{fri2}},
+		{"dom-step-is-unrestricted", "0 0 */2 * 4", []time.Time{thu1, thu15}, []time.Time{wed31, fri2}},
+		{"dow-step-is-unrestricted", "0 0 1,2 * */2", []time.Time{thu1}, []time.Time{wed31, fri2, thu15}},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			schedule, err := parseCron(st.input)
+			require.NoError(t, err)
+
+			for _, day := range st.matches {
+				require.True(t, schedule.matchesDay(day), "%s must match", day.Format(time.DateOnly))
+			}
+
+			for _, day := range st.misses {
+				require.False(t, schedule.matchesDay(day), "%s must not match", day.Format(time.DateOnly))
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package periodic
 
 import (
 	"context"
+	"math/rand/v2"
 	"sync"
 	"time"
 )
@@ -32,6 +33,26 @@ func Immediate() Option {
 	})
 }
 
+// Aligned aligns the ticks to multiples of the interval since the zero time,
+// e.g. to full minutes for an interval of one minute or to full hours (UTC) for an interval of one hour,
+// instead of starting the interval now. Ticks missed because the task took too long are skipped.
+func Aligned() Option {
+	return optionFunc(func(p *periodic) {
+		p.next = func(now time.Time) time.Time {
+			return now.Truncate(p.interval).Add(p.interval)
+		}
+	})
+}
+
+// Jitter delays the execution of the task after each tick by a random duration in [0, maxDelay),
+// so that tasks of multiple processes that tick at the same time don't run at the very same instant.
+// Tick.Time remains the time of the tick itself.
+func Jitter(maxDelay time.Duration) Option {
+	return optionFunc(func(p *periodic) {
+		p.jitter = maxDelay
+	})
+}
+
 // OnStop configures a callback that is executed when a periodic task is stopped or canceled.
 func OnStop(f func(Tick)) Option {
 	return optionFunc(func(p *periodic) {
@@ -55,37 +76,46 @@ func Start(ctx context.Context, interval time.Duration, callback func(Tick), opt
 		option.apply(t)
 	}
 
+	return t.start(ctx)
+}
+
+// StartCron starts a periodic task which executes the given callback at the times matching the cron expression,
+// in the local time zone. The expression consists of the five fields minute, hour, day of month, month and
+// day of week, e.g. "*/15 * * * *" for every quarter of an hour or "30 2 * * 1-5" for 2:30 on weekdays.
+// Each field may be "*", a value, a range or a comma-separated list, all optionally followed by a step like "/5".
+// The macros @yearly, @monthly, @weekly, @daily and @hourly are supported as well.
+// Ticks missed because the task took too long are skipped. The Aligned option has no effect.
+// Call Stop() on the return value in order to stop the task and to release associated resources.
+func StartCron(ctx context.Context, expr string, callback func(Tick), options ...Option) (Stopper, error) {
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &periodic{
+		callback: callback,
+	}
+
+	for _, option := range options {
+		option.apply(t)
+	}
+
+	t.next = schedule.next
+
+	return t.start(ctx), nil
+}
+
+// start starts the periodic task in a new goroutine.
+func (t *periodic) start(ctx context.Context) Stopper {
 	ctx, cancelCtx := context.WithCancel(ctx)
 
 	start := time.Now()
 
 	go func() {
-		done := false
-
-		if !t.immediate {
-			select {
-			case <-time.After(interval):
-			case <-ctx.Done():
-				done = true
-			}
-		}
-
-		if !done {
-			ticker := time.NewTicker(t.interval)
-			defer ticker.Stop()
-
-			for tickTime := time.Now(); !done; {
-				t.callback(Tick{
-					Elapsed: tickTime.Sub(start),
-					Time:    tickTime,
-				})
-
-				select {
-				case tickTime = <-ticker.C:
-				case <-ctx.Done():
-					done = true
-				}
-			}
+		if t.next == nil {
+			t.runTicker(ctx, start)
+		} else {
+			t.runSchedule(ctx, start)
 		}
 
 		if t.onStop != nil {
@@ -102,6 +132,81 @@ func Start(ctx context.Context, interval time.Duration, callback func(Tick), opt
 	})
 }
 
+// runTicker executes the callback at the fixed interval starting now until ctx is canceled.
+func (t *periodic) runTicker(ctx context.Context, start time.Time) {
+	if !t.immediate {
+		select {
+		case <-time.After(t.interval):
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for tickTime := time.Now(); ; {
+		if !t.tick(ctx, start, tickTime) {
+			return
+		}
+
+		select {
+		case tickTime = <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runSchedule executes the callback at the times returned by t.next until ctx is canceled.
+func (t *periodic) runSchedule(ctx context.Context, start time.Time) {
+	if t.immediate && !t.tick(ctx, start, start) {
+		return
+	}
+
+	for {
+		next := t.next(time.Now())
+		if next.IsZero() {
+			<-ctx.Done()
+
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		}
+
+		if !t.tick(ctx, start, next) {
+			return
+		}
+	}
+}
+
+// tick executes the callback for the tick at tickTime after the configured jitter, if any.
+// Returns false if ctx is canceled while waiting for the jitter.
+func (t *periodic) tick(ctx context.Context, start, tickTime time.Time) bool {
+	if t.jitter > 0 {
+		select {
+		case <-time.After(rand.N(t.jitter)):
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	t.callback(Tick{
+		Elapsed: tickTime.Sub(start),
+		Time:    tickTime,
+	})
+
+	return true
+}
+
 type optionFunc func(*periodic)
 
 func (f optionFunc) apply(p *periodic) {
@@ -120,4 +225,9 @@ type periodic struct {
 	immediate bool
 	stop      sync.Once
 	onStop    func(Tick)
+	jitter    time.Duration
+
+	// next returns the time of the next tick after the given time.
+	// If nil, the task ticks at the fixed interval starting now.
+	next func(time.Time) time.Time
 }
@@ -6,9 +6,12 @@ import "github.com/redis/go-redis/v9"
 // so that only this redis package needs to be imported and not go-redis additionally.
 
 type IntCmd = redis.IntCmd
+type Message = redis.Message
 type Pipeliner = redis.Pipeliner
 type XAddArgs = redis.XAddArgs
 type XMessage = redis.XMessage
 type XReadArgs = redis.XReadArgs
 
 var NewScript = redis.NewScript
+
+const Nil = redis.Nil
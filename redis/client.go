@@ -12,11 +12,15 @@ import (
 	"github.com/icinga/icinga-go-library/utils"
 	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
 	"net"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,11 +31,24 @@ type Client struct {
 
 	Options *Options
 
-	logger *logging.Logger
+	logger    *logging.Logger
+	keyPrefix string
 }
 
+// retryHookedClients contains the redis.Clients to which NewClient has added a retry hook.
+var retryHookedClients sync.Map
+
 // NewClient returns a new Client wrapper for a pre-existing redis.Client.
+// If options enable RetryReads or RetryWrites, a hook that retries the respective commands is added to client.
+// The hook is added only once per redis.Client, so if the same client is wrapped multiple times,
+// the retry options of the first call enabling retries apply to all of its wrappers.
 func NewClient(client *redis.Client, logger *logging.Logger, options *Options) *Client {
+	if options != nil && (options.RetryReads || options.RetryWrites) {
+		if _, hooked := retryHookedClients.LoadOrStore(client, struct{}{}); !hooked {
+			client.AddHook(newRetryHook(logger, options))
+		}
+	}
+
 	return &Client{Client: client, logger: logger, Options: options}
 }
 
@@ -52,7 +69,7 @@ func NewClientFromConfig(c *Config, logger *logging.Logger) (*Client, error) {
 	}
 
 	options := &redis.Options{
-		Dialer:      dialWithLogging(dialer, logger),
+		Dialer:      dialWithLogging(dialer, logger, c.Options.RetryRegistry),
 		Username:    c.Username,
 		Password:    c.Password,
 		DB:          c.Database,
@@ -77,7 +94,22 @@ func NewClientFromConfig(c *Config, logger *logging.Logger) (*Client, error) {
 	options.PoolSize = max(32, options.PoolSize)
 	options.MaxRetries = options.PoolSize + 1 // https://github.com/go-redis/redis/issues/1737
 
-	return NewClient(redis.NewClient(options), logger, &c.Options), nil
+	return NewClient(redis.NewClient(options), logger, &c.Options).WithKeyPrefix(c.KeyPrefix), nil
+}
+
+// WithKeyPrefix returns a shallow copy of the Client, sharing its connections, that transparently prefixes
+// the keys used by its helpers, e.g. HYield, HMYield, XReadUntilResult, XAddBulk, HSetStreamed, GetSchemaVersion and NewLock,
+// so that multiple environments can share a Redis instance. Use Key to prefix keys for other commands.
+func (c *Client) WithKeyPrefix(prefix string) *Client {
+	clone := *c
+	clone.keyPrefix = prefix
+
+	return &clone
+}
+
+// Key returns the key with the Client's key prefix, if any.
+func (c *Client) Key(key string) string {
+	return c.keyPrefix + key
 }
 
 // GetAddr returns a URI-like Redis connection string.
@@ -106,11 +138,12 @@ func (c *Client) GetAddr() string {
 	return description
 }
 
-// MarshalLogObject implements [zapcore.ObjectMarshaler], adding the redis address [Client.GetAddr] to each log message.
+// MarshalLogObject implements [zapcore.ObjectMarshaler], adding the redis address [Client.GetAddr]
+// and the statistics of the connection pool [Client.PoolStats] to each log message.
 func (c *Client) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
 	encoder.AddString("redis_address", c.GetAddr())
 
-	return nil
+	return encoder.AddObject("redis_pool", c.PoolStats())
 }
 
 // HPair defines Redis hashes field-value pairs.
@@ -121,6 +154,7 @@ type HPair struct {
 
 // HYield yields HPair field-value pairs for all fields in the hash stored at key.
 func (c *Client) HYield(ctx context.Context, key string) (<-chan HPair, <-chan error) {
+	key = c.Key(key)
 	pairs := make(chan HPair, c.Options.HScanCount)
 
 	return pairs, com.WaitAsync(com.WaiterFunc(func() error {
@@ -172,6 +206,7 @@ func (c *Client) HYield(ctx context.Context, key string) (<-chan HPair, <-chan e
 
 // HMYield yields HPair field-value pairs for the specified fields in the hash stored at key.
 func (c *Client) HMYield(ctx context.Context, key string, fields ...string) (<-chan HPair, <-chan error) {
+	key = c.Key(key)
 	pairs := make(chan HPair)
 
 	return pairs, com.WaitAsync(com.WaiterFunc(func() error {
@@ -199,9 +234,13 @@ func (c *Client) HMYield(ctx context.Context, key string, fields ...string) (<-c
 			}
 
 			batch := batch
-			g.Go(func() error {
+			g.Go(func() (err error) {
 				defer sem.Release(1)
 
+				ctx, span := c.startSpan(ctx, "HMGET",
+					attribute.String("db.redis.key", key), attribute.Int("db.operation.batch.size", len(batch)))
+				defer func() { endSpan(span, err) }()
+
 				cmd := c.HMGet(ctx, key, batch...)
 				vals, err := cmd.Result()
 
@@ -238,11 +277,25 @@ func (c *Client) HMYield(ctx context.Context, key string, fields ...string) (<-c
 // Each call blocks at most for the duration specified in Options.BlockTimeout until data
 // is available before it times out and the next call is made.
 // This also means that an already set block timeout is overridden.
-func (c *Client) XReadUntilResult(ctx context.Context, a *redis.XReadArgs) ([]redis.XStream, error) {
+// A single span covers all XREAD calls until a result is returned.
+// The stream keys are prefixed with the Client's key prefix, if any, which is removed from the returned streams.
+func (c *Client) XReadUntilResult(ctx context.Context, a *redis.XReadArgs) (_ []redis.XStream, err error) {
 	a.Block = c.Options.BlockTimeout
 
+	args := *a
+	if c.keyPrefix != "" {
+		// Streams contains the keys followed by their IDs.
+		args.Streams = slices.Clone(a.Streams)
+		for i := range len(args.Streams) / 2 {
+			args.Streams[i] = c.Key(args.Streams[i])
+		}
+	}
+
+	ctx, span := c.startSpan(ctx, "XREAD", attribute.StringSlice("db.redis.streams", args.Streams))
+	defer func() { endSpan(span, err) }()
+
 	for {
-		cmd := c.XRead(ctx, a)
+		cmd := c.XRead(ctx, &args)
 		streams, err := cmd.Result()
 		if err != nil {
 			// We need to retry the XREAD commands in the following situations:
@@ -264,6 +317,10 @@ func (c *Client) XReadUntilResult(ctx context.Context, a *redis.XReadArgs) ([]re
 			return streams, WrapCmdErr(cmd)
 		}
 
+		for i := range streams {
+			streams[i].Stream = strings.TrimPrefix(streams[i].Stream, c.keyPrefix)
+		}
+
 		return streams, nil
 	}
 }
@@ -273,8 +330,8 @@ func (c *Client) log(ctx context.Context, key string, counter *com.Counter) peri
 		// We may never get to progress logging here,
 		// as fetching should be completed before the interval expires,
 		// but if it does, it is good to have this log message.
-		if count := counter.Reset(); count > 0 {
-			c.logger.Debugf("Fetched %d items from %s", count, key)
+		if s := counter.ResetSnapshot(); s.Delta > 0 {
+			c.logger.Debugf("Fetched %d items from %s (%.2f items/s)", s.Delta, key, s.Rate())
 		}
 	}, periodic.OnStop(func(tick periodic.Tick) {
 		c.logger.Debugf("Finished fetching from %s with %d items in %s", key, counter.Total(), tick.Elapsed)
@@ -284,7 +341,8 @@ func (c *Client) log(ctx context.Context, key string, counter *com.Counter) peri
 type ctxDialerFunc = func(ctx context.Context, network, addr string) (net.Conn, error)
 
 // dialWithLogging returns a Redis Dialer with logging capabilities.
-func dialWithLogging(dialer ctxDialerFunc, logger *logging.Logger) ctxDialerFunc {
+// If registry is set, retried dialing is registered there as "redis connect".
+func dialWithLogging(dialer ctxDialerFunc, logger *logging.Logger, registry *retry.Registry) ctxDialerFunc {
 	// dial behaves like net.Dialer#DialContext,
 	// but re-tries on common errors that are considered retryable.
 	return func(ctx context.Context, network, addr string) (conn net.Conn, err error) {
@@ -297,7 +355,9 @@ func dialWithLogging(dialer ctxDialerFunc, logger *logging.Logger) ctxDialerFunc
 			retry.Retryable,
 			backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
 			retry.Settings{
-				Timeout: retry.DefaultTimeout,
+				Timeout:  retry.DefaultTimeout,
+				Registry: registry,
+				Name:     "redis connect",
 				OnRetryableError: func(_ time.Duration, _ uint64, err, lastErr error) {
 					if lastErr == nil || err.Error() != lastErr.Error() {
 						logger.Warnw("Can't connect to Redis. Retrying", zap.Error(err))
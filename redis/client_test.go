@@ -1,10 +1,15 @@
 package redis
 
 import (
+	"context"
 	"github.com/icinga/icinga-go-library/config"
 	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -85,3 +90,41 @@ func TestNewClientFromConfig_GetAddr(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_WithKeyPrefix(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+
+	c := newTestClient(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch strings.ToUpper(args[0]) {
+		case "HSCAN":
+			keys = append(keys, args[1])
+			return resp.Array(resp.Bulk("0"), resp.Bulks("f", "v"))
+		case "XREAD":
+			// XREAD BLOCK <ms> STREAMS <key> <id>
+			keys = append(keys, args[4])
+			return resp.Array(resp.Array(resp.Bulk(args[4]), resp.Array(resp.Array(resp.Bulk("1-0"), resp.Bulks("k", "v")))))
+		default:
+			return resp.Error("ERR unknown command")
+		}
+	})
+	c.Options.HScanCount = 1
+	c = c.WithKeyPrefix("icinga:prod:")
+
+	require.Equal(t, "icinga:prod:stream", c.Key("stream"))
+
+	pairs, errs := c.HYield(context.Background(), "host")
+	for range pairs {
+	}
+	require.NoError(t, <-errs)
+
+	streams, err := c.XReadUntilResult(context.Background(), &redis.XReadArgs{Streams: []string{"stream", "0-0"}})
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Equal(t, "stream", streams[0].Stream, "key prefix must be removed from the returned streams")
+
+	require.Equal(t, []string{"icinga:prod:host", "icinga:prod:stream"}, keys)
+}
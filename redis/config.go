@@ -2,18 +2,51 @@ package redis
 
 import (
 	"github.com/icinga/icinga-go-library/config"
+	"github.com/icinga/icinga-go-library/retry"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
 	"time"
 )
 
+// SubscribeBlock and SubscribeDropOldest are the supported values of Options.SubscribeOverflow.
+const (
+	// SubscribeBlock stops receiving messages until the consumer catches up if the buffer is full.
+	SubscribeBlock = "block"
+
+	// SubscribeDropOldest discards the oldest buffered message to make room for a new one if the buffer is full.
+	SubscribeDropOldest = "drop_oldest"
+)
+
 // Options define user configurable Redis options.
+//
+// RetryReads and RetryWrites enable retrying single read and write commands respectively on retryable errors,
+// using the same backoff and timeout as the database layer. Dialing is always retried.
+// Note that a retried write may be applied twice if the connection was lost after Redis executed it.
+//
+// SubscribeBufferSize and SubscribeOverflow configure the buffer of the messages delivered by SubscribeStreamed
+// and what happens if it is full, i.e. either SubscribeBlock or SubscribeDropOldest.
 type Options struct {
 	BlockTimeout        time.Duration `yaml:"block_timeout" env:"BLOCK_TIMEOUT" default:"1s"`
 	HMGetCount          int           `yaml:"hmget_count" env:"HMGET_COUNT" default:"4096"`
 	HScanCount          int           `yaml:"hscan_count" env:"HSCAN_COUNT" default:"4096"`
+	HSetCount           int           `yaml:"hset_count" env:"HSET_COUNT" default:"4096"`
 	MaxHMGetConnections int           `yaml:"max_hmget_connections" env:"MAX_HMGET_CONNECTIONS" default:"8"`
+	RetryReads          bool          `yaml:"retry_reads" env:"RETRY_READS" default:"false"`
+	RetryWrites         bool          `yaml:"retry_writes" env:"RETRY_WRITES" default:"false"`
+	SubscribeBufferSize int           `yaml:"subscribe_buffer_size" env:"SUBSCRIBE_BUFFER_SIZE" default:"1024"`
+	SubscribeOverflow   string        `yaml:"subscribe_overflow" env:"SUBSCRIBE_OVERFLOW" default:"block"`
 	Timeout             time.Duration `yaml:"timeout" env:"TIMEOUT" default:"30s"`
 	XReadCount          int           `yaml:"xread_count" env:"XREAD_COUNT" default:"4096"`
+
+	// TracerProvider, if set, is used to create OpenTelemetry spans for
+	// the HMGET calls of HMYield and the XREAD calls of XReadUntilResult.
+	// It can only be set programmatically, not via YAML or environment variables.
+	TracerProvider trace.TracerProvider `yaml:"-"`
+
+	// RetryRegistry, if set, keeps track of dialing, commands, scripts and writes while they are being retried,
+	// registered as "redis connect", "redis command", "redis script" and "redis write" respectively,
+	// e.g. for health.RetryChecker. It can only be set programmatically, not via YAML or environment variables.
+	RetryRegistry *retry.Registry `yaml:"-"`
 }
 
 // Validate checks constraints in the supplied Redis options and returns an error if they are violated.
@@ -27,9 +60,19 @@ func (o *Options) Validate() error {
 	if o.HScanCount < 1 {
 		return errors.New("hscan_count must be at least 1")
 	}
+	if o.HSetCount < 1 {
+		return errors.New("hset_count must be at least 1")
+	}
 	if o.MaxHMGetConnections < 1 {
 		return errors.New("max_hmget_connections must be at least 1")
 	}
+	if o.SubscribeBufferSize < 1 {
+		return errors.New("subscribe_buffer_size must be at least 1")
+	}
+	if o.SubscribeOverflow != SubscribeBlock && o.SubscribeOverflow != SubscribeDropOldest {
+		return errors.Errorf(
+			"subscribe_overflow must be either %q or %q, got %q", SubscribeBlock, SubscribeDropOldest, o.SubscribeOverflow)
+	}
 	if o.Timeout == 0 {
 		return errors.New("timeout cannot be 0. Configure a value greater than zero, or use -1 for no timeout")
 	}
@@ -45,8 +88,9 @@ type Config struct {
 	Host       string     `yaml:"host" env:"HOST"`
 	Port       int        `yaml:"port" env:"PORT"`
 	Username   string     `yaml:"username" env:"USERNAME"`
-	Password   string     `yaml:"password" env:"PASSWORD,unset"`
+	Password   string     `yaml:"password" env:"PASSWORD,unset" secret:"true"`
 	Database   int        `yaml:"database" env:"DATABASE" default:"0"`
+	KeyPrefix  string     `yaml:"key_prefix" env:"KEY_PREFIX"`
 	TlsOptions config.TLS `yaml:",inline"`
 	Options    Options    `yaml:"options" envPrefix:"OPTIONS_"`
 }
@@ -139,6 +139,20 @@ options:
 			},
 			Error: testutils.ErrorContains("hscan_count must be at least 1"),
 		},
+		{
+			Name: "hset_count must be at least 1",
+			Data: testutils.ConfigTestData{
+				Yaml: `
+host: localhost
+options:
+  hset_count: 0`,
+				Env: map[string]string{
+					"HOST":               "localhost",
+					"OPTIONS_HSET_COUNT": "0",
+				},
+			},
+			Error: testutils.ErrorContains("hset_count must be at least 1"),
+		},
 		{
 			Name: "max_hmget_connections must be at least 1",
 			Data: testutils.ConfigTestData{
@@ -153,6 +167,34 @@ options:
 			},
 			Error: testutils.ErrorContains("max_hmget_connections must be at least 1"),
 		},
+		{
+			Name: "subscribe_buffer_size must be at least 1",
+			Data: testutils.ConfigTestData{
+				Yaml: `
+host: localhost
+options:
+  subscribe_buffer_size: 0`,
+				Env: map[string]string{
+					"HOST":                          "localhost",
+					"OPTIONS_SUBSCRIBE_BUFFER_SIZE": "0",
+				},
+			},
+			Error: testutils.ErrorContains("subscribe_buffer_size must be at least 1"),
+		},
+		{
+			Name: "Unknown subscribe_overflow",
+			Data: testutils.ConfigTestData{
+				Yaml: `
+host: localhost
+options:
+  subscribe_overflow: drop_newest`,
+				Env: map[string]string{
+					"HOST":                       "localhost",
+					"OPTIONS_SUBSCRIBE_OVERFLOW": "drop_newest",
+				},
+			},
+			Error: testutils.ErrorContains(`subscribe_overflow must be either "block" or "drop_oldest", got "drop_newest"`),
+		},
 		{
 			Name: "timeout cannot be 0",
 			Data: testutils.ConfigTestData{
@@ -201,7 +243,10 @@ options:
 					BlockTimeout:        2 * time.Second,
 					HMGetCount:          512,
 					HScanCount:          defaultOptions.HScanCount,
+					HSetCount:           defaultOptions.HSetCount,
 					MaxHMGetConnections: defaultOptions.MaxHMGetConnections,
+					SubscribeBufferSize: defaultOptions.SubscribeBufferSize,
+					SubscribeOverflow:   defaultOptions.SubscribeOverflow,
 					Timeout:             defaultOptions.Timeout,
 					XReadCount:          defaultOptions.XReadCount,
 				},
@@ -216,7 +261,10 @@ options:
   block_timeout: 2s
   hmget_count: 512
   hscan_count: 1024
+  hset_count: 256
   max_hmget_connections: 16
+  subscribe_buffer_size: 64
+  subscribe_overflow: drop_oldest
   timeout: 60s
   xread_count: 2048`,
 				Env: map[string]string{
@@ -224,7 +272,10 @@ options:
 					"OPTIONS_BLOCK_TIMEOUT":         "2s",
 					"OPTIONS_HMGET_COUNT":           "512",
 					"OPTIONS_HSCAN_COUNT":           "1024",
+					"OPTIONS_HSET_COUNT":            "256",
 					"OPTIONS_MAX_HMGET_CONNECTIONS": "16",
+					"OPTIONS_SUBSCRIBE_BUFFER_SIZE": "64",
+					"OPTIONS_SUBSCRIBE_OVERFLOW":    "drop_oldest",
 					"OPTIONS_TIMEOUT":               "60s",
 					"OPTIONS_XREAD_COUNT":           "2048",
 				},
@@ -235,7 +286,10 @@ options:
 					BlockTimeout:        2 * time.Second,
 					HMGetCount:          512,
 					HScanCount:          1024,
+					HSetCount:           256,
 					MaxHMGetConnections: 16,
+					SubscribeBufferSize: 64,
+					SubscribeOverflow:   SubscribeDropOldest,
 					Timeout:             60 * time.Second,
 					XReadCount:          2048,
 				},
@@ -0,0 +1,304 @@
+// Package heartbeat provides a component that reads heartbeats from a Redis stream and
+// signals their reception and loss, so that HA implementations share the same heartbeat semantics.
+package heartbeat
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/icinga/icinga-go-library/utils"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout defines how long a heartbeat may be absent by default if a heartbeat has already been received.
+// After this time, a heartbeat loss is propagated.
+const DefaultTimeout = 60 * time.Second
+
+// DefaultThrottle is the default minimum interval between two reads from the heartbeat stream.
+const DefaultThrottle = 3 * time.Second
+
+// TimeFunc extracts the time at which a heartbeat was sent from its stream message.
+type TimeFunc func(redis.XMessage) (time.Time, error)
+
+// Option configures New.
+type Option interface {
+	apply(*Heartbeat)
+}
+
+// WithTimeout sets how long a heartbeat may be absent before a heartbeat loss is propagated.
+// Defaults to DefaultTimeout.
+func WithTimeout(timeout time.Duration) Option {
+	return optionFunc(func(h *Heartbeat) {
+		h.timeout = timeout
+	})
+}
+
+// WithThrottle sets the minimum interval between two reads from the heartbeat stream.
+// Heartbeats sent more frequently are skipped. Defaults to DefaultThrottle.
+func WithThrottle(throttle time.Duration) Option {
+	return optionFunc(func(h *Heartbeat) {
+		h.throttle = throttle
+	})
+}
+
+// WithTimeFunc configures how the time at which a heartbeat was sent is extracted from its stream message.
+// If set, heartbeats which were sent more than the timeout ago are considered expired and are discarded, and
+// heartbeats which claim to be sent more than the timeout in the future are logged, as they indicate clock skew.
+func WithTimeFunc(f TimeFunc) Option {
+	return optionFunc(func(h *Heartbeat) {
+		h.timeFunc = f
+	})
+}
+
+// Heartbeat periodically reads heartbeats from a Redis stream and signals in Events when they are received.
+// Also signals if the heartbeat is lost.
+type Heartbeat struct {
+	active         bool
+	events         chan *Message
+	lastReceivedMs atomic.Int64
+	cancelCtx      context.CancelFunc
+	client         *redis.Client
+	done           chan struct{}
+	errMu          sync.Mutex
+	err            error
+	logger         *logging.Logger
+	stream         string
+	timeout        time.Duration
+	throttle       time.Duration
+	timeFunc       TimeFunc
+}
+
+// New returns a new Heartbeat for the given stream key and starts the heartbeat controller loop.
+func New(ctx context.Context, client *redis.Client, logger *logging.Logger, stream string, options ...Option) *Heartbeat {
+	ctx, cancelCtx := context.WithCancel(ctx)
+
+	heartbeat := &Heartbeat{
+		events:    make(chan *Message, 1),
+		cancelCtx: cancelCtx,
+		client:    client,
+		done:      make(chan struct{}),
+		logger:    logger,
+		stream:    stream,
+		timeout:   DefaultTimeout,
+		throttle:  DefaultThrottle,
+	}
+
+	for _, option := range options {
+		option.apply(heartbeat)
+	}
+
+	go heartbeat.controller(ctx)
+
+	return heartbeat
+}
+
+// Events returns a channel that is sent to on heartbeat events.
+//
+// A non-nil pointer signals that a heartbeat was received whereas a nil pointer signals a heartbeat loss.
+func (h *Heartbeat) Events() <-chan *Message {
+	return h.events
+}
+
+// LastReceived returns the last heartbeat's receive time in ms.
+func (h *Heartbeat) LastReceived() int64 {
+	return h.lastReceivedMs.Load()
+}
+
+// Close stops the heartbeat controller loop, waits for it to finish, and returns an error if any.
+// Implements the io.Closer interface.
+func (h *Heartbeat) Close() error {
+	h.cancelCtx()
+	<-h.Done()
+
+	return h.Err()
+}
+
+// Done returns a channel that will be closed when the heartbeat controller loop has ended.
+func (h *Heartbeat) Done() <-chan struct{} {
+	return h.done
+}
+
+// Err returns an error if Done has been closed and there is an error. Otherwise returns nil.
+func (h *Heartbeat) Err() error {
+	h.errMu.Lock()
+	defer h.errMu.Unlock()
+
+	return h.err
+}
+
+func (h *Heartbeat) controller(ctx context.Context) {
+	defer close(h.done)
+
+	messages := make(chan *Message)
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	// Message producer loop.
+	g.Go(func() error {
+		throttle := time.NewTicker(h.throttle)
+		defer throttle.Stop()
+
+		for id := "$"; ; {
+			streams, err := h.client.XReadUntilResult(ctx, &redis.XReadArgs{
+				Streams: []string{h.stream, id},
+				Count:   1,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "can't read heartbeat from %s", h.stream)
+			}
+
+			xm := streams[0].Messages[0]
+			id = xm.ID
+
+			m := &Message{
+				XMessage: xm,
+				received: time.Now(),
+				timeout:  h.timeout,
+			}
+
+			if h.timeFunc != nil {
+				sent, err := h.timeFunc(xm)
+				if err != nil {
+					return errors.Wrapf(err, "can't parse heartbeat time from %s", h.stream)
+				}
+
+				m.sent = sent
+
+				switch {
+				case IsExpired(sent, m.received, h.timeout):
+					h.logger.Warnw("Discarding expired heartbeat", zap.String("stream", h.stream),
+						zap.Time("sent", sent), zap.Duration("age", types.UnixMilli(m.received).Sub(types.UnixMilli(sent))))
+
+					continue
+				case IsFromFuture(sent, m.received, h.timeout):
+					h.logger.Warnw("Received heartbeat from the future, please check the system clocks", zap.String("stream", h.stream),
+						zap.Time("sent", sent), zap.Duration("ahead", types.UnixMilli(sent).Sub(types.UnixMilli(m.received))))
+				}
+			}
+
+			select {
+			case messages <- m:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			select {
+			case <-throttle.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+
+	// State loop.
+	g.Go(func() error {
+		for {
+			select {
+			case m := <-messages:
+				if !h.active {
+					h.logger.Infow("Received heartbeat", zap.String("stream", h.stream))
+					h.active = true
+				}
+
+				h.lastReceivedMs.Store(m.received.UnixMilli())
+				h.sendEvent(m)
+			case <-time.After(h.timeout):
+				if h.active {
+					h.logger.Warnw("Lost heartbeat", zap.String("stream", h.stream), zap.Duration("timeout", h.timeout))
+					h.sendEvent(nil)
+					h.active = false
+				} else {
+					h.logger.Warnw("Waiting for heartbeat", zap.String("stream", h.stream))
+				}
+
+				h.lastReceivedMs.Store(0)
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+
+	// Since the goroutines of the group actually run endlessly,
+	// we wait here forever, unless an error occurs.
+	if err := g.Wait(); err != nil && !utils.IsContextCanceled(err) {
+		// Do not propagate any context-canceled errors here,
+		// as this is to be expected when calling Close or
+		// when the parent context is canceled.
+		h.setError(err)
+	}
+}
+
+// IsExpired reports whether a heartbeat sent at sent is older than timeout at now, tolerating clock skew up to timeout.
+func IsExpired(sent, now time.Time, timeout time.Duration) bool {
+	return types.UnixMilli(sent).SkewTolerantBefore(types.UnixMilli(now), timeout)
+}
+
+// IsFromFuture reports whether a heartbeat claims to be sent more than timeout after now,
+// which indicates that the clocks of the sender and the receiver are out of sync.
+func IsFromFuture(sent, now time.Time, timeout time.Duration) bool {
+	return types.UnixMilli(sent).SkewTolerantAfter(types.UnixMilli(now), timeout)
+}
+
+func (h *Heartbeat) setError(err error) {
+	h.errMu.Lock()
+	defer h.errMu.Unlock()
+
+	h.err = errors.Wrap(err, "heartbeat failed")
+}
+
+func (h *Heartbeat) sendEvent(m *Message) {
+	// Remove any not yet delivered event
+	select {
+	case old := <-h.events:
+		if old != nil {
+			kv := []any{zap.Time("previous", old.received)}
+			if m != nil {
+				kv = append(kv, zap.Time("current", m.received))
+			}
+
+			h.logger.Debugw("Previous heartbeat not read from channel", kv...)
+		} else {
+			h.logger.Debug("Previous heartbeat loss event not read from channel")
+		}
+	default:
+	}
+
+	h.events <- m
+}
+
+// Message represents a heartbeat stream message together with a timestamp when it was received.
+type Message struct {
+	redis.XMessage
+
+	received time.Time
+	sent     time.Time
+	timeout  time.Duration
+}
+
+// Received returns the time at which the heartbeat was received.
+func (m *Message) Received() time.Time {
+	return m.received
+}
+
+// Sent returns the time at which the heartbeat was sent,
+// or the zero time if no TimeFunc has been configured via WithTimeFunc.
+func (m *Message) Sent() time.Time {
+	return m.sent
+}
+
+// ExpiryTime returns the timestamp when the heartbeat expires.
+func (m *Message) ExpiryTime() time.Time {
+	return m.received.Add(m.timeout)
+}
+
+type optionFunc func(*Heartbeat)
+
+func (f optionFunc) apply(h *Heartbeat) {
+	f(h)
+}
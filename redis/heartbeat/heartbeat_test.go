@@ -0,0 +1,199 @@
+package heartbeat
+
+import (
+	"context"
+	"github.com/creasty/defaults"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/icinga/icinga-go-library/testutils/redistest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// sendHeartbeats adds a heartbeat to the stream every 10ms until the returned function is called.
+// Its timestamp field is set to the time returned by sent, or the current time if sent is nil.
+func sendHeartbeats(s *redistest.Server, stream string, sent func() time.Time) (stop func()) {
+	if sent == nil {
+		sent = time.Now
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			s.XAdd(stream, map[string]string{"timestamp": strconv.FormatInt(sent().UnixMilli(), 10)})
+
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// timestampFunc is a TimeFunc that parses the timestamp field written by sendHeartbeats.
+func timestampFunc(xm redis.XMessage) (time.Time, error) {
+	ms, err := strconv.ParseInt(xm.Values["timestamp"].(string), 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "can't parse timestamp")
+	}
+
+	return time.UnixMilli(ms), nil
+}
+
+// requireEvent waits for the next event of h and asserts whether it signals a received heartbeat or a loss.
+func requireEvent(t *testing.T, h *Heartbeat, received bool) *Message {
+	t.Helper()
+
+	select {
+	case m := <-h.Events():
+		if received {
+			require.NotNil(t, m, "heartbeat must be received")
+		} else {
+			require.Nil(t, m, "heartbeat must be lost")
+		}
+
+		return m
+	case <-time.After(2 * time.Second):
+		require.Fail(t, "no heartbeat event", "expected received=%v", received)
+
+		return nil
+	}
+}
+
+func newTestHeartbeat(t *testing.T, s *redistest.Server, heartbeatOptions ...Option) *Heartbeat {
+	var options redis.Options
+	require.NoError(t, defaults.Set(&options))
+	options.BlockTimeout = 50 * time.Millisecond
+
+	client := s.NewClient(t, options)
+	logger := logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour)
+
+	h := New(context.Background(), client, logger, "icinga:heartbeat", heartbeatOptions...)
+	t.Cleanup(func() { _ = h.Close() })
+
+	return h
+}
+
+func TestHeartbeat(t *testing.T) {
+	s := redistest.Start(t)
+	h := newTestHeartbeat(t, s, WithTimeout(200*time.Millisecond), WithThrottle(10*time.Millisecond))
+
+	stop := sendHeartbeats(s, "icinga:heartbeat", nil)
+
+	m := requireEvent(t, h, true)
+	require.NotEmpty(t, m.ID)
+	require.Equal(t, m.Received().Add(200*time.Millisecond), m.ExpiryTime())
+	require.True(t, m.Sent().IsZero(), "sent time must not be parsed without TimeFunc")
+	require.NotZero(t, h.LastReceived())
+
+	t.Run("loss", func(t *testing.T) {
+		stop()
+
+		// Skip the events of heartbeats received before they stopped.
+		for lost := false; !lost; {
+			select {
+			case m := <-h.Events():
+				lost = m == nil
+			case <-time.After(2 * time.Second):
+				require.Fail(t, "heartbeat must be lost")
+			}
+		}
+
+		require.Zero(t, h.LastReceived(), "last receive time must be reset on loss")
+	})
+
+	t.Run("recovery", func(t *testing.T) {
+		stop := sendHeartbeats(s, "icinga:heartbeat", nil)
+		defer stop()
+
+		requireEvent(t, h, true)
+		require.NotZero(t, h.LastReceived())
+	})
+
+	require.NoError(t, h.Close())
+}
+
+func TestHeartbeat_TimeFunc(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		s := redistest.Start(t)
+		h := newTestHeartbeat(t, s, WithTimeout(time.Second), WithTimeFunc(timestampFunc))
+
+		stop := sendHeartbeats(s, "icinga:heartbeat", nil)
+		defer stop()
+
+		m := requireEvent(t, h, true)
+		require.WithinDuration(t, m.Received(), m.Sent(), time.Second)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		s := redistest.Start(t)
+		h := newTestHeartbeat(t, s, WithTimeout(100*time.Millisecond), WithTimeFunc(timestampFunc))
+
+		stop := sendHeartbeats(s, "icinga:heartbeat", func() time.Time {
+			return time.Now().Add(-time.Hour)
+		})
+		defer stop()
+
+		select {
+		case m := <-h.Events():
+			require.Nil(t, m, "expired heartbeats must be discarded")
+		case <-time.After(300 * time.Millisecond):
+		}
+
+		require.Zero(t, h.LastReceived())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		s := redistest.Start(t)
+		h := newTestHeartbeat(t, s, WithTimeFunc(timestampFunc))
+
+		for {
+			// Keep adding heartbeats, as those added before the first read are not read.
+			s.XAdd("icinga:heartbeat", map[string]string{"timestamp": "invalid"})
+
+			select {
+			case <-h.Done():
+				require.ErrorContains(t, h.Err(), "can't parse heartbeat time")
+				require.ErrorContains(t, h.Close(), "can't parse timestamp")
+
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Now()
+
+	require.False(t, IsExpired(now, now, time.Minute))
+	require.False(t, IsExpired(now.Add(-59*time.Second), now, time.Minute))
+	require.True(t, IsExpired(now.Add(-61*time.Second), now, time.Minute))
+	require.False(t, IsExpired(now.Add(time.Hour), now, time.Minute))
+}
+
+func TestIsFromFuture(t *testing.T) {
+	now := time.Now()
+
+	require.False(t, IsFromFuture(now, now, time.Minute))
+	require.False(t, IsFromFuture(now.Add(59*time.Second), now, time.Minute))
+	require.True(t, IsFromFuture(now.Add(61*time.Second), now, time.Minute))
+	require.False(t, IsFromFuture(now.Add(-time.Hour), now, time.Minute))
+}
@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// ErrLockNotHeld is returned by Lock methods that require the lock to be held,
+// i.e. if it has never been acquired, has already been released or has expired in the meantime.
+var ErrLockNotHeld = errors.New("lock not held")
+
+// releaseScript deletes the lock key, but only if it still holds our token,
+// so that a lock that has expired and been acquired by someone else is not released by accident.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// renewScript resets the expiry of the lock key, but only if it still holds our token.
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Lock is a distributed lock based on a single Redis key, which can be used to coordinate singleton work
+// across multiple processes. The lock is acquired using SET NX PX with a random token and
+// is only released or renewed if the key still holds this token.
+// While held, the lock is automatically renewed in the background every third of its TTL until it is released.
+// If the renewal fails, the lock is considered lost, see Lost.
+// Use Client.NewLock to create a Lock.
+type Lock struct {
+	client *Client
+	key    string
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	token  string
+	cancel context.CancelFunc
+	done   chan struct{}
+	lost   chan struct{}
+}
+
+// NewLock returns a new Lock for the specified key that expires after ttl unless renewed.
+// Panics if ttl is less than one millisecond, which is the smallest expiry supported by Redis.
+func (c *Client) NewLock(key string, ttl time.Duration) *Lock {
+	if ttl < time.Millisecond {
+		panic("lock TTL must be at least 1ms")
+	}
+
+	return &Lock{client: c, key: c.Key(key), ttl: ttl}
+}
+
+// Key returns the Redis key of the lock, including the Client's key prefix, if any.
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// TryAcquire tries to acquire the lock once and reports whether it succeeded.
+// If the lock is already held by this Lock, TryAcquire returns true without doing anything.
+// Once acquired, the lock is renewed in the background until Release is called,
+// even if ctx is canceled in the meantime.
+func (l *Lock) TryAcquire(ctx context.Context) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.token != "" {
+		return true, nil
+	}
+
+	token := uuid.NewString()
+
+	cmd := l.client.SetNX(ctx, l.key, token, l.ttl)
+	ok, err := cmd.Result()
+	if err != nil {
+		return false, WrapCmdErr(cmd)
+	}
+
+	if ok {
+		l.token = token
+		l.startRenewal(ctx)
+	}
+
+	return ok, nil
+}
+
+// Acquire blocks until the lock is acquired or ctx is canceled.
+// Between attempts, Acquire sleeps with an exponential backoff of at most the lock TTL.
+// Once acquired, the lock is renewed in the background until Release is called,
+// even if ctx is canceled in the meantime.
+func (l *Lock) Acquire(ctx context.Context) error {
+	b := backoff.NewExponentialWithJitter(10*time.Millisecond, max(l.ttl, 20*time.Millisecond))
+
+	for attempt := uint64(1); ; attempt++ {
+		ok, err := l.TryAcquire(ctx)
+		if err != nil {
+			if !retry.Retryable(err) || ctx.Err() != nil {
+				return errors.Wrapf(err, "can't acquire lock %q", l.key)
+			}
+
+			l.client.logger.Warnw("Can't acquire lock. Retrying", zap.String("key", l.key), zap.Error(err))
+		} else if ok {
+			return nil
+		}
+
+		select {
+		case <-time.After(b(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Renew resets the expiry of the lock to its TTL.
+// Returns ErrLockNotHeld if the lock is not held (anymore).
+// Renew is called automatically in the background while the lock is held,
+// so there is usually no need to call it manually.
+func (l *Lock) Renew(ctx context.Context) error {
+	l.mu.Lock()
+	token := l.token
+	l.mu.Unlock()
+
+	if token == "" {
+		return ErrLockNotHeld
+	}
+
+	cmd := renewScript.Run(ctx, l.client, []string{l.key}, token, l.ttl.Milliseconds())
+	n, err := cmd.Int64()
+	if err != nil {
+		return WrapCmdErr(cmd)
+	}
+
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Release stops the background renewal and deletes the lock key if it is still held by this Lock.
+// Returns ErrLockNotHeld if the lock is not held (anymore).
+func (l *Lock) Release(ctx context.Context) error {
+	// Take over the token and the renewal at once, so that concurrent calls neither consider the lock held
+	// nor stop the same renewal. The renewal is canceled before the token is visibly cleared,
+	// so that it doesn't mistake the release for a lost lock.
+	l.mu.Lock()
+	token, done := l.token, l.done
+	if l.cancel != nil {
+		l.cancel()
+	}
+	l.token, l.cancel, l.done = "", nil, nil
+	l.mu.Unlock()
+
+	if done != nil {
+		// l.mu must not be held while waiting for the renewal, which may need it.
+		// l.lost is kept, as it is closed by the renewal, so that Lost reports the release.
+		<-done
+	}
+
+	// The background renewal may have noticed that the lock is lost in the meantime.
+	if token == "" {
+		return ErrLockNotHeld
+	}
+
+	cmd := releaseScript.Run(ctx, l.client, []string{l.key}, token)
+	n, err := cmd.Int64()
+	if err != nil {
+		return WrapCmdErr(cmd)
+	}
+
+	if n == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+// Lost returns a channel that is closed once the lock is no longer held, i.e. when the background renewal
+// notices that the lock has expired or has been taken over by someone else, when the lock couldn't be renewed
+// within its TTL, e.g. because Redis is unavailable, or when the lock is released.
+// Returns nil if the lock has never been acquired.
+func (l *Lock) Lost() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lost
+}
+
+// startRenewal starts the background renewal of the lock. l.mu must be held.
+func (l *Lock) startRenewal(ctx context.Context) {
+	if l.cancel != nil {
+		// Release the context of a previous renewal that has ended because the lock was lost.
+		l.cancel()
+	}
+
+	// ctx may only be meant for acquiring the lock, so the renewal must only be stopped by Release.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	lost := make(chan struct{})
+
+	l.cancel = cancel
+	l.done = done
+	l.lost = lost
+
+	go func() {
+		defer close(done)
+		defer close(lost)
+
+		ticker := time.NewTicker(max(l.ttl/3, time.Millisecond))
+		defer ticker.Stop()
+
+		renewed := time.Now()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := l.Renew(ctx)
+				if err == nil {
+					renewed = time.Now()
+
+					continue
+				}
+
+				if ctx.Err() != nil {
+					return
+				}
+
+				if errors.Is(err, ErrLockNotHeld) || time.Since(renewed) >= l.ttl {
+					// Either someone else holds the lock or it has expired, as it couldn't be renewed in time.
+					l.client.logger.Warnw("Lost lock", zap.String("key", l.key), zap.Error(err))
+
+					l.mu.Lock()
+					if l.done == done {
+						l.token = ""
+					}
+					l.mu.Unlock()
+
+					return
+				}
+
+				l.client.logger.Warnw("Can't renew lock", zap.String("key", l.key), zap.Error(err))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
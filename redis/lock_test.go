@@ -0,0 +1,281 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLockServer is a fake Redis server that implements the commands used by Lock.
+type testLockServer struct {
+	mu      sync.Mutex
+	value   map[string]string
+	expires map[string]time.Time
+
+	// unavailable lets all commands fail, e.g. to simulate a connection problem.
+	unavailable bool
+}
+
+// handle implements resp.Handler.
+func (s *testLockServer) handle(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unavailable {
+		return resp.Error("LOADING Redis is loading the dataset in memory")
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "SET":
+		// SET key value [PX ms | EX s] NX
+		key := args[1]
+		if _, ok := s.get(key); ok {
+			return resp.NilBulk
+		}
+
+		s.value[key] = args[2]
+		delete(s.expires, key)
+
+		for i := 3; i+1 < len(args); i++ {
+			n, _ := strconv.ParseInt(args[i+1], 10, 64)
+
+			switch strings.ToUpper(args[i]) {
+			case "PX":
+				s.expires[key] = time.Now().Add(time.Duration(n) * time.Millisecond)
+			case "EX":
+				s.expires[key] = time.Now().Add(time.Duration(n) * time.Second)
+			}
+		}
+
+		return resp.OK
+	case "EVALSHA":
+		return resp.Error("NOSCRIPT No matching script. Please use EVAL.")
+	case "EVAL":
+		// EVAL script 1 key token [ttl]
+		key, token := args[3], args[4]
+		if v, ok := s.get(key); !ok || v != token {
+			return resp.Integer(0)
+		}
+
+		switch {
+		case strings.Contains(args[1], "PEXPIRE"):
+			ms, _ := strconv.ParseInt(args[5], 10, 64)
+			s.expires[key] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		case strings.Contains(args[1], "DEL"):
+			delete(s.value, key)
+			delete(s.expires, key)
+		}
+
+		return resp.Integer(1)
+	default:
+		return resp.Error("ERR unknown command")
+	}
+}
+
+// get returns the value of key, if it exists and has not expired. s.mu must be held.
+func (s *testLockServer) get(key string) (string, bool) {
+	if expires, ok := s.expires[key]; ok && !time.Now().Before(expires) {
+		delete(s.value, key)
+		delete(s.expires, key)
+	}
+
+	v, ok := s.value[key]
+
+	return v, ok
+}
+
+// steal replaces the token of the lock stored at key, as if it had expired and been acquired by someone else.
+func (s *testLockServer) steal(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.value[key] = "someone else"
+}
+
+// setUnavailable sets whether all commands fail.
+func (s *testLockServer) setUnavailable(unavailable bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unavailable = unavailable
+}
+
+func newTestLockClient(t *testing.T) (*Client, *testLockServer) {
+	s := &testLockServer{value: map[string]string{}, expires: map[string]time.Time{}}
+
+	return newTestClient(t, s.handle).WithKeyPrefix("icinga:"), s
+}
+
+func TestLock_Acquire(t *testing.T) {
+	c, _ := newTestLockClient(t)
+
+	l := c.NewLock("lock", time.Minute)
+	require.Equal(t, "icinga:lock", l.Key())
+	require.Nil(t, l.Lost(), "lock must not be lost before it has been acquired")
+
+	require.NoError(t, l.Acquire(context.Background()))
+	defer func() { _ = l.Release(context.Background()) }()
+
+	ok, err := l.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok, "acquiring a held lock again must succeed")
+
+	select {
+	case <-l.Lost():
+		require.Fail(t, "lock must not be lost")
+	default:
+	}
+}
+
+func TestLock_Contention(t *testing.T) {
+	c, _ := newTestLockClient(t)
+
+	l1 := c.NewLock("lock", time.Minute)
+	l2 := c.NewLock("lock", time.Minute)
+
+	ok, err := l1.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = l2.TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok, "lock must not be acquired twice")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l2.Acquire(ctx), context.DeadlineExceeded)
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- l2.Acquire(context.Background())
+	}()
+
+	require.NoError(t, l1.Release(context.Background()))
+	require.NoError(t, <-acquired, "lock must be acquired once released")
+	require.NoError(t, l2.Release(context.Background()))
+}
+
+func TestLock_Renewal(t *testing.T) {
+	c, _ := newTestLockClient(t)
+
+	l := c.NewLock("lock", 60*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, l.Acquire(ctx))
+
+	// The renewal must not depend on the context used for acquiring the lock.
+	cancel()
+
+	time.Sleep(200 * time.Millisecond)
+
+	ok, err := c.NewLock("lock", time.Minute).TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.False(t, ok, "lock must be renewed beyond its TTL")
+
+	select {
+	case <-l.Lost():
+		require.Fail(t, "lock must not be lost")
+	default:
+	}
+
+	require.NoError(t, l.Release(context.Background()))
+}
+
+func TestLock_Release(t *testing.T) {
+	c, _ := newTestLockClient(t)
+
+	l := c.NewLock("lock", time.Minute)
+	require.NoError(t, l.Acquire(context.Background()))
+
+	lost := l.Lost()
+	require.NoError(t, l.Release(context.Background()))
+
+	select {
+	case <-lost:
+	default:
+		require.Fail(t, "lock must be reported as not held anymore once released")
+	}
+
+	require.ErrorIs(t, l.Release(context.Background()), ErrLockNotHeld)
+	require.ErrorIs(t, l.Renew(context.Background()), ErrLockNotHeld)
+
+	ok, err := c.NewLock("lock", time.Minute).TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok, "released lock must be acquirable")
+}
+
+func TestLock_ConcurrentRelease(t *testing.T) {
+	c, _ := newTestLockClient(t)
+
+	l := c.NewLock("lock", 30*time.Millisecond)
+	require.NoError(t, l.Acquire(context.Background()))
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() { errs <- l.Release(context.Background()) }()
+	}
+
+	var released int
+	for range 2 {
+		if err := <-errs; err == nil {
+			released++
+		} else {
+			require.ErrorIs(t, err, ErrLockNotHeld)
+		}
+	}
+
+	require.Equal(t, 1, released, "lock must be released exactly once")
+
+	select {
+	case <-l.Lost():
+	default:
+		require.Fail(t, "lock must be reported as not held anymore once released")
+	}
+
+	ok, err := c.NewLock("lock", time.Minute).TryAcquire(context.Background())
+	require.NoError(t, err)
+	require.True(t, ok, "released lock must be acquirable")
+}
+
+func TestLock_Lost(t *testing.T) {
+	t.Run("taken-over", func(t *testing.T) {
+		c, s := newTestLockClient(t)
+
+		l := c.NewLock("lock", 30*time.Millisecond)
+		require.NoError(t, l.Acquire(context.Background()))
+
+		s.steal("icinga:lock")
+
+		select {
+		case <-l.Lost():
+		case <-time.After(time.Second):
+			require.Fail(t, "lock must be lost once taken over")
+		}
+
+		require.ErrorIs(t, l.Renew(context.Background()), ErrLockNotHeld)
+		require.ErrorIs(t, l.Release(context.Background()), ErrLockNotHeld)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		c, s := newTestLockClient(t)
+
+		l := c.NewLock("lock", 30*time.Millisecond)
+		require.NoError(t, l.Acquire(context.Background()))
+
+		s.setUnavailable(true)
+
+		select {
+		case <-l.Lost():
+		case <-time.After(time.Second):
+			require.Fail(t, "lock must be lost once it can't be renewed within its TTL")
+		}
+
+		s.setUnavailable(false)
+		require.ErrorIs(t, l.Release(context.Background()), ErrLockNotHeld)
+	})
+}
@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/periodic"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap/zapcore"
+)
+
+// PoolStats provides statistics of the connection pool of a Client, see Client.PoolStats.
+type PoolStats struct {
+	redis.PoolStats
+
+	// PoolSize is the maximum number of connections in the pool.
+	PoolSize int
+}
+
+// InUse returns the number of connections currently in use, i.e. not idle.
+func (s *PoolStats) InUse() uint32 {
+	if s.IdleConns > s.TotalConns {
+		return 0
+	}
+
+	return s.TotalConns - s.IdleConns
+}
+
+// MarshalLogObject implements the [zapcore.ObjectMarshaler] interface.
+func (s *PoolStats) MarshalLogObject(encoder zapcore.ObjectEncoder) error {
+	encoder.AddInt("size", s.PoolSize)
+	encoder.AddUint32("total", s.TotalConns)
+	encoder.AddUint32("idle", s.IdleConns)
+	encoder.AddUint32("stale", s.StaleConns)
+	encoder.AddUint32("hits", s.Hits)
+	encoder.AddUint32("misses", s.Misses)
+	encoder.AddUint32("timeouts", s.Timeouts)
+
+	return nil
+}
+
+// PoolStats returns the statistics of the connection pool, including its size.
+// Timeouts are the number of times waiting for a free connection timed out because all connections were in use,
+// i.e. an increasing number indicates that the pool is exhausted.
+func (c *Client) PoolStats() *PoolStats {
+	return &PoolStats{PoolStats: *c.Client.PoolStats(), PoolSize: c.Client.Options().PoolSize}
+}
+
+// LogPoolStats periodically logs the statistics of the connection pool at debug level,
+// if connections have been requested from the pool since the last time, until ctx is canceled or
+// the returned periodic.Stopper is stopped.
+func (c *Client) LogPoolStats(ctx context.Context) periodic.Stopper {
+	var last PoolStats
+
+	return periodic.Start(ctx, c.logger.Interval(), func(periodic.Tick) {
+		s := c.PoolStats()
+		if s.Hits == last.Hits && s.Misses == last.Misses && s.Timeouts == last.Timeouts {
+			return
+		}
+
+		c.logger.Debugf(
+			"Redis connection pool: %d of %d connections in use, %d idle, %d hits, %d misses and %d timeouts",
+			s.InUse(), s.PoolSize, s.IdleConns, s.Hits-last.Hits, s.Misses-last.Misses, s.Timeouts-last.Timeouts,
+		)
+
+		last = *s
+	})
+}
+
+// Assert interface compliance.
+var _ zapcore.ObjectMarshaler = (*PoolStats)(nil)
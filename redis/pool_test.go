@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"testing"
+)
+
+func TestClient_PoolStats(t *testing.T) {
+	c := newTestClient(t, func(args []string) string {
+		if strings.ToUpper(args[0]) != "PING" {
+			return resp.Error("ERR unknown command")
+		}
+
+		return "+PONG\r\n"
+	})
+
+	require.NoError(t, c.Ping(context.Background()).Err())
+
+	s := c.PoolStats()
+	require.Equal(t, c.Client.Options().PoolSize, s.PoolSize)
+	require.Equal(t, uint32(1), s.TotalConns)
+	require.Equal(t, uint32(1), s.IdleConns)
+	require.Equal(t, uint32(0), s.InUse())
+	require.Equal(t, uint32(1), s.Misses)
+
+	enc := zapcore.NewMapObjectEncoder()
+	require.NoError(t, c.MarshalLogObject(enc))
+	require.Equal(t, c.GetAddr(), enc.Fields["redis_address"])
+	require.Equal(t, map[string]any{
+		"size":     s.PoolSize,
+		"total":    uint32(1),
+		"idle":     uint32(1),
+		"stale":    uint32(0),
+		"hits":     s.Hits,
+		"misses":   uint32(1),
+		"timeouts": uint32(0),
+	}, enc.Fields["redis_pool"])
+}
+
+func TestPoolStats_InUse(t *testing.T) {
+	s := PoolStats{PoolStats: redis.PoolStats{TotalConns: 5, IdleConns: 2}}
+	require.Equal(t, uint32(3), s.InUse())
+
+	s.IdleConns = 6
+	require.Equal(t, uint32(0), s.InUse())
+}
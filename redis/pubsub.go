@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/periodic"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// SubscribeStreamed subscribes to the given channels and yields the messages published to them until ctx is canceled.
+// The channel names are prefixed with the Client's key prefix, if any, which is removed from the yielded messages.
+// If the connection is lost, it is re-established using the retry logic of the dialer and the channels are
+// subscribed again. Messages published in the meantime are lost, as Redis doesn't buffer them.
+// The messages are buffered according to Options.SubscribeBufferSize and Options.SubscribeOverflow.
+func (c *Client) SubscribeStreamed(ctx context.Context, channels ...string) (<-chan Message, <-chan error) {
+	prefixed := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		prefixed = append(prefixed, c.Key(channel))
+	}
+
+	messages := make(chan Message, c.Options.SubscribeBufferSize)
+	dropOldest := c.Options.SubscribeOverflow == SubscribeDropOldest
+
+	return messages, com.WaitAsync(com.WaiterFunc(func() error {
+		defer close(messages)
+
+		var dropped com.Counter
+		logDropped := func(periodic.Tick) {
+			if n := dropped.Reset(); n > 0 {
+				c.logger.Warnw("Dropped Redis messages because the buffer is full",
+					zap.Strings("channels", prefixed), zap.Uint64("dropped", n))
+			}
+		}
+		defer periodic.Start(ctx, c.logger.Interval(), logDropped, periodic.OnStop(logDropped)).Stop()
+
+		pubsub := c.Subscribe(ctx, prefixed...)
+		defer func() { _ = pubsub.Close() }()
+
+		// Receiving blocks regardless of ctx, so we have to close the subscription to stop it.
+		stop := context.AfterFunc(ctx, func() { _ = pubsub.Close() })
+		defer stop()
+
+		b := backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second)
+		var failures uint64
+
+		for {
+			msg, err := pubsub.ReceiveMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+
+				if !retry.Retryable(err) {
+					return errors.Wrapf(err, "can't receive messages from %s", strings.Join(prefixed, ", "))
+				}
+
+				// The next receive reconnects and subscribes to the channels again.
+				failures++
+				if failures == 1 {
+					c.logger.Warnw("Lost Redis subscription. Resubscribing",
+						zap.Strings("channels", prefixed), zap.Error(err))
+				}
+
+				select {
+				case <-time.After(b(failures)):
+					continue
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			if failures > 0 {
+				c.logger.Infow("Redis subscription restored", zap.Strings("channels", prefixed))
+				failures = 0
+			}
+
+			m := *msg
+			m.Channel = strings.TrimPrefix(m.Channel, c.keyPrefix)
+
+			if dropOldest {
+				select {
+				case messages <- m:
+				default:
+					// We are the only sender, so there is room for m after discarding one message,
+					// unless the consumer has taken one in the meantime, which also makes room.
+					select {
+					case <-messages:
+						dropped.Inc()
+					default:
+					}
+
+					messages <- m
+				}
+
+				continue
+			}
+
+			select {
+			case messages <- m:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}))
+}
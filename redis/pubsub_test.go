@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_SubscribeStreamed(t *testing.T) {
+	c := newPubSubTestClient(t, func(n int, channel string) (string, bool) {
+		// Drop the first connection after the first message to force a resubscription.
+		return subscribedReply(channel) + messageReply(channel, fmt.Sprint(n)), n == 0
+	}).WithKeyPrefix("icinga:")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages, errs := c.SubscribeStreamed(ctx, "events")
+
+	for _, payload := range []string{"0", "1"} {
+		select {
+		case m := <-messages:
+			require.Equal(t, "events", m.Channel, "key prefix must be removed from the channel")
+			require.Equal(t, payload, m.Payload)
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			require.Fail(t, "timeout")
+		}
+	}
+
+	cancel()
+	require.ErrorIs(t, <-errs, context.Canceled)
+
+	_, ok := <-messages
+	require.False(t, ok, "messages must be closed")
+}
+
+func TestClient_SubscribeStreamed_DropOldest(t *testing.T) {
+	resubscribed := make(chan struct{})
+
+	c := newPubSubTestClient(t, func(n int, channel string) (string, bool) {
+		if n > 0 {
+			// All messages of the first connection have been processed once the client resubscribes.
+			close(resubscribed)
+
+			return subscribedReply(channel), false
+		}
+
+		reply := subscribedReply(channel)
+		for i := range 4 {
+			reply += messageReply(channel, fmt.Sprint(i))
+		}
+
+		return reply, true
+	})
+	c.Options.SubscribeBufferSize = 2
+	c.Options.SubscribeOverflow = SubscribeDropOldest
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages, _ := c.SubscribeStreamed(ctx, "events")
+
+	select {
+	case <-resubscribed:
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "timeout")
+	}
+
+	require.Equal(t, "2", (<-messages).Payload)
+	require.Equal(t, "3", (<-messages).Payload)
+}
+
+// newPubSubTestClient returns a Client connected to a test server that answers the n-th SUBSCRIBE, counting from 0,
+// with the reply returned by subscribe and closes the connection afterwards if requested.
+func newPubSubTestClient(t *testing.T, subscribe func(n int, channel string) (string, bool)) *Client {
+	var mu sync.Mutex
+	var subscriptions int
+
+	return newRespTestClient(t, func(args []string) (string, bool) {
+		if strings.ToUpper(args[0]) != "SUBSCRIBE" {
+			return resp.Error("ERR unknown command"), false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		reply, drop := subscribe(subscriptions, args[1])
+		subscriptions++
+
+		return reply, drop
+	})
+}
+
+// subscribedReply returns the RESP reply confirming the subscription to channel.
+func subscribedReply(channel string) string {
+	return resp.Array(resp.Bulk("subscribe"), resp.Bulk(channel), resp.Integer(1))
+}
+
+// messageReply returns the RESP push of a message published to channel.
+func messageReply(channel, payload string) string {
+	return resp.Bulks("message", channel, payload)
+}
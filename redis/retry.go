@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// readCommands contains the names of the supported commands that don't modify any data.
+// All other commands are considered writes.
+var readCommands = map[string]struct{}{
+	"dbsize": {}, "exists": {}, "get": {}, "getrange": {}, "hexists": {}, "hget": {}, "hgetall": {},
+	"hkeys": {}, "hlen": {}, "hmget": {}, "hscan": {}, "hstrlen": {}, "hvals": {}, "keys": {},
+	"lindex": {}, "llen": {}, "lrange": {}, "mget": {}, "ping": {}, "pttl": {}, "scan": {},
+	"scard": {}, "sismember": {}, "smembers": {}, "sscan": {}, "strlen": {}, "ttl": {}, "type": {},
+	"xinfo": {}, "xlen": {}, "xpending": {}, "xrange": {}, "xread": {}, "xrevrange": {},
+	"zcard": {}, "zcount": {}, "zrange": {}, "zrangebyscore": {}, "zrank": {}, "zrevrange": {},
+	"zrevrangebyscore": {}, "zscan": {}, "zscore": {},
+}
+
+// isReadCommand returns whether cmd doesn't modify any data.
+func isReadCommand(cmd redis.Cmder) bool {
+	_, ok := readCommands[strings.ToLower(cmd.Name())]
+
+	return ok
+}
+
+// retryHook is a redis.Hook that retries single commands on retryable errors
+// using the same backoff and logging behavior as the database layer.
+// Pipelines and transactions are passed through as is, as they may be partially applied.
+type retryHook struct {
+	logger   *logging.Logger
+	registry *retry.Registry
+	reads    bool
+	writes   bool
+}
+
+// newRetryHook returns a redis.Hook that retries read and/or write commands as configured in options.
+func newRetryHook(logger *logging.Logger, options *Options) redis.Hook {
+	return retryHook{
+		logger: logger, registry: options.RetryRegistry, reads: options.RetryReads, writes: options.RetryWrites,
+	}
+}
+
+// DialHook implements the redis.Hook interface.
+// Dialing is already retried by dialWithLogging.
+func (h retryHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements the redis.Hook interface.
+func (h retryHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if read := isReadCommand(cmd); read && !h.reads || !read && !h.writes {
+			return next(ctx, cmd)
+		}
+
+		// The error returned here becomes the error of cmd, so callers can still compare it to redis.Nil.
+		// Hence, return the error of the last attempt instead of the one decorated by retry.WithBackoff.
+		var lastErr error
+		err := retry.WithBackoff(
+			ctx,
+			func(ctx context.Context) error {
+				lastErr = next(ctx, cmd)
+
+				return lastErr
+			},
+			retryableCommandError,
+			backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
+			retry.Settings{
+				Timeout:  retry.DefaultTimeout,
+				Registry: h.registry,
+				Name:     "redis command",
+				OnRetryableError: func(_ time.Duration, _ uint64, err, lastErr error) {
+					if lastErr == nil || err.Error() != lastErr.Error() {
+						h.logger.Warnw("Can't execute Redis command. Retrying",
+							zap.String("command", cmd.Name()), zap.Error(err))
+					}
+				},
+				OnSuccess: func(elapsed time.Duration, attempt uint64, _ error) {
+					if attempt > 1 {
+						h.logger.Infow("Redis command finally succeeded", zap.String("command", cmd.Name()),
+							zap.Duration("after", elapsed), zap.Uint64("attempts", attempt))
+					}
+				},
+			},
+		)
+		if err != nil && lastErr != nil {
+			return lastErr
+		}
+
+		return err
+	}
+}
+
+// ProcessPipelineHook implements the redis.Hook interface.
+func (h retryHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+// retryableCommandError returns true for errors that are considered retryable by retry.Retryable
+// and for Redis error replies indicating a temporary condition, e.g. while loading the dataset.
+func retryableCommandError(err error) bool {
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+
+	if retry.Retryable(err) {
+		return true
+	}
+
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range []string{"LOADING ", "READONLY ", "MASTERDOWN ", "TRYAGAIN ", "CLUSTERDOWN "} {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Assert interface compliance.
+var (
+	_ redis.Hook = retryHook{}
+)
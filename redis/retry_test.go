@@ -0,0 +1,150 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryHook_ProcessHook(t *testing.T) {
+	logger := logging.NewLogger(zap.NewNop().Sugar(), time.Second)
+
+	subtests := []struct {
+		name     string
+		options  Options
+		cmd      redis.Cmder
+		err      error
+		attempts int
+	}{
+		{"read-retried", Options{RetryReads: true}, redis.NewStringCmd(context.Background(), "get", "k"), nil, 3},
+		{"read-not-retried", Options{RetryWrites: true}, redis.NewStringCmd(context.Background(), "get", "k"), syscall.ECONNRESET, 1},
+		{"write-retried", Options{RetryWrites: true}, redis.NewStatusCmd(context.Background(), "set", "k", "v"), nil, 3},
+		{"write-not-retried", Options{RetryReads: true}, redis.NewStatusCmd(context.Background(), "set", "k", "v"), syscall.ECONNRESET, 1},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			attempts := 0
+			process := newRetryHook(logger, &st.options).ProcessHook(func(context.Context, redis.Cmder) error {
+				if attempts++; attempts < 3 {
+					return syscall.ECONNRESET
+				}
+
+				return nil
+			})
+
+			require.ErrorIs(t, process(context.Background(), st.cmd), st.err)
+			require.Equal(t, st.attempts, attempts)
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		attempts := 0
+		process := newRetryHook(logger, &Options{RetryReads: true}).ProcessHook(func(context.Context, redis.Cmder) error {
+			attempts++
+
+			return redis.Nil
+		})
+
+		require.Equal(t, redis.Nil, process(context.Background(), redis.NewStringCmd(context.Background(), "get", "k")))
+		require.Equal(t, 1, attempts)
+	})
+}
+
+func TestNewClient_Retry(t *testing.T) {
+	for _, retry := range []bool{false, true} {
+		t.Run(fmt.Sprintf("retry-reads=%v", retry), func(t *testing.T) {
+			var attempts atomic.Int32
+			s := resp.Start(t, resp.Replies(func(args []string) string {
+				if args[0] != "get" {
+					return resp.Error("ERR unknown command")
+				}
+
+				if attempts.Add(1) < 3 {
+					return resp.Error("LOADING Redis is loading the dataset in memory")
+				}
+
+				return resp.Bulk("v")
+			}))
+
+			// Disable the retries of go-redis itself, so that only those of the hook remain.
+			rc := redis.NewClient(&redis.Options{Addr: s.Addr(), Protocol: 2, DisableIndentity: true, MaxRetries: -1})
+			t.Cleanup(func() { _ = rc.Close() })
+
+			c := NewClient(rc, logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour), &Options{RetryReads: retry})
+
+			v, err := c.Get(context.Background(), "k").Result()
+			if retry {
+				require.NoError(t, err, "NewClient must install the retry hook")
+				require.Equal(t, "v", v)
+				require.Equal(t, int32(3), attempts.Load())
+			} else {
+				require.ErrorContains(t, err, "LOADING")
+				require.Equal(t, int32(1), attempts.Load())
+			}
+		})
+	}
+}
+
+func TestNewClient_RetryHookOnce(t *testing.T) {
+	var attempts atomic.Int32
+	blocked := make(chan struct{})
+	s := resp.Start(t, resp.Replies(func(args []string) string {
+		if args[0] != "get" {
+			return resp.Error("ERR unknown command")
+		}
+
+		if attempts.Add(1) == 1 {
+			return resp.Error("LOADING Redis is loading the dataset in memory")
+		}
+
+		<-blocked
+
+		return resp.Bulk("v")
+	}))
+
+	rc := redis.NewClient(&redis.Options{Addr: s.Addr(), Protocol: 2, DisableIndentity: true, MaxRetries: -1})
+	t.Cleanup(func() { _ = rc.Close() })
+
+	// If the second call added another hook, it would be called by the first one and retry on its own.
+	logger := logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour)
+	first, second := &retry.Registry{}, &retry.Registry{}
+	c := NewClient(rc, logger, &Options{RetryReads: true, RetryRegistry: first})
+	_ = NewClient(rc, logger, &Options{RetryReads: true, RetryRegistry: second})
+
+	done := make(chan error, 1)
+	go func() { done <- c.Get(context.Background(), "k").Err() }()
+
+	require.Eventually(t, func() bool { return len(first.States()) == 1 }, time.Second, time.Millisecond,
+		"command must be retried by the hook of the first call")
+	require.Empty(t, second.States(), "hook must not be added twice")
+
+	close(blocked)
+	require.NoError(t, <-done)
+	require.Equal(t, int32(2), attempts.Load())
+}
+
+func TestRetryableCommandError(t *testing.T) {
+	require.False(t, retryableCommandError(redis.Nil))
+	require.False(t, retryableCommandError(errors.New("ERR wrong number of arguments")))
+	require.True(t, retryableCommandError(syscall.ECONNRESET))
+	require.True(t, retryableCommandError(errorReply("LOADING Redis is loading the dataset in memory")))
+}
+
+// errorReply is a Redis error reply.
+type errorReply string
+
+func (p errorReply) Error() string { return string(p) }
+
+func (errorReply) RedisError() {}
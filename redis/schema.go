@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+// SchemaKey is the Redis stream to which Icinga 2 writes the version of the schema of the data it writes to Redis.
+// Each entry has a field "version", whose value is an unsigned integer.
+const SchemaKey = "icinga:schema"
+
+var (
+	// ErrSchemaVersionMissing is returned if the schema stream is empty or doesn't exist,
+	// e.g. because Icinga 2 has not yet written to Redis.
+	ErrSchemaVersionMissing = errors.New("schema version missing")
+
+	// ErrSchemaTooOld matches a SchemaVersionError whose version is lower than the minimum supported one.
+	ErrSchemaTooOld = errors.New("schema version too old")
+
+	// ErrSchemaTooNew matches a SchemaVersionError whose version is higher than the maximum supported one.
+	ErrSchemaTooNew = errors.New("schema version too new")
+)
+
+// SchemaVersionError is returned by CheckSchemaVersion if the schema version is not supported.
+// It matches ErrSchemaTooOld or ErrSchemaTooNew in errors.Is.
+type SchemaVersionError struct {
+	Key     string // Key is the Redis key the version was read from.
+	Version uint64 // Version is the actual schema version.
+	Min     uint64 // Min is the minimum supported schema version.
+	Max     uint64 // Max is the maximum supported schema version.
+}
+
+// Error implements the error interface.
+func (e *SchemaVersionError) Error() string {
+	if e.Version < e.Min {
+		return fmt.Sprintf(
+			"unsupported Redis schema version %d in %q, expected at least %d. Please upgrade Icinga 2",
+			e.Version, e.Key, e.Min,
+		)
+	}
+
+	return fmt.Sprintf(
+		"unsupported Redis schema version %d in %q, expected at most %d. Please upgrade this software",
+		e.Version, e.Key, e.Max,
+	)
+}
+
+// Is returns true if target is ErrSchemaTooOld or ErrSchemaTooNew and matches the error.
+func (e *SchemaVersionError) Is(target error) bool {
+	switch target {
+	case ErrSchemaTooOld:
+		return e.Version < e.Min
+	case ErrSchemaTooNew:
+		return e.Version > e.Max
+	default:
+		return false
+	}
+}
+
+// GetSchemaVersion returns the version of the latest entry of the schema stream at key, usually SchemaKey.
+// Returns ErrSchemaVersionMissing if there is no such entry.
+func (c *Client) GetSchemaVersion(ctx context.Context, key string) (uint64, error) {
+	key = c.Key(key)
+
+	cmd := c.XRevRangeN(ctx, key, "+", "-", 1)
+	messages, err := cmd.Result()
+	if err != nil {
+		return 0, WrapCmdErr(cmd)
+	}
+
+	if len(messages) == 0 {
+		return 0, errors.Wrapf(ErrSchemaVersionMissing, "stream %q is empty", key)
+	}
+
+	v, ok := messages[0].Values["version"].(string)
+	if !ok {
+		return 0, errors.Wrapf(ErrSchemaVersionMissing, "latest entry of stream %q has no version", key)
+	}
+
+	version, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "can't parse schema version %q from stream %q", v, key)
+	}
+
+	return version, nil
+}
+
+// CheckSchemaVersion verifies that the version of the schema stream at key, usually SchemaKey,
+// is within the supported range [minVersion, maxVersion]. If not, a *SchemaVersionError is returned.
+func (c *Client) CheckSchemaVersion(ctx context.Context, key string, minVersion, maxVersion uint64) error {
+	version, err := c.GetSchemaVersion(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	return checkSchemaVersion(c.Key(key), version, minVersion, maxVersion)
+}
+
+// checkSchemaVersion returns a *SchemaVersionError if version is not within [minVersion, maxVersion].
+func checkSchemaVersion(key string, version, minVersion, maxVersion uint64) error {
+	if version < minVersion || version > maxVersion {
+		return errors.WithStack(&SchemaVersionError{Key: key, Version: version, Min: minVersion, Max: maxVersion})
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCheckSchemaVersion(t *testing.T) {
+	subtests := []struct {
+		name    string
+		version uint64
+		tooOld  bool
+		tooNew  bool
+	}{
+		{"too-old", 4, true, false},
+		{"min", 5, false, false},
+		{"max", 6, false, false},
+		{"too-new", 7, false, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			err := checkSchemaVersion(SchemaKey, st.version, 5, 6)
+			if !st.tooOld && !st.tooNew {
+				require.NoError(t, err)
+
+				return
+			}
+
+			var sve *SchemaVersionError
+			require.ErrorAs(t, err, &sve)
+			require.Equal(t, st.version, sve.Version)
+			require.Equal(t, st.tooOld, errors.Is(err, ErrSchemaTooOld))
+			require.Equal(t, st.tooNew, errors.Is(err, ErrSchemaTooNew))
+		})
+	}
+}
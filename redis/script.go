@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"time"
+)
+
+// ScriptOption configures a Script.
+type ScriptOption interface {
+	apply(*Script)
+}
+
+// ScriptTimeout limits each attempt to run the script to the given duration.
+// By default, only the context passed to Script.Run limits the attempts.
+func ScriptTimeout(timeout time.Duration) ScriptOption {
+	return scriptOptionFunc(func(s *Script) {
+		s.timeout = timeout
+	})
+}
+
+// Script is a Lua script that is executed atomically by Redis, e.g. to renew a heartbeat and set its expiry at once.
+// The script is referenced by its SHA1 digest using EVALSHA, so that its source is only sent to Redis
+// if Redis doesn't know it yet, i.e. on NOSCRIPT errors, which cause a fallback to EVAL that also caches the script.
+// Use Client.NewScript to create a Script.
+type Script struct {
+	client  *Client
+	script  *redis.Script
+	timeout time.Duration
+}
+
+// NewScript returns a new Script for the given Lua source.
+// Scripts are usually created once, e.g. at startup, and then run many times.
+func (c *Client) NewScript(src string, options ...ScriptOption) *Script {
+	s := &Script{client: c, script: redis.NewScript(src)}
+	for _, option := range options {
+		option.apply(s)
+	}
+
+	return s
+}
+
+// Hash returns the SHA1 digest of the script, by which it is referenced in EVALSHA.
+func (s *Script) Hash() string {
+	return s.script.Hash()
+}
+
+// Load loads the script into the script cache of Redis, which is not required but
+// saves the fallback to EVAL when the script is run for the first time.
+func (s *Script) Load(ctx context.Context) error {
+	cmd := s.script.Load(ctx, s.client)
+	if err := cmd.Err(); err != nil {
+		return WrapCmdErr(cmd)
+	}
+
+	return nil
+}
+
+// Run runs the script with the given keys, which are prefixed with the Client's key prefix, if any, and args.
+// The script is retried on transient errors, so it should be idempotent.
+// As with go-redis, the result and the error of the last attempt are available via the returned command,
+// i.e. a nil result is reported as redis.Nil.
+func (s *Script) Run(ctx context.Context, keys []string, args ...any) *redis.Cmd {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, s.client.Key(key))
+	}
+
+	var cmd *redis.Cmd
+	err := retry.WithBackoff(
+		ctx,
+		func(ctx context.Context) (err error) {
+			if s.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, s.timeout)
+				defer cancel()
+			}
+
+			ctx, span := s.client.startSpan(ctx, "EVALSHA", attribute.String("db.redis.script", s.Hash()))
+			defer func() { endSpan(span, err) }()
+
+			cmd = s.script.Run(ctx, s.client, prefixed, args...)
+			if err = cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+				return WrapCmdErr(cmd)
+			}
+
+			return nil
+		},
+		retryableCommandError,
+		backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
+		retry.Settings{
+			Timeout:  retry.DefaultTimeout,
+			Registry: s.client.Options.RetryRegistry,
+			Name:     "redis script",
+			OnRetryableError: func(_ time.Duration, _ uint64, err, lastErr error) {
+				if lastErr == nil || err.Error() != lastErr.Error() {
+					s.client.logger.Warnw("Can't run Redis script. Retrying", zap.String("script", s.Hash()), zap.Error(err))
+				}
+			},
+			OnSuccess: func(elapsed time.Duration, attempt uint64, _ error) {
+				if attempt > 1 {
+					s.client.logger.Infow("Redis script finally succeeded", zap.String("script", s.Hash()),
+						zap.Duration("after", elapsed), zap.Uint64("attempts", attempt))
+				}
+			},
+		},
+	)
+	if err != nil && cmd.Err() == nil {
+		// The context has been canceled while waiting for the next attempt.
+		cmd.SetErr(err)
+	}
+
+	return cmd
+}
+
+// scriptOptionFunc is a function that implements ScriptOption.
+type scriptOptionFunc func(*Script)
+
+// apply implements the ScriptOption interface.
+func (f scriptOptionFunc) apply(s *Script) {
+	f(s)
+}
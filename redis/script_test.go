@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScript_Run(t *testing.T) {
+	var mu sync.Mutex
+	var commands []string
+	var loaded, failed bool
+
+	c := newTestClient(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch strings.ToUpper(args[0]) {
+		case "EVALSHA":
+			commands = append(commands, "EVALSHA "+strings.Join(args[2:], " "))
+
+			if !loaded {
+				return resp.Error("NOSCRIPT No matching script. Please use EVAL.")
+			}
+			if !failed {
+				failed = true
+
+				return resp.Error("LOADING Redis is loading the dataset in memory")
+			}
+
+			return resp.Integer(2)
+		case "EVAL":
+			commands = append(commands, "EVAL "+strings.Join(args[2:], " "))
+			loaded = true
+
+			return resp.Integer(1)
+		default:
+			return resp.Error("ERR unknown command")
+		}
+	}).WithKeyPrefix("icinga:")
+
+	s := c.NewScript(`return redis.call("INCR", KEYS[1])`, ScriptTimeout(time.Second))
+
+	n, err := s.Run(context.Background(), []string{"heartbeat"}).Int64()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	n, err = s.Run(context.Background(), []string{"heartbeat"}).Int64()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+
+	require.Equal(t, []string{
+		"EVALSHA 1 icinga:heartbeat",
+		"EVAL 1 icinga:heartbeat",
+		"EVALSHA 1 icinga:heartbeat",
+		"EVALSHA 1 icinga:heartbeat",
+	}, commands)
+}
+
+func TestScript_Run_Nil(t *testing.T) {
+	c := newTestClient(t, func([]string) string {
+		return resp.NilBulk
+	})
+
+	require.Equal(t, Nil, c.NewScript(`return nil`).Run(context.Background(), nil).Err())
+}
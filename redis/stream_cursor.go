@@ -0,0 +1,209 @@
+package redis
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CursorStore persists the last acknowledged IDs of a StreamCursor as a stream key to ID mapping.
+// Client.NewHashCursorStore returns a CursorStore that uses a Redis hash,
+// but the IDs can also be stored elsewhere, e.g. in a database table.
+type CursorStore interface {
+	// Load returns the persisted IDs, which is empty if none have been saved yet.
+	Load(ctx context.Context) (map[string]string, error)
+
+	// Save persists the given IDs. Streams not contained in ids are kept as they are.
+	Save(ctx context.Context, ids map[string]string) error
+}
+
+// StreamCursor tracks the IDs of the last messages acknowledged for Redis streams
+// and persists them in a CursorStore, so that consumers can continue where they left off after a restart
+// without replaying or skipping messages. Typically, the IDs are restored using Restore on startup,
+// passed to Client.XReadUntilResult via Streams, acknowledged via Ack once a message has been processed, and
+// checkpointed periodically via Run. Use NewStreamCursor to create a StreamCursor.
+type StreamCursor struct {
+	store CursorStore
+
+	mu      sync.Mutex
+	streams Streams
+	dirty   Streams // IDs acknowledged since the last checkpoint.
+}
+
+// NewStreamCursor returns a new StreamCursor that persists its IDs in store.
+func NewStreamCursor(store CursorStore) *StreamCursor {
+	return &StreamCursor{store: store, streams: Streams{}, dirty: Streams{}}
+}
+
+// Restore loads the persisted IDs from the store. Streams without a persisted ID start at the ID given in defaults,
+// e.g. "0-0" to read all messages or "$" to only read new ones. Persisted IDs of other streams are ignored.
+func (c *StreamCursor) Restore(ctx context.Context, defaults Streams) error {
+	persisted, err := c.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.streams = make(Streams, len(defaults))
+	for stream, id := range defaults {
+		if persisted, ok := persisted[stream]; ok {
+			id = persisted
+		}
+
+		c.streams[stream] = id
+	}
+
+	c.dirty = Streams{}
+
+	return nil
+}
+
+// Streams returns the current IDs of all streams, e.g. for the STREAMS option of XREAD via Streams.Option.
+func (c *StreamCursor) Streams() Streams {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.streams)
+}
+
+// Ack marks the message with the given ID and all previous messages of stream as processed.
+// The ID is persisted with the next Checkpoint. IDs lower than the current one of the stream are ignored,
+// so that the cursor never moves backwards, e.g. if messages are acknowledged out of order.
+func (c *StreamCursor) Ack(stream, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.streams[stream]; ok && streamIdLess(id, current) {
+		return
+	}
+
+	c.streams[stream] = id
+	c.dirty[stream] = id
+}
+
+// Checkpoint persists the IDs acknowledged since the last checkpoint, if any.
+// If saving them fails, they are retained for the next Checkpoint.
+func (c *StreamCursor) Checkpoint(ctx context.Context) error {
+	c.mu.Lock()
+	dirty := c.dirty
+	c.dirty = Streams{}
+	c.mu.Unlock()
+
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	if err := c.store.Save(ctx, dirty); err != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		// Don't overwrite IDs that have been acknowledged in the meantime.
+		for stream, id := range dirty {
+			if _, ok := c.dirty[stream]; !ok {
+				c.dirty[stream] = id
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Run calls Checkpoint every interval until ctx is canceled or Checkpoint fails and returns the respective error.
+// As IDs acknowledged after the last checkpoint are not persisted,
+// Checkpoint should be called once more after the consumer has stopped.
+func (c *StreamCursor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Checkpoint(ctx); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// streamIdLess returns whether the stream ID a is lower than b. IDs consist of a millisecond timestamp and
+// an optional sequence number separated by a hyphen, e.g. "1526919030474-55". If either isn't such an ID,
+// e.g. the special ID "$", false is returned.
+func streamIdLess(a, b string) bool {
+	aMs, aSeq, ok := parseStreamId(a)
+	if !ok {
+		return false
+	}
+
+	bMs, bSeq, ok := parseStreamId(b)
+	if !ok {
+		return false
+	}
+
+	return aMs < bMs || aMs == bMs && aSeq < bSeq
+}
+
+// parseStreamId returns the millisecond timestamp and sequence number of the stream ID id,
+// which defaults to 0 if omitted. Returns false if id is not a valid stream ID.
+func parseStreamId(id string) (ms, seq uint64, ok bool) {
+	msPart, seqPart, hasSeq := strings.Cut(id, "-")
+
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if hasSeq {
+		seq, err = strconv.ParseUint(seqPart, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return ms, seq, true
+}
+
+// hashCursorStore is a CursorStore that stores the IDs in the fields of a Redis hash.
+type hashCursorStore struct {
+	client *Client
+	key    string
+}
+
+// NewHashCursorStore returns a CursorStore that stores the IDs in the Redis hash key,
+// with the stream keys as fields. The key is prefixed with the Client's key prefix, if any.
+func (c *Client) NewHashCursorStore(key string) CursorStore {
+	return hashCursorStore{client: c, key: c.Key(key)}
+}
+
+// Load implements the CursorStore interface.
+func (s hashCursorStore) Load(ctx context.Context) (map[string]string, error) {
+	cmd := s.client.HGetAll(ctx, s.key)
+	ids, err := cmd.Result()
+	if err != nil {
+		return nil, WrapCmdErr(cmd)
+	}
+
+	return ids, nil
+}
+
+// Save implements the CursorStore interface.
+func (s hashCursorStore) Save(ctx context.Context, ids map[string]string) error {
+	if cmd := s.client.HSet(ctx, s.key, ids); cmd.Err() != nil {
+		return WrapCmdErr(cmd)
+	}
+
+	return nil
+}
+
+// Assert interface compliance.
+var (
+	_ CursorStore = hashCursorStore{}
+)
@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"maps"
+	"sync"
+	"testing"
+)
+
+func TestStreamCursor(t *testing.T) {
+	ctx := context.Background()
+	store := &memCursorStore{ids: map[string]string{"icinga:state": "5-0", "icinga:other": "1-0"}}
+	c := NewStreamCursor(store)
+
+	require.NoError(t, c.Restore(ctx, Streams{"icinga:state": "0-0", "icinga:history": "$"}))
+	require.Equal(t, Streams{"icinga:state": "5-0", "icinga:history": "$"}, c.Streams())
+
+	// Nothing to checkpoint yet.
+	require.NoError(t, c.Checkpoint(ctx))
+	require.Equal(t, 0, store.saves)
+
+	c.Ack("icinga:history", "7-0")
+	require.Equal(t, Streams{"icinga:state": "5-0", "icinga:history": "7-0"}, c.Streams())
+
+	// Lower IDs must not move the cursor backwards.
+	c.Ack("icinga:state", "4-9")
+	c.Ack("icinga:history", "6")
+	require.Equal(t, Streams{"icinga:state": "5-0", "icinga:history": "7-0"}, c.Streams())
+
+	store.err = errors.New("test")
+	require.ErrorIs(t, c.Checkpoint(ctx), store.err)
+
+	// The failed IDs are retained, unless acknowledged again in the meantime.
+	store.err = nil
+	c.Ack("icinga:state", "6-0")
+	require.NoError(t, c.Checkpoint(ctx))
+	require.Equal(t, map[string]string{"icinga:state": "6-0", "icinga:history": "7-0", "icinga:other": "1-0"}, store.ids)
+
+	// A restart continues where the previous cursor left off.
+	restarted := NewStreamCursor(store)
+	require.NoError(t, restarted.Restore(ctx, Streams{"icinga:state": "0-0", "icinga:history": "$"}))
+	require.Equal(t, Streams{"icinga:state": "6-0", "icinga:history": "7-0"}, restarted.Streams())
+}
+
+func TestStreamIdLess(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"1-0", "2-0", true},
+		{"2-0", "1-0", false},
+		{"1-1", "1-2", true},
+		{"1-2", "1-2", false},
+		{"9-0", "10-0", true},
+		{"1", "1-1", true},
+		{"1-1", "1", false},
+		{"1-0", "$", false},
+		{"$", "1-0", false},
+		{"0-0", "0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" < "+tt.b, func(t *testing.T) {
+			require.Equal(t, tt.expected, streamIdLess(tt.a, tt.b))
+		})
+	}
+}
+
+func TestHashCursorStore(t *testing.T) {
+	var mu sync.Mutex
+	var saved []string
+
+	client := newTestClient(t, func(args []string) string {
+		switch args[0] {
+		case "hgetall":
+			return resp.Bulks("icinga:state", "5-0")
+		case "hset":
+			mu.Lock()
+			saved = append(saved, args[1:]...)
+			mu.Unlock()
+
+			return resp.Integer(1)
+		default:
+			return resp.Error("ERR unknown command")
+		}
+	})
+	client.keyPrefix = "prefix:"
+	store := client.NewHashCursorStore("cursor")
+
+	ids, err := store.Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"icinga:state": "5-0"}, ids)
+
+	require.NoError(t, store.Save(context.Background(), map[string]string{"icinga:state": "6-0"}))
+	require.Equal(t, []string{"prefix:cursor", "icinga:state", "6-0"}, saved)
+}
+
+// memCursorStore is a CursorStore that keeps the IDs in memory and fails with err, if set.
+type memCursorStore struct {
+	ids   map[string]string
+	err   error
+	saves int
+}
+
+func (s *memCursorStore) Load(context.Context) (map[string]string, error) {
+	return maps.Clone(s.ids), nil
+}
+
+func (s *memCursorStore) Save(_ context.Context, ids map[string]string) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.saves++
+	maps.Copy(s.ids, ids)
+
+	return nil
+}
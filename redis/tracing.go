@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the name of the OpenTelemetry tracer that creates the spans of Redis operations.
+const tracerName = "github.com/icinga/icinga-go-library/redis"
+
+// startSpan starts a span for the Redis command using the tracer provider from Options.TracerProvider, if any.
+func (c *Client) startSpan(
+	ctx context.Context, command string, attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	tp := c.Options.TracerProvider
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+
+	attrs = append([]attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", command),
+	}, attrs...)
+
+	return tp.Tracer(tracerName).Start(ctx, command, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, in span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
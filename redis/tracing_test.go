@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"strings"
+	"testing"
+)
+
+func TestClient_startSpan(t *testing.T) {
+	t.Run("with-provider", func(t *testing.T) {
+		tp := &testTracerProvider{}
+		c := newTestClient(t, func([]string) string { return resp.Error("ERR unknown command") })
+		c.Options.TracerProvider = tp
+
+		_, span := c.startSpan(context.Background(), "HSET", attribute.String("db.redis.key", "icinga:host"))
+		endSpan(span, errors.New("failed"))
+
+		require.Len(t, tp.spans, 1)
+		require.Equal(t, "HSET", tp.spans[0].name)
+		require.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", "HSET"),
+			attribute.String("db.redis.key", "icinga:host"),
+		}, tp.spans[0].attrs)
+		require.Equal(t, codes.Error, tp.spans[0].status)
+		require.True(t, tp.spans[0].ended)
+	})
+
+	t.Run("without-provider", func(t *testing.T) {
+		c := newTestClient(t, func([]string) string { return resp.Error("ERR unknown command") })
+
+		_, span := c.startSpan(context.Background(), "HSET")
+		require.False(t, span.IsRecording())
+		endSpan(span, nil)
+	})
+}
+
+func TestClient_XReadUntilResult_Span(t *testing.T) {
+	tp := &testTracerProvider{}
+	c := newTestClient(t, func(args []string) string {
+		if !strings.EqualFold(args[0], "XREAD") {
+			return resp.Error("ERR unknown command")
+		}
+
+		return resp.Array(resp.Array(
+			resp.Bulk("icinga:stream"),
+			resp.Array(resp.Array(resp.Bulk("1-0"), resp.Bulks("k", "v"))),
+		))
+	}).WithKeyPrefix("icinga:")
+	c.Options.TracerProvider = tp
+
+	streams, err := c.XReadUntilResult(context.Background(), &redis.XReadArgs{Streams: []string{"stream", "0-0"}})
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+
+	require.Len(t, tp.spans, 1)
+	require.Equal(t, "XREAD", tp.spans[0].name)
+	require.ElementsMatch(t, []attribute.KeyValue{
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation", "XREAD"),
+		attribute.StringSlice("db.redis.streams", []string{"icinga:stream", "0-0"}),
+	}, tp.spans[0].attrs)
+	require.Equal(t, codes.Unset, tp.spans[0].status)
+	require.True(t, tp.spans[0].ended)
+}
+
+// testTracerProvider is a trace.TracerProvider that records the spans started by its tracers.
+type testTracerProvider struct {
+	noop.TracerProvider
+	spans []*testSpan
+}
+
+func (tp *testTracerProvider) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return testTracer{tp: tp}
+}
+
+type testTracer struct {
+	noop.Tracer
+	tp *testTracerProvider
+}
+
+func (t testTracer) Start(
+	ctx context.Context, name string, options ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(options...)
+	span := &testSpan{name: name, attrs: config.Attributes()}
+	t.tp.spans = append(t.tp.spans, span)
+
+	return ctx, span
+}
+
+type testSpan struct {
+	noop.Span
+	name   string
+	attrs  []attribute.KeyValue
+	status codes.Code
+	ended  bool
+}
+
+func (s *testSpan) SetStatus(code codes.Code, _ string) {
+	s.status = code
+}
+
+func (s *testSpan) End(...trace.SpanEndOption) {
+	s.ended = true
+}
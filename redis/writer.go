@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/periodic"
+	"github.com/icinga/icinga-go-library/retry"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"time"
+)
+
+// DefaultXAddBatchSize is the default number of XADD commands that XAddBulk sends in one transaction.
+const DefaultXAddBatchSize = 1024
+
+// OnSuccess is a callback for successfully written data, e.g. the IDs of the entries added by XAddBulk.
+type OnSuccess[T any] func(ctx context.Context, written []T) error
+
+// XAddBulkOption configures XAddBulk.
+type XAddBulkOption interface {
+	apply(*xAddBulkOptions)
+}
+
+// XAddBatchSize sets the number of XADD commands that are sent in one transaction. Defaults to DefaultXAddBatchSize.
+func XAddBatchSize(size int) XAddBulkOption {
+	return xAddBulkOptionFunc(func(o *xAddBulkOptions) {
+		o.batchSize = size
+	})
+}
+
+// XAddMaxLen trims the stream to approximately maxLen entries, i.e. XADD MAXLEN ~ maxLen.
+func XAddMaxLen(maxLen int64) XAddBulkOption {
+	return xAddBulkOptionFunc(func(o *xAddBulkOptions) {
+		o.maxLen = maxLen
+	})
+}
+
+// XAddOnSuccess sets a callback that is called with the IDs of the entries added by each transaction.
+func XAddOnSuccess(onSuccess OnSuccess[string]) XAddBulkOption {
+	return xAddBulkOptionFunc(func(o *xAddBulkOptions) {
+		o.onSuccess = onSuccess
+	})
+}
+
+// XAddBulk adds the entries to the stream until the entries channel is closed.
+// The entries are batched and each batch is sent as one transaction of XADD commands,
+// which is retried on transient errors. Note that a batch may be added twice
+// if the connection was lost after Redis executed the transaction.
+// The batches are sent sequentially, so that the entries are added in order.
+func (c *Client) XAddBulk(
+	ctx context.Context, stream string, entries <-chan map[string]any, options ...XAddBulkOption,
+) error {
+	stream = c.Key(stream)
+	opts := xAddBulkOptions{batchSize: DefaultXAddBatchSize}
+	for _, option := range options {
+		option.apply(&opts)
+	}
+
+	var counter com.Counter
+	defer c.logWrites(ctx, stream, &counter).Stop()
+
+	for batch := range com.Bulk(ctx, entries, opts.batchSize, com.NeverSplit[map[string]any]) {
+		cmds, err := c.txPipelinedWithRetry(ctx, "XADD", stream, func(pipe redis.Pipeliner) {
+			for _, values := range batch {
+				pipe.XAdd(ctx, &redis.XAddArgs{
+					Stream: stream,
+					MaxLen: opts.maxLen,
+					Approx: opts.maxLen > 0,
+					Values: values,
+				})
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		ids := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			ids = append(ids, cmd.(*redis.StringCmd).Val())
+		}
+
+		counter.Add(uint64(len(ids)))
+
+		if opts.onSuccess != nil {
+			if err := opts.onSuccess(ctx, ids); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.Err()
+}
+
+// HSetStreamed sets the field-value pairs in the hash stored at key until the pairs channel is closed.
+// The pairs are batched into HSET commands of at most Options.HSetCount pairs,
+// which are sent sequentially and retried on transient errors.
+func (c *Client) HSetStreamed(ctx context.Context, key string, pairs <-chan HPair) error {
+	key = c.Key(key)
+	var counter com.Counter
+	defer c.logWrites(ctx, key, &counter).Stop()
+
+	for batch := range com.Bulk(ctx, pairs, c.Options.HSetCount, com.NeverSplit[HPair]) {
+		values := make([]any, 0, len(batch)*2)
+		for _, pair := range batch {
+			values = append(values, pair.Field, pair.Value)
+		}
+
+		if _, err := c.txPipelinedWithRetry(ctx, "HSET", key, func(pipe redis.Pipeliner) {
+			pipe.HSet(ctx, key, values...)
+		}); err != nil {
+			return err
+		}
+
+		counter.Add(uint64(len(batch)))
+	}
+
+	return ctx.Err()
+}
+
+// txPipelinedWithRetry executes the commands queued by fn in a transaction, which is retried on transient errors.
+// command and key are used for tracing and logging.
+func (c *Client) txPipelinedWithRetry(
+	ctx context.Context, command, key string, fn func(redis.Pipeliner),
+) (cmds []redis.Cmder, err error) {
+	err = retry.WithBackoff(
+		ctx,
+		func(ctx context.Context) (err error) {
+			ctx, span := c.startSpan(ctx, command, attribute.String("db.redis.key", key))
+			defer func() { endSpan(span, err) }()
+
+			cmds, err = c.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				fn(pipe)
+				span.SetAttributes(attribute.Int("db.operation.batch.size", pipe.Len()))
+
+				return nil
+			})
+			if err != nil {
+				for _, cmd := range cmds {
+					if cmd.Err() != nil {
+						return WrapCmdErr(cmd)
+					}
+				}
+			}
+
+			return err
+		},
+		retryableCommandError,
+		backoff.NewExponentialWithJitter(1*time.Millisecond, 1*time.Second),
+		retry.Settings{
+			Timeout:  retry.DefaultTimeout,
+			Registry: c.Options.RetryRegistry,
+			Name:     "redis write",
+			OnRetryableError: func(_ time.Duration, _ uint64, err, lastErr error) {
+				if lastErr == nil || err.Error() != lastErr.Error() {
+					c.logger.Warnw("Can't write to Redis. Retrying", zap.String("key", key), zap.Error(err))
+				}
+			},
+			OnSuccess: func(elapsed time.Duration, attempt uint64, _ error) {
+				if attempt > 1 {
+					c.logger.Infow("Redis write finally succeeded", zap.String("key", key),
+						zap.Duration("after", elapsed), zap.Uint64("attempts", attempt))
+				}
+			},
+		},
+	)
+
+	return cmds, errors.Wrapf(err, "can't write to %s", key)
+}
+
+// logWrites logs the progress of writing to key periodically and when stopped.
+func (c *Client) logWrites(ctx context.Context, key string, counter *com.Counter) periodic.Stopper {
+	return periodic.Start(ctx, c.logger.Interval(), func(tick periodic.Tick) {
+		if s := counter.ResetSnapshot(); s.Delta > 0 {
+			c.logger.Debugf("Wrote %d items to %s (%.2f items/s)", s.Delta, key, s.Rate())
+		}
+	}, periodic.OnStop(func(tick periodic.Tick) {
+		c.logger.Debugf("Finished writing %d items to %s in %s", counter.Total(), key, tick.Elapsed)
+	}))
+}
+
+// xAddBulkOptions contains the options of XAddBulk.
+type xAddBulkOptions struct {
+	batchSize int
+	maxLen    int64
+	onSuccess OnSuccess[string]
+}
+
+// xAddBulkOptionFunc is a function that implements XAddBulkOption.
+type xAddBulkOptionFunc func(*xAddBulkOptions)
+
+// apply implements the XAddBulkOption interface.
+func (f xAddBulkOptionFunc) apply(o *xAddBulkOptions) {
+	f(o)
+}
@@ -0,0 +1,151 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_XAddBulk(t *testing.T) {
+	var mu sync.Mutex
+	var added [][]string
+	var transactions int
+	var failed bool
+
+	c := newTestClient(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch strings.ToUpper(args[0]) {
+		case "MULTI":
+			return resp.OK
+		case "XADD":
+			added = append(added, args[1:])
+			return resp.Queued
+		case "EXEC":
+			if !failed {
+				// Fail the first transaction with a transient error reply.
+				failed = true
+				added = nil
+
+				return resp.Error("LOADING Redis is loading the dataset in memory")
+			}
+
+			ids := make([]string, 0, len(added))
+			for i := range added {
+				ids = append(ids, fmt.Sprintf("%d-0", transactions*100+i))
+			}
+
+			transactions++
+			added = nil
+
+			return resp.Bulks(ids...)
+		default:
+			return resp.Error("ERR unknown command")
+		}
+	})
+
+	entries := make(chan map[string]any, 3)
+	for i := range 3 {
+		entries <- map[string]any{"i": i}
+	}
+	close(entries)
+
+	var ids []string
+	err := c.XAddBulk(context.Background(), "icinga:history", entries,
+		XAddBatchSize(2), XAddMaxLen(1000), XAddOnSuccess(func(_ context.Context, written []string) error {
+			ids = append(ids, written...)
+			return nil
+		}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"0-0", "1-0", "100-0"}, ids)
+	require.Equal(t, 2, transactions)
+}
+
+func TestClient_XAddBulk_Args(t *testing.T) {
+	var mu sync.Mutex
+	var xadd []string
+
+	c := newTestClient(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch strings.ToUpper(args[0]) {
+		case "MULTI":
+			return resp.OK
+		case "XADD":
+			xadd = args
+			return resp.Queued
+		case "EXEC":
+			return resp.Bulks("1-0")
+		default:
+			return resp.Error("ERR unknown command")
+		}
+	})
+
+	entries := make(chan map[string]any, 1)
+	entries <- map[string]any{"k": "v"}
+	close(entries)
+
+	require.NoError(t, c.XAddBulk(context.Background(), "stream", entries, XAddMaxLen(10)))
+	require.Equal(t, []string{"xadd", "stream", "maxlen", "~", "10", "*", "k", "v"}, xadd)
+}
+
+// newTestClient returns a Client connected to a fake Redis server, which replies to each command
+// with the raw RESP2 reply returned by handle for the command's arguments.
+func newTestClient(t *testing.T, handle func(args []string) string) *Client {
+	return newRespTestClient(t, resp.Replies(handle))
+}
+
+// newRespTestClient returns a Client connected to a resp.Server using the given handler.
+func newRespTestClient(t *testing.T, handle resp.Handler) *Client {
+	s := resp.Start(t, handle)
+
+	client := redis.NewClient(&redis.Options{Addr: s.Addr(), Protocol: 2, DisableIndentity: true})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewClient(client, logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour), &Options{})
+}
+
+func TestClient_HSetStreamed(t *testing.T) {
+	var mu sync.Mutex
+	var hsets [][]string
+
+	c := newTestClient(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch strings.ToUpper(args[0]) {
+		case "MULTI":
+			return resp.OK
+		case "HSET":
+			hsets = append(hsets, args[1:])
+			return resp.Queued
+		case "EXEC":
+			return resp.Array(resp.Integer(1))
+		default:
+			return resp.Error("ERR unknown command")
+		}
+	})
+	c.Options.HSetCount = 2
+
+	pairs := make(chan HPair, 3)
+	for i := range 3 {
+		pairs <- HPair{Field: fmt.Sprintf("f%d", i), Value: fmt.Sprintf("v%d", i)}
+	}
+	close(pairs)
+
+	require.NoError(t, c.HSetStreamed(context.Background(), "icinga:host", pairs))
+	require.Equal(t, [][]string{
+		{"icinga:host", "f0", "v0", "f1", "v1"},
+		{"icinga:host", "f2", "v2"},
+	}, hsets)
+}
@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/icinga/icinga-go-library/com"
+	"github.com/icinga/icinga-go-library/structify"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"runtime"
+)
+
+// YieldEntitiesOption configures YieldEntities.
+type YieldEntitiesOption interface {
+	apply(*yieldEntitiesOptions)
+}
+
+// YieldWorkers sets the number of goroutines decoding the hash values concurrently.
+// Defaults to the number of CPUs.
+func YieldWorkers(workers int) YieldEntitiesOption {
+	return yieldEntitiesOptionFunc(func(o *yieldEntitiesOptions) {
+		o.workers = workers
+	})
+}
+
+// YieldEntities yields the values of all fields in the hash stored at key, see Client.HYield,
+// decoded into values of type T.
+//
+// Each value is expected to be a JSON object, which is decoded into a map and passed to structifier,
+// which must return values of type T, e.g. a structifier created by structify.MakeMapStructifier
+// for the type Foo with T being *Foo. JSON numbers and booleans are passed to structifier as json.Number and bool.
+// The values are decoded concurrently, see YieldWorkers, so their order is not preserved.
+// At most one decoded value per worker is kept in memory until the caller receives it.
+func YieldEntities[T any](
+	ctx context.Context, client *Client, key string, structifier structify.MapStructifier, options ...YieldEntitiesOption,
+) (<-chan T, <-chan error) {
+	o := yieldEntitiesOptions{workers: runtime.NumCPU()}
+	for _, option := range options {
+		option.apply(&o)
+	}
+
+	entities := make(chan T)
+
+	return entities, com.WaitAsync(com.WaiterFunc(func() error {
+		defer close(entities)
+
+		g, ctx := errgroup.WithContext(ctx)
+
+		pairs, errs := client.HYield(ctx, key)
+		com.ErrgroupReceive(g, errs)
+
+		for range max(o.workers, 1) {
+			g.Go(func() error {
+				for pair := range pairs {
+					entity, err := decodeEntity[T](pair, structifier)
+					if err != nil {
+						return errors.Wrapf(err, "can't decode field %q of %q", pair.Field, key)
+					}
+
+					select {
+					case entities <- entity:
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+
+				return nil
+			})
+		}
+
+		return g.Wait()
+	}))
+}
+
+// decodeEntity decodes the JSON object of pair into a value of type T using structifier.
+func decodeEntity[T any](pair HPair, structifier structify.MapStructifier) (T, error) {
+	var zero T
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(pair.Value)))
+	dec.UseNumber()
+
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		return zero, errors.Wrap(err, "can't parse JSON")
+	}
+
+	v, err := structifier(m)
+	if err != nil {
+		return zero, err
+	}
+
+	entity, ok := v.(T)
+	if !ok {
+		return zero, errors.Errorf("structifier returned %T instead of %T", v, zero)
+	}
+
+	return entity, nil
+}
+
+// yieldEntitiesOptions stores the options of YieldEntities.
+type yieldEntitiesOptions struct {
+	workers int
+}
+
+// yieldEntitiesOptionFunc is a function that implements YieldEntitiesOption.
+type yieldEntitiesOptionFunc func(*yieldEntitiesOptions)
+
+// apply implements the YieldEntitiesOption interface.
+func (f yieldEntitiesOptionFunc) apply(o *yieldEntitiesOptions) {
+	f(o)
+}
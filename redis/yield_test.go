@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/structify"
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/stretchr/testify/require"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type testYieldEntity struct {
+	Name   string     `redis:"name"`
+	Port   int64      `redis:"port"`
+	Active types.Bool `redis:"active"`
+	Checks types.Int  `redis:"checks"`
+}
+
+func TestYieldEntities(t *testing.T) {
+	structifier := structify.MakeMapStructifier(reflect.TypeOf(testYieldEntity{}), "redis", nil)
+
+	tests := []struct {
+		name     string
+		hash     map[string]string
+		expected []testYieldEntity
+		error    bool
+	}{
+		{
+			name: "Valid",
+			hash: map[string]string{
+				"a": `{"name":"a","port":22,"active":true,"checks":9007199254740993}`,
+				"b": `{"name":"b","port":"80","active":false}`,
+				"c": `{"name":"c","unknown":null}`,
+			},
+			expected: []testYieldEntity{
+				{
+					Name:   "a",
+					Port:   22,
+					Active: types.Bool{Bool: true, Valid: true},
+					Checks: types.MakeInt(9007199254740993),
+				},
+				{Name: "b", Port: 80, Active: types.Bool{Bool: false, Valid: true}},
+				{Name: "c"},
+			},
+		},
+		{
+			name:  "Invalid JSON",
+			hash:  map[string]string{"a": `{"name":"a"}`, "b": `{`},
+			error: true,
+		},
+		{
+			name:  "Invalid value",
+			hash:  map[string]string{"a": `{"port":"x"}`},
+			error: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, func(args []string) string {
+				if strings.ToUpper(args[0]) != "HSCAN" || args[1] != "icinga:test" {
+					return resp.Error("ERR unexpected command")
+				}
+
+				return hscanReply(tt.hash)
+			}).WithKeyPrefix("icinga:")
+
+			entities, errs := YieldEntities[*testYieldEntity](context.Background(), c, "test", structifier, YieldWorkers(2))
+
+			var actual []testYieldEntity
+			for e := range entities {
+				actual = append(actual, *e)
+			}
+
+			err := <-errs
+			if tt.error {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			sort.Slice(actual, func(i, j int) bool { return actual[i].Name < actual[j].Name })
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestYieldEntities_WrongType(t *testing.T) {
+	c := newTestClient(t, func([]string) string {
+		return hscanReply(map[string]string{"a": `{}`})
+	})
+
+	structifier := structify.MakeMapStructifier(reflect.TypeOf(testYieldEntity{}), "redis", nil)
+	entities, errs := YieldEntities[testYieldEntity](context.Background(), c, "test", structifier)
+
+	for range entities {
+		require.Fail(t, "no entities expected")
+	}
+
+	require.ErrorContains(t, <-errs, "structifier returned *redis.testYieldEntity instead of redis.testYieldEntity")
+}
+
+// hscanReply returns the RESP reply of a complete HSCAN of hash.
+func hscanReply(hash map[string]string) string {
+	pairs := make([]string, 0, len(hash)*2)
+	for field, value := range hash {
+		pairs = append(pairs, field, value)
+	}
+
+	return resp.Array(resp.Bulk("0"), resp.Bulks(pairs...))
+}
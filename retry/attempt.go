@@ -0,0 +1,53 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Attempt describes the current attempt of a RetryableFunc called by WithBackoff,
+// which is available to it via AttemptFromContext.
+// This allows retried operations to adjust their behavior on later attempts,
+// e.g. to process smaller batches or to fall back to simpler queries.
+type Attempt struct {
+	// Number is the number of the current attempt, starting at 1.
+	Number uint64
+
+	// Since is the time at which the operation was first attempted.
+	Since time.Time
+
+	// Deadline is the time after which WithBackoff stops retrying, as configured via Settings.Timeout.
+	// It is the zero time if there is no timeout.
+	Deadline time.Time
+}
+
+// IsRetry returns whether the current attempt is not the first one.
+func (a Attempt) IsRetry() bool {
+	return a.Number > 1
+}
+
+// Remaining returns the time left until Deadline, which is negative once it has passed.
+// The second return value is false if there is no Deadline.
+func (a Attempt) Remaining() (time.Duration, bool) {
+	if a.Deadline.IsZero() {
+		return 0, false
+	}
+
+	return time.Until(a.Deadline), true
+}
+
+// attemptKey is the context key for the Attempt of WithBackoff.
+type attemptKey struct{}
+
+// AttemptFromContext returns the Attempt passed to a RetryableFunc by WithBackoff via its context.
+// The second return value is false if ctx does not originate from WithBackoff.
+func AttemptFromContext(ctx context.Context) (Attempt, bool) {
+	a, ok := ctx.Value(attemptKey{}).(Attempt)
+
+	return a, ok
+}
+
+// withAttempt returns a copy of ctx that carries the given Attempt.
+func withAttempt(ctx context.Context, a Attempt) context.Context {
+	return context.WithValue(ctx, attemptKey{}, a)
+}
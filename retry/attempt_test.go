@@ -0,0 +1,72 @@
+package retry
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/backoff"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAttemptFromContext(t *testing.T) {
+	t.Run("outside", func(t *testing.T) {
+		_, ok := AttemptFromContext(context.Background())
+		require.False(t, ok)
+	})
+
+	t.Run("WithBackoff", func(t *testing.T) {
+		var attempts []Attempt
+
+		err := WithBackoff(
+			context.Background(),
+			func(ctx context.Context) error {
+				a, ok := AttemptFromContext(ctx)
+				require.True(t, ok)
+				attempts = append(attempts, a)
+
+				if len(attempts) < 3 {
+					return errors.New("retry")
+				}
+
+				return nil
+			},
+			func(error) bool { return true },
+			func(uint64) time.Duration { return 0 },
+			Settings{Timeout: time.Minute},
+		)
+		require.NoError(t, err)
+		require.Len(t, attempts, 3)
+
+		for i, a := range attempts {
+			require.Equal(t, uint64(i+1), a.Number)
+			require.Equal(t, i > 0, a.IsRetry())
+			require.Equal(t, attempts[0].Since, a.Since)
+			require.Equal(t, a.Since.Add(time.Minute), a.Deadline)
+
+			remaining, ok := a.Remaining()
+			require.True(t, ok)
+			require.LessOrEqual(t, remaining, time.Minute)
+		}
+	})
+
+	t.Run("without-timeout", func(t *testing.T) {
+		err := WithBackoff(
+			context.Background(),
+			func(ctx context.Context) error {
+				a, ok := AttemptFromContext(ctx)
+				require.True(t, ok)
+				require.True(t, a.Deadline.IsZero())
+
+				_, ok = a.Remaining()
+				require.False(t, ok)
+
+				return nil
+			},
+			Retryable,
+			backoff.NewExponentialWithJitter(time.Millisecond, 2*time.Millisecond),
+			Settings{},
+		)
+		require.NoError(t, err)
+	})
+}
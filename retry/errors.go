@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ErrNotRetryable indicates that an error is permanent, i.e. that an operation failing with it
+// must not be retried, regardless of what IsRetryable would otherwise say.
+// Errors can be marked as such via MarkPermanent or by wrapping ErrNotRetryable itself.
+var ErrNotRetryable = errors.New("not retryable")
+
+// MarkPermanent returns an error wrapping err that is neither retried by WithBackoff nor considered retryable
+// by Retryable. The returned error matches ErrNotRetryable via errors.Is and otherwise behaves like err.
+// Returns nil if err is nil.
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &markedError{err: err, retryable: false}
+}
+
+// MarkRetryable returns an error wrapping err that is always retried by WithBackoff and considered retryable
+// by Retryable, even if it would otherwise be considered permanent, e.g. because it wraps ErrNotRetryable.
+// The returned error otherwise behaves like err. Returns nil if err is nil.
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &markedError{err: err, retryable: true}
+}
+
+// markedError is an error marked as permanent or retryable via MarkPermanent or MarkRetryable.
+type markedError struct {
+	err       error
+	retryable bool
+}
+
+// Error implements the error interface.
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+// Is returns true for ErrNotRetryable if the error is marked as permanent.
+func (e *markedError) Is(target error) bool {
+	return target == ErrNotRetryable && !e.retryable
+}
+
+// Unwrap returns the marked error.
+func (e *markedError) Unwrap() error {
+	return e.err
+}
+
+// marked returns whether err has been marked as retryable or permanent, with the outermost mark taking precedence.
+// The second return value is false if err is not marked at all.
+func marked(err error) (retryable bool, ok bool) {
+	var me *markedError
+	if errors.As(err, &me) {
+		return me.retryable, true
+	}
+
+	if errors.Is(err, ErrNotRetryable) {
+		return false, true
+	}
+
+	return false, false
+}
+
+// Assert interface compliance.
+var (
+	_ error = (*markedError)(nil)
+)
@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMarkPermanent(t *testing.T) {
+	require.NoError(t, MarkPermanent(nil))
+
+	err := MarkPermanent(io.EOF)
+	require.ErrorIs(t, err, ErrNotRetryable)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, io.EOF.Error(), err.Error())
+	require.False(t, Retryable(err))
+	require.False(t, Retryable(errors.Wrap(err, "wrapped")))
+}
+
+func TestMarkRetryable(t *testing.T) {
+	require.NoError(t, MarkRetryable(nil))
+
+	errFailed := errors.New("failed")
+	require.False(t, Retryable(errFailed))
+
+	err := MarkRetryable(errFailed)
+	require.NotErrorIs(t, err, ErrNotRetryable)
+	require.ErrorIs(t, err, errFailed)
+	require.True(t, Retryable(err))
+	require.True(t, Retryable(MarkRetryable(MarkPermanent(errFailed))))
+	require.False(t, Retryable(MarkPermanent(MarkRetryable(errFailed))))
+	require.True(t, Retryable(MarkRetryable(errors.Wrap(ErrNotRetryable, "wrapped"))))
+	require.False(t, Retryable(errors.Wrap(ErrNotRetryable, "wrapped")))
+}
+
+func TestWithBackoff_Marked(t *testing.T) {
+	subtests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"permanent", MarkPermanent(io.EOF), 1},
+		{"not-retryable", errors.Wrap(ErrNotRetryable, "failed"), 1},
+		{"retryable", MarkRetryable(errors.New("failed")), 3},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			attempts := 0
+
+			err := WithBackoff(
+				context.Background(),
+				func(context.Context) error {
+					attempts++
+					if attempts < 3 {
+						return st.err
+					}
+
+					return nil
+				},
+				// Always claim the opposite of the mark to ensure that the mark takes precedence.
+				func(error) bool { return st.attempts == 1 },
+				func(uint64) time.Duration { return 0 },
+				Settings{},
+			)
+			require.Equal(t, st.attempts, attempts)
+
+			if st.attempts < 3 {
+				require.ErrorIs(t, err, ErrNotRetryable)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// State describes an operation that is currently being retried by WithBackoff.
+type State struct {
+	// Name identifies the operation, as specified in Settings.Name.
+	Name string
+
+	// Since is the time at which the operation was first attempted.
+	Since time.Time
+
+	// Attempt is the number of the last failed attempt.
+	Attempt uint64
+
+	// NextRetry is the time at which the next attempt is scheduled.
+	NextRetry time.Time
+
+	// LastErr is the error returned by the last failed attempt.
+	LastErr error
+}
+
+// Registry keeps track of the operations that are currently being retried.
+// Operations register themselves by setting Settings.Registry and are removed once WithBackoff returns.
+// A Registry is safe for concurrent use. The zero value is ready to use.
+type Registry struct {
+	mu     sync.Mutex
+	states map[*State]struct{}
+}
+
+// States returns a snapshot of all operations currently being retried, ordered by name and start time.
+func (r *Registry) States() []State {
+	r.mu.Lock()
+	states := make([]State, 0, len(r.states))
+	for s := range r.states {
+		states = append(states, *s)
+	}
+	r.mu.Unlock()
+
+	slices.SortFunc(states, func(a, b State) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+
+		return a.Since.Compare(b.Since)
+	})
+
+	return states
+}
+
+// update adds s to the registry if not yet present and applies f to it.
+func (r *Registry) update(s *State, f func(*State)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.states == nil {
+		r.states = make(map[*State]struct{})
+	}
+
+	f(s)
+	r.states[s] = struct{}{}
+}
+
+// remove removes s from the registry.
+func (r *Registry) remove(s *State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.states, s)
+}
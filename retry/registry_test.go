@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	var registry Registry
+	errTemporary := errors.New("temporary")
+
+	var attempts uint64
+	err := WithBackoff(
+		context.Background(),
+		func(context.Context) error {
+			attempts++
+
+			states := registry.States()
+			if attempts == 1 {
+				require.Empty(t, states, "operation should not be registered before the first failure")
+
+				return errTemporary
+			}
+
+			require.Len(t, states, 1)
+			require.Equal(t, "test", states[0].Name)
+			require.Equal(t, uint64(1), states[0].Attempt)
+			require.ErrorIs(t, states[0].LastErr, errTemporary)
+			require.False(t, states[0].NextRetry.Before(states[0].Since))
+
+			return nil
+		},
+		func(error) bool { return true },
+		func(uint64) time.Duration { return time.Millisecond },
+		Settings{Registry: &registry, Name: "test"},
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), attempts)
+	require.Empty(t, registry.States(), "operation should be removed once done")
+}
@@ -40,10 +40,17 @@ type Settings struct {
 	Timeout          time.Duration
 	OnRetryableError OnRetryableErrorFunc
 	OnSuccess        OnSuccessFunc
+
+	// If Registry is set, the operation is registered under Name while it is being retried,
+	// so that its State can be queried via Registry.States.
+	Registry *Registry
+	Name     string
 }
 
 // WithBackoff retries the passed function if it fails and the error allows it to retry.
 // The specified backoff policy is used to determine how long to sleep between attempts.
+// The function can inspect the current attempt via AttemptFromContext.
+// Errors marked via MarkPermanent or MarkRetryable are never or always retried, regardless of retryable.
 func WithBackoff(
 	ctx context.Context, retryableFunc RetryableFunc, retryable IsRetryable, b backoff.Backoff, settings Settings,
 ) (err error) {
@@ -59,10 +66,22 @@ func WithBackoff(
 
 	start := time.Now()
 	timedOut := false
+
+	var deadline time.Time
+	if settings.Timeout > 0 {
+		deadline = start.Add(settings.Timeout)
+	}
+
+	var state *State
+	if settings.Registry != nil {
+		state = &State{Name: settings.Name, Since: start}
+		defer settings.Registry.remove(state)
+	}
+
 	for attempt := uint64(1); ; /* true */ attempt++ {
 		prevErr := err
 
-		if err = retryableFunc(ctx); err == nil {
+		if err = retryableFunc(withAttempt(ctx, Attempt{Number: attempt, Since: start, Deadline: deadline})); err == nil {
 			if settings.OnSuccess != nil {
 				settings.OnSuccess(time.Since(start), attempt, prevErr)
 			}
@@ -83,7 +102,7 @@ func WithBackoff(
 			return
 		}
 
-		if !retryable(err) {
+		if r, ok := marked(err); ok && !r || !ok && !retryable(err) {
 			err = errors.Wrap(err, "can't retry")
 
 			return
@@ -106,8 +125,24 @@ func WithBackoff(
 			settings.OnRetryableError(time.Since(start), attempt, err, prevErr)
 		}
 
+		sleep := b(attempt)
+		if sleep < 0 {
+			// The backoff returned backoff.Stop.
+			err = errors.Wrap(err, "retry backoff stopped")
+
+			return
+		}
+
+		if state != nil {
+			settings.Registry.update(state, func(s *State) {
+				s.Attempt = attempt
+				s.NextRetry = time.Now().Add(sleep)
+				s.LastErr = err
+			})
+		}
+
 		select {
-		case <-time.After(b(attempt)):
+		case <-time.After(sleep):
 		case <-timeout:
 			// Do not stop retrying immediately, but start one last attempt to mitigate timing issues where
 			// the timeout expires while waiting for the next attempt and
@@ -136,7 +171,12 @@ func ResetTimeout(t *time.Timer, d time.Duration) {
 // Retryable returns true for common errors that are considered retryable,
 // i.e. temporary, timeout, DNS, connection refused and reset, host down and unreachable and
 // network down and unreachable errors. In addition, any database error is considered retryable.
+// Errors marked via MarkPermanent or MarkRetryable are never or always considered retryable.
 func Retryable(err error) bool {
+	if r, ok := marked(err); ok {
+		return r
+	}
+
 	var temporary interface {
 		Temporary() bool
 	}
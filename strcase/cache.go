@@ -0,0 +1,47 @@
+package strcase
+
+import "sync"
+
+// maxCacheSize is the maximum number of results a cache keeps,
+// so that converting arbitrary input, e.g. from users, does not grow it indefinitely.
+const maxCacheSize = 4096
+
+// cacheKey identifies a conversion of s.
+type cacheKey struct {
+	s     string
+	_case int
+	d     rune
+}
+
+// cache caches conversion results. The zero value is ready to use.
+type cache struct {
+	mu sync.RWMutex
+	m  map[cacheKey]string
+}
+
+// get returns the cached result for key, calling convert and caching its result if there is none.
+// Once the cache is full, results are no longer cached.
+func (c *cache) get(key cacheKey, convert func(cacheKey) string) string {
+	c.mu.RLock()
+	result, ok := c.m[key]
+	c.mu.RUnlock()
+
+	if ok {
+		return result
+	}
+
+	result = convert(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.m == nil {
+		c.m = make(map[cacheKey]string)
+	}
+
+	if len(c.m) < maxCacheSize {
+		c.m[key] = result
+	}
+
+	return result
+}
@@ -0,0 +1,112 @@
+package strcase
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultAcronyms are the acronyms used by Pascal and Camel.
+var DefaultAcronyms = []string{"API", "HTTP", "ID", "JSON", "URL", "UUID"}
+
+// defaultConverter is the Converter used by Pascal and Camel.
+var defaultConverter = NewConverter(DefaultAcronyms...)
+
+// Converter converts strings in snake_case, kebab-case, camelCase or PascalCase to PascalCase or camelCase,
+// keeping acronyms in the form they were specified in, e.g. "ID" instead of "Id".
+// Its results are cached, so a Converter should be reused. It is safe for concurrent use.
+type Converter struct {
+	// acronyms maps the lowercase form of the acronyms to their specified form.
+	acronyms map[string]string
+	cache    cache
+}
+
+// NewConverter returns a new Converter that keeps the given acronyms,
+// e.g. "ID" or "IPv4", in the form they are specified in.
+// Acronyms are matched case-insensitively against whole words.
+func NewConverter(acronyms ...string) *Converter {
+	c := &Converter{acronyms: make(map[string]string, len(acronyms))}
+	for _, acronym := range acronyms {
+		c.acronyms[strings.ToLower(acronym)] = acronym
+	}
+
+	return c
+}
+
+// Pascal converts a string to PascalCase, e.g. "user_id" and "userId" to "UserID".
+func (c *Converter) Pascal(s string) string {
+	return c.cache.get(cacheKey{s: s, _case: unicode.UpperCase}, c.convert)
+}
+
+// Camel converts a string to camelCase, e.g. "user_id" and "UserId" to "userID".
+// A leading acronym is lowercased entirely, e.g. "api_url" becomes "apiURL".
+func (c *Converter) Camel(s string) string {
+	return c.cache.get(cacheKey{s: s, _case: unicode.LowerCase}, c.convert)
+}
+
+// convert converts key.s to PascalCase if key._case is unicode.UpperCase, to camelCase otherwise.
+func (c *Converter) convert(key cacheKey) string {
+	return c.join(key.s, key._case == unicode.UpperCase)
+}
+
+// join joins the words of s, each capitalized, except the first one if upperFirst is false.
+func (c *Converter) join(s string, upperFirst bool) string {
+	n := strings.Builder{}
+	n.Grow(len(s))
+
+	for i, word := range words(s) {
+		lower := strings.ToLower(word)
+
+		switch acronym, ok := c.acronyms[lower]; {
+		case i == 0 && !upperFirst:
+			n.WriteString(lower)
+		case ok:
+			n.WriteString(acronym)
+		default:
+			r, size := utf8.DecodeRuneInString(lower)
+			n.WriteRune(unicode.ToUpper(r))
+			n.WriteString(lower[size:])
+		}
+	}
+
+	return n.String()
+}
+
+// words splits s into words at any rune that is neither a letter nor a number,
+// on any change from lowercase or number to uppercase letter, and before the last uppercase letter
+// of a sequence of uppercase letters that is followed by a lowercase letter, e.g. "APIKey" into "API" and "Key".
+func words(s string) []string {
+	var words []string
+	runes := []rune(s)
+	start := -1
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+
+			continue
+		}
+
+		if start >= 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsNumber(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
@@ -1,4 +1,5 @@
 // Package strcase implements functions to convert a camelCase UTF-8 string into various cases.
+// In addition, Pascal and Camel convert delimited strings and camelCase back, as described in Converter.
 //
 // New delimiters will be inserted based on the following transitions:
 //   - On any change from lowercase to uppercase letter.
@@ -12,12 +13,12 @@ import (
 
 // Delimited converts a string to delimited.lower.case, here using `.` as delimiter.
 func Delimited(s string, d rune) string {
-	return convert(s, unicode.LowerCase, d)
+	return delimitedCache.get(cacheKey{s: s, _case: unicode.LowerCase, d: d}, convertKey)
 }
 
 // ScreamingDelimited converts a string to DELIMITED.UPPER.CASE, here using `.` as delimiter.
 func ScreamingDelimited(s string, d rune) string {
-	return convert(s, unicode.UpperCase, d)
+	return delimitedCache.get(cacheKey{s: s, _case: unicode.UpperCase, d: d}, convertKey)
 }
 
 // Snake converts a string to snake_case.
@@ -30,6 +31,35 @@ func ScreamingSnake(s string) string {
 	return ScreamingDelimited(s, '_')
 }
 
+// Kebab converts a string to kebab-case.
+func Kebab(s string) string {
+	return Delimited(s, '-')
+}
+
+// ScreamingKebab converts a string to SCREAMING-KEBAB-CASE.
+func ScreamingKebab(s string) string {
+	return ScreamingDelimited(s, '-')
+}
+
+// Pascal converts a string to PascalCase using the DefaultAcronyms, as described in Converter.Pascal.
+func Pascal(s string) string {
+	return defaultConverter.Pascal(s)
+}
+
+// Camel converts a string to camelCase using the DefaultAcronyms, as described in Converter.Camel.
+func Camel(s string) string {
+	return defaultConverter.Camel(s)
+}
+
+// delimitedCache caches the results of Delimited and ScreamingDelimited,
+// which are called for every struct field on hot paths, e.g. by database mappers.
+var delimitedCache cache
+
+// convertKey calls convert with the arguments specified in key.
+func convertKey(key cacheKey) string {
+	return convert(key.s, key._case, key.d)
+}
+
 // convert converts a camelCase UTF-8 string into various cases.
 // _case must be unicode.LowerCase or unicode.UpperCase.
 func convert(s string, _case int, d rune) string {
@@ -56,3 +56,109 @@ func TestScreamingSnake(t *testing.T) {
 		}
 	}
 }
+
+func TestKebab(t *testing.T) {
+	for _, test := range tests {
+		s, expected := test[0], strings.ReplaceAll(test[1], "_", "-")
+		if strings.Contains(s, "_") {
+			// Existing underscores are kept as they are.
+			continue
+		}
+
+		actual := Kebab(s)
+		if actual != expected {
+			t.Errorf("%q: %q != %q", s, actual, expected)
+		}
+	}
+}
+
+var caseTests = []struct {
+	s      string
+	pascal string
+	camel  string
+}{
+	{"", "", ""},
+	{"test", "Test", "test"},
+	{"test_case", "TestCase", "testCase"},
+	{"test-case", "TestCase", "testCase"},
+	{"test.case", "TestCase", "testCase"},
+	{"test case", "TestCase", "testCase"},
+	{"__test__case__", "TestCase", "testCase"},
+	{"TEST_CASE", "TestCase", "testCase"},
+	{"testCase", "TestCase", "testCase"},
+	{"TestCase", "TestCase", "testCase"},
+	{"id", "ID", "id"},
+	{"user_id", "UserID", "userID"},
+	{"userId", "UserID", "userID"},
+	{"UserID", "UserID", "userID"},
+	{"api_url", "APIURL", "apiURL"},
+	{"APIKey", "APIKey", "apiKey"},
+	{"http_api_id", "HTTPAPIID", "httpAPIID"},
+	{"icinga2_version", "Icinga2Version", "icinga2Version"},
+	{"with1234Digits", "With1234Digits", "with1234Digits"},
+	{"café_crème", "CaféCrème", "caféCrème"},
+	{"élan_vital", "ÉlanVital", "élanVital"},
+}
+
+func TestPascal(t *testing.T) {
+	for _, test := range caseTests {
+		actual := Pascal(test.s)
+		if actual != test.pascal {
+			t.Errorf("%q: %q != %q", test.s, actual, test.pascal)
+		}
+	}
+}
+
+func TestCamel(t *testing.T) {
+	for _, test := range caseTests {
+		actual := Camel(test.s)
+		if actual != test.camel {
+			t.Errorf("%q: %q != %q", test.s, actual, test.camel)
+		}
+	}
+}
+
+func TestConverter(t *testing.T) {
+	c := NewConverter("IPv4", "id")
+
+	for s, expected := range map[string]string{
+		"ipv4_address": "IPv4Address",
+		"user_id":      "Userid",
+		"api_url":      "ApiUrl",
+	} {
+		// Convert twice to also test cached results.
+		for range 2 {
+			actual := c.Pascal(s)
+			if actual != expected {
+				t.Errorf("%q: %q != %q", s, actual, expected)
+			}
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	var c cache
+	calls := 0
+	convert := func(key cacheKey) string {
+		calls++
+		return strings.ToUpper(key.s)
+	}
+
+	for range 2 {
+		if actual := c.get(cacheKey{s: "a"}, convert); actual != "A" {
+			t.Errorf("%q != %q", actual, "A")
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("convert called %d times, expected once", calls)
+	}
+
+	for i := range maxCacheSize + 1 {
+		c.get(cacheKey{s: "a", d: rune(i)}, convert)
+	}
+
+	if len(c.m) != maxCacheSize {
+		t.Errorf("cache holds %d entries, expected at most %d", len(c.m), maxCacheSize)
+	}
+}
@@ -2,21 +2,56 @@ package structify
 
 import (
 	"encoding"
+	"encoding/json"
 	"fmt"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/constraints"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"unsafe"
 )
 
+// DecoderFunc parses src into dest, which is a pointer to a value of the type the function has been registered for.
+type DecoderFunc func(src string, dest interface{}) error
+
+var (
+	decoders   = make(map[reflect.Type]DecoderFunc)
+	decodersMu sync.RWMutex
+)
+
+// RegisterDecoder registers fn for parsing map values into struct fields of type t, e.g. to parse
+// Icinga-specific encodings without implementing encoding.TextUnmarshaler on the type.
+// Registered decoders take precedence over any other way of parsing a field except for the json tag option.
+// They only affect structifiers created by MakeMapStructifier after the registration,
+// so decoders should be registered in init() functions. A decoder registered for t before is replaced.
+func RegisterDecoder(t reflect.Type, fn DecoderFunc) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	decoders[t] = fn
+}
+
+// lookupDecoder returns the decoder registered for t, if any.
+func lookupDecoder(t reflect.Type) DecoderFunc {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	return decoders[t]
+}
+
 // structBranch represents either a leaf or a subTree.
 type structBranch struct {
 	// field specifies the struct field index.
 	field int
 	// leaf specifies the map key to parse the struct field from.
+	// For a subTree, it specifies the map key of the nested map, or is empty if the subTree is inlined.
 	leaf string
+	// json specifies whether the leaf's value is JSON-encoded.
+	json bool
+	// decoder specifies the decoder registered for the leaf's type, if any.
+	decoder DecoderFunc
 	// subTree specifies the struct field's inner tree.
 	subTree []structBranch
 }
@@ -25,6 +60,11 @@ type MapStructifier = func(map[string]interface{}) (interface{}, error)
 
 // MakeMapStructifier builds a function which parses a map's string values into a new struct of type t
 // and returns a pointer to it. tag specifies which tag connects struct fields to map keys.
+// Struct fields whose tag carries the json option, e.g. `tag:"vars,json"`, are decoded from JSON instead,
+// which allows them to be of any type supported by encoding/json, such as maps, slices and structs.
+// Struct fields of struct type are parsed from a nested map[string]interface{} under their map key,
+// unless the tag is ",inline", in which case they are parsed from the same map.
+// Fields of types for which a decoder has been registered via RegisterDecoder are parsed using that decoder.
 // MakeMapStructifier panics if it detects an unsupported type (suitable for usage in init() or global vars).
 func MakeMapStructifier(t reflect.Type, tag string, initer func(any)) MapStructifier {
 	tree := buildStructTree(t, tag)
@@ -53,13 +93,24 @@ func buildStructTree(t reflect.Type, tag string) []structBranch {
 			case "", "-":
 			case ",inline":
 				if subTree := buildStructTree(field.Type, tag); subTree != nil {
-					tree = append(tree, structBranch{i, "", subTree})
+					tree = append(tree, structBranch{field: i, subTree: subTree})
 				}
 			default:
-				// If parseString doesn't support *T, it'll panic.
-				_ = parseString("", reflect.New(field.Type).Interface())
+				name, option, _ := strings.Cut(tagValue, ",")
+
+				switch decoder := lookupDecoder(field.Type); {
+				case option == "json":
+					tree = append(tree, structBranch{field: i, leaf: name, json: true})
+				case decoder != nil:
+					tree = append(tree, structBranch{field: i, leaf: name, decoder: decoder})
+				case field.Type.Kind() == reflect.Struct && !reflect.PointerTo(field.Type).Implements(tTextUnmarshaler):
+					tree = append(tree, structBranch{field: i, leaf: name, subTree: buildStructTree(field.Type, tag)})
+				default:
+					// If parseString doesn't support *T, it'll panic.
+					_ = parseString("", reflect.New(field.Type).Interface())
 
-				tree = append(tree, structBranch{i, tagValue, nil})
+					tree = append(tree, structBranch{field: i, leaf: name})
+				}
 			}
 		}
 	}
@@ -77,33 +128,98 @@ func structifyMapByTree(src map[string]interface{}, tree []structBranch, dest, r
 	for _, branch := range tree {
 		(*stack)[len(*stack)-1] = branch.field
 
-		if branch.subTree == nil {
-			if v, ok := src[branch.leaf]; ok {
-				if vs, ok := v.(string); ok {
-					if err := parseString(vs, dest.Field(branch.field).Addr().Interface()); err != nil {
-						rt := root.Type()
-						typ := rt
-						var path []string
-
-						for _, i := range *stack {
-							f := typ.Field(i)
-							path = append(path, f.Name)
-							typ = f.Type
-						}
-
-						return errors.Wrapf(err, "can't parse %s into the %s %s#%s: %s",
-							branch.leaf, typ.Name(), rt.Name(), strings.Join(path, "."), vs)
-					}
+		switch {
+		case branch.subTree == nil:
+			v, ok := src[branch.leaf]
+			if !ok {
+				continue
+			}
+
+			if branch.json {
+				if err := parseJSON(v, dest.Field(branch.field).Addr().Interface()); err != nil {
+					return wrapParseError(err, branch.leaf, root, *stack, v)
+				}
+			} else if vs, ok := scalarString(v); ok {
+				parse := parseString
+				if branch.decoder != nil {
+					parse = branch.decoder
+				}
+
+				if err := parse(vs, dest.Field(branch.field).Addr().Interface()); err != nil {
+					return wrapParseError(err, branch.leaf, root, *stack, vs)
+				}
+			}
+		case branch.leaf == "":
+			if err := structifyMapByTree(src, branch.subTree, dest.Field(branch.field), root, stack); err != nil {
+				return err
+			}
+		default:
+			if nested, ok := src[branch.leaf].(map[string]interface{}); ok {
+				if err := structifyMapByTree(nested, branch.subTree, dest.Field(branch.field), root, stack); err != nil {
+					return err
 				}
 			}
-		} else if err := structifyMapByTree(src, branch.subTree, dest.Field(branch.field), root, stack); err != nil {
-			return err
 		}
 	}
 
 	return nil
 }
 
+// scalarString returns the string representation of v if v is a string or a scalar decoded from JSON
+// with json.Decoder.UseNumber, i.e. a json.Number or a bool, so that such maps can be structified as well.
+// Bools are represented as "1" and "0" like in the Redis hashes of Icinga 2, e.g. for parsing them into a types.Bool.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "1", true
+		}
+
+		return "0", true
+	default:
+		return "", false
+	}
+}
+
+// wrapParseError wraps err with the map key and value that could not be parsed and
+// the path of the struct field designated by stack within root.
+func wrapParseError(err error, key string, root reflect.Value, stack []int, value interface{}) error {
+	rt := root.Type()
+	typ := rt
+	var path []string
+
+	for _, i := range stack {
+		f := typ.Field(i)
+		path = append(path, f.Name)
+		typ = f.Type
+	}
+
+	return errors.Wrapf(err, "can't parse %s into the %s %s#%s: %v",
+		key, typ.Name(), rt.Name(), strings.Join(path, "."), value)
+}
+
+// parseJSON parses src into *dest. If src is a string, it is expected to be JSON-encoded.
+// Otherwise, src is expected to be already decoded, e.g. a nested map[string]interface{}, and is converted via JSON.
+func parseJSON(src interface{}, dest interface{}) error {
+	data, ok := src.(string)
+	if !ok {
+		encoded, err := json.Marshal(src)
+		if err != nil {
+			return err
+		}
+
+		data = string(encoded)
+	}
+
+	return json.Unmarshal([]byte(data), dest)
+}
+
+var tTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
 // parseString parses src into *dest.
 func parseString(src string, dest interface{}) error {
 	switch ptr := dest.(type) {
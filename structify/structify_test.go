@@ -0,0 +1,206 @@
+package structify
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/icinga/icinga-go-library/types"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testInner struct {
+	Name  string `test:"name"`
+	Count int64  `test:"count"`
+}
+
+type Embedded struct {
+	Note types.String `test:"note"`
+}
+
+type testSubject struct {
+	Embedded `test:",inline"`
+
+	Id     string                 `test:"id"`
+	Vars   map[string]interface{} `test:"vars,json"`
+	Groups []string               `test:"groups,json"`
+	Inner  testInner              `test:"inner"`
+	Raw    testInner              `test:"raw,json"`
+	Ignore string                 `test:"-"`
+}
+
+func TestMakeMapStructifier(t *testing.T) {
+	structifier := MakeMapStructifier(reflect.TypeOf(testSubject{}), "test", nil)
+
+	tests := []struct {
+		name   string
+		input  map[string]interface{}
+		output testSubject
+		error  bool
+	}{
+		{
+			name:   "empty",
+			input:  map[string]interface{}{},
+			output: testSubject{},
+		},
+		{
+			name: "flat",
+			input: map[string]interface{}{
+				"id":     "42",
+				"note":   "hello",
+				"ignore": "me",
+			},
+			output: testSubject{Embedded: Embedded{Note: types.MakeString("hello")}, Id: "42"},
+		},
+		{
+			name: "json-encoded",
+			input: map[string]interface{}{
+				"vars":   `{"os":"Linux","ports":[22,80]}`,
+				"groups": `["a","b"]`,
+				"raw":    `{"Name":"x","Count":3}`,
+			},
+			output: testSubject{
+				Vars:   map[string]interface{}{"os": "Linux", "ports": []interface{}{float64(22), float64(80)}},
+				Groups: []string{"a", "b"},
+				Raw:    testInner{Name: "x", Count: 3},
+			},
+		},
+		{
+			name: "json-decoded",
+			input: map[string]interface{}{
+				"vars":   map[string]interface{}{"os": "Linux"},
+				"groups": []interface{}{"a"},
+			},
+			output: testSubject{
+				Vars:   map[string]interface{}{"os": "Linux"},
+				Groups: []string{"a"},
+			},
+		},
+		{
+			name: "nested",
+			input: map[string]interface{}{
+				"inner": map[string]interface{}{"name": "nested", "count": "23"},
+			},
+			output: testSubject{Inner: testInner{Name: "nested", Count: 23}},
+		},
+		{
+			name: "json-scalars",
+			input: map[string]interface{}{
+				"id":    json.Number("42"),
+				"note":  true,
+				"inner": map[string]interface{}{"count": json.Number("23")},
+			},
+			output: testSubject{Embedded: Embedded{Note: types.MakeString("1")}, Id: "42", Inner: testInner{Count: 23}},
+		},
+		{
+			name:  "invalid-json",
+			input: map[string]interface{}{"vars": `{`},
+			error: true,
+		},
+		{
+			name:  "invalid-nested",
+			input: map[string]interface{}{"inner": map[string]interface{}{"count": "x"}},
+			error: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := structifier(tt.input)
+			if tt.error {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, &tt.output, actual)
+		})
+	}
+}
+
+func TestMakeMapStructifier_JSONScalars(t *testing.T) {
+	type subject struct {
+		Active   types.Bool  `test:"active"`
+		Disabled types.Bool  `test:"disabled"`
+		Checks   types.Int   `test:"checks"`
+		Interval types.Float `test:"interval"`
+		Count    int64       `test:"count"`
+		Ratio    float64     `test:"ratio"`
+		State    uint8       `test:"state"`
+	}
+
+	structifier := MakeMapStructifier(reflect.TypeOf(subject{}), "test", nil)
+
+	actual, err := structifier(map[string]interface{}{
+		"active":   true,
+		"disabled": false,
+		"checks":   json.Number("9007199254740993"),
+		"interval": json.Number("2.5"),
+		"count":    json.Number("-3"),
+		"ratio":    json.Number("0.25"),
+		"state":    json.Number("2"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, &subject{
+		Active:   types.Bool{Bool: true, Valid: true},
+		Disabled: types.Bool{Bool: false, Valid: true},
+		Checks:   types.Int{NullInt64: sql.NullInt64{Int64: 9007199254740993, Valid: true}},
+		Interval: types.Float{NullFloat64: sql.NullFloat64{Float64: 2.5, Valid: true}},
+		Count:    -3,
+		Ratio:    0.25,
+		State:    2,
+	}, actual)
+
+	_, err = structifier(map[string]interface{}{"state": true})
+	require.NoError(t, err, "bools must be parsable into integers")
+
+	_, err = structifier(map[string]interface{}{"count": json.Number("1.5")})
+	require.Error(t, err)
+}
+
+// testYesNo is a bool encoded as "y" or "n".
+type testYesNo bool
+
+// testRange is a struct encoded as "from-to".
+type testRange struct {
+	From, To string
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	RegisterDecoder(reflect.TypeOf(testYesNo(false)), func(src string, dest interface{}) error {
+		switch src {
+		case "y":
+			*dest.(*testYesNo) = true
+		case "n":
+			*dest.(*testYesNo) = false
+		default:
+			return errors.Errorf("invalid boolean %q", src)
+		}
+
+		return nil
+	})
+
+	RegisterDecoder(reflect.TypeOf(testRange{}), func(src string, dest interface{}) error {
+		r := dest.(*testRange)
+		r.From, r.To, _ = strings.Cut(src, "-")
+
+		return nil
+	})
+
+	type subject struct {
+		Active testYesNo `test:"active"`
+		Range  testRange `test:"range"`
+		Raw    testRange `test:"raw,json"`
+	}
+
+	structifier := MakeMapStructifier(reflect.TypeOf(subject{}), "test", nil)
+
+	actual, err := structifier(map[string]interface{}{"active": "y", "range": "a-z", "raw": `{"From":"0","To":"9"}`})
+	require.NoError(t, err)
+	require.Equal(t, &subject{Active: true, Range: testRange{"a", "z"}, Raw: testRange{"0", "9"}}, actual)
+
+	_, err = structifier(map[string]interface{}{"active": "x"})
+	require.ErrorContains(t, err, `invalid boolean "x"`)
+}
@@ -0,0 +1,264 @@
+// Package dbtest provides fixtures for database integration tests that run against
+// disposable MySQL, MariaDB and PostgreSQL containers.
+//
+// Containers are managed via the docker CLI, which must be available in PATH.
+// Otherwise, tests using this package are skipped, so that integration tests remain opt-in.
+package dbtest
+
+import (
+	"context"
+	"github.com/creasty/defaults"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Credentials of the database created in each container.
+const (
+	User     = "icinga"
+	Password = "icinga"
+	Database = "icinga"
+)
+
+// Flavor describes a database server image and how to configure it.
+type Flavor struct {
+	// Type is the database type as in database.Config.Type, i.e. "mysql" or "pgsql".
+	Type string
+
+	// Image is the container image, including its tag.
+	Image string
+
+	// Port is the port the server listens on inside the container, e.g. "3306/tcp".
+	Port string
+
+	// Env is the environment of the container, which creates the database with the credentials above.
+	Env map[string]string
+}
+
+// Supported flavors. Copy and adjust them to use other images or versions.
+var (
+	MySQL = Flavor{
+		Type:  "mysql",
+		Image: "mysql:8.4",
+		Port:  "3306/tcp",
+		Env: map[string]string{
+			"MYSQL_RANDOM_ROOT_PASSWORD": "yes",
+			"MYSQL_DATABASE":             Database,
+			"MYSQL_USER":                 User,
+			"MYSQL_PASSWORD":             Password,
+		},
+	}
+
+	MariaDB = Flavor{
+		Type:  "mysql",
+		Image: "mariadb:11",
+		Port:  "3306/tcp",
+		Env: map[string]string{
+			"MARIADB_RANDOM_ROOT_PASSWORD": "yes",
+			"MARIADB_DATABASE":             Database,
+			"MARIADB_USER":                 User,
+			"MARIADB_PASSWORD":             Password,
+		},
+	}
+
+	PostgreSQL = Flavor{
+		Type:  "pgsql",
+		Image: "postgres:17",
+		Port:  "5432/tcp",
+		Env: map[string]string{
+			"POSTGRES_DB":       Database,
+			"POSTGRES_USER":     User,
+			"POSTGRES_PASSWORD": Password,
+		},
+	}
+)
+
+// DefaultStartupTimeout is the maximum time Start waits for a container to accept connections.
+const DefaultStartupTimeout = 2 * time.Minute
+
+// BootstrapFunc prepares a freshly started database, e.g. by importing a schema.
+type BootstrapFunc func(ctx context.Context, db *database.DB) error
+
+// Option configures Start.
+type Option interface {
+	apply(*options)
+}
+
+// options holds the settings of Start.
+type options struct {
+	bootstrap      []BootstrapFunc
+	startupTimeout time.Duration
+	dbOptions      database.Options
+}
+
+// optionFunc implements Option.
+type optionFunc func(*options)
+
+// apply implements Option.
+func (f optionFunc) apply(o *options) {
+	f(o)
+}
+
+// WithBootstrap adds a function that is called once the database accepts connections,
+// in the order the functions were added.
+func WithBootstrap(f BootstrapFunc) Option {
+	return optionFunc(func(o *options) {
+		o.bootstrap = append(o.bootstrap, f)
+	})
+}
+
+// WithSchema executes the given statements once the database accepts connections,
+// e.g. the CREATE TABLE statements of a schema. Each statement must be passed separately.
+func WithSchema(statements ...string) Option {
+	return WithBootstrap(func(ctx context.Context, db *database.DB) error {
+		for _, stmt := range statements {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return database.CantPerformQuery(err, stmt)
+			}
+		}
+
+		return nil
+	})
+}
+
+// WithStartupTimeout overrides DefaultStartupTimeout.
+func WithStartupTimeout(timeout time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.startupTimeout = timeout
+	})
+}
+
+// WithDatabaseOptions overrides the default database.Options of the returned DB.
+func WithDatabaseOptions(dbOptions database.Options) Option {
+	return optionFunc(func(o *options) {
+		o.dbOptions = dbOptions
+	})
+}
+
+// Start starts a disposable container of the given flavor, waits until it accepts connections,
+// applies all bootstrap options and returns a DB connected to it.
+// The container is removed once the test and all its subtests have completed.
+// Skips the test if the docker CLI is not available and fails it fatally if anything else goes wrong.
+func Start(t testing.TB, flavor Flavor, opts ...Option) *database.DB {
+	t.Helper()
+
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		t.Skipf("docker CLI not available, skipping test: %v", err)
+	}
+
+	o := options{startupTimeout: DefaultStartupTimeout}
+	require.NoError(t, defaults.Set(&o.dbOptions), "applying database option defaults should not fail")
+
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::" + flavor.Port}
+	for k, v := range flavor.Env {
+		args = append(args, "--env", k+"="+v)
+	}
+	args = append(args, flavor.Image)
+
+	id, err := run(docker, args...)
+	require.NoError(t, err, "starting container should not fail")
+
+	t.Cleanup(func() {
+		if _, err := run(docker, "rm", "--force", "--volumes", id); err != nil {
+			t.Logf("Can't remove container %s: %v", id, err)
+		}
+	})
+
+	binding, err := run(docker, "port", id, flavor.Port)
+	require.NoError(t, err, "getting container port should not fail")
+
+	port, err := parsePort(binding)
+	require.NoError(t, err, "parsing container port should not fail")
+
+	c := &database.Config{
+		Type:     flavor.Type,
+		Host:     "127.0.0.1",
+		Port:     port,
+		Database: Database,
+		User:     User,
+		Password: Password,
+		Options:  o.dbOptions,
+	}
+	require.NoError(t, c.Validate(), "database config validation should not fail")
+
+	db, err := database.NewDbFromConfig(
+		c, logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour), database.RetryConnectorCallbacks{})
+	require.NoError(t, err, "creating database should not fail")
+
+	t.Cleanup(func() { _ = db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.startupTimeout)
+	defer cancel()
+
+	require.NoError(t, waitForDatabase(ctx, db), "database should accept connections")
+
+	for _, bootstrap := range o.bootstrap {
+		require.NoError(t, bootstrap(ctx, db), "bootstrapping database should not fail")
+	}
+
+	return db
+}
+
+// waitForDatabase pings db until it succeeds or ctx is done.
+// Database images usually restart the server once initialized,
+// so connections may be refused or reset for a while.
+func waitForDatabase(ctx context.Context, db *database.DB) error {
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return errors.Wrap(err, "can't ping database")
+		}
+	}
+}
+
+// run executes the docker CLI with the given arguments and returns its trimmed standard output.
+func run(docker string, args ...string) (string, error) {
+	out, err := exec.Command(docker, args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			err = errors.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+
+		return "", errors.Wrapf(err, "can't run docker %s", strings.Join(args, " "))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// parsePort parses the host port from the output of docker port,
+// which lists one address per line, e.g. "127.0.0.1:32768".
+func parsePort(binding string) (int, error) {
+	line, _, _ := strings.Cut(binding, "\n")
+
+	_, port, err := net.SplitHostPort(strings.TrimSpace(line))
+	if err != nil {
+		return 0, errors.Wrapf(err, "can't parse port binding %q", binding)
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, errors.Wrapf(err, "can't parse port %q", port)
+	}
+
+	return p, nil
+}
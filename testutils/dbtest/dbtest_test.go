@@ -0,0 +1,57 @@
+package dbtest
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/database"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParsePort(t *testing.T) {
+	subtests := []struct {
+		name    string
+		binding string
+		port    int
+		error   bool
+	}{
+		{"ipv4", "127.0.0.1:32768", 32768, false},
+		{"multiple", "0.0.0.0:32769\n[::]:32769", 32769, false},
+		{"ipv6", "[::1]:5432", 5432, false},
+		{"empty", "", 0, true},
+		{"invalid", "127.0.0.1:port", 0, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			port, err := parsePort(st.binding)
+			if st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.port, port)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping container test in short mode")
+	}
+
+	for name, flavor := range map[string]Flavor{"mysql": MySQL, "mariadb": MariaDB, "postgresql": PostgreSQL} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db := Start(t, flavor, WithSchema(`CREATE TABLE "host" ("id" INT PRIMARY KEY)`),
+				WithBootstrap(func(ctx context.Context, db *database.DB) error {
+					_, err := db.ExecContext(ctx, `INSERT INTO "host" ("id") VALUES (1)`)
+					return err
+				}))
+
+			var count int
+			require.NoError(t, db.Get(&count, `SELECT COUNT(*) FROM "host"`))
+			require.Equal(t, 1, count)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package testutils
+
+import (
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+)
+
+// PerDriver maps database driver names, e.g. "mysql" and "postgres", to the value expected for the driver.
+type PerDriver[T any] map[string]T
+
+// RunPerDriver runs f as a subtest named after each driver in expected, in a deterministic order,
+// passing the driver name and its expected value.
+// This allows a single table entry to cover all supported drivers instead of one entry per driver.
+func RunPerDriver[T any](t *testing.T, expected PerDriver[T], f func(t *testing.T, driver string, expected T)) {
+	drivers := make([]string, 0, len(expected))
+	for driver := range expected {
+		drivers = append(drivers, driver)
+	}
+	slices.Sort(drivers)
+
+	for _, driver := range drivers {
+		t.Run(driver, func(t *testing.T) {
+			f(t, driver, expected[driver])
+		})
+	}
+}
+
+// AssertStatementPerDriver calls build for each driver in expected and
+// asserts that the returned statement equals the one expected for that driver.
+//
+// Example usage:
+//
+//	testutils.AssertStatementPerDriver(t, testutils.PerDriver[string]{
+//		"mysql":    `DELETE FROM "host" WHERE id IN (?)`,
+//		"postgres": `DELETE FROM "host" WHERE id IN ($1)`,
+//	}, func(t *testing.T, driver string) string {
+//		return build(newDb(t, driver))
+//	})
+func AssertStatementPerDriver(t *testing.T, expected PerDriver[string], build func(t *testing.T, driver string) string) {
+	RunPerDriver(t, expected, func(t *testing.T, driver string, expected string) {
+		require.Equal(t, expected, build(t, driver))
+	})
+}
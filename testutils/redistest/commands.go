@@ -0,0 +1,573 @@
+package redistest
+
+import (
+	"github.com/pkg/errors"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wrongType is the error reply for commands executed against keys holding other types.
+const wrongType = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+
+// exec executes the given command and returns its reply. s.mu must be held.
+func (s *Server) exec(args []string) string {
+	name := upper(args[0])
+
+	minArgs, known := arity[name]
+	if !known {
+		return errorReply("ERR unknown command '" + args[0] + "'")
+	}
+
+	if len(args) < minArgs {
+		return errorReply("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+	}
+
+	switch name {
+	case "PING":
+		if len(args) > 1 {
+			return bulk(args[1])
+		}
+
+		return "+PONG\r\n"
+	case "SELECT", "CLIENT":
+		return replyOK
+	case "FLUSHALL", "FLUSHDB":
+		s.flush()
+
+		return replyOK
+	case "DEL", "EXISTS":
+		n := 0
+		for _, key := range args[1:] {
+			if s.keyType(key) != "none" {
+				n++
+
+				if name == "DEL" {
+					delete(s.strings, key)
+					delete(s.hashes, key)
+					delete(s.streams, key)
+				}
+			}
+		}
+
+		return integer(n)
+	case "TYPE":
+		return "+" + s.keyType(args[1]) + "\r\n"
+	case "GET":
+		return s.get(args[1])
+	case "SET":
+		return s.set(args[1:])
+	case "HSET", "HMSET":
+		return s.hset(name, args[1:])
+	case "HGET", "HMGET", "HGETALL", "HDEL", "HLEN", "HSCAN":
+		if t := s.keyType(args[1]); t != "none" && t != "hash" {
+			return wrongType
+		}
+
+		return s.hashCommand(name, args[1], s.hashes[args[1]], args[2:])
+	case "XADD", "XLEN", "XRANGE", "XREVRANGE", "XDEL", "XTRIM":
+		if t := s.keyType(args[1]); t != "none" && t != "stream" {
+			return wrongType
+		}
+
+		return s.streamCommand(name, args[1], args[2:])
+	case "XREAD":
+		xr, err := s.parseXRead(args)
+		if err != nil {
+			return errorReply("ERR " + err.Error())
+		}
+
+		reply, _ := s.xread(xr)
+
+		return reply
+	default:
+		return errorReply("ERR unsupported command '" + args[0] + "'")
+	}
+}
+
+// arity maps the supported commands to their minimum number of arguments, including the command name.
+var arity = map[string]int{
+	"PING": 1, "SELECT": 2, "CLIENT": 2, "FLUSHALL": 1, "FLUSHDB": 1,
+	"DEL": 2, "EXISTS": 2, "TYPE": 2, "GET": 2, "SET": 3,
+	"HSET": 4, "HMSET": 4, "HGET": 3, "HMGET": 3, "HGETALL": 2, "HDEL": 3, "HLEN": 2, "HSCAN": 3,
+	"XADD": 5, "XLEN": 2, "XRANGE": 4, "XREVRANGE": 4, "XREAD": 4, "XDEL": 3, "XTRIM": 4,
+}
+
+// keyType returns the type of the value stored at key, or "none" if there is none.
+func (s *Server) keyType(key string) string {
+	if v, ok := s.strings[key]; ok {
+		if v.expires.IsZero() || time.Now().Before(v.expires) {
+			return "string"
+		}
+
+		delete(s.strings, key)
+	}
+
+	if _, ok := s.hashes[key]; ok {
+		return "hash"
+	}
+
+	if _, ok := s.streams[key]; ok {
+		return "stream"
+	}
+
+	return "none"
+}
+
+// get implements GET.
+func (s *Server) get(key string) string {
+	switch s.keyType(key) {
+	case "none":
+		return replyNilBulk
+	case "string":
+		return bulk(s.strings[key].value)
+	default:
+		return wrongType
+	}
+}
+
+// set implements SET key value [NX | XX] [EX seconds | PX milliseconds].
+func (s *Server) set(args []string) string {
+	key, value := args[0], stringValue{value: args[1]}
+	var nx, xx bool
+
+	for i := 2; i < len(args); i++ {
+		switch opt := upper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return errorReply("ERR syntax error")
+			}
+
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return errorReply("ERR invalid expire time in 'set' command")
+			}
+
+			unit := time.Millisecond
+			if opt == "EX" {
+				unit = time.Second
+			}
+
+			value.expires = time.Now().Add(time.Duration(n) * unit)
+			i++
+		default:
+			return errorReply("ERR syntax error")
+		}
+	}
+
+	exists := s.keyType(key) != "none"
+	if nx && exists || xx && !exists {
+		return replyNilBulk
+	}
+
+	delete(s.hashes, key)
+	delete(s.streams, key)
+	s.strings[key] = value
+
+	return replyOK
+}
+
+// hset implements HSET and HMSET key field value [field value ...].
+func (s *Server) hset(name string, args []string) string {
+	key, pairs := args[0], args[1:]
+	if len(pairs)%2 != 0 {
+		return errorReply("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+	}
+
+	if t := s.keyType(key); t != "none" && t != "hash" {
+		return wrongType
+	}
+
+	h, ok := s.hashes[key]
+	if !ok {
+		h = make(map[string]string, len(pairs)/2)
+		s.hashes[key] = h
+	}
+
+	added := 0
+	for i := 0; i < len(pairs); i += 2 {
+		if _, ok := h[pairs[i]]; !ok {
+			added++
+		}
+
+		h[pairs[i]] = pairs[i+1]
+	}
+
+	if name == "HMSET" {
+		return replyOK
+	}
+
+	return integer(added)
+}
+
+// hashCommand implements the read and delete commands for the hash h stored at key, which is nil if there is none.
+func (s *Server) hashCommand(name, key string, h map[string]string, args []string) string {
+	switch name {
+	case "HGET":
+		if v, ok := h[args[0]]; ok {
+			return bulk(v)
+		}
+
+		return replyNilBulk
+	case "HMGET":
+		replies := make([]string, 0, len(args))
+		for _, field := range args {
+			if v, ok := h[field]; ok {
+				replies = append(replies, bulk(v))
+			} else {
+				replies = append(replies, replyNilBulk)
+			}
+		}
+
+		return array(replies...)
+	case "HGETALL":
+		return bulks(flatten(h, "*")...)
+	case "HDEL":
+		n := 0
+		for _, field := range args {
+			if _, ok := h[field]; ok {
+				delete(h, field)
+				n++
+			}
+		}
+
+		if len(h) == 0 {
+			delete(s.hashes, key)
+		}
+
+		return integer(n)
+	case "HLEN":
+		return integer(len(h))
+	default: // HSCAN key cursor [MATCH pattern] [COUNT count]
+		pattern := "*"
+		for i := 1; i+1 < len(args); i += 2 {
+			if upper(args[i]) == "MATCH" {
+				pattern = args[i+1]
+			}
+		}
+
+		// All fields are returned at once, so the cursor is always 0.
+		return array(bulk("0"), bulks(flatten(h, pattern)...))
+	}
+}
+
+// flatten returns the field-value pairs of h ordered by field, limited to fields matching the glob-style pattern.
+func flatten(h map[string]string, pattern string) []string {
+	pairs := make([]string, 0, 2*len(h))
+	for _, field := range sortedFields(h) {
+		if matched, _ := path.Match(pattern, field); matched {
+			pairs = append(pairs, field, h[field])
+		}
+	}
+
+	return pairs
+}
+
+// streamCommand implements the stream commands except XREAD for the stream stored at key.
+func (s *Server) streamCommand(name, key string, args []string) string {
+	st, exists := s.streams[key]
+
+	switch name {
+	case "XADD":
+		return s.xadd(key, args)
+	case "XLEN":
+		if !exists {
+			return integer(0)
+		}
+
+		return integer(len(st.entries))
+	case "XRANGE", "XREVRANGE":
+		startArg, endArg := args[0], args[1]
+		if name == "XREVRANGE" {
+			startArg, endArg = endArg, startArg
+		}
+
+		start, err := parseRangeID(startArg, false)
+		if err != nil {
+			return errorReply("ERR " + err.Error())
+		}
+
+		end, err := parseRangeID(endArg, true)
+		if err != nil {
+			return errorReply("ERR " + err.Error())
+		}
+
+		count := 0
+		if len(args) >= 4 && upper(args[2]) == "COUNT" {
+			if count, err = strconv.Atoi(args[3]); err != nil {
+				return errorReply("ERR value is not an integer or out of range")
+			}
+		}
+
+		if !exists {
+			return array()
+		}
+
+		var entries []entry
+		if name == "XREVRANGE" {
+			entries = st.rangeOf(start, end, 0)
+			slices.Reverse(entries)
+			if count > 0 && len(entries) > count {
+				entries = entries[:count]
+			}
+		} else {
+			entries = st.rangeOf(start, end, count)
+		}
+
+		replies := make([]string, 0, len(entries))
+		for _, e := range entries {
+			replies = append(replies, e.reply())
+		}
+
+		return array(replies...)
+	case "XDEL":
+		if !exists {
+			return integer(0)
+		}
+
+		n := 0
+		for _, arg := range args {
+			id, err := parseStreamID(arg, 0)
+			if err != nil {
+				return errorReply("ERR " + err.Error())
+			}
+
+			st.entries = slices.DeleteFunc(st.entries, func(e entry) bool {
+				if e.id == id {
+					n++
+					return true
+				}
+
+				return false
+			})
+		}
+
+		return integer(n)
+	default: // XTRIM
+		if !exists {
+			return integer(0)
+		}
+
+		removed, err := trim(st, args)
+		if err != nil {
+			return errorReply("ERR " + err.Error())
+		}
+
+		return integer(removed)
+	}
+}
+
+// xadd implements XADD key [NOMKSTREAM] [MAXLEN | MINID [= | ~] threshold [LIMIT count]] id field value [...].
+func (s *Server) xadd(key string, args []string) string {
+	i := 0
+	nomkstream := false
+	if upper(args[i]) == "NOMKSTREAM" {
+		nomkstream = true
+		i++
+	}
+
+	var trimArgs []string
+	if opt := upper(args[i]); opt == "MAXLEN" || opt == "MINID" {
+		j := i + 1
+		if j < len(args) && (args[j] == "=" || args[j] == "~") {
+			j++
+		}
+		j++ // threshold
+		if j+1 < len(args) && upper(args[j]) == "LIMIT" {
+			j += 2
+		}
+
+		if j > len(args) {
+			return errorReply("ERR syntax error")
+		}
+
+		trimArgs = args[i:j]
+		i = j
+	}
+
+	if i >= len(args) || (len(args)-i-1) < 2 || (len(args)-i-1)%2 != 0 {
+		return errorReply("ERR wrong number of arguments for 'xadd' command")
+	}
+
+	if _, exists := s.streams[key]; !exists && nomkstream {
+		return replyNilBulk
+	}
+
+	st := s.stream(key)
+
+	id, err := st.add(args[i], slices.Clone(args[i+1:]))
+	if err != nil {
+		if len(st.entries) == 0 {
+			delete(s.streams, key)
+		}
+
+		return errorReply("ERR " + err.Error())
+	}
+
+	if trimArgs != nil {
+		if _, err := trim(st, trimArgs); err != nil {
+			return errorReply("ERR " + err.Error())
+		}
+	}
+
+	s.notify()
+
+	return bulk(id.String())
+}
+
+// trim implements the MAXLEN | MINID [= | ~] threshold [LIMIT count] arguments of XTRIM and XADD.
+// Trimming is always exact, even if approximate trimming is requested, and LIMIT is ignored.
+// Returns the number of removed entries.
+func trim(st *stream, args []string) (int, error) {
+	i := 1
+	if i < len(args) && (args[i] == "=" || args[i] == "~") {
+		i++
+	}
+
+	if i >= len(args) {
+		return 0, errors.New("syntax error")
+	}
+
+	threshold := args[i]
+
+	switch upper(args[0]) {
+	case "MAXLEN":
+		n, err := strconv.Atoi(threshold)
+		if err != nil || n < 0 {
+			return 0, errors.New("The MAXLEN argument must be >= 0.")
+		}
+
+		return st.trimLen(n), nil
+	case "MINID":
+		id, err := parseStreamID(threshold, 0)
+		if err != nil {
+			return 0, err
+		}
+
+		return st.trimMinID(id), nil
+	default:
+		return 0, errors.New("syntax error")
+	}
+}
+
+// xreadArgs are the parsed arguments of XREAD.
+type xreadArgs struct {
+	count int
+	block time.Duration // block is negative if XREAD must not block and 0 if it blocks forever.
+	keys  []string
+	ids   []streamID
+}
+
+// parseXRead parses XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...]
+// and resolves the special ID "$" to the ID of the last entry of the respective stream. s.mu must be held.
+func (s *Server) parseXRead(args []string) (xreadArgs, error) {
+	xr := xreadArgs{block: -1}
+
+	i := 1
+	for ; i < len(args); i += 2 {
+		opt := upper(args[i])
+		if opt == "STREAMS" {
+			i++
+			break
+		}
+
+		if i+1 >= len(args) {
+			return xr, errors.New("syntax error")
+		}
+
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n < 0 {
+			return xr, errors.New("value is not an integer or out of range")
+		}
+
+		switch opt {
+		case "COUNT":
+			xr.count = n
+		case "BLOCK":
+			xr.block = time.Duration(n) * time.Millisecond
+		default:
+			return xr, errors.New("syntax error")
+		}
+	}
+
+	streams := args[min(i, len(args)):]
+	if len(streams) == 0 || len(streams)%2 != 0 {
+		return xr, errors.New("Unbalanced 'xread' list of streams: for each stream key an ID or '$' must be specified.")
+	}
+
+	xr.keys = streams[:len(streams)/2]
+	for j, arg := range streams[len(streams)/2:] {
+		if arg == "$" {
+			var last streamID
+			if st, ok := s.streams[xr.keys[j]]; ok {
+				last = st.last
+			}
+
+			xr.ids = append(xr.ids, last)
+
+			continue
+		}
+
+		id, err := parseStreamID(arg, 0)
+		if err != nil {
+			return xr, err
+		}
+
+		xr.ids = append(xr.ids, id)
+	}
+
+	return xr, nil
+}
+
+// xread returns the XREAD reply for the entries after the given IDs of the given streams and
+// whether there are any such entries at all. s.mu must be held.
+func (s *Server) xread(xr xreadArgs) (string, bool) {
+	var replies []string
+	for i, key := range xr.keys {
+		st, ok := s.streams[key]
+		if !ok {
+			continue
+		}
+
+		entries := st.rangeOf(xr.ids[i].next(), streamID{ms: ^uint64(0), seq: ^uint64(0)}, xr.count)
+		if len(entries) == 0 {
+			continue
+		}
+
+		entryReplies := make([]string, 0, len(entries))
+		for _, e := range entries {
+			entryReplies = append(entryReplies, e.reply())
+		}
+
+		replies = append(replies, array(bulk(key), array(entryReplies...)))
+	}
+
+	if len(replies) == 0 {
+		return replyNilArray, false
+	}
+
+	return array(replies...), true
+}
+
+// upper returns s in upper case, as used for command names and options.
+func upper(s string) string {
+	return strings.ToUpper(s)
+}
+
+// sortedFields returns the fields of h in ascending order.
+func sortedFields(h map[string]string) []string {
+	fields := make([]string, 0, len(h))
+	for field := range h {
+		fields = append(fields, field)
+	}
+
+	slices.Sort(fields)
+
+	return fields
+}
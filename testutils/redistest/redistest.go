@@ -0,0 +1,356 @@
+// Package redistest provides an in-memory Redis server for unit tests of code using redis.Client,
+// e.g. consumers of HYield, HMYield and XReadUntilResult, along with fixtures to pre-populate it
+// with hashes and streams and helpers to assert what has been written to it.
+//
+// The server speaks RESP2 and implements only the commands used by this library and their most common options,
+// i.e. PING, SELECT, CLIENT, FLUSHALL, FLUSHDB, DEL, EXISTS, TYPE, GET, SET, HSET, HMSET, HGET, HMGET, HGETALL,
+// HDEL, HLEN, HSCAN, XADD, XLEN, XRANGE, XREVRANGE, XREAD, XDEL, XTRIM, MULTI, EXEC and DISCARD.
+// Other commands, e.g. EVAL, are rejected with an error.
+package redistest
+
+import (
+	"bufio"
+	"github.com/creasty/defaults"
+	"github.com/icinga/icinga-go-library/logging"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"maps"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server is an in-memory Redis server. Use Start to create one.
+// Its fixture and assertion methods are safe for concurrent use, also while clients are connected.
+type Server struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	strings map[string]stringValue
+	hashes  map[string]map[string]string
+	streams map[string]*stream
+
+	// changed is closed and replaced each time entries are added to a stream, waking up blocking XREADs.
+	changed chan struct{}
+
+	// done is closed once the server is closed.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// stringValue is a string key with an optional expiry.
+type stringValue struct {
+	value   string
+	expires time.Time
+}
+
+// Start starts a new Server listening on a random local port, which is closed once the test has completed.
+func Start(t testing.TB) *Server {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "listening should not fail")
+
+	s := &Server{
+		listener: l,
+		changed:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	s.flush()
+
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() *net.TCPAddr {
+	return s.listener.Addr().(*net.TCPAddr)
+}
+
+// Close stops the server. Connected clients receive errors from then on.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.listener.Close()
+	})
+}
+
+// NewClient returns a redis.Client connected to the server, with the default redis.Options
+// overridden by the given ones, if any, which is closed once the test has completed.
+func (s *Server) NewClient(t testing.TB, options ...redis.Options) *redis.Client {
+	t.Helper()
+
+	c := &redis.Config{}
+	require.NoError(t, defaults.Set(c), "applying config defaults should not fail")
+
+	c.Host = s.Addr().IP.String()
+	c.Port = s.Addr().Port
+	if len(options) > 0 {
+		c.Options = options[0]
+	}
+	require.NoError(t, c.Validate(), "config validation should not fail")
+
+	client, err := redis.NewClientFromConfig(c, logging.NewLogger(zaptest.NewLogger(t).Sugar(), time.Hour))
+	require.NoError(t, err, "creating client should not fail")
+
+	t.Cleanup(func() { _ = client.Close() })
+
+	return client
+}
+
+// FlushAll removes all keys.
+func (s *Server) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.flush()
+}
+
+// HSet sets the given fields of the hash stored at key, creating it if necessary.
+func (s *Server) HSet(key string, fields map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, ok := s.hashes[key]
+	if !ok {
+		h = make(map[string]string, len(fields))
+		s.hashes[key] = h
+	}
+
+	maps.Copy(h, fields)
+}
+
+// Hash returns a copy of the hash stored at key, or nil if there is none.
+func (s *Server) Hash(key string) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.hashes[key])
+}
+
+// XAdd adds an entry with the given values to the stream stored at key, creating it if necessary,
+// and returns the ID of the entry. The values are added in the order of their fields.
+func (s *Server) XAdd(key string, values map[string]string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pairs := make([]string, 0, 2*len(values))
+	for _, field := range sortedFields(values) {
+		pairs = append(pairs, field, values[field])
+	}
+
+	id, err := s.stream(key).add("*", pairs)
+	if err != nil {
+		// We don't expect an error here, as "*" always generates a valid ID.
+		panic(err)
+	}
+
+	s.notify()
+
+	return id.String()
+}
+
+// Stream returns the entries of the stream stored at key, or nil if there is none.
+func (s *Server) Stream(key string) []redis.XMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[key]
+	if !ok {
+		return nil
+	}
+
+	messages := make([]redis.XMessage, 0, len(st.entries))
+	for _, e := range st.entries {
+		values := make(map[string]any, len(e.values)/2)
+		for i := 0; i < len(e.values); i += 2 {
+			values[e.values[i]] = e.values[i+1]
+		}
+
+		messages = append(messages, redis.XMessage{ID: e.id.String(), Values: values})
+	}
+
+	return messages
+}
+
+// RequireStream asserts that the stream stored at key consists of entries with exactly the expected values,
+// in the given order, e.g. to verify what has been written via XADD.
+func (s *Server) RequireStream(t testing.TB, key string, expected ...map[string]string) {
+	t.Helper()
+
+	var actual []map[string]string
+	for _, m := range s.Stream(key) {
+		values := make(map[string]string, len(m.Values))
+		for k, v := range m.Values {
+			values[k] = v.(string)
+		}
+
+		actual = append(actual, values)
+	}
+
+	require.Equal(t, expected, actual, "entries of stream %q", key)
+}
+
+// serve accepts connections until the server is closed.
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn reads commands from conn and writes their replies until conn or the server is closed.
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	go func() {
+		<-s.done
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	// queue holds the commands of a transaction started with MULTI, or is nil outside of transactions.
+	var queue [][]string
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		var reply string
+		switch name := upper(args[0]); {
+		case name == "MULTI":
+			if queue != nil {
+				reply = errorReply("ERR MULTI calls can not be nested")
+			} else {
+				queue = [][]string{}
+				reply = replyOK
+			}
+		case name == "EXEC" || name == "DISCARD":
+			if queue == nil {
+				reply = errorReply("ERR " + name + " without MULTI")
+			} else if name == "DISCARD" {
+				reply = replyOK
+			} else {
+				reply = s.execMulti(queue)
+			}
+
+			queue = nil
+		case queue != nil:
+			queue = append(queue, args)
+			reply = replyQueued
+		default:
+			reply = s.execBlocking(args)
+		}
+
+		if _, err := w.WriteString(reply); err != nil {
+			return
+		}
+
+		// Only flush once all pipelined commands have been processed.
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// execMulti executes the given commands atomically and returns their replies as array.
+func (s *Server) execMulti(commands [][]string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replies := make([]string, 0, len(commands))
+	for _, args := range commands {
+		replies = append(replies, s.exec(args))
+	}
+
+	return array(replies...)
+}
+
+// execBlocking executes the given command, which, in the case of XREAD with BLOCK,
+// waits for entries to be added until the timeout elapses.
+func (s *Server) execBlocking(args []string) string {
+	if upper(args[0]) != "XREAD" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return s.exec(args)
+	}
+
+	s.mu.Lock()
+	xr, err := s.parseXRead(args)
+	if err != nil {
+		s.mu.Unlock()
+
+		return errorReply("ERR " + err.Error())
+	}
+
+	var timeout <-chan time.Time
+	if xr.block > 0 {
+		timer := time.NewTimer(xr.block)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		reply, found := s.xread(xr)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if found || xr.block < 0 {
+			return reply
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return replyNilArray
+		case <-s.done:
+			return replyNilArray
+		}
+
+		s.mu.Lock()
+	}
+}
+
+// flush removes all keys.
+func (s *Server) flush() {
+	s.strings = make(map[string]stringValue)
+	s.hashes = make(map[string]map[string]string)
+	s.streams = make(map[string]*stream)
+}
+
+// notify wakes up blocking XREADs.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// stream returns the stream stored at key, creating it if necessary.
+func (s *Server) stream(key string) *stream {
+	st, ok := s.streams[key]
+	if !ok {
+		st = &stream{}
+		s.streams[key] = st
+	}
+
+	return st
+}
+
+// now returns the current time in milliseconds, as used in stream IDs.
+func now() uint64 {
+	return uint64(time.Now().UnixMilli())
+}
@@ -0,0 +1,144 @@
+package redistest
+
+import (
+	"context"
+	"github.com/icinga/icinga-go-library/redis"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestServer_HYield(t *testing.T) {
+	s := Start(t)
+	s.HSet("icinga:host", map[string]string{"h1": "a", "h2": "b"})
+
+	c := s.NewClient(t)
+
+	t.Run("HYield", func(t *testing.T) {
+		pairs, errs := c.HYield(context.Background(), "icinga:host")
+
+		actual := map[string]string{}
+		for pair := range pairs {
+			actual[pair.Field] = pair.Value
+		}
+		require.NoError(t, <-errs)
+		require.Equal(t, map[string]string{"h1": "a", "h2": "b"}, actual)
+	})
+
+	t.Run("HMYield", func(t *testing.T) {
+		pairs, errs := c.HMYield(context.Background(), "icinga:host", "h2", "missing")
+
+		var actual []redis.HPair
+		for pair := range pairs {
+			actual = append(actual, pair)
+		}
+		require.NoError(t, <-errs)
+		require.Equal(t, []redis.HPair{{Field: "h2", Value: "b"}}, actual)
+	})
+}
+
+func TestServer_HSetStreamed(t *testing.T) {
+	s := Start(t)
+	c := s.NewClient(t)
+
+	pairs := make(chan redis.HPair, 2)
+	pairs <- redis.HPair{Field: "f1", Value: "v1"}
+	pairs <- redis.HPair{Field: "f2", Value: "v2\r\nwith newline"}
+	close(pairs)
+
+	require.NoError(t, c.HSetStreamed(context.Background(), "icinga:host", pairs))
+	require.Equal(t, map[string]string{"f1": "v1", "f2": "v2\r\nwith newline"}, s.Hash("icinga:host"))
+}
+
+func TestServer_XAddBulk(t *testing.T) {
+	s := Start(t)
+	c := s.NewClient(t)
+
+	entries := make(chan map[string]any, 3)
+	for _, v := range []string{"1", "2", "3"} {
+		entries <- map[string]any{"v": v}
+	}
+	close(entries)
+
+	require.NoError(t, c.XAddBulk(context.Background(), "icinga:history", entries, redis.XAddMaxLen(2)))
+	s.RequireStream(t, "icinga:history", map[string]string{"v": "2"}, map[string]string{"v": "3"})
+}
+
+func TestServer_XReadUntilResult(t *testing.T) {
+	s := Start(t)
+	c := s.NewClient(t)
+
+	first := s.XAdd("icinga:runtime", map[string]string{"k": "1"})
+
+	streams, err := c.XReadUntilResult(context.Background(), &redis.XReadArgs{Streams: []string{"icinga:runtime", "0-0"}})
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Equal(t, []redis.XMessage{{ID: first, Values: map[string]any{"k": "1"}}}, streams[0].Messages)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.XAdd("icinga:runtime", map[string]string{"k": "2"})
+	}()
+
+	streams, err = c.XReadUntilResult(context.Background(), &redis.XReadArgs{Streams: []string{"icinga:runtime", first}})
+	require.NoError(t, err)
+	require.Len(t, streams, 1)
+	require.Len(t, streams[0].Messages, 1)
+	require.Equal(t, map[string]any{"k": "2"}, streams[0].Messages[0].Values)
+}
+
+func TestServer_Commands(t *testing.T) {
+	s := Start(t)
+	c := s.NewClient(t)
+	ctx := context.Background()
+
+	t.Run("SET", func(t *testing.T) {
+		ok, err := c.SetNX(ctx, "lock", "token", time.Minute).Result()
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = c.SetNX(ctx, "lock", "other", time.Minute).Result()
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		v, err := c.Get(ctx, "lock").Result()
+		require.NoError(t, err)
+		require.Equal(t, "token", v)
+	})
+
+	t.Run("XRANGE", func(t *testing.T) {
+		for _, v := range []string{"1", "2", "3"} {
+			_, err := c.XAdd(ctx, &redis.XAddArgs{Stream: "stream", ID: v + "-0", Values: []string{"v", v}}).Result()
+			require.NoError(t, err)
+		}
+
+		_, err := c.XAdd(ctx, &redis.XAddArgs{Stream: "stream", ID: "1-0", Values: []string{"v", "0"}}).Result()
+		require.ErrorContains(t, err, "equal or smaller")
+
+		messages, err := c.XRange(ctx, "stream", "(1-0", "+").Result()
+		require.NoError(t, err)
+		require.Equal(t, []redis.XMessage{
+			{ID: "2-0", Values: map[string]any{"v": "2"}},
+			{ID: "3-0", Values: map[string]any{"v": "3"}},
+		}, messages)
+
+		messages, err = c.XRevRangeN(ctx, "stream", "+", "-", 1).Result()
+		require.NoError(t, err)
+		require.Equal(t, []redis.XMessage{{ID: "3-0", Values: map[string]any{"v": "3"}}}, messages)
+	})
+
+	t.Run("WRONGTYPE", func(t *testing.T) {
+		require.ErrorContains(t, c.HSet(ctx, "stream", "f", "v").Err(), "WRONGTYPE")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		require.ErrorContains(t, c.Do(ctx, "EVAL", "return 1", "0").Err(), "unknown command")
+	})
+
+	t.Run("DEL", func(t *testing.T) {
+		n, err := c.Del(ctx, "lock", "stream", "missing").Result()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), n)
+		require.Nil(t, s.Stream("stream"))
+	})
+}
@@ -0,0 +1,23 @@
+package redistest
+
+import (
+	"github.com/icinga/icinga-go-library/testutils/redistest/resp"
+)
+
+// Replies without any data.
+const (
+	replyOK       = resp.OK
+	replyQueued   = resp.Queued
+	replyNilBulk  = resp.NilBulk
+	replyNilArray = resp.NilArray
+)
+
+// Shorthands for the RESP2 encoding and decoding helpers of package resp used throughout this package.
+var (
+	errorReply  = resp.Error
+	integer     = resp.Integer
+	bulk        = resp.Bulk
+	array       = resp.Array
+	bulks       = resp.Bulks
+	readCommand = resp.ReadCommand
+)
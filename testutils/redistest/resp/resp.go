@@ -0,0 +1,194 @@
+// Package resp provides a minimal RESP2 server for unit tests that need full control over the replies of Redis,
+// e.g. to inject errors or to test the redis package itself, which can't use the redistest package
+// without an import cycle. Unlike redistest.Server, it doesn't implement any commands on its own,
+// but replies to each command with the raw reply returned by a Handler.
+// It also provides the RESP2 encoding helpers to build such replies.
+package resp
+
+import (
+	"bufio"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Replies without any data.
+const (
+	OK       = "+OK\r\n"
+	Queued   = "+QUEUED\r\n"
+	NilBulk  = "$-1\r\n"
+	NilArray = "*-1\r\n"
+)
+
+// Error returns a RESP2 error reply with the given message, e.g. "ERR unknown command".
+func Error(msg string) string {
+	return "-" + msg + "\r\n"
+}
+
+// Integer returns a RESP2 integer reply.
+func Integer(n int) string {
+	return ":" + strconv.Itoa(n) + "\r\n"
+}
+
+// Bulk returns a RESP2 bulk string reply.
+func Bulk(s string) string {
+	return "$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n"
+}
+
+// Array returns a RESP2 array reply of the given, already encoded replies.
+func Array(replies ...string) string {
+	return "*" + strconv.Itoa(len(replies)) + "\r\n" + strings.Join(replies, "")
+}
+
+// Bulks returns a RESP2 array reply of the given strings as bulk strings.
+func Bulks(strs ...string) string {
+	replies := make([]string, 0, len(strs))
+	for _, s := range strs {
+		replies = append(replies, Bulk(s))
+	}
+
+	return Array(replies...)
+}
+
+// ReadCommand reads a command, i.e. a RESP2 array of bulk strings, from r.
+func ReadCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, 0, n)
+	for range n {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, errors.Wrap(err, "can't read bulk string")
+		}
+
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+// readLength reads a line consisting of the given type prefix and a non-negative length from r.
+func readLength(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) < 2 || line[0] != prefix {
+		return 0, errors.Errorf("unexpected RESP line %q, expected %q prefix", line, prefix)
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return 0, errors.Errorf("invalid RESP length in %q", line)
+	}
+
+	return n, nil
+}
+
+// Handler returns the raw RESP2 reply to the command with the given arguments, including the command name.
+// If it returns true for closeConn, the connection is closed after writing the reply, e.g. to simulate a failure.
+// Handlers may be called concurrently for different connections.
+type Handler func(args []string) (reply string, closeConn bool)
+
+// Replies returns a Handler that replies to each command with the reply returned by fn and never closes connections.
+func Replies(fn func(args []string) string) Handler {
+	return func(args []string) (string, bool) {
+		return fn(args), false
+	}
+}
+
+// Server is a RESP2 server that replies to commands using a Handler. Use Start to create one.
+type Server struct {
+	listener net.Listener
+	handle   Handler
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// Start starts a new Server listening on a random local port, which is closed once the test has completed.
+func Start(t testing.TB, handle Handler) *Server {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err, "listening should not fail")
+
+	s := &Server{listener: l, handle: handle, conns: make(map[net.Conn]struct{})}
+
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Addr returns the address the server listens on as host:port.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and closes all connections.
+func (s *Server) Close() {
+	_ = s.listener.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+}
+
+// serve accepts connections until the server is closed.
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn reads commands from conn and writes the replies returned by the handler until conn is closed.
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		args, err := ReadCommand(r)
+		if err != nil {
+			return
+		}
+
+		reply, closeConn := s.handle(args)
+		if _, err := io.WriteString(conn, reply); err != nil || closeConn {
+			return
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package redistest
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// streamID is the ID of a stream entry, i.e. a millisecond timestamp and a sequence number.
+type streamID struct {
+	ms  uint64
+	seq uint64
+}
+
+// String returns the ID in its <ms>-<seq> form.
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+// less returns whether id is smaller than other.
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || id.ms == other.ms && id.seq < other.seq
+}
+
+// next returns the smallest ID greater than id.
+func (id streamID) next() streamID {
+	if id.seq == math.MaxUint64 {
+		return streamID{ms: id.ms + 1}
+	}
+
+	return streamID{ms: id.ms, seq: id.seq + 1}
+}
+
+// prev returns the greatest ID smaller than id.
+func (id streamID) prev() streamID {
+	if id.seq == 0 {
+		return streamID{ms: id.ms - 1, seq: math.MaxUint64}
+	}
+
+	return streamID{ms: id.ms, seq: id.seq - 1}
+}
+
+// parseStreamID parses an ID in its <ms>-<seq> or <ms> form, in which case the sequence number is seq.
+func parseStreamID(s string, seq uint64) (streamID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return streamID{}, errors.Errorf("invalid stream ID %q", s)
+	}
+
+	if hasSeq {
+		if seq, err = strconv.ParseUint(seqPart, 10, 64); err != nil {
+			return streamID{}, errors.Errorf("invalid stream ID %q", s)
+		}
+	}
+
+	return streamID{ms: ms, seq: seq}, nil
+}
+
+// parseRangeID parses the start or, if end is true, the end of an XRANGE,
+// i.e. "-", "+", an ID or an exclusive ID prefixed with "(".
+func parseRangeID(s string, end bool) (streamID, error) {
+	switch s {
+	case "-":
+		return streamID{}, nil
+	case "+":
+		return streamID{ms: math.MaxUint64, seq: math.MaxUint64}, nil
+	}
+
+	var seq uint64
+	if end {
+		seq = math.MaxUint64
+	}
+
+	exclusive := strings.HasPrefix(s, "(")
+
+	id, err := parseStreamID(strings.TrimPrefix(s, "("), seq)
+	if err != nil || !exclusive {
+		return id, err
+	}
+
+	if end {
+		return id.prev(), nil
+	}
+
+	return id.next(), nil
+}
+
+// entry is a stream entry with its field-value pairs in the order they were added.
+type entry struct {
+	id     streamID
+	values []string
+}
+
+// reply returns the entry as RESP2 reply.
+func (e entry) reply() string {
+	return array(bulk(e.id.String()), bulks(e.values...))
+}
+
+// stream is a stream of entries ordered by their IDs.
+type stream struct {
+	entries []entry
+	last    streamID
+}
+
+// add adds an entry with the given ID, which is "*", "<ms>-*" or an ID greater than all others.
+func (s *stream) add(id string, values []string) (streamID, error) {
+	var next streamID
+
+	switch {
+	case id == "*":
+		next = streamID{ms: now()}
+		if !s.last.less(next) {
+			next = s.last.next()
+		}
+	case strings.HasSuffix(id, "-*"):
+		ms, err := strconv.ParseUint(strings.TrimSuffix(id, "-*"), 10, 64)
+		if err != nil {
+			return streamID{}, errors.Errorf("invalid stream ID %q", id)
+		}
+
+		next = streamID{ms: ms}
+		if ms == s.last.ms {
+			next = s.last.next()
+		}
+	default:
+		var err error
+		if next, err = parseStreamID(id, 0); err != nil {
+			return streamID{}, err
+		}
+	}
+
+	if !s.last.less(next) {
+		return streamID{}, errors.New("The ID specified in XADD is equal or smaller than the target stream top item")
+	}
+
+	s.entries = append(s.entries, entry{id: next, values: values})
+	s.last = next
+
+	return next, nil
+}
+
+// rangeOf returns the entries with IDs in [start, end], limited to count unless count is 0.
+func (s *stream) rangeOf(start, end streamID, count int) []entry {
+	var entries []entry
+	for _, e := range s.entries {
+		if e.id.less(start) || end.less(e.id) {
+			continue
+		}
+
+		entries = append(entries, e)
+		if len(entries) == count {
+			break
+		}
+	}
+
+	return entries
+}
+
+// trimLen removes the oldest entries, so that at most n remain, and returns the number of removed entries.
+func (s *stream) trimLen(n int) int {
+	removed := max(len(s.entries)-n, 0)
+	s.entries = s.entries[removed:]
+
+	return removed
+}
+
+// trimMinID removes all entries with an ID smaller than id and returns the number of removed entries.
+func (s *stream) trimMinID(id streamID) int {
+	removed := 0
+	for removed < len(s.entries) && s.entries[removed].id.less(id) {
+		removed++
+	}
+
+	s.entries = s.entries[removed:]
+
+	return removed
+}
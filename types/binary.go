@@ -2,6 +2,7 @@ package types
 
 import (
 	"bytes"
+	"crypto/rand"
 	"database/sql"
 	"database/sql/driver"
 	"encoding"
@@ -17,11 +18,33 @@ type Binary []byte
 // nullBinary for validating whether a Binary is valid.
 var nullBinary Binary
 
+// NewRandomBinary returns a new Binary of n cryptographically secure random bytes, e.g. for instance IDs.
+func NewRandomBinary(n int) (Binary, error) {
+	b := make(Binary, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Wrap(err, "can't read random bytes")
+	}
+
+	return b, nil
+}
+
 // Valid returns whether the Binary is valid.
 func (binary Binary) Valid() bool {
 	return !bytes.Equal(binary, nullBinary)
 }
 
+// Equal returns whether the Binary and other consist of the same bytes.
+// Note that nil and empty Binaries are considered equal.
+func (binary Binary) Equal(other Binary) bool {
+	return bytes.Equal(binary, other)
+}
+
+// Compare compares the Binary and other lexicographically and returns 0 if they are equal,
+// -1 if the Binary is less than other and +1 if it is greater.
+func (binary Binary) Compare(other Binary) int {
+	return bytes.Compare(binary, other)
+}
+
 // String returns the hex string representation form of the Binary.
 func (binary Binary) String() string {
 	return hex.EncodeToString(binary)
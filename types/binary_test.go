@@ -1,11 +1,47 @@
 package types
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"unicode/utf8"
 )
 
+func TestNewRandomBinary(t *testing.T) {
+	a, err := NewRandomBinary(16)
+	require.NoError(t, err)
+	require.Len(t, a, 16)
+
+	b, err := NewRandomBinary(16)
+	require.NoError(t, err)
+	require.False(t, a.Equal(b), "random binaries must differ")
+}
+
+func TestBinary_Compare(t *testing.T) {
+	subtests := []struct {
+		name  string
+		a     Binary
+		b     Binary
+		equal bool
+		cmp   int
+	}{
+		{"nil", nil, nil, true, 0},
+		{"nil-empty", nil, Binary{}, true, 0},
+		{"equal", Binary{1, 254}, Binary{1, 254}, true, 0},
+		{"less", Binary{1, 2}, Binary{1, 3}, false, -1},
+		{"prefix", Binary{1}, Binary{1, 0}, false, -1},
+		{"greater", Binary{2}, Binary{1, 255}, false, 1},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			require.Equal(t, st.equal, st.a.Equal(st.b))
+			require.Equal(t, st.cmp, st.a.Compare(st.b))
+			require.Equal(t, -st.cmp, st.b.Compare(st.a))
+		})
+	}
+}
+
 func TestBinary_Valid(t *testing.T) {
 	subtests := []struct {
 		name   string
@@ -197,3 +233,49 @@ func TestBinary_Value(t *testing.T) {
 		})
 	}
 }
+
+func FuzzBinary_Text(f *testing.F) {
+	for _, seed := range [][]byte{nil, {0}, {10}, {1, 254}} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		text, err := Binary(data).MarshalText()
+		require.NoError(t, err)
+
+		var actual Binary
+		require.NoError(t, actual.UnmarshalText(text))
+		require.True(t, bytes.Equal(data, actual), "round trip of %x resulted in %x", data, actual)
+	})
+}
+
+func FuzzBinary_UnmarshalText(f *testing.F) {
+	for _, seed := range []string{"", "00", "0a", "01fe", "0", "zz"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		var b Binary
+		if err := b.UnmarshalText([]byte(text)); err != nil {
+			return
+		}
+
+		require.Equal(t, len(text)/2, len(b))
+		require.Zero(t, b.Compare(b))
+	})
+}
+
+func FuzzBinary_JSON(f *testing.F) {
+	for _, seed := range [][]byte{nil, {0}, {10}, {1, 254}} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		j, err := Binary(data).MarshalJSON()
+		require.NoError(t, err)
+
+		var actual Binary
+		require.NoError(t, actual.UnmarshalJSON(j))
+		require.True(t, Binary(data).Equal(actual), "round trip of %x via %s resulted in %x", data, j, actual)
+	})
+}
@@ -0,0 +1,74 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"fmt"
+)
+
+// Checksum is a nullable checksum, e.g. of an entity's properties, as returned by checksum.Checksummer.
+// It is stored binarily in SQL context and represented as hex in JSON and text context, like Binary.
+type Checksum []byte
+
+// Valid returns whether the Checksum is valid, i.e. not NULL.
+func (c Checksum) Valid() bool {
+	return Binary(c).Valid()
+}
+
+// Equal returns whether c and other are the same checksum.
+func (c Checksum) Equal(other Checksum) bool {
+	return bytes.Equal(c, other)
+}
+
+// String returns the hex string representation form of the Checksum.
+func (c Checksum) String() string {
+	return Binary(c).String()
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (c Checksum) MarshalText() ([]byte, error) {
+	return Binary(c).MarshalText()
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (c *Checksum) UnmarshalText(text []byte) error {
+	return (*Binary)(c).UnmarshalText(text)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// Supports JSON null.
+func (c Checksum) MarshalJSON() ([]byte, error) {
+	return Binary(c).MarshalJSON()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Supports JSON null.
+func (c *Checksum) UnmarshalJSON(data []byte) error {
+	return (*Binary)(c).UnmarshalJSON(data)
+}
+
+// Scan implements the sql.Scanner interface.
+// Supports SQL NULL.
+func (c *Checksum) Scan(src interface{}) error {
+	return (*Binary)(c).Scan(src)
+}
+
+// Value implements the driver.Valuer interface.
+// Supports SQL NULL.
+func (c Checksum) Value() (driver.Value, error) {
+	return Binary(c).Value()
+}
+
+// Assert interface compliance.
+var (
+	_ fmt.Stringer             = Checksum{}
+	_ encoding.TextMarshaler   = Checksum{}
+	_ encoding.TextUnmarshaler = (*Checksum)(nil)
+	_ json.Marshaler           = Checksum{}
+	_ json.Unmarshaler         = (*Checksum)(nil)
+	_ sql.Scanner              = (*Checksum)(nil)
+	_ driver.Valuer            = Checksum{}
+)
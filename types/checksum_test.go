@@ -0,0 +1,57 @@
+package types
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestChecksum_Equal(t *testing.T) {
+	require.True(t, Checksum{1, 2}.Equal(Checksum{1, 2}))
+	require.False(t, Checksum{1, 2}.Equal(Checksum{1, 3}))
+	require.False(t, Checksum{1}.Equal(nil))
+}
+
+func TestChecksum_JSON(t *testing.T) {
+	subtests := []struct {
+		name  string
+		input Checksum
+		json  string
+	}{
+		{"nil", nil, `null`},
+		{"checksum", Checksum{0xca, 0xfe}, `"cafe"`},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			actual, err := json.Marshal(st.input)
+			require.NoError(t, err)
+			require.Equal(t, st.json, string(actual))
+
+			var c Checksum
+			require.NoError(t, json.Unmarshal(actual, &c))
+			require.Equal(t, st.input, c)
+		})
+	}
+}
+
+func TestChecksum_Scan(t *testing.T) {
+	var c Checksum
+	require.NoError(t, c.Scan(nil))
+	require.Nil(t, c)
+
+	require.NoError(t, c.Scan([]byte{0xca, 0xfe}))
+	require.Equal(t, Checksum{0xca, 0xfe}, c)
+
+	require.Error(t, c.Scan("cafe"))
+}
+
+func TestChecksum_Value(t *testing.T) {
+	v, err := Checksum(nil).Value()
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	v, err = Checksum{0xca, 0xfe}.Value()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xca, 0xfe}, v)
+}
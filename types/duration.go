@@ -0,0 +1,154 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Duration is a nullable time.Duration.
+// In text context, e.g. YAML and environment variables, and in JSON, it is represented like "1h30m".
+// In databases, it is stored as milliseconds, i.e. sub-millisecond precision is lost.
+type Duration struct {
+	time.Duration
+	Valid bool // Valid is true if Duration is not NULL.
+}
+
+// MakeDuration constructs a new non-NULL Duration from d.
+func MakeDuration(d time.Duration) Duration {
+	return Duration{Duration: d, Valid: true}
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// Supports JSON null.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	if !d.Valid {
+		return []byte("null"), nil
+	}
+
+	return MarshalJSON(d.Duration.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Unmarshals from strings like "1h30m" and, for compatibility, from numbers of milliseconds.
+// Supports JSON null.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) || len(data) == 0 {
+		*d = Duration{}
+
+		return nil
+	}
+
+	if data[0] != '"' {
+		return d.fromMillisecondString(data)
+	}
+
+	var s string
+	if err := UnmarshalJSON(data, &s); err != nil {
+		return err
+	}
+
+	return d.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Duration) MarshalText() ([]byte, error) {
+	if !d.Valid {
+		return []byte{}, nil
+	}
+
+	return []byte(d.Duration.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// Empty text results in NULL.
+func (d *Duration) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*d = Duration{}
+
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.Wrapf(err, "can't parse %q into time.Duration", text)
+	}
+
+	*d = MakeDuration(parsed)
+
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+// Scans from milliseconds, clamped to the range of time.Duration. Supports SQL NULL.
+func (d *Duration) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Duration{}
+	case []byte:
+		return d.fromMillisecondString(v)
+	// https://github.com/go-sql-driver/mysql/pull/1452
+	case uint64:
+		if v > math.MaxInt64 {
+			return errors.Errorf("value %v out of range for int64", v)
+		}
+
+		*d = MakeDuration(millisecondsToDuration(int64(v)))
+	case int64:
+		*d = MakeDuration(millisecondsToDuration(v))
+	default:
+		return errors.Errorf("bad (u)int64/[]byte type assertion from %[1]v (%[1]T)", src)
+	}
+
+	return nil
+}
+
+// Value implements the driver.Valuer interface.
+// Returns milliseconds. Supports SQL NULL.
+func (d Duration) Value() (driver.Value, error) {
+	if !d.Valid {
+		return nil, nil
+	}
+
+	return d.Milliseconds(), nil
+}
+
+func (d *Duration) fromMillisecondString(data []byte) error {
+	i, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return CantParseInt64(err, string(data))
+	}
+
+	*d = MakeDuration(millisecondsToDuration(i))
+
+	return nil
+}
+
+// millisecondsToDuration converts ms milliseconds to a time.Duration,
+// which is clamped to the range of time.Duration instead of overflowing.
+func millisecondsToDuration(ms int64) time.Duration {
+	switch {
+	case ms > math.MaxInt64/int64(time.Millisecond):
+		return math.MaxInt64
+	case ms < math.MinInt64/int64(time.Millisecond):
+		return math.MinInt64
+	default:
+		return time.Duration(ms) * time.Millisecond
+	}
+}
+
+// Assert interface compliance.
+var (
+	_ encoding.TextMarshaler   = Duration{}
+	_ encoding.TextUnmarshaler = (*Duration)(nil)
+	_ json.Marshaler           = Duration{}
+	_ json.Unmarshaler         = (*Duration)(nil)
+	_ driver.Valuer            = Duration{}
+	_ sql.Scanner              = (*Duration)(nil)
+)
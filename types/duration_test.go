@@ -0,0 +1,123 @@
+package types
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDuration_MarshalJSON(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  Duration
+		output string
+	}{
+		{"null", Duration{}, `null`},
+		{"zero", MakeDuration(0), `"0s"`},
+		{"hour-and-a-half", MakeDuration(90 * time.Minute), `"1h30m0s"`},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			actual, err := json.Marshal(st.input)
+			require.NoError(t, err)
+			require.Equal(t, st.output, string(actual))
+		})
+	}
+}
+
+func TestDuration_UnmarshalJSON(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  string
+		output Duration
+		error  bool
+	}{
+		{"null", `null`, Duration{}, false},
+		{"string", `"1h30m"`, MakeDuration(90 * time.Minute), false},
+		{"milliseconds", `1500`, MakeDuration(1500 * time.Millisecond), false},
+		{"invalid-string", `"1x"`, Duration{}, true},
+		{"float", `1.5`, Duration{}, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual Duration
+			if err := json.Unmarshal([]byte(st.input), &actual); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual)
+			}
+		})
+	}
+}
+
+func TestDuration_UnmarshalText(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  string
+		output Duration
+		error  bool
+	}{
+		{"empty", "", Duration{}, false},
+		{"seconds", "30s", MakeDuration(30 * time.Second), false},
+		{"minutes", "5m", MakeDuration(5 * time.Minute), false},
+		{"unitless", "30", Duration{}, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual Duration
+			if err := actual.UnmarshalText([]byte(st.input)); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual)
+			}
+		})
+	}
+}
+
+func TestDuration_Scan(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  any
+		output Duration
+		error  bool
+	}{
+		{"nil", nil, Duration{}, false},
+		{"int64", int64(1500), MakeDuration(1500 * time.Millisecond), false},
+		{"uint64", uint64(60000), MakeDuration(time.Minute), false},
+		{"bytes", []byte("250"), MakeDuration(250 * time.Millisecond), false},
+		{"string", "250", Duration{}, true},
+		{"int64-overflow", int64(math.MaxInt64), MakeDuration(math.MaxInt64), false},
+		{"int64-underflow", int64(math.MinInt64), MakeDuration(math.MinInt64), false},
+		{"uint64-overflow", uint64(math.MaxInt64 / 1000), MakeDuration(math.MaxInt64), false},
+		{"bytes-overflow", []byte("9223372036854775807"), MakeDuration(math.MaxInt64), false},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual Duration
+			if err := actual.Scan(st.input); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual)
+			}
+		})
+	}
+}
+
+func TestDuration_Value(t *testing.T) {
+	v, err := Duration{}.Value()
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	v, err = MakeDuration(time.Minute + time.Microsecond).Value()
+	require.NoError(t, err)
+	require.Equal(t, int64(60000), v)
+}
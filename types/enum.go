@@ -0,0 +1,186 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"slices"
+	"strings"
+)
+
+// EnumValues is the constraint of the string types used with Enum.
+// EnumValues must return all allowed values of the type, e.g.:
+//
+//	type Severity string
+//
+//	const (
+//		SeverityOK   Severity = "ok"
+//		SeverityCrit Severity = "crit"
+//	)
+//
+//	func (Severity) EnumValues() []Severity {
+//		return []Severity{SeverityOK, SeverityCrit}
+//	}
+type EnumValues[T any] interface {
+	~string
+	EnumValues() []T
+}
+
+// EnumDefaulter may be implemented by a type used with Enum
+// to provide the value returned by Enum.Get for NULL.
+type EnumDefaulter[T any] interface {
+	EnumDefault() T
+}
+
+// Enum represents a string type for ENUM columns, which can be NULL.
+// It only accepts the values listed by the EnumValues method of T when it is unmarshalled or scanned.
+type Enum[T EnumValues[T]] struct {
+	Enum  T
+	Valid bool // Valid is true if Enum is not NULL
+}
+
+// ParseEnum constructs a new non-NULL Enum from s.
+// Returns an error if s is not one of the values listed by T.
+func ParseEnum[T EnumValues[T]](s string) (Enum[T], error) {
+	var e Enum[T]
+	if err := e.set(s); err != nil {
+		return Enum[T]{}, err
+	}
+
+	return e, nil
+}
+
+// Get returns the value of e or, if e is NULL, the default of T if it implements EnumDefaulter or its zero value.
+func (e Enum[T]) Get() T {
+	if e.Valid {
+		return e.Enum
+	}
+
+	if d, ok := any(e.Enum).(EnumDefaulter[T]); ok {
+		return d.EnumDefault()
+	}
+
+	var zero T
+
+	return zero
+}
+
+// String implements the fmt.Stringer interface.
+// Returns an empty string for NULL.
+func (e Enum[T]) String() string {
+	if !e.Valid {
+		return ""
+	}
+
+	return string(e.Enum)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// Supports JSON null.
+func (e Enum[T]) MarshalJSON() ([]byte, error) {
+	var v interface{}
+	if e.Valid {
+		v = string(e.Enum)
+	}
+
+	return MarshalJSON(v)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Supports JSON null.
+func (e *Enum[T]) UnmarshalJSON(data []byte) error {
+	// Ignore null, like in the main JSON package.
+	if bytes.HasPrefix(data, []byte{'n'}) {
+		return nil
+	}
+
+	var s string
+	if err := UnmarshalJSON(data, &s); err != nil {
+		return err
+	}
+
+	return e.set(s)
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+// NULL is marshalled as an empty string.
+func (e Enum[T]) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+// An empty string is unmarshalled as NULL, so that the result of MarshalText round-trips.
+func (e *Enum[T]) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*e = Enum[T]{}
+
+		return nil
+	}
+
+	return e.set(string(text))
+}
+
+// Scan implements the sql.Scanner interface.
+// Supports SQL NULL, string and []byte.
+func (e *Enum[T]) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*e = Enum[T]{}
+
+		return nil
+	case []byte:
+		return e.set(string(v))
+	case string:
+		return e.set(v)
+	default:
+		return errors.Errorf("bad %s type assertion from %#v", Name(e.Enum), src)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+// Supports SQL NULL.
+func (e Enum[T]) Value() (driver.Value, error) {
+	if !e.Valid {
+		return nil, nil
+	}
+
+	return string(e.Enum), nil
+}
+
+// set sets e to the non-NULL value s. Returns an error if s is not one of the values listed by T.
+func (e *Enum[T]) set(s string) error {
+	values := e.Enum.EnumValues()
+	if !slices.Contains(values, T(s)) {
+		allowed := make([]string, 0, len(values))
+		for _, v := range values {
+			allowed = append(allowed, `"`+string(v)+`"`)
+		}
+
+		return errors.Errorf("bad %s %q, must be one of %s", Name(e.Enum), s, strings.Join(allowed, ", "))
+	}
+
+	*e = Enum[T]{Enum: T(s), Valid: true}
+
+	return nil
+}
+
+// Assert interface compliance.
+var (
+	_ json.Marshaler           = Enum[enumAssertion]{}
+	_ encoding.TextMarshaler   = Enum[enumAssertion]{}
+	_ encoding.TextUnmarshaler = (*Enum[enumAssertion])(nil)
+	_ json.Unmarshaler         = (*Enum[enumAssertion])(nil)
+	_ sql.Scanner              = (*Enum[enumAssertion])(nil)
+	_ driver.Valuer            = Enum[enumAssertion]{}
+)
+
+// enumAssertion is only used to assert the interface compliance of Enum.
+type enumAssertion string
+
+// EnumValues implements the EnumValues interface.
+func (enumAssertion) EnumValues() []enumAssertion {
+	return nil
+}
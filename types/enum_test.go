@@ -0,0 +1,139 @@
+package types
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type testSeverity string
+
+func (testSeverity) EnumValues() []testSeverity {
+	return []testSeverity{"ok", "crit"}
+}
+
+type testState string
+
+func (testState) EnumValues() []testState {
+	return []testState{"pending", "done"}
+}
+
+func (testState) EnumDefault() testState {
+	return "pending"
+}
+
+func TestParseEnum(t *testing.T) {
+	e, err := ParseEnum[testSeverity]("crit")
+	require.NoError(t, err)
+	require.Equal(t, Enum[testSeverity]{Enum: "crit", Valid: true}, e)
+
+	_, err = ParseEnum[testSeverity]("warn")
+	require.EqualError(t, err, `bad testSeverity "warn", must be one of "ok", "crit"`)
+}
+
+func TestEnum_Get(t *testing.T) {
+	require.Equal(t, testSeverity("crit"), Enum[testSeverity]{Enum: "crit", Valid: true}.Get())
+	require.Equal(t, testSeverity(""), Enum[testSeverity]{}.Get())
+	require.Equal(t, testState("done"), Enum[testState]{Enum: "done", Valid: true}.Get())
+	require.Equal(t, testState("pending"), Enum[testState]{}.Get())
+}
+
+func TestEnum_MarshalJSON(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  Enum[testSeverity]
+		output string
+	}{
+		{"null", Enum[testSeverity]{}, `null`},
+		{"ok", Enum[testSeverity]{Enum: "ok", Valid: true}, `"ok"`},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			actual, err := st.input.MarshalJSON()
+			require.NoError(t, err)
+			require.Equal(t, st.output, string(actual))
+		})
+	}
+}
+
+func TestEnum_UnmarshalJSON(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  string
+		output Enum[testSeverity]
+		error  bool
+	}{
+		{"null", `null`, Enum[testSeverity]{}, false},
+		{"ok", `"ok"`, Enum[testSeverity]{Enum: "ok", Valid: true}, false},
+		{"unknown", `"warn"`, Enum[testSeverity]{}, true},
+		{"number", `0`, Enum[testSeverity]{}, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual Enum[testSeverity]
+			if err := actual.UnmarshalJSON([]byte(st.input)); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual)
+			}
+		})
+	}
+}
+
+func TestEnum_Text(t *testing.T) {
+	var e Enum[testSeverity]
+	require.NoError(t, e.UnmarshalText([]byte("crit")))
+	require.Equal(t, Enum[testSeverity]{Enum: "crit", Valid: true}, e)
+
+	text, err := e.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "crit", string(text))
+
+	require.Error(t, e.UnmarshalText([]byte("critical")))
+
+	require.NoError(t, e.UnmarshalText([]byte("")))
+	require.Equal(t, Enum[testSeverity]{}, e, "empty string must be unmarshalled as NULL")
+
+	text, err = e.MarshalText()
+	require.NoError(t, err)
+	require.Empty(t, text, "NULL must be marshalled as empty string")
+}
+
+func TestEnum_Scan(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  any
+		output Enum[testSeverity]
+		error  bool
+	}{
+		{"nil", nil, Enum[testSeverity]{}, false},
+		{"bytes", []byte("ok"), Enum[testSeverity]{Enum: "ok", Valid: true}, false},
+		{"string", "crit", Enum[testSeverity]{Enum: "crit", Valid: true}, false},
+		{"unknown", "warn", Enum[testSeverity]{}, true},
+		{"int", 1, Enum[testSeverity]{}, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			actual := Enum[testSeverity]{Enum: "ok", Valid: true}
+			if err := actual.Scan(st.input); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual)
+			}
+		})
+	}
+}
+
+func TestEnum_Value(t *testing.T) {
+	v, err := Enum[testSeverity]{}.Value()
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	v, err = Enum[testSeverity]{Enum: "ok", Valid: true}.Value()
+	require.NoError(t, err)
+	require.Equal(t, "ok", v)
+}
@@ -6,6 +6,7 @@ import (
 	"database/sql/driver"
 	"encoding"
 	"encoding/json"
+	"math"
 	"strconv"
 )
 
@@ -14,6 +15,36 @@ type Float struct {
 	sql.NullFloat64
 }
 
+// MakeFloat constructs a new non-NULL Float from f and applies the given transformers in order,
+// e.g. TransformZeroFloatToNull.
+func MakeFloat(f float64, transformers ...func(*Float)) Float {
+	v := Float{sql.NullFloat64{
+		Float64: f,
+		Valid:   true,
+	}}
+
+	for _, transform := range transformers {
+		transform(&v)
+	}
+
+	return v
+}
+
+// TransformZeroFloatToNull transforms a valid Float carrying a zero value to a SQL NULL.
+// When combined with ClampFloatNonNegative, it should be applied last to also transform clamped values.
+func TransformZeroFloatToNull(f *Float) {
+	if f.Valid && f.Float64 == 0 {
+		f.Valid = false
+	}
+}
+
+// ClampFloatNonNegative transforms a valid Float carrying a negative value, including negative zero, to zero.
+func ClampFloatNonNegative(f *Float) {
+	if f.Valid && (f.Float64 < 0 || math.Signbit(f.Float64)) {
+		f.Float64 = 0
+	}
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 // Supports JSON null.
 func (f Float) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,59 @@
+package types
+
+import (
+	"database/sql"
+	"github.com/stretchr/testify/require"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestMakeFloat(t *testing.T) {
+	subtests := []struct {
+		name         string
+		input        float64
+		transformers []func(*Float)
+		output       sql.NullFloat64
+	}{
+		{"zero", 0, nil, sql.NullFloat64{Float64: 0, Valid: true}},
+		{"zero-to-null", 0, []func(*Float){TransformZeroFloatToNull}, sql.NullFloat64{}},
+		{"non-zero-to-null", 0.5, []func(*Float){TransformZeroFloatToNull}, sql.NullFloat64{Float64: 0.5, Valid: true}},
+		{"clamp", -0.5, []func(*Float){ClampFloatNonNegative}, sql.NullFloat64{Float64: 0, Valid: true}},
+		{"clamp-positive", 0.5, []func(*Float){ClampFloatNonNegative}, sql.NullFloat64{Float64: 0.5, Valid: true}},
+		{"clamp-to-null", -0.5, []func(*Float){ClampFloatNonNegative, TransformZeroFloatToNull}, sql.NullFloat64{}},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			require.Equal(t, Float{st.output}, MakeFloat(st.input, st.transformers...))
+		})
+	}
+
+	t.Run("clamp-negative-zero", func(t *testing.T) {
+		require.False(t, math.Signbit(MakeFloat(math.Copysign(0, -1), ClampFloatNonNegative).Float64))
+	})
+}
+
+func TestFloat_RoundTrip(t *testing.T) {
+	for _, f := range []Float{{}, MakeFloat(0), MakeFloat(-42.5), MakeFloat(1e-300)} {
+		j, err := f.MarshalJSON()
+		require.NoError(t, err)
+
+		var fromJSON Float
+		require.NoError(t, fromJSON.UnmarshalJSON(j))
+		require.Equal(t, f, fromJSON)
+
+		v, err := f.Value()
+		require.NoError(t, err)
+
+		var fromSQL Float
+		require.NoError(t, fromSQL.Scan(v))
+		require.Equal(t, f, fromSQL)
+
+		if f.Valid {
+			var fromText Float
+			require.NoError(t, fromText.UnmarshalText([]byte(strconv.FormatFloat(f.Float64, 'g', -1, 64))))
+			require.Equal(t, f, fromText)
+		}
+	}
+}
@@ -14,6 +14,36 @@ type Int struct {
 	sql.NullInt64
 }
 
+// MakeInt constructs a new non-NULL Int from i and applies the given transformers in order,
+// e.g. TransformZeroIntToNull.
+func MakeInt(i int64, transformers ...func(*Int)) Int {
+	v := Int{sql.NullInt64{
+		Int64: i,
+		Valid: true,
+	}}
+
+	for _, transform := range transformers {
+		transform(&v)
+	}
+
+	return v
+}
+
+// TransformZeroIntToNull transforms a valid Int carrying a zero value to a SQL NULL.
+// When combined with ClampIntNonNegative, it should be applied last to also transform clamped values.
+func TransformZeroIntToNull(i *Int) {
+	if i.Valid && i.Int64 == 0 {
+		i.Valid = false
+	}
+}
+
+// ClampIntNonNegative transforms a valid Int carrying a negative value to zero.
+func ClampIntNonNegative(i *Int) {
+	if i.Valid && i.Int64 < 0 {
+		i.Int64 = 0
+	}
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 // Supports JSON null.
 func (i Int) MarshalJSON() ([]byte, error) {
@@ -6,6 +6,46 @@ import (
 	"testing"
 )
 
+func TestMakeInt(t *testing.T) {
+	subtests := []struct {
+		name         string
+		input        int64
+		transformers []func(*Int)
+		output       sql.NullInt64
+	}{
+		{"zero", 0, nil, sql.NullInt64{Int64: 0, Valid: true}},
+		{"zero-to-null", 0, []func(*Int){TransformZeroIntToNull}, sql.NullInt64{}},
+		{"non-zero-to-null", 42, []func(*Int){TransformZeroIntToNull}, sql.NullInt64{Int64: 42, Valid: true}},
+		{"clamp", -1, []func(*Int){ClampIntNonNegative}, sql.NullInt64{Int64: 0, Valid: true}},
+		{"clamp-positive", 1, []func(*Int){ClampIntNonNegative}, sql.NullInt64{Int64: 1, Valid: true}},
+		{"clamp-to-null", -1, []func(*Int){ClampIntNonNegative, TransformZeroIntToNull}, sql.NullInt64{}},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			require.Equal(t, Int{st.output}, MakeInt(st.input, st.transformers...))
+		})
+	}
+}
+
+func TestInt_RoundTrip(t *testing.T) {
+	for _, i := range []Int{{}, MakeInt(0), MakeInt(-42), MakeInt(1 << 62)} {
+		j, err := i.MarshalJSON()
+		require.NoError(t, err)
+
+		var fromJSON Int
+		require.NoError(t, fromJSON.UnmarshalJSON(j))
+		require.Equal(t, i, fromJSON)
+
+		v, err := i.Value()
+		require.NoError(t, err)
+
+		var fromSQL Int
+		require.NoError(t, fromSQL.Scan(v))
+		require.Equal(t, i, fromSQL)
+	}
+}
+
 func TestInt_MarshalJSON(t *testing.T) {
 	subtests := []struct {
 		name   string
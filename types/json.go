@@ -0,0 +1,128 @@
+package types
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"github.com/pkg/errors"
+)
+
+// JSON is a nullable, raw JSON document, e.g. serialized custom variables.
+// It is validated when unmarshalled or scanned and is marshalled to JSON as is, not as a string.
+//
+// In databases, JSON is passed as text, so that it can be stored in JSONB columns on PostgreSQL
+// and JSON columns on MySQL alike.
+// Note that lib/pq would send []byte as binary parameter, which PostgreSQL does not accept for JSONB.
+type JSON []byte
+
+// MakeJSON returns the JSON encoding of v as JSON.
+// If v is encoded as JSON null, e.g. nil or a nil map, it returns a NULL JSON.
+func MakeJSON(v any) (JSON, error) {
+	b, err := MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(b, []byte("null")) {
+		return nil, nil
+	}
+
+	return b, nil
+}
+
+// Valid returns whether the JSON is valid, i.e. not NULL.
+func (j JSON) Valid() bool {
+	return len(j) > 0
+}
+
+// String returns the JSON document as string.
+func (j JSON) String() string {
+	return string(j)
+}
+
+// Unmarshal parses the JSON document into v.
+func (j JSON) Unmarshal(v any) error {
+	return UnmarshalJSON(j, v)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// Supports JSON null.
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if !j.Valid() {
+		return []byte("null"), nil
+	}
+
+	return j, nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Supports JSON null.
+func (j *JSON) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) || len(data) == 0 {
+		*j = nil
+
+		return nil
+	}
+
+	return j.set(data)
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (j *JSON) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*j = nil
+
+		return nil
+	}
+
+	return j.set(text)
+}
+
+// Scan implements the sql.Scanner interface.
+// Supports SQL NULL.
+func (j *JSON) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*j = nil
+
+		return nil
+	case []byte:
+		return j.set(src)
+	case string:
+		return j.set([]byte(src))
+	default:
+		return errors.Errorf("unable to scan type %T into JSON", src)
+	}
+}
+
+// Value implements the driver.Valuer interface.
+// Supports SQL NULL.
+func (j JSON) Value() (driver.Value, error) {
+	if !j.Valid() {
+		return nil, nil
+	}
+
+	return string(j), nil
+}
+
+// set validates data and stores a copy of it in j.
+func (j *JSON) set(data []byte) error {
+	if !json.Valid(data) {
+		return errors.Errorf("invalid JSON %q", data)
+	}
+
+	*j = bytes.Clone(data)
+
+	return nil
+}
+
+// Assert interface compliance.
+var (
+	_ json.Marshaler           = JSON{}
+	_ json.Unmarshaler         = (*JSON)(nil)
+	_ encoding.TextUnmarshaler = (*JSON)(nil)
+	_ sql.Scanner              = (*JSON)(nil)
+	_ driver.Valuer            = JSON{}
+)
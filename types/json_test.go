@@ -0,0 +1,106 @@
+package types
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestJSON_MarshalJSON(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  any
+		output string
+	}{
+		{"nil", struct{ V JSON }{}, `{"V":null}`},
+		{"object", struct{ V JSON }{JSON(`{"a":1}`)}, `{"V":{"a":1}}`},
+		{"array", struct{ V JSON }{JSON(`[1,"2"]`)}, `{"V":[1,"2"]}`},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			actual, err := json.Marshal(st.input)
+			require.NoError(t, err)
+			require.Equal(t, st.output, string(actual))
+		})
+	}
+}
+
+func TestJSON_UnmarshalJSON(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  string
+		output JSON
+	}{
+		{"null", `{"V":null}`, nil},
+		{"object", `{"V":{"a":1}}`, JSON(`{"a":1}`)},
+		{"string", `{"V":"a"}`, JSON(`"a"`)},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual struct{ V JSON }
+			require.NoError(t, json.Unmarshal([]byte(st.input), &actual))
+			require.Equal(t, st.output, actual.V)
+		})
+	}
+}
+
+func TestJSON_Scan(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  any
+		output JSON
+		error  bool
+	}{
+		{"nil", nil, nil, false},
+		{"bytes", []byte(`{"a":1}`), JSON(`{"a":1}`), false},
+		{"string", `[1,2]`, JSON(`[1,2]`), false},
+		{"invalid", []byte(`{`), nil, true},
+		{"number", 10, nil, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual JSON
+			if err := actual.Scan(st.input); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual)
+			}
+		})
+	}
+}
+
+func TestJSON_Value(t *testing.T) {
+	v, err := JSON(nil).Value()
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	v, err = JSON(`{"a":1}`).Value()
+	require.NoError(t, err)
+	require.Equal(t, `{"a":1}`, v)
+}
+
+func TestMakeJSON(t *testing.T) {
+	j, err := MakeJSON(map[string]int{"a": 1})
+	require.NoError(t, err)
+	require.Equal(t, JSON(`{"a":1}`), j)
+
+	var v map[string]int
+	require.NoError(t, j.Unmarshal(&v))
+	require.Equal(t, map[string]int{"a": 1}, v)
+
+	t.Run("null", func(t *testing.T) {
+		for _, v := range []any{nil, (map[string]int)(nil), (*int)(nil)} {
+			j, err := MakeJSON(v)
+			require.NoError(t, err)
+			require.False(t, j.Valid(), "%#v must not be valid", v)
+
+			value, err := j.Value()
+			require.NoError(t, err)
+			require.Nil(t, value, "%#v must be SQL NULL", v)
+		}
+	})
+}
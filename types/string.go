@@ -6,6 +6,7 @@ import (
 	"database/sql/driver"
 	"encoding"
 	"encoding/json"
+	"github.com/icinga/icinga-go-library/utils"
 	"strings"
 )
 
@@ -14,12 +15,37 @@ type String struct {
 	sql.NullString
 }
 
-// MakeString constructs a new non-NULL String from s.
-func MakeString(s string) String {
-	return String{sql.NullString{
+// MakeString constructs a new non-NULL String from s and applies the given transformers in order,
+// e.g. TransformEmptyStringToNull.
+func MakeString(s string, transformers ...func(*String)) String {
+	v := String{sql.NullString{
 		String: s,
 		Valid:  true,
 	}}
+
+	for _, transform := range transformers {
+		transform(&v)
+	}
+
+	return v
+}
+
+// TransformEmptyStringToNull transforms a valid String carrying an empty value to a SQL NULL.
+// When combined with TruncateStringTo, it should be applied last.
+func TransformEmptyStringToNull(s *String) {
+	if s.Valid && s.String == "" {
+		s.Valid = false
+	}
+}
+
+// TruncateStringTo returns a transformer that shortens a valid String to at most n runes,
+// e.g. to fit into a varchar column, and indicates shortening by "..." using utils.Ellipsize.
+func TruncateStringTo(n int) func(*String) {
+	return func(s *String) {
+		if s.Valid {
+			s.String = utils.Ellipsize(s.String, n)
+		}
+	}
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -60,6 +86,24 @@ func (s *String) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Scan implements the sql.Scanner interface.
+// Supports SQL NULL, string and []byte, which is copied as the driver may reuse its memory,
+// and falls back to sql.NullString for all other types.
+func (s *String) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		s.String, s.Valid = "", false
+	case []byte:
+		s.String, s.Valid = string(v), true
+	case string:
+		s.String, s.Valid = v, true
+	default:
+		return s.NullString.Scan(src)
+	}
+
+	return nil
+}
+
 // Value implements the driver.Valuer interface.
 // Supports SQL NULL.
 func (s String) Value() (driver.Value, error) {
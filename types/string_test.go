@@ -25,6 +25,61 @@ func TestMakeString(t *testing.T) {
 	}
 }
 
+func TestMakeString_Transformers(t *testing.T) {
+	subtests := []struct {
+		name         string
+		input        string
+		transformers []func(*String)
+		output       sql.NullString
+	}{
+		{"empty", "", nil, sql.NullString{String: "", Valid: true}},
+		{"empty-to-null", "", []func(*String){TransformEmptyStringToNull}, sql.NullString{}},
+		{"non-empty-to-null", "abc", []func(*String){TransformEmptyStringToNull}, sql.NullString{String: "abc", Valid: true}},
+		{"truncate", "abcdef", []func(*String){TruncateStringTo(5)}, sql.NullString{String: "ab...", Valid: true}},
+		{"truncate-short", "abc", []func(*String){TruncateStringTo(5)}, sql.NullString{String: "abc", Valid: true}},
+		{"truncate-utf8", "äöüäöü", []func(*String){TruncateStringTo(5)}, sql.NullString{String: "äö...", Valid: true}},
+		{"truncate-to-null", "", []func(*String){TruncateStringTo(5), TransformEmptyStringToNull}, sql.NullString{}},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			require.Equal(t, String{st.output}, MakeString(st.input, st.transformers...))
+		})
+	}
+}
+
+func TestString_Scan(t *testing.T) {
+	subtests := []struct {
+		name   string
+		input  any
+		output sql.NullString
+	}{
+		{"nil", nil, sql.NullString{}},
+		{"string", "abc", sql.NullString{String: "abc", Valid: true}},
+		{"bytes", []byte("abc"), sql.NullString{String: "abc", Valid: true}},
+		{"empty-bytes", []byte{}, sql.NullString{String: "", Valid: true}},
+		{"int64", int64(42), sql.NullString{String: "42", Valid: true}},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			actual := MakeString("previous")
+			require.NoError(t, actual.Scan(st.input))
+			require.Equal(t, String{st.output}, actual)
+		})
+	}
+
+	t.Run("copy", func(t *testing.T) {
+		src := []byte("abc")
+
+		var actual String
+		require.NoError(t, actual.Scan(src))
+
+		src[0] = 'x'
+		require.Equal(t, "abc", actual.String)
+	})
+}
+
 func TestString_MarshalJSON(t *testing.T) {
 	subtests := []struct {
 		name   string
@@ -9,17 +9,78 @@ import (
 	"github.com/pkg/errors"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // UnixMilli is a nullable millisecond UNIX timestamp in databases and JSON.
 type UnixMilli time.Time
 
+// ParseUnixSeconds parses a UNIX timestamp in seconds with an optional fractional part, rounded to milliseconds,
+// as Icinga 2 writes timestamps, e.g. "1234567890.062".
+func ParseUnixSeconds(s string) (UnixMilli, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return UnixMilli{}, CantParseFloat64(err, s)
+	}
+
+	ms := math.Round(f * 1000)
+	if math.IsNaN(ms) || ms < math.MinInt64 || ms >= math.MaxInt64 {
+		return UnixMilli{}, errors.Errorf("value %q out of range", s)
+	}
+
+	return UnixMilli(time.UnixMilli(int64(ms))), nil
+}
+
 // Time returns the time.Time conversion of UnixMilli.
 func (t UnixMilli) Time() time.Time {
 	return time.Time(t)
 }
 
+// Add returns t+d. Any monotonic clock reading of t is dropped, see Sub.
+func (t UnixMilli) Add(d time.Duration) UnixMilli {
+	return UnixMilli(t.wall().Add(d))
+}
+
+// Sub returns the duration t-u. Unlike time.Time.Sub, the monotonic clock readings of t and u are ignored,
+// so that the result is consistent with the wall clock times, which are the only ones that are (un)marshalled.
+func (t UnixMilli) Sub(u UnixMilli) time.Duration {
+	return t.wall().Sub(u.wall())
+}
+
+// Before reports whether t is before u, ignoring monotonic clock readings.
+func (t UnixMilli) Before(u UnixMilli) bool {
+	return t.wall().Before(u.wall())
+}
+
+// After reports whether t is after u, ignoring monotonic clock readings.
+func (t UnixMilli) After(u UnixMilli) bool {
+	return t.wall().After(u.wall())
+}
+
+// Equal reports whether t and u represent the same instant, ignoring monotonic clock readings.
+func (t UnixMilli) Equal(u UnixMilli) bool {
+	return t.wall().Equal(u.wall())
+}
+
+// SkewTolerantBefore reports whether t is before u by more than the tolerance d,
+// e.g. whether a timestamp received from another node has expired despite clock skew.
+func (t UnixMilli) SkewTolerantBefore(u UnixMilli, d time.Duration) bool {
+	return t.Sub(u) < -d
+}
+
+// SkewTolerantAfter reports whether t is after u by more than the tolerance d,
+// e.g. whether a timestamp received from another node lies in the future despite clock skew.
+func (t UnixMilli) SkewTolerantAfter(u UnixMilli, d time.Duration) bool {
+	return t.Sub(u) > d
+}
+
+// Truncate returns t rounded down to a multiple of d since the zero time, see time.Time.Truncate.
+// Truncate(time.Millisecond) yields the precision of the marshalled representations.
+func (t UnixMilli) Truncate(d time.Duration) UnixMilli {
+	return UnixMilli(t.Time().Truncate(d))
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 // Marshals to milliseconds. Supports JSON null.
 func (t UnixMilli) MarshalJSON() ([]byte, error) {
@@ -59,7 +120,9 @@ func (t *UnixMilli) UnmarshalText(text []byte) error {
 }
 
 // Scan implements the sql.Scanner interface.
-// Scans from milliseconds. Supports SQL NULL.
+// Scans from milliseconds, regardless of whether the driver returns them as integer, []byte or string.
+// Strings and []byte with a fractional part are scanned from seconds as written by Icinga 2, see ParseUnixSeconds.
+// Supports SQL NULL.
 func (t *UnixMilli) Scan(src interface{}) error {
 	if src == nil {
 		return nil
@@ -67,7 +130,7 @@ func (t *UnixMilli) Scan(src interface{}) error {
 
 	switch v := src.(type) {
 	case []byte:
-		return t.fromByteString(v)
+		return t.scanString(string(v))
 	// https://github.com/go-sql-driver/mysql/pull/1452
 	case uint64:
 		if v > math.MaxInt64 {
@@ -77,8 +140,10 @@ func (t *UnixMilli) Scan(src interface{}) error {
 		*t = UnixMilli(time.UnixMilli(int64(v)))
 	case int64:
 		*t = UnixMilli(time.UnixMilli(v))
+	case string:
+		return t.scanString(v)
 	default:
-		return errors.Errorf("bad (u)int64/[]byte type assertion from %[1]v (%[1]T)", src)
+		return errors.Errorf("bad (u)int64/[]byte/string type assertion from %[1]v (%[1]T)", src)
 	}
 
 	return nil
@@ -105,6 +170,27 @@ func (t *UnixMilli) fromByteString(data []byte) error {
 	return nil
 }
 
+// scanString parses s as milliseconds or, if it has a fractional part, as seconds using ParseUnixSeconds.
+func (t *UnixMilli) scanString(s string) error {
+	if !strings.Contains(s, ".") {
+		return t.fromByteString([]byte(s))
+	}
+
+	seconds, err := ParseUnixSeconds(s)
+	if err != nil {
+		return err
+	}
+
+	*t = seconds
+
+	return nil
+}
+
+// wall returns t without its monotonic clock reading.
+func (t UnixMilli) wall() time.Time {
+	return t.Time().Round(0)
+}
+
 // Assert interface compliance.
 var (
 	_ encoding.TextMarshaler   = UnixMilli{}
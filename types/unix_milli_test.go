@@ -111,10 +111,35 @@ func TestUnixMilli_Scan(t *testing.T) {
 			expectErr: true,
 		},
 		{
-			name:      "Invalid type",
+			name:     "string",
+			v:        "1234567890062",
+			expected: UnixMilli(time.Unix(1234567890, 62000000)),
+		},
+		{
+			name:      "Invalid string",
 			v:         "invalid",
 			expectErr: true,
 		},
+		{
+			name:     "Seconds string",
+			v:        "1234567890.062",
+			expected: UnixMilli(time.UnixMilli(1234567890062)),
+		},
+		{
+			name:     "Seconds bytes",
+			v:        []byte("1234567890.0625"),
+			expected: UnixMilli(time.UnixMilli(1234567890063)),
+		},
+		{
+			name:      "Invalid seconds string",
+			v:         "1234567890.invalid",
+			expectErr: true,
+		},
+		{
+			name:      "Invalid type",
+			v:         1.5,
+			expectErr: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -147,3 +172,79 @@ func TestUnixMilli_Value(t *testing.T) {
 		assert.Equal(t, expected, actual)
 	})
 }
+
+func TestParseUnixSeconds(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		expected  UnixMilli
+		expectErr bool
+	}{
+		{name: "Fraction", s: "1234567890.062", expected: UnixMilli(time.Unix(1234567890, 62000000))},
+		{name: "Without fraction", s: "1234567890", expected: UnixMilli(time.Unix(1234567890, 0))},
+		{name: "Rounded to milliseconds", s: "1234567890.0619", expected: UnixMilli(time.Unix(1234567890, 62000000))},
+		{name: "Invalid", s: "invalid", expectErr: true},
+		{name: "NaN", s: "NaN", expectErr: true},
+		{name: "Out of range", s: "1e300", expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := ParseUnixSeconds(test.s)
+			if test.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnixMilli_Sub(t *testing.T) {
+	now := time.Now()
+	require.NotEqual(t, now, now.Round(0), "time.Now() should have a monotonic clock reading")
+
+	later := now.Add(time.Second)
+
+	require.Equal(t, time.Second, UnixMilli(later).Sub(UnixMilli(now)))
+	require.Equal(t, time.Second, UnixMilli(later).Sub(UnixMilli(now.Round(0))))
+	require.True(t, UnixMilli(now).Before(UnixMilli(later)))
+	require.True(t, UnixMilli(later).After(UnixMilli(now)))
+	require.True(t, UnixMilli(now).Equal(UnixMilli(now.Round(0))))
+
+	// The monotonic clock reading must not survive Add, as it isn't (un)marshalled.
+	require.Equal(t, UnixMilli(now.Round(0).Add(time.Minute)), UnixMilli(now).Add(time.Minute))
+}
+
+func TestUnixMilli_SkewTolerant(t *testing.T) {
+	now := UnixMilli(time.Unix(1234567890, 0))
+
+	tests := []struct {
+		name          string
+		t             UnixMilli
+		before, after bool
+	}{
+		{"Equal", now, false, false},
+		{"Slightly before", now.Add(-time.Second), false, false},
+		{"Slightly after", now.Add(time.Second), false, false},
+		{"Exactly tolerance before", now.Add(-time.Minute), false, false},
+		{"Exactly tolerance after", now.Add(time.Minute), false, false},
+		{"Far before", now.Add(-time.Minute - time.Millisecond), true, false},
+		{"Far after", now.Add(time.Minute + time.Millisecond), false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.before, test.t.SkewTolerantBefore(now, time.Minute))
+			require.Equal(t, test.after, test.t.SkewTolerantAfter(now, time.Minute))
+		})
+	}
+}
+
+func TestUnixMilli_Truncate(t *testing.T) {
+	v := UnixMilli(time.Unix(1234567890, 62999999))
+
+	require.Equal(t, UnixMilli(time.Unix(1234567890, 62000000)), v.Truncate(time.Millisecond))
+	require.Equal(t, UnixMilli(time.Unix(1234567890, 0)), v.Truncate(time.Second))
+}
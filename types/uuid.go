@@ -1,16 +1,30 @@
 package types
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding"
 	"github.com/google/uuid"
+	"github.com/pkg/errors"
 )
 
 // UUID is like uuid.UUID, but marshals itself binarily (not like xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx) in SQL context.
+// In JSON and text context, e.g. structify, it is represented in its canonical string form.
 type UUID struct {
 	uuid.UUID
 }
 
+// Scan implements sql.Scanner.
+// Scans from 16-byte binary as stored by Value, but also accepts the canonical string form.
+// SQL NULL results in the zero UUID.
+func (uuid *UUID) Scan(src interface{}) error {
+	if err := uuid.UUID.Scan(src); err != nil {
+		return errors.Wrapf(err, "can't scan %#v into UUID", src)
+	}
+
+	return nil
+}
+
 // Value implements driver.Valuer.
 func (uuid UUID) Value() (driver.Value, error) {
 	return uuid.UUID[:], nil
@@ -18,6 +32,8 @@ func (uuid UUID) Value() (driver.Value, error) {
 
 // Assert interface compliance.
 var (
+	_ encoding.TextMarshaler   = UUID{}
 	_ encoding.TextUnmarshaler = (*UUID)(nil)
+	_ sql.Scanner              = (*UUID)(nil)
 	_ driver.Valuer            = UUID{}
 )
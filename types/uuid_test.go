@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -27,3 +28,44 @@ func TestUUID_Value(t *testing.T) {
 		})
 	}
 }
+
+func TestUUID_Scan(t *testing.T) {
+	nonzero := uuid.New()
+
+	subtests := []struct {
+		name   string
+		input  any
+		output uuid.UUID
+		error  bool
+	}{
+		{"nil", nil, uuid.UUID{}, false},
+		{"binary", nonzero[:], nonzero, false},
+		{"string", nonzero.String(), nonzero, false},
+		{"short", []byte{1, 2, 3}, uuid.UUID{}, true},
+		{"number", 42, uuid.UUID{}, true},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			var actual UUID
+			if err := actual.Scan(st.input); st.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, st.output, actual.UUID)
+			}
+		})
+	}
+}
+
+func TestUUID_JSON(t *testing.T) {
+	id := UUID{uuid.MustParse("f81d4fae-7dec-11d0-a765-00a0c91e6bf6")}
+
+	actual, err := json.Marshal(id)
+	require.NoError(t, err)
+	require.Equal(t, `"f81d4fae-7dec-11d0-a765-00a0c91e6bf6"`, string(actual))
+
+	var unmarshalled UUID
+	require.NoError(t, json.Unmarshal(actual, &unmarshalled))
+	require.Equal(t, id, unmarshalled)
+}
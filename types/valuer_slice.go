@@ -0,0 +1,34 @@
+package types
+
+import (
+	"database/sql/driver"
+)
+
+// ValuerSlice is a slice of driver.Valuer, e.g. of Binary, whose Value returns the values of its elements,
+// so that sqlx.In expands it to one placeholder per element:
+//
+//	query, args, err := sqlx.In(`SELECT * FROM "host" WHERE "id" IN (?)`, types.ValuerSlice[types.Binary](ids))
+//
+// Note that a ValuerSlice can only be passed to sqlx.In, as a slice is not a valid driver.Value.
+type ValuerSlice[T driver.Valuer] []T
+
+// Value implements the driver.Valuer interface.
+// Returns the values of the elements as []any.
+func (s ValuerSlice[T]) Value() (driver.Value, error) {
+	values := make([]any, 0, len(s))
+	for _, v := range s {
+		value, err := v.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// Assert interface compliance.
+var (
+	_ driver.Valuer = ValuerSlice[Binary](nil)
+)
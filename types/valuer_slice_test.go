@@ -0,0 +1,18 @@
+package types
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestValuerSlice_Value(t *testing.T) {
+	query, args, err := sqlx.In(
+		`SELECT * FROM "host" WHERE "environment_id" = ? AND "id" IN (?)`,
+		Binary{0},
+		ValuerSlice[Binary]{{1, 254}, nil, {10}},
+	)
+	require.NoError(t, err)
+	require.Equal(t, `SELECT * FROM "host" WHERE "environment_id" = ? AND "id" IN (?, ?, ?)`, query)
+	require.Equal(t, []any{[]byte{0}, []byte{1, 254}, nil, []byte{10}}, args)
+}
@@ -63,7 +63,8 @@ func IsContextCanceled(err error) bool {
 	return errors.Is(err, context.Canceled)
 }
 
-// Checksum returns the SHA-1 checksum of the data.
+// Checksum returns the SHA-1 checksum of the data, which must be a string or a byte slice.
+// Use the checksum package to compute checksums of arbitrary values or with other algorithms.
 func Checksum(data interface{}) []byte {
 	var chksm [sha1.Size]byte
 